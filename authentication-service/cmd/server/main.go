@@ -1,26 +1,59 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/Grpc"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/http"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/rabbitmq"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
 )
 
 func main() {
+	logging.Setup()
+
 	config, err := pkg.LoadConfig(".")
 	if err != nil {
 		log.Fatalf("failed to load config file: %v", err)
 	}
 
-	maker, err := pkg.NewJWTMaker(config.PRIVATE_KEY_PATH, config.PUBLIC_KEY_PATH)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TRACING_EXPORTER, config.OTLP_ENDPOINT)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	maker, err := pkg.NewJWTMaker(config.PRIVATE_KEY_PATH, splitPaths(config.VERIFICATION_KEY_PATHS))
 	if err != nil {
 		log.Fatalf("Failed to create token maker: %v", err)
 	}
 
+	emailTokens, err := pkg.NewEmailTokenMaker(config.EMAIL_TOKEN_SECRET)
+	if err != nil {
+		log.Fatalf("Failed to create email token maker: %v", err)
+	}
+
+	mail, err := mailer.New(config)
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	emails := mailer.NewEmails(mail, emailTokens, config)
+
 	db := postgres.NewStore(config)
 
 	// connects to the db and run migrations
@@ -31,45 +64,123 @@ func main() {
 
 	// create an instance of the user repository
 	userRepository := postgres.NewUserService(db)
+	refreshTokenRepository := postgres.NewRefreshTokenService(db)
+	apiKeyRepository := postgres.NewAPIKeyService(db)
+	auditRepository := postgres.NewAuditService(db)
+	passwordResetRepository := postgres.NewPasswordResetService(db)
+	mfaRepository := postgres.NewMFAService(db)
+
+	logins := login.NewLogins(config)
+	logins.UserRepository = userRepository
+	logins.AuditRepository = auditRepository
+	logins.LoginAttemptRepository = postgres.NewLoginAttemptService(db)
+	logins.MFARepository = mfaRepository
 
 	grpcServer := Grpc.NewGRPCServer(config, *maker)
 	grpcServer.UserRepository = userRepository
+	grpcServer.RefreshTokenRepository = refreshTokenRepository
+	grpcServer.APIKeyRepository = apiKeyRepository
+	grpcServer.AuditRepository = auditRepository
+	grpcServer.PasswordResetRepository = passwordResetRepository
+	grpcServer.Logins = logins
+	grpcServer.MFARepository = mfaRepository
+	grpcServer.Emails = emails
 
 	rabbitConn := rabbitmq.NewRabbitConn(config, *maker)
 	rabbitConn.UserRepository = userRepository
+	rabbitConn.RefreshTokenRepository = refreshTokenRepository
+	rabbitConn.PasswordResetRepository = passwordResetRepository
+	rabbitConn.Logins = logins
+	rabbitConn.Emails = emails
 
-	// connects to rabbitmq
+	// connects to rabbitmq, or sets up an in-process bus when BUS_DRIVER is "memory"
 	if err = rabbitConn.ConnectToRabbit(); err != nil {
 		log.Fatalf("Failed to create new rabbit conn: %v", err)
 	}
 
+	checker := health.NewChecker()
+	checker.Add("postgres", 2*time.Second, db.Ping)
+	checker.Add("rabbitmq", time.Second, rabbitConn.Ready)
+
 	httpServer := http.NewHTTPServer(config, *maker)
 	httpServer.UserRepository = userRepository
+	httpServer.RefreshTokenRepository = refreshTokenRepository
+	httpServer.PasswordResetRepository = passwordResetRepository
+	httpServer.Logins = logins
+	httpServer.HealthChecker = checker
+	httpServer.Emails = emails
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 3)
 
 	go func() {
-		log.Printf("Starting Authentication Grpc Server on port: %s", config.GRPC_PORT)
+		slog.Info("starting authentication grpc server", "address", config.GRPC_PORT)
 
-		if err = grpcServer.Start(config.GRPC_PORT); err != nil {
-			log.Fatalf("Failed to create new gRPC server instance: %v", err)
+		if err := grpcServer.Start(config.GRPC_PORT); err != nil {
+			errCh <- fmt.Errorf("grpc server: %w", err)
 		}
 	}()
 
 	go func() {
-		log.Println("Starting Authentication Rabbit Consumer...")
+		slog.Info("starting authentication rabbit consumer")
 
-		// declares the auth queue, binds topics, and starts consuming messages
-		if err = rabbitConn.SetConsumer([]string{
+		// registers the handlers, then declares the auth queue and starts consuming messages
+		if err := rabbitConn.SetConsumer([]string{
 			"authentication.register_user",
 			"authentication.login_user",
+			"authentication.forgot_password",
+			"authentication.reset_password",
 		}); err != nil {
-			log.Fatalf("Failed to create new rabbit conn: %v", err)
+			errCh <- fmt.Errorf("rabbit consumer: %w", err)
 		}
 	}()
 
-	log.Printf("Starting Authentication Http Server on port: %s", config.HTTP_PORT)
+	go func() {
+		slog.Info("starting authentication http server", "address", config.HTTP_PORT)
 
-	err = httpServer.Start()
-	if err != nil {
-		log.Fatalf("Failed to start http server instance: %v", err)
+		if err := httpServer.Start(); err != nil {
+			errCh <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down authentication service")
+	case err := <-errCh:
+		slog.Error("shutting down authentication service", "error", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	// stop taking work from the queue first, then drain the servers, and release the
+	// database once nothing can use it anymore
+	if err := rabbitConn.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to stop rabbit consumer", "error", err)
+	}
+
+	if err := httpServer.Stop(shutdownCtx); err != nil {
+		slog.Error("failed to stop http server", "error", err)
+	}
+
+	grpcServer.Shutdown(shutdownCtx)
+
+	db.Close()
+
+	slog.Info("authentication service stopped")
+}
+
+// splitPaths returns the paths of a comma separated list, ignoring empty entries.
+func splitPaths(value string) []string {
+	var paths []string
+
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
 	}
+
+	return paths
 }
@@ -0,0 +1,253 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AdminGetUser looks up a user for support staff and admins, by id or by email.
+func (s *GRPCServer) AdminGetUser(ctx context.Context, req *pb.AdminGetUserRequest) (*pb.AdminGetUserResponse, error) {
+	if err := s.authorize(ctx, req.GetActorId(), repository.ActionUserLookup); err != nil {
+		return nil, err
+	}
+
+	var (
+		user *repository.User
+		err  error
+	)
+
+	switch {
+	case req.GetUserId() != 0:
+		user, err = s.UserRepository.GetUserByID(ctx, req.GetUserId())
+	case req.GetEmail() != "":
+		user, err = s.UserRepository.GetUser(ctx, req.GetEmail())
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "user_id or email is required")
+	}
+
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on admin get user: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if _, err := s.recordAuditEvent(ctx, req.GetActorId(), repository.ActionUserLookup, user.ID, req.GetReason()); err != nil {
+		return nil, err
+	}
+
+	return &pb.AdminGetUserResponse{User: userAccountToPb(user)}, nil
+}
+
+// DisableUser disables the account of a user, who can no longer log in, and revokes
+// their refresh tokens. Their access tokens are revoked by the gateway.
+func (s *GRPCServer) DisableUser(ctx context.Context, req *pb.DisableUserRequest) (*pb.DisableUserResponse, error) {
+	if err := s.authorize(ctx, req.GetActorId(), repository.ActionUserDisable); err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	if req.GetUserId() == req.GetActorId() {
+		return nil, status.Errorf(codes.InvalidArgument, "admins can not disable their own account")
+	}
+
+	if _, err := s.UserRepository.GetUserByID(ctx, req.GetUserId()); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on disable user: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if _, err := s.recordAuditEvent(ctx, req.GetActorId(), repository.ActionUserDisable, req.GetUserId(), req.GetReason()); err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.DisableUser(ctx, req.GetUserId())
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on disable user: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if err := s.RefreshTokenRepository.RevokeRefreshTokens(ctx, user.ID, ""); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on revoke refresh tokens: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.DisableUserResponse{User: userAccountToPb(user)}, nil
+}
+
+// UnlockUser lifts the lockout that failed logins put on the account of a user, who can
+// log in again at once.
+func (s *GRPCServer) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	if err := s.authorize(ctx, req.GetActorId(), repository.ActionUserUnlock); err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	if s.Logins == nil || s.Logins.LoginAttemptRepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "logins are not limited")
+	}
+
+	user, err := s.UserRepository.GetUserByID(ctx, req.GetUserId())
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on unlock user: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if _, err := s.recordAuditEvent(ctx, req.GetActorId(), repository.ActionUserUnlock, user.ID, req.GetReason()); err != nil {
+		return nil, err
+	}
+
+	if err := s.Logins.LoginAttemptRepository.ClearLoginFailures(ctx, user.Email); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on unlock user: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.UnlockUserResponse{User: userAccountToPb(user)}, nil
+}
+
+// RecordAuditEvent records an admin action that another service takes, such as viewing
+// the transactions of a user. The caller takes it once it is recorded.
+func (s *GRPCServer) RecordAuditEvent(
+	ctx context.Context,
+	req *pb.RecordAuditEventRequest,
+) (*pb.RecordAuditEventResponse, error) {
+	// the actions the authentication service takes are recorded by their own RPC
+	if req.GetAction() != repository.ActionTransactionsView {
+		return nil, status.Errorf(codes.InvalidArgument, "action %q can not be recorded on its own", req.GetAction())
+	}
+
+	if err := s.authorize(ctx, req.GetActorId(), req.GetAction()); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.UserRepository.GetUserByID(ctx, req.GetTargetUserId()); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on record audit event: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	event, err := s.recordAuditEvent(ctx, req.GetActorId(), req.GetAction(), req.GetTargetUserId(), req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RecordAuditEventResponse{Id: event.ID}, nil
+}
+
+// authorize fails unless the actor's account is enabled and their role allows action.
+// The role is read from the database rather than trusted from the access token, so that
+// an actor who was demoted or disabled loses access at once.
+func (s *GRPCServer) authorize(ctx context.Context, actorID int64, action string) error {
+	if actorID == 0 {
+		return status.Errorf(codes.InvalidArgument, "actor_id is required")
+	}
+
+	actor, err := s.UserRepository.GetUserByID(ctx, actorID)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return status.Errorf(codes.PermissionDenied, "actor not found")
+		}
+
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on authorize: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if actor.Disabled() || !repository.RoleAtLeast(actor.Role, repository.ActionRoles[action]) {
+		return status.Errorf(codes.PermissionDenied, "not allowed to %s", action)
+	}
+
+	return nil
+}
+
+func (s *GRPCServer) recordAuditEvent(
+	ctx context.Context,
+	actorID int64,
+	action string,
+	targetUserID int64,
+	reason string,
+) (*repository.AuditEvent, error) {
+	event, err := s.AuditRepository.RecordAuditEvent(ctx, repository.AuditEvent{
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Reason:       reason,
+	})
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on record audit event: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return event, nil
+}
+
+func userAccountToPb(user *repository.User) *pb.UserAccount {
+	account := &pb.UserAccount{
+		Id:            user.ID,
+		Fullname:      user.FullName,
+		Email:         user.Email,
+		Role:          user.Role,
+		PaydUsername:  user.PaydUsername,
+		PaydAccountId: user.PaydAccountID,
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+	}
+
+	if user.DisabledAt != nil {
+		account.DisabledAt = timestamppb.New(*user.DisabledAt)
+	}
+
+	return account
+}
@@ -0,0 +1,358 @@
+package Grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	adminID         int64 = 1
+	supportID       int64 = 2
+	customerID      int64 = 3
+	disabledAdminID int64 = 4
+)
+
+// newTestAdminServer returns a server knowing the actors above and the user foundID,
+// recording the audit events it is asked to in events.
+func newTestAdminServer(events *[]repository.AuditEvent) *TestGRPCServer {
+	s := NewTestGRPCServer()
+
+	users := map[int64]*repository.User{
+		adminID:         {ID: adminID, Email: "admin@gmail.com", Role: repository.RoleAdmin, CreatedAt: TestTime},
+		supportID:       {ID: supportID, Email: "support@gmail.com", Role: repository.RoleSupport, CreatedAt: TestTime},
+		customerID:      {ID: customerID, Email: "customer@gmail.com", Role: repository.RoleUser, CreatedAt: TestTime},
+		disabledAdminID: {ID: disabledAdminID, Email: "former@gmail.com", Role: repository.RoleAdmin, DisabledAt: &TestTime, CreatedAt: TestTime},
+		foundID:         {ID: foundID, Email: "found@gmail.com", Role: repository.RoleUser, CreatedAt: TestTime},
+	}
+
+	s.UserRepository.GetUserByIDFunc = func(id int64) (*repository.User, error) {
+		user, ok := users[id]
+		if !ok {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return user, nil
+	}
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		for _, user := range users {
+			if user.Email == email {
+				return user, nil
+			}
+		}
+
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+	}
+	s.UserRepository.DisableUserFunc = func(id int64) (*repository.User, error) {
+		user := *users[id]
+		user.DisabledAt = &TestTime
+
+		return &user, nil
+	}
+	s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(_ int64, _ string) error {
+		return nil
+	}
+	s.AuditRepository.RecordAuditEventFunc = func(event repository.AuditEvent) (*repository.AuditEvent, error) {
+		if err := event.Validate(); err != nil {
+			return nil, err
+		}
+
+		event.ID = int64(len(*events) + 1)
+		event.CreatedAt = TestTime
+		*events = append(*events, event)
+
+		return &event, nil
+	}
+
+	return s
+}
+
+func TestGRPCServer_AdminGetUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.AdminGetUserRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "by id",
+			req:      &pb.AdminGetUserRequest{ActorId: supportID, UserId: foundID},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "by email",
+			req:      &pb.AdminGetUserRequest{ActorId: adminID, Email: "found@gmail.com", Reason: "ticket 42"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "not a support",
+			req:      &pb.AdminGetUserRequest{ActorId: customerID, UserId: foundID},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "disabled actor",
+			req:      &pb.AdminGetUserRequest{ActorId: disabledAdminID, UserId: foundID},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "unknown actor",
+			req:      &pb.AdminGetUserRequest{ActorId: notFoundID, UserId: foundID},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "no user",
+			req:      &pb.AdminGetUserRequest{ActorId: supportID},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "user not found",
+			req:      &pb.AdminGetUserRequest{ActorId: supportID, UserId: notFoundID},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []repository.AuditEvent
+
+			s := newTestAdminServer(&events)
+
+			got, err := s.server.AdminGetUser(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+				require.Empty(t, events)
+
+				return
+			}
+
+			require.Equal(t, foundID, got.GetUser().GetId())
+			require.Equal(t, repository.RoleUser, got.GetUser().GetRole())
+			require.Nil(t, got.GetUser().GetDisabledAt())
+
+			require.Len(t, events, 1)
+			require.Equal(t, tc.req.GetActorId(), events[0].ActorID)
+			require.Equal(t, repository.ActionUserLookup, events[0].Action)
+			require.Equal(t, foundID, events[0].TargetUserID)
+			require.Equal(t, tc.req.GetReason(), events[0].Reason)
+		})
+	}
+}
+
+func TestGRPCServer_DisableUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.DisableUserRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "disabled",
+			req:      &pb.DisableUserRequest{ActorId: adminID, UserId: foundID, Reason: "fraud"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "no reason",
+			req:      &pb.DisableUserRequest{ActorId: adminID, UserId: foundID},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "support can not disable",
+			req:      &pb.DisableUserRequest{ActorId: supportID, UserId: foundID, Reason: "fraud"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "own account",
+			req:      &pb.DisableUserRequest{ActorId: adminID, UserId: adminID, Reason: "leaving"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "user not found",
+			req:      &pb.DisableUserRequest{ActorId: adminID, UserId: notFoundID, Reason: "fraud"},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				events  []repository.AuditEvent
+				revoked []int64
+			)
+
+			s := newTestAdminServer(&events)
+			s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(userID int64, _ string) error {
+				revoked = append(revoked, userID)
+
+				return nil
+			}
+
+			got, err := s.server.DisableUser(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+				require.Empty(t, events)
+				require.Empty(t, revoked)
+
+				return
+			}
+
+			require.Equal(t, foundID, got.GetUser().GetId())
+			require.Equal(t, TestTime, got.GetUser().GetDisabledAt().AsTime())
+			require.Equal(t, []int64{foundID}, revoked)
+
+			require.Len(t, events, 1)
+			require.Equal(t, repository.ActionUserDisable, events[0].Action)
+			require.Equal(t, "fraud", events[0].Reason)
+		})
+	}
+}
+
+func TestGRPCServer_UnlockUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.UnlockUserRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "unlocked",
+			req:      &pb.UnlockUserRequest{ActorId: adminID, UserId: foundID, Reason: "verified by phone"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "no reason",
+			req:      &pb.UnlockUserRequest{ActorId: adminID, UserId: foundID},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "support can not unlock",
+			req:      &pb.UnlockUserRequest{ActorId: supportID, UserId: foundID, Reason: "verified by phone"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "user not found",
+			req:      &pb.UnlockUserRequest{ActorId: adminID, UserId: notFoundID, Reason: "verified by phone"},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				events  []repository.AuditEvent
+				cleared []string
+			)
+
+			s := newTestAdminServer(&events)
+			s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+				cleared = append(cleared, email)
+
+				return nil
+			}
+
+			got, err := s.server.UnlockUser(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+				require.Empty(t, events)
+				require.Empty(t, cleared)
+
+				return
+			}
+
+			require.Equal(t, foundID, got.GetUser().GetId())
+			require.Equal(t, []string{"found@gmail.com"}, cleared)
+
+			require.Len(t, events, 1)
+			require.Equal(t, repository.ActionUserUnlock, events[0].Action)
+			require.Equal(t, "verified by phone", events[0].Reason)
+		})
+	}
+}
+
+func TestGRPCServer_RecordAuditEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.RecordAuditEventRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "recorded",
+			req: &pb.RecordAuditEventRequest{
+				ActorId:      supportID,
+				Action:       repository.ActionTransactionsView,
+				TargetUserId: foundID,
+				Reason:       "ticket 42",
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "no reason",
+			req: &pb.RecordAuditEventRequest{
+				ActorId:      supportID,
+				Action:       repository.ActionTransactionsView,
+				TargetUserId: foundID,
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "action with its own rpc",
+			req: &pb.RecordAuditEventRequest{
+				ActorId:      adminID,
+				Action:       repository.ActionUserDisable,
+				TargetUserId: foundID,
+				Reason:       "fraud",
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "not a support",
+			req: &pb.RecordAuditEventRequest{
+				ActorId:      customerID,
+				Action:       repository.ActionTransactionsView,
+				TargetUserId: foundID,
+				Reason:       "curious",
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "user not found",
+			req: &pb.RecordAuditEventRequest{
+				ActorId:      supportID,
+				Action:       repository.ActionTransactionsView,
+				TargetUserId: notFoundID,
+				Reason:       "ticket 42",
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []repository.AuditEvent
+
+			s := newTestAdminServer(&events)
+
+			got, err := s.server.RecordAuditEvent(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+				require.Empty(t, events)
+
+				return
+			}
+
+			require.Equal(t, int64(1), got.GetId())
+			require.Len(t, events, 1)
+			require.Equal(t, repository.ActionTransactionsView, events[0].Action)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateAPIKey issues an API key to a user. The key is only ever returned here.
+func (s *GRPCServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	allowedIPs, err := parseAllowedIPs(req.GetAllowedIps())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	apiKey := repository.APIKey{
+		UserID:     req.GetUserId(),
+		Name:       req.GetName(),
+		Scopes:     req.GetScopes(),
+		AllowedIPs: allowedIPs,
+	}
+
+	if req.GetExpiresAt() != nil {
+		expiresAt := req.GetExpiresAt().AsTime()
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	key, created, err := s.APIKeyRepository.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on create api key: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.CreateAPIKeyResponse{Key: key, ApiKey: apiKeyToPb(created)}, nil
+}
+
+func (s *GRPCServer) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	apiKeys, err := s.APIKeyRepository.ListAPIKeys(ctx, req.GetUserId())
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on list api keys: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	rsp := &pb.ListAPIKeysResponse{ApiKeys: make([]*pb.APIKey, 0, len(apiKeys))}
+	for i := range apiKeys {
+		rsp.ApiKeys = append(rsp.ApiKeys, apiKeyToPb(&apiKeys[i]))
+	}
+
+	return rsp, nil
+}
+
+func (s *GRPCServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	if req.GetUserId() == 0 || req.GetId() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and id are required")
+	}
+
+	if err := s.APIKeyRepository.RevokeAPIKey(ctx, req.GetUserId(), req.GetId()); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on revoke api key: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.RevokeAPIKeyResponse{}, nil
+}
+
+// VerifyAPIKey returns the API key a client of the gateway authenticated with, which
+// enforces its scopes and allowed IPs.
+func (s *GRPCServer) VerifyAPIKey(ctx context.Context, req *pb.VerifyAPIKeyRequest) (*pb.VerifyAPIKeyResponse, error) {
+	if !pkg.IsAPIKey(req.GetKey()) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+	}
+
+	apiKey, err := s.APIKeyRepository.VerifyAPIKey(ctx, req.GetKey())
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on verify api key: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.VerifyAPIKeyResponse{ApiKey: apiKeyToPb(apiKey)}, nil
+}
+
+// parseAllowedIPs parses networks in CIDR notation, or single addresses.
+func parseAllowedIPs(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed ip %q", value)
+			}
+
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed ip %q", value)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func apiKeyToPb(apiKey *repository.APIKey) *pb.APIKey {
+	allowedIPs := make([]string, 0, len(apiKey.AllowedIPs))
+	for _, prefix := range apiKey.AllowedIPs {
+		allowedIPs = append(allowedIPs, prefix.String())
+	}
+
+	return &pb.APIKey{
+		Id:         apiKey.ID,
+		UserId:     apiKey.UserID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		AllowedIps: allowedIPs,
+		ExpiresAt:  timestampOrNil(apiKey.ExpiresAt),
+		LastUsedAt: timestampOrNil(apiKey.LastUsedAt),
+		CreatedAt:  timestamppb.New(apiKey.CreatedAt),
+	}
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
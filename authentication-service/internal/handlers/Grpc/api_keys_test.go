@@ -0,0 +1,166 @@
+package Grpc
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGRPCServer_CreateAPIKey(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var created repository.APIKey
+
+	s.APIKeyRepository.CreateAPIKeyFunc = func(apiKey repository.APIKey) (string, *repository.APIKey, error) {
+		if err := apiKey.Validate(); err != nil {
+			return "", nil, err
+		}
+
+		created = apiKey
+		apiKey.ID = 1
+		apiKey.Prefix = "ppk_abcdefgh"
+		apiKey.CreatedAt = TestTime
+
+		return "ppk_abcdefghsecret", &apiKey, nil
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC()
+
+	tests := []struct {
+		name     string
+		req      *pb.CreateAPIKeyRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "created",
+			req: &pb.CreateAPIKeyRequest{
+				UserId:     foundID,
+				Name:       "billing",
+				Scopes:     []string{repository.ScopePaymentsInitiate, repository.ScopePaymentsRead},
+				AllowedIps: []string{"10.1.2.3", "192.168.1.7/24"},
+				ExpiresAt:  timestamppb.New(expiresAt),
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "unknown scope",
+			req:      &pb.CreateAPIKeyRequest{UserId: foundID, Name: "billing", Scopes: []string{"admin"}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid ip",
+			req:      &pb.CreateAPIKeyRequest{UserId: foundID, Name: "billing", Scopes: []string{repository.ScopePaymentsRead}, AllowedIps: []string{"10.1.2"}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.server.CreateAPIKey(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+
+				return
+			}
+
+			require.Equal(t, "ppk_abcdefghsecret", got.GetKey())
+			require.Equal(t, "ppk_abcdefgh", got.GetApiKey().GetPrefix())
+			require.Equal(t, []string{"10.1.2.3/32", "192.168.1.0/24"}, got.GetApiKey().GetAllowedIps())
+			require.Equal(t, expiresAt, got.GetApiKey().GetExpiresAt().AsTime())
+			require.Nil(t, got.GetApiKey().GetLastUsedAt())
+
+			require.Equal(t, []netip.Prefix{
+				netip.MustParsePrefix("10.1.2.3/32"),
+				netip.MustParsePrefix("192.168.1.0/24"),
+			}, created.AllowedIPs)
+		})
+	}
+}
+
+func TestGRPCServer_VerifyAPIKey(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	lastUsedAt := TestTime.Add(time.Minute)
+
+	s.APIKeyRepository.VerifyAPIKeyFunc = func(key string) (*repository.APIKey, error) {
+		if key != "ppk_valid" {
+			return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid api key")
+		}
+
+		return &repository.APIKey{
+			ID:         1,
+			UserID:     foundID,
+			Scopes:     []string{repository.ScopePaymentsRead},
+			AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			LastUsedAt: &lastUsedAt,
+			CreatedAt:  TestTime,
+		}, nil
+	}
+
+	got, err := s.server.VerifyAPIKey(context.Background(), &pb.VerifyAPIKeyRequest{Key: "ppk_valid"})
+	require.NoError(t, err)
+	require.Equal(t, foundID, got.GetApiKey().GetUserId())
+	require.Equal(t, []string{repository.ScopePaymentsRead}, got.GetApiKey().GetScopes())
+	require.Equal(t, []string{"10.0.0.0/8"}, got.GetApiKey().GetAllowedIps())
+	require.Nil(t, got.GetApiKey().GetExpiresAt())
+	require.Equal(t, lastUsedAt, got.GetApiKey().GetLastUsedAt().AsTime())
+
+	_, err = s.server.VerifyAPIKey(context.Background(), &pb.VerifyAPIKeyRequest{Key: "ppk_revoked"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// access tokens are not looked up as keys
+	_, err = s.server.VerifyAPIKey(context.Background(), &pb.VerifyAPIKeyRequest{Key: "eyJhbGciOiJSUzI1NiJ9"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCServer_ListAndRevokeAPIKeys(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	apiKeys := []repository.APIKey{
+		{ID: 1, UserID: foundID, Name: "billing", Prefix: "ppk_aaaaaaaa", CreatedAt: TestTime},
+		{ID: 2, UserID: foundID, Name: "reports", Prefix: "ppk_bbbbbbbb", CreatedAt: TestTime},
+	}
+
+	s.APIKeyRepository.ListAPIKeysFunc = func(userID int64) ([]repository.APIKey, error) {
+		if userID != foundID {
+			return nil, nil
+		}
+
+		return apiKeys, nil
+	}
+
+	s.APIKeyRepository.RevokeAPIKeyFunc = func(userID, id int64) error {
+		for _, apiKey := range apiKeys {
+			if apiKey.UserID == userID && apiKey.ID == id {
+				return nil
+			}
+		}
+
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "api key not found")
+	}
+
+	list, err := s.server.ListAPIKeys(context.Background(), &pb.ListAPIKeysRequest{UserId: foundID})
+	require.NoError(t, err)
+	require.Len(t, list.GetApiKeys(), 2)
+	require.Equal(t, "reports", list.GetApiKeys()[1].GetName())
+
+	_, err = s.server.ListAPIKeys(context.Background(), &pb.ListAPIKeysRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.server.RevokeAPIKey(context.Background(), &pb.RevokeAPIKeyRequest{UserId: foundID, Id: 2})
+	require.NoError(t, err)
+
+	_, err = s.server.RevokeAPIKey(context.Background(), &pb.RevokeAPIKeyRequest{UserId: notFoundID, Id: 2})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,80 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// VerifyCredentials checks that a user proved who they are again, for the actions an
+// access token alone is not enough for. Users with two-factor authentication prove it
+// with a code, the others with their password. Failures count as failed logins of the
+// user, and are held back the same way.
+func (s *GRPCServer) VerifyCredentials(
+	ctx context.Context,
+	req *pb.VerifyCredentialsRequest,
+) (*pb.VerifyCredentialsResponse, error) {
+	if req.GetUserId() == 0 || req.GetPassword() == "" && req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and a password or code are required")
+	}
+
+	user, err := s.mfaUser(ctx, req.GetUserId(), "verify credentials")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Logins.CheckLogin(ctx, user.Email, req.GetClientIp()); err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify credentials: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	mfaEnabled, err := s.Logins.MFAEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify credentials: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	// the password is not enough once there is a second factor, or it would be the
+	// way around it
+	if mfaEnabled {
+		if req.GetCode() == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "two-factor authentication is enabled, a code is required")
+		}
+
+		if err := s.MFARepository.VerifyMFACode(ctx, user.ID, req.GetCode()); err != nil {
+			if pkg.ErrorCode(err) == pkg.AUTHENTICATION_ERROR {
+				s.Logins.RecordLoginFailure(ctx, user, user.Email, req.GetClientIp())
+			}
+
+			return nil, status.Errorf(
+				convertPkgError(pkg.ErrorCode(err)),
+				"%v",
+				fmt.Sprintf("error on verify credentials: %v", pkg.ErrorMessage(err)),
+			)
+		}
+
+		return &pb.VerifyCredentialsResponse{}, nil
+	}
+
+	if req.GetPassword() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "two-factor authentication is not enabled, the password is required")
+	}
+
+	if err := pkg.ComparePasswordAndHash(user.Password, req.GetPassword()); err != nil {
+		s.Logins.RecordLoginFailure(ctx, user, user.Email, req.GetClientIp())
+
+		return nil, status.Errorf(codes.Unauthenticated, "invalid password")
+	}
+
+	return &pb.VerifyCredentialsResponse{}, nil
+}
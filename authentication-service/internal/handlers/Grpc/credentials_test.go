@@ -0,0 +1,107 @@
+package Grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServer_VerifyCredentials(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var failures []string
+
+	enabled := false
+
+	s.UserRepository.GetUserByIDFunc = mockMFAUser
+	s.MFARepository.MFAEnabledFunc = func(int64) (bool, error) {
+		return enabled, nil
+	}
+	s.MFARepository.VerifyMFACodeFunc = func(_ int64, code string) error {
+		if code != "123456" {
+			return pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid code")
+		}
+
+		return nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, ip string) (bool, error) {
+		failures = append(failures, email+" "+ip)
+
+		return false, nil
+	}
+
+	tests := []struct {
+		name       string
+		mfa        bool
+		req        *pb.VerifyCredentialsRequest
+		wantCode   codes.Code
+		wantFailed bool
+	}{
+		{
+			name:     "password",
+			req:      &pb.VerifyCredentialsRequest{UserId: foundID, Password: "password"},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "wrong password",
+			req:        &pb.VerifyCredentialsRequest{UserId: foundID, Password: "wrong", ClientIp: "192.0.2.1"},
+			wantCode:   codes.Unauthenticated,
+			wantFailed: true,
+		},
+		{
+			name:     "code without two-factor authentication",
+			req:      &pb.VerifyCredentialsRequest{UserId: foundID, Code: "123456"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "code",
+			mfa:      true,
+			req:      &pb.VerifyCredentialsRequest{UserId: foundID, Code: "123456"},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "wrong code",
+			mfa:        true,
+			req:        &pb.VerifyCredentialsRequest{UserId: foundID, Code: "000000", ClientIp: "192.0.2.1"},
+			wantCode:   codes.Unauthenticated,
+			wantFailed: true,
+		},
+		{
+			name:     "password with two-factor authentication",
+			mfa:      true,
+			req:      &pb.VerifyCredentialsRequest{UserId: foundID, Password: "password"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "nothing to verify",
+			req:      &pb.VerifyCredentialsRequest{UserId: foundID},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unknown user",
+			req:      &pb.VerifyCredentialsRequest{UserId: notFoundID, Password: "password"},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			failures = nil
+			enabled = tc.mfa
+
+			_, err := s.server.VerifyCredentials(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantFailed {
+				require.Equal(t, []string{"found@gmail.com 192.0.2.1"}, failures)
+			} else {
+				require.Empty(t, failures)
+			}
+		})
+	}
+}
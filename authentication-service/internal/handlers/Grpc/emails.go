@@ -0,0 +1,89 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *GRPCServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if s.Emails == nil {
+		return nil, status.Errorf(codes.Unimplemented, "email verification is not enabled")
+	}
+
+	payload, err := s.Emails.VerifyToken(req.GetToken(), pkg.EmailVerificationPurpose)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+	}
+
+	// the token is bound to the email it was sent to: it no longer verifies the user
+	// once they changed it
+	user, err := s.UserRepository.VerifyEmail(ctx, payload.UserID, payload.Email)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+		}
+
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify email: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	rsp := &pb.VerifyEmailResponse{Email: user.Email}
+	if user.EmailVerifiedAt != nil {
+		rsp.VerifiedAt = timestamppb.New(*user.EmailVerifiedAt)
+	}
+
+	return rsp, nil
+}
+
+// ResendVerificationEmail answers the same whether the email belongs to a user or not,
+// so that it can not be used to find out who has an account.
+func (s *GRPCServer) ResendVerificationEmail(
+	ctx context.Context,
+	req *pb.ResendVerificationEmailRequest,
+) (*pb.ResendVerificationEmailResponse, error) {
+	if s.Emails == nil {
+		return nil, status.Errorf(codes.Unimplemented, "email verification is not enabled")
+	}
+
+	user, err := s.UserRepository.GetUser(ctx, req.GetEmail())
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return &pb.ResendVerificationEmailResponse{}, nil
+		}
+
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on resend verification email: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if !user.EmailVerified() && !user.Disabled() {
+		s.sendVerification(ctx, user)
+	}
+
+	return &pb.ResendVerificationEmailResponse{}, nil
+}
+
+// sendVerification sends user the email confirming their address. A failure is only
+// logged: the user can ask for the email again.
+func (s *GRPCServer) sendVerification(ctx context.Context, user *repository.User) {
+	if s.Emails == nil {
+		return
+	}
+
+	if err := s.Emails.SendVerification(ctx, user.ID, user.Email); err != nil {
+		slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+	}
+}
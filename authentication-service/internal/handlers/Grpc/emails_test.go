@@ -0,0 +1,241 @@
+package Grpc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testEmailTokenSecret = "an email token secret of 32 char"
+
+// withEmails makes s send its emails to sent, returning the token maker they are
+// signed with.
+func withEmails(t *testing.T, s *TestGRPCServer, sent *[]mailer.Message) *pkg.EmailTokenMaker {
+	t.Helper()
+
+	tokens, err := pkg.NewEmailTokenMaker(testEmailTokenSecret)
+	require.NoError(t, err)
+
+	m := &mock.MockMailer{SendFunc: func(msg mailer.Message) error {
+		*sent = append(*sent, msg)
+
+		return nil
+	}}
+
+	s.server.Emails = mailer.NewEmails(m, tokens, pkg.Config{
+		EMAIL_VERIFICATION_URL:            "http://localhost:8080/verify-email",
+		EMAIL_VERIFICATION_TOKEN_DURATION: time.Hour,
+		PASSWORD_RESET_URL:                "http://localhost:8080/password/reset",
+		PASSWORD_RESET_TOKEN_DURATION:     30 * time.Minute,
+	})
+
+	return tokens
+}
+
+// tokenOf returns the token of the link in msg.
+func tokenOf(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+
+	start := strings.Index(msg.Body, "http://")
+	require.NotEqual(t, -1, start)
+
+	link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+	require.NoError(t, err)
+
+	return link.Query().Get("token")
+}
+
+func TestGRPCServer_RegisterUser_SendsVerification(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var sent []mailer.Message
+
+	tokens := withEmails(t, s, &sent)
+
+	s.UserRepository.CreateUserFunc = func(user repository.User) (*repository.User, error) {
+		user.ID = foundID
+		user.CreatedAt = TestTime
+
+		return &user, nil
+	}
+
+	user := randomPbUser()
+
+	_, err := s.server.RegisterUser(context.Background(), user)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	require.Equal(t, user.GetEmail(), sent[0].To)
+
+	payload, err := tokens.VerifyToken(tokenOf(t, sent[0]), pkg.EmailVerificationPurpose)
+	require.NoError(t, err)
+	require.Equal(t, foundID, payload.UserID)
+	require.Equal(t, user.GetEmail(), payload.Email)
+
+	// the user is registered even when the email can not be sent
+	s.server.Emails = mailer.NewEmails(&mock.MockMailer{SendFunc: func(mailer.Message) error {
+		return errors.New("smtp server down")
+	}}, tokens, pkg.Config{})
+
+	_, err = s.server.RegisterUser(context.Background(), randomPbUser())
+	require.NoError(t, err)
+}
+
+func TestGRPCServer_VerifyEmail(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var sent []mailer.Message
+
+	tokens := withEmails(t, s, &sent)
+
+	s.UserRepository.VerifyEmailFunc = func(id int64, email string) (*repository.User, error) {
+		if id != foundID || email != "found@gmail.com" {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return &repository.User{ID: id, Email: email, EmailVerifiedAt: &TestTime}, nil
+	}
+
+	token := func(purpose string, userID int64, email string, duration time.Duration) string {
+		token, err := tokens.CreateToken(purpose, userID, email, duration)
+		require.NoError(t, err)
+
+		return token
+	}
+
+	otherTokens, err := pkg.NewEmailTokenMaker("another email token secret of 32")
+	require.NoError(t, err)
+
+	forged, err := otherTokens.CreateToken(pkg.EmailVerificationPurpose, foundID, "found@gmail.com", time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode codes.Code
+	}{
+		{
+			name:     "verified",
+			token:    token(pkg.EmailVerificationPurpose, foundID, "found@gmail.com", time.Hour),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "expired token",
+			token:    token(pkg.EmailVerificationPurpose, foundID, "found@gmail.com", -time.Minute),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "token signed with another secret",
+			token:    forged,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "token for another purpose",
+			token:    token("password_reset", foundID, "found@gmail.com", time.Hour),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "email changed since the token was sent",
+			token:    token(pkg.EmailVerificationPurpose, foundID, "old@gmail.com", time.Hour),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "not a token",
+			token:    "not-a-token",
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp, err := s.server.VerifyEmail(context.Background(), &pb.VerifyEmailRequest{Token: tc.token})
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode == codes.OK {
+				require.Equal(t, "found@gmail.com", rsp.GetEmail())
+				require.True(t, rsp.GetVerifiedAt().AsTime().Equal(TestTime))
+			}
+		})
+	}
+}
+
+func TestGRPCServer_ResendVerificationEmail(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var sent []mailer.Message
+
+	withEmails(t, s, &sent)
+
+	users := map[string]*repository.User{
+		"unverified@gmail.com": {ID: 1, Email: "unverified@gmail.com"},
+		"verified@gmail.com":   {ID: 2, Email: "verified@gmail.com", EmailVerifiedAt: &TestTime},
+		"disabled@gmail.com":   {ID: 3, Email: "disabled@gmail.com", DisabledAt: &TestTime},
+	}
+
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		if email == "error@gmail.com" {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "database is down")
+		}
+
+		user, ok := users[email]
+		if !ok {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return user, nil
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		wantCode codes.Code
+		wantSent bool
+	}{
+		{name: "unverified user", email: "unverified@gmail.com", wantCode: codes.OK, wantSent: true},
+		{name: "verified user", email: "verified@gmail.com", wantCode: codes.OK},
+		{name: "disabled user", email: "disabled@gmail.com", wantCode: codes.OK},
+		{name: "unknown email", email: "nobody@gmail.com", wantCode: codes.OK},
+		{name: "internal error", email: "error@gmail.com", wantCode: codes.Internal},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sent = nil
+
+			_, err := s.server.ResendVerificationEmail(
+				context.Background(),
+				&pb.ResendVerificationEmailRequest{Email: tc.email},
+			)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantSent {
+				require.Len(t, sent, 1)
+				require.Equal(t, tc.email, sent[0].To)
+			} else {
+				require.Empty(t, sent)
+			}
+		})
+	}
+}
+
+func TestGRPCServer_LoginUser_UnverifiedAllowed(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	s.UserRepository.GetUserFunc = mockGetUserFunc
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = mockCreateRefreshToken
+
+	// unverified users log in unless REQUIRE_VERIFIED_EMAIL is set
+	_, err := s.server.LoginUser(context.Background(), &pb.LoginUserRequest{Email: unverifiedEmail, Password: "password"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,208 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EnrollMFA issues a TOTP secret to a user who gave their password again. It only
+// protects their logins once ActivateMFA confirmed they added it to their authenticator.
+func (s *GRPCServer) EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error) {
+	if s.MFARepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "two-factor authentication is not enabled")
+	}
+
+	if req.GetUserId() == 0 || req.GetPassword() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and password are required")
+	}
+
+	user, err := s.mfaUser(ctx, req.GetUserId(), "enroll mfa")
+	if err != nil {
+		return nil, err
+	}
+
+	// the password is guessed no faster here than at login
+	if err := s.Logins.CheckLogin(ctx, user.Email, ""); err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on enroll mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if err := pkg.ComparePasswordAndHash(user.Password, req.GetPassword()); err != nil {
+		s.Logins.RecordLoginFailure(ctx, user, user.Email, "")
+
+		return nil, status.Errorf(codes.Unauthenticated, "invalid password")
+	}
+
+	enrollment, err := s.MFARepository.EnrollMFA(ctx, user.ID)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on enroll mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.EnrollMFAResponse{
+		Secret:        enrollment.Secret,
+		OtpauthUri:    pkg.TOTPURI(s.config.MFA_ISSUER, user.Email, enrollment.Secret),
+		RecoveryCodes: enrollment.RecoveryCodes,
+	}, nil
+}
+
+func (s *GRPCServer) ActivateMFA(ctx context.Context, req *pb.ActivateMFARequest) (*pb.ActivateMFAResponse, error) {
+	if s.MFARepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "two-factor authentication is not enabled")
+	}
+
+	if req.GetUserId() == 0 || req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and code are required")
+	}
+
+	enabledAt, err := s.MFARepository.ActivateMFA(ctx, req.GetUserId(), req.GetCode())
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on activate mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.ActivateMFAResponse{EnabledAt: timestamppb.New(enabledAt)}, nil
+}
+
+// VerifyMFA completes the login of a user with two-factor authentication, with the token
+// LoginUser returned and a code. Wrong codes count as failed logins of the user.
+func (s *GRPCServer) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginUserResponse, error) {
+	if s.MFARepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "two-factor authentication is not enabled")
+	}
+
+	if req.GetMfaToken() == "" || req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "mfa_token and code are required")
+	}
+
+	userID, err := s.MFARepository.GetMFAChallenge(ctx, req.GetMfaToken())
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	user, err := s.mfaUser(ctx, userID, "verify mfa")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Logins.CheckLogin(ctx, user.Email, req.GetClientIp()); err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if _, err := s.MFARepository.CompleteMFAChallenge(ctx, req.GetMfaToken(), req.GetCode()); err != nil {
+		if pkg.ErrorCode(err) == pkg.AUTHENTICATION_ERROR {
+			s.Logins.RecordLoginFailure(ctx, user, user.Email, req.GetClientIp())
+		}
+
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on verify mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	s.Logins.ClearLoginFailures(ctx, user.Email)
+
+	// the account may have been disabled since the password was checked
+	if user.Disabled() {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	return s.loginResponse(ctx, user)
+}
+
+// DisableMFA turns two-factor authentication off, given a current code. Wrong codes
+// count as failed logins of the user.
+func (s *GRPCServer) DisableMFA(ctx context.Context, req *pb.DisableMFARequest) (*pb.DisableMFAResponse, error) {
+	if s.MFARepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "two-factor authentication is not enabled")
+	}
+
+	if req.GetUserId() == 0 || req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and code are required")
+	}
+
+	user, err := s.mfaUser(ctx, req.GetUserId(), "disable mfa")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Logins.CheckLogin(ctx, user.Email, ""); err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on disable mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	enabled, err := s.MFARepository.MFAEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on disable mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if !enabled {
+		return nil, status.Errorf(codes.InvalidArgument, "two-factor authentication is not enabled")
+	}
+
+	if err := s.MFARepository.DisableMFA(ctx, user.ID, req.GetCode()); err != nil {
+		// with two-factor authentication enabled, only a wrong code is invalid
+		if pkg.ErrorCode(err) == pkg.INVALID_ERROR {
+			s.Logins.RecordLoginFailure(ctx, user, user.Email, "")
+		}
+
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on disable mfa: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.DisableMFAResponse{}, nil
+}
+
+// mfaUser returns the user changing or using their second factor, or proving who they
+// are again, who has to be enabled.
+func (s *GRPCServer) mfaUser(ctx context.Context, userID int64, action string) (*repository.User, error) {
+	user, err := s.UserRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on %s: %v", action, pkg.ErrorMessage(err)),
+		)
+	}
+
+	if user.Disabled() {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	return user, nil
+}
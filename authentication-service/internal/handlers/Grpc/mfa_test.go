@@ -0,0 +1,295 @@
+package Grpc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func mockMFAUser(id int64) (*repository.User, error) {
+	if id != foundID {
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+	}
+
+	hashPassword, _ := pkg.GenerateHashPassword("password", 4)
+
+	return &repository.User{
+		ID:        foundID,
+		FullName:  "Jane Doe",
+		Email:     "found@gmail.com",
+		Password:  hashPassword,
+		Role:      repository.RoleUser,
+		CreatedAt: TestTime,
+	}, nil
+}
+
+func TestGRPCServer_LoginUserMFA(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var (
+		cleared    []string
+		challenges []int64
+	)
+
+	s.UserRepository.GetUserFunc = mockGetUserFunc
+	s.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return true, nil
+	}
+	s.MFARepository.CreateMFAChallengeFunc = func(userID int64, expiresAt time.Time) (string, error) {
+		require.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Second)
+
+		challenges = append(challenges, userID)
+
+		return "mfa-token", nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = func(int64, time.Time) (string, *repository.RefreshToken, error) {
+		t.Fatal("no refresh token is issued before the code is given")
+
+		return "", nil, nil
+	}
+
+	rsp, err := s.server.LoginUser(context.Background(), &pb.LoginUserRequest{
+		Email:    authorizedEmail,
+		Password: "password",
+	})
+	require.NoError(t, err)
+	require.True(t, rsp.GetMfaRequired())
+	require.Equal(t, "mfa-token", rsp.GetMfaToken())
+	require.NotNil(t, rsp.GetMfaExpirationAt())
+	require.Empty(t, rsp.GetAccessToken())
+	require.Empty(t, rsp.GetRefreshToken())
+	require.Len(t, challenges, 1)
+
+	// failures are only forgotten once the code is given too
+	require.Empty(t, cleared)
+
+	// a wrong password gets no challenge
+	_, err = s.server.LoginUser(context.Background(), &pb.LoginUserRequest{
+		Email:    unauthorizedEmail,
+		Password: "password",
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Len(t, challenges, 1)
+}
+
+func TestGRPCServer_EnrollMFA(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var failures []string
+
+	s.UserRepository.GetUserByIDFunc = mockMFAUser
+	s.MFARepository.EnrollMFAFunc = func(userID int64) (*repository.MFAEnrollment, error) {
+		require.Equal(t, foundID, userID)
+
+		return &repository.MFAEnrollment{
+			Secret:        "JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"abcde-fghij"},
+		}, nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, _ string) (bool, error) {
+		failures = append(failures, email)
+
+		return false, nil
+	}
+
+	rsp, err := s.server.EnrollMFA(context.Background(), &pb.EnrollMFARequest{
+		UserId:   foundID,
+		Password: "password",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", rsp.GetSecret())
+	require.Equal(t, []string{"abcde-fghij"}, rsp.GetRecoveryCodes())
+
+	uri, err := url.Parse(rsp.GetOtpauthUri())
+	require.NoError(t, err)
+	require.Equal(t, "/Payment Polling:found@gmail.com", uri.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+
+	// a wrong password counts as a failed login
+	_, err = s.server.EnrollMFA(context.Background(), &pb.EnrollMFARequest{
+		UserId:   foundID,
+		Password: "wrong",
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, []string{"found@gmail.com"}, failures)
+
+	_, err = s.server.EnrollMFA(context.Background(), &pb.EnrollMFARequest{UserId: foundID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	s.MFARepository.EnrollMFAFunc = func(int64) (*repository.MFAEnrollment, error) {
+		return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "two-factor authentication is already enabled")
+	}
+
+	_, err = s.server.EnrollMFA(context.Background(), &pb.EnrollMFARequest{
+		UserId:   foundID,
+		Password: "password",
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	s.server.MFARepository = nil
+
+	_, err = s.server.EnrollMFA(context.Background(), &pb.EnrollMFARequest{
+		UserId:   foundID,
+		Password: "password",
+	})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGRPCServer_ActivateMFA(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	s.MFARepository.ActivateMFAFunc = func(_ int64, code string) (time.Time, error) {
+		if code != "123456" {
+			return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid code")
+		}
+
+		return TestTime, nil
+	}
+
+	rsp, err := s.server.ActivateMFA(context.Background(), &pb.ActivateMFARequest{UserId: foundID, Code: "123456"})
+	require.NoError(t, err)
+	require.Equal(t, TestTime, rsp.GetEnabledAt().AsTime())
+
+	_, err = s.server.ActivateMFA(context.Background(), &pb.ActivateMFARequest{UserId: foundID, Code: "000000"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.server.ActivateMFA(context.Background(), &pb.ActivateMFARequest{UserId: foundID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCServer_VerifyMFA(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var (
+		failures []string
+		cleared  []string
+	)
+
+	s.UserRepository.GetUserByIDFunc = mockMFAUser
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = mockCreateRefreshToken
+	s.MFARepository.GetMFAChallengeFunc = func(token string) (int64, error) {
+		if token != "mfa-token" {
+			return 0, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid or expired mfa token")
+		}
+
+		return foundID, nil
+	}
+	s.MFARepository.CompleteMFAChallengeFunc = func(_ string, code string) (int64, error) {
+		if code != "123456" {
+			return 0, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid code")
+		}
+
+		return foundID, nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, ip string) (bool, error) {
+		require.Equal(t, "10.0.0.1", ip)
+
+		failures = append(failures, email)
+
+		return false, nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+
+	verify := func(token, code string) (*pb.LoginUserResponse, error) {
+		return s.server.VerifyMFA(context.Background(), &pb.VerifyMFARequest{
+			MfaToken: token,
+			Code:     code,
+			ClientIp: "10.0.0.1",
+		})
+	}
+
+	// a wrong code counts as a failed login of the user
+	_, err := verify("mfa-token", "000000")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, []string{"found@gmail.com"}, failures)
+
+	// an unknown token does not name anyone to count it for
+	_, err = verify("unknown", "123456")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Len(t, failures, 1)
+
+	_, err = verify("mfa-token", "")
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	rsp, err := verify("mfa-token", "123456")
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.GetAccessToken())
+	require.NotEmpty(t, rsp.GetRefreshToken())
+	require.False(t, rsp.GetMfaRequired())
+	require.Equal(t, "found@gmail.com", rsp.GetData().GetEmail())
+	require.Equal(t, []string{"found@gmail.com"}, cleared)
+
+	// locked out users are refused before the code is checked
+	s.LoginAttemptRepository.CheckLoginFunc = func(_, _ string) error {
+		return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed login attempts, try again later")
+	}
+
+	_, err = verify("mfa-token", "000000")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Len(t, failures, 1)
+}
+
+func TestGRPCServer_DisableMFA(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var (
+		failures []string
+		disabled []int64
+	)
+
+	enabled := true
+
+	s.UserRepository.GetUserByIDFunc = mockMFAUser
+	s.MFARepository.MFAEnabledFunc = func(int64) (bool, error) {
+		return enabled, nil
+	}
+	s.MFARepository.DisableMFAFunc = func(userID int64, code string) error {
+		if code != "123456" {
+			return pkg.Errorf(pkg.INVALID_ERROR, "invalid code")
+		}
+
+		disabled = append(disabled, userID)
+
+		return nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, _ string) (bool, error) {
+		failures = append(failures, email)
+
+		return false, nil
+	}
+
+	_, err := s.server.DisableMFA(context.Background(), &pb.DisableMFARequest{UserId: foundID, Code: "000000"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, []string{"found@gmail.com"}, failures)
+	require.Empty(t, disabled)
+
+	_, err = s.server.DisableMFA(context.Background(), &pb.DisableMFARequest{UserId: foundID, Code: "123456"})
+	require.NoError(t, err)
+	require.Equal(t, []int64{foundID}, disabled)
+
+	enabled = false
+
+	_, err = s.server.DisableMFA(context.Background(), &pb.DisableMFARequest{UserId: foundID, Code: "123456"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Len(t, failures, 1)
+
+	_, err = s.server.DisableMFA(context.Background(), &pb.DisableMFARequest{UserId: notFoundID, Code: "123456"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,92 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ForgotPassword answers the same whether the email belongs to a user or not, so that
+// it can not be used to find out who has an account.
+func (s *GRPCServer) ForgotPassword(ctx context.Context, req *pb.ForgotPasswordRequest) (*pb.ForgotPasswordResponse, error) {
+	if s.Emails == nil || s.PasswordResetRepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "password reset is not enabled")
+	}
+
+	if req.GetEmail() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	user, err := s.UserRepository.GetUser(ctx, req.GetEmail())
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return &pb.ForgotPasswordResponse{}, nil
+		}
+
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on forgot password: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if user.Disabled() {
+		return &pb.ForgotPasswordResponse{}, nil
+	}
+
+	token, err := s.PasswordResetRepository.CreatePasswordResetToken(
+		ctx,
+		user.ID,
+		time.Now().Add(s.config.PASSWORD_RESET_TOKEN_DURATION),
+	)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on forgot password: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if err := s.Emails.SendPasswordReset(ctx, user.Email, token); err != nil {
+		slog.ErrorContext(ctx, "failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+
+	return &pb.ForgotPasswordResponse{}, nil
+}
+
+// ResetPassword sets a new password with a reset token, and revokes the refresh tokens
+// of the user for every session to log in again with it.
+func (s *GRPCServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if s.PasswordResetRepository == nil {
+		return nil, status.Errorf(codes.Unimplemented, "password reset is not enabled")
+	}
+
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	user, err := s.PasswordResetRepository.ResetPassword(ctx, req.GetToken(), req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on reset password: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if err := s.RefreshTokenRepository.RevokeRefreshTokens(ctx, user.ID, ""); err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error on reset password: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.ResetPasswordResponse{UserId: user.ID, Email: user.Email}, nil
+}
@@ -0,0 +1,150 @@
+package Grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServer_ForgotPassword(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var sent []mailer.Message
+
+	withEmails(t, s, &sent)
+
+	users := map[string]*repository.User{
+		"found@gmail.com":    {ID: 1, Email: "found@gmail.com"},
+		"disabled@gmail.com": {ID: 2, Email: "disabled@gmail.com", DisabledAt: &TestTime},
+	}
+
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		if email == "error@gmail.com" {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "database is down")
+		}
+
+		user, ok := users[email]
+		if !ok {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return user, nil
+	}
+
+	var expiresAt time.Time
+
+	s.PasswordResetRepository.CreatePasswordResetTokenFunc = func(userID int64, expires time.Time) (string, error) {
+		expiresAt = expires
+
+		return "reset-token", nil
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		wantCode codes.Code
+		wantSent bool
+	}{
+		{
+			name:     "sent",
+			email:    "found@gmail.com",
+			wantCode: codes.OK,
+			wantSent: true,
+		},
+		{
+			name:     "unknown email",
+			email:    "unknown@gmail.com",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "disabled account",
+			email:    "disabled@gmail.com",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "no email",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "database error",
+			email:    "error@gmail.com",
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sent = nil
+
+			_, err := s.server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: tc.email})
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if !tc.wantSent {
+				require.Empty(t, sent)
+
+				return
+			}
+
+			require.Len(t, sent, 1)
+			require.Equal(t, tc.email, sent[0].To)
+			require.Equal(t, "reset-token", tokenOf(t, sent[0]))
+			require.WithinDuration(t, time.Now().Add(30*time.Minute), expiresAt, time.Minute)
+		})
+	}
+
+	s.server.Emails = nil
+
+	_, err := s.server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: "found@gmail.com"})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGRPCServer_ResetPassword(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	s.PasswordResetRepository.ResetPasswordFunc = func(token string, password string) (*repository.User, error) {
+		if token != "reset-token" {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid or expired reset token")
+		}
+
+		return &repository.User{ID: foundID, Email: "found@gmail.com"}, nil
+	}
+
+	var revoked []int64
+
+	s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(userID int64, token string) error {
+		require.Empty(t, token)
+
+		revoked = append(revoked, userID)
+
+		return nil
+	}
+
+	rsp, err := s.server.ResetPassword(context.Background(), &pb.ResetPasswordRequest{Token: "reset-token", Password: "new password"})
+	require.NoError(t, err)
+	require.Equal(t, foundID, rsp.GetUserId())
+	require.Equal(t, "found@gmail.com", rsp.GetEmail())
+
+	// every session of the user has to log in again with the new password
+	require.Equal(t, []int64{foundID}, revoked)
+
+	_, err = s.server.ResetPassword(context.Background(), &pb.ResetPasswordRequest{Token: "used-token", Password: "new password"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.server.ResetPassword(context.Background(), &pb.ResetPasswordRequest{Password: "new password"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(int64, string) error {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "database is down")
+	}
+
+	_, err = s.server.ResetPassword(context.Background(), &pb.ResetPasswordRequest{Token: "reset-token", Password: "new password"})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
@@ -1,26 +1,51 @@
 package Grpc
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"sync"
 
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 type GRPCServer struct {
 	pb.UnimplementedAuthenticationServiceServer
 	gRPCServer *grpc.Server
+	health     *health.Server
 	config     pkg.Config
 	maker      pkg.JWTMaker
 	// shutdownCh chan struct{}
 	mu sync.Mutex
 
-	UserRepository repository.UserRepository
+	UserRepository          repository.UserRepository
+	RefreshTokenRepository  repository.RefreshTokenRepository
+	APIKeyRepository        repository.APIKeyRepository
+	AuditRepository         repository.AuditRepository
+	PasswordResetRepository repository.PasswordResetRepository
+
+	// Logins checks the passwords of users logging in, holds back those that failed
+	// too often, and asks those with two-factor authentication for a code.
+	Logins *login.Logins
+
+	// MFARepository holds the TOTP secrets of users with two-factor authentication, who
+	// are asked for a code after their password. No code is asked for when it is nil.
+	MFARepository repository.MFARepository
+
+	// Emails sends the verification emails of new users and the password reset emails,
+	// none are sent when it is nil.
+	Emails *mailer.Emails
 }
 
 func NewGRPCServer(config pkg.Config, tokenMaker pkg.JWTMaker) *GRPCServer {
@@ -33,25 +58,68 @@ func NewGRPCServer(config pkg.Config, tokenMaker pkg.JWTMaker) *GRPCServer {
 
 func (s *GRPCServer) Start(port string) error {
 	s.mu.Lock()
-	s.gRPCServer = grpc.NewServer()
-	s.mu.Unlock()
+	s.gRPCServer = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logging.UnaryServerInterceptor(),
+		tracing.UnaryServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+	))
 
 	pb.RegisterAuthenticationServiceServer(s.gRPCServer, s)
 
+	s.health = health.NewServer()
+	s.health.SetServingStatus(pb.AuthenticationService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s.gRPCServer, s.health)
+
 	reflection.Register(s.gRPCServer)
 
+	server := s.gRPCServer
+	s.mu.Unlock()
+
 	listener, err := net.Listen("tcp", port)
 	if err != nil {
 		return fmt.Errorf("failed to start grpc server on port: %s", err)
 	}
 
-	return s.gRPCServer.Serve(listener)
+	return server.Serve(listener)
+}
+
+// Shutdown stops the server gracefully, letting in-flight RPCs finish, and closes the
+// connections that are still open once ctx expires.
+func (s *GRPCServer) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	server := s.gRPCServer
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+	s.mu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
 }
 
 func (s *GRPCServer) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
 	if s.gRPCServer != nil {
 		s.gRPCServer.GracefulStop()
 	}
@@ -7,13 +7,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 )
 
 type TestGRPCServer struct {
-	server         *GRPCServer
-	UserRepository mock.MockUsersRepositry
+	server                  *GRPCServer
+	UserRepository          mock.MockUsersRepositry
+	RefreshTokenRepository  mock.MockRefreshTokenRepository
+	APIKeyRepository        mock.MockAPIKeyRepository
+	AuditRepository         mock.MockAuditRepository
+	PasswordResetRepository mock.MockPasswordResetRepository
+	LoginAttemptRepository  mock.MockLoginAttemptRepository
+	MFARepository           mock.MockMFARepository
 }
 
 func NewTestGRPCServer() *TestGRPCServer {
@@ -28,16 +35,59 @@ func NewTestGRPCServer() *TestGRPCServer {
 
 	s := &TestGRPCServer{
 		server: NewGRPCServer(
-			pkg.Config{TOKEN_DURATION: time.Second},
+			pkg.Config{
+				TOKEN_DURATION:                time.Second,
+				REFRESH_TOKEN_DURATION:        time.Hour,
+				PASSWORD_RESET_TOKEN_DURATION: 30 * time.Minute,
+				MFA_ISSUER:                    "Payment Polling",
+				MFA_CHALLENGE_DURATION:        5 * time.Minute,
+			},
 			pkg.JWTMaker{PublicKey: publicKey, PrivateKey: privateKey},
 		),
 	}
 
 	s.server.UserRepository = &s.UserRepository
+	s.server.RefreshTokenRepository = &s.RefreshTokenRepository
+	s.server.APIKeyRepository = &s.APIKeyRepository
+	s.server.AuditRepository = &s.AuditRepository
+	s.server.PasswordResetRepository = &s.PasswordResetRepository
+	s.server.MFARepository = &s.MFARepository
+	s.server.Logins = s.newLogins(s.server.config)
+
+	mockLoginAttempts(&s.LoginAttemptRepository)
+
+	// users log in without a code unless a test turns two-factor authentication on
+	s.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return false, nil
+	}
 
 	return s
 }
 
+// newLogins checks logins against the mocked repositories of s, under config.
+func (s *TestGRPCServer) newLogins(config pkg.Config) *login.Logins {
+	logins := login.NewLogins(config)
+	logins.UserRepository = &s.UserRepository
+	logins.AuditRepository = &s.AuditRepository
+	logins.LoginAttemptRepository = &s.LoginAttemptRepository
+	logins.MFARepository = &s.MFARepository
+
+	return logins
+}
+
+// mockLoginAttempts lets every login through, without locking anyone out.
+func mockLoginAttempts(r *mock.MockLoginAttemptRepository) {
+	r.CheckLoginFunc = func(_, _ string) error {
+		return nil
+	}
+	r.RecordLoginFailureFunc = func(_, _ string) (bool, error) {
+		return false, nil
+	}
+	r.ClearLoginFailuresFunc = func(_ string) error {
+		return nil
+	}
+}
+
 func TestGRPCServer_Start(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -83,3 +133,29 @@ func TestGRPCServer_Start(t *testing.T) {
 		})
 	}
 }
+
+func TestGRPCServer_Shutdown(t *testing.T) {
+	s := NewTestGRPCServer()
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.server.Start("127.0.0.1:0")
+	}()
+
+	// wait for Start to create the server
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.server.Shutdown(ctx)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("GRPCServer.Start() error = %v after shutdown", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("GRPCServer.Start() did not return after shutdown")
+	}
+}
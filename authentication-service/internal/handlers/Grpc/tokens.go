@@ -0,0 +1,104 @@
+package Grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RefreshToken exchanges a refresh token for a new access token, and a new refresh token
+// in place of the one used.
+func (s *GRPCServer) RefreshToken(
+	ctx context.Context,
+	req *pb.RefreshTokenRequest,
+) (*pb.RefreshTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	refreshToken, refreshPayload, err := s.RefreshTokenRepository.RotateRefreshToken(ctx, req.GetRefreshToken())
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on refresh token: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	user, err := s.UserRepository.GetUserByID(ctx, refreshPayload.UserID)
+	if err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on refresh token: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if user.Disabled() {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	accessToken, err := s.maker.CreateToken(user.Email, user.ID, user.Role, s.config.TOKEN_DURATION)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Error creating token: %v", err)
+	}
+
+	return &pb.RefreshTokenResponse{
+		AccessToken:         accessToken,
+		ExpirationAt:        timestamppb.New(time.Now().Add(s.config.TOKEN_DURATION)),
+		RefreshToken:        refreshToken,
+		RefreshExpirationAt: timestamppb.New(refreshPayload.ExpiresAt),
+	}, nil
+}
+
+// RevokeRefreshTokens revokes the family of a refresh token on logout, or every refresh
+// token of the user when none is given.
+func (s *GRPCServer) RevokeRefreshTokens(
+	ctx context.Context,
+	req *pb.RevokeRefreshTokensRequest,
+) (*pb.RevokeRefreshTokensResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	if err := s.RefreshTokenRepository.RevokeRefreshTokens(ctx, req.GetUserId(), req.GetRefreshToken()); err != nil {
+		grpcCode := convertPkgError(pkg.ErrorCode(err))
+
+		return nil, status.Errorf(
+			grpcCode,
+			"%v",
+			fmt.Sprintf("error on revoke refresh tokens: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	return &pb.RevokeRefreshTokensResponse{}, nil
+}
+
+// GetJWKS returns the public keys tokens are verified with, the signing key first.
+func (s *GRPCServer) GetJWKS(_ context.Context, _ *pb.GetJWKSRequest) (*pb.GetJWKSResponse, error) {
+	jwks := s.maker.JWKS()
+
+	rsp := &pb.GetJWKSResponse{Keys: make([]*pb.JSONWebKey, 0, len(jwks.Keys))}
+	for _, key := range jwks.Keys {
+		rsp.Keys = append(rsp.Keys, &pb.JSONWebKey{
+			Kid: key.KeyID,
+			Kty: key.KeyType,
+			Alg: key.Algorithm,
+			Use: key.Use,
+			N:   key.N,
+			E:   key.E,
+		})
+	}
+
+	return rsp, nil
+}
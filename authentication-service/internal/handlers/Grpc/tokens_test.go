@@ -0,0 +1,178 @@
+package Grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func mockCreateRefreshToken(userID int64, expiresAt time.Time) (string, *repository.RefreshToken, error) {
+	return "refresh-token", &repository.RefreshToken{
+		ID:        1,
+		UserID:    userID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: expiresAt,
+		CreatedAt: TestTime,
+	}, nil
+}
+
+func mockRotateRefreshToken(token string) (string, *repository.RefreshToken, error) {
+	switch token {
+	case "valid":
+		return "rotated", &repository.RefreshToken{
+			ID:        2,
+			UserID:    foundID,
+			FamilyID:  uuid.New(),
+			ExpiresAt: TestTime.Add(time.Hour),
+			CreatedAt: TestTime,
+		}, nil
+	case "orphan":
+		return "rotated", &repository.RefreshToken{ID: 3, UserID: notFoundID}, nil
+	default:
+		return "", nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "refresh token reuse detected, log in again")
+	}
+}
+
+func mockGetUserByID(id int64) (*repository.User, error) {
+	if id == foundID {
+		return &repository.User{ID: foundID, Email: "found@gmail.com", CreatedAt: TestTime}, nil
+	}
+
+	return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+}
+
+func TestGRPCServer_RefreshToken(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	s.RefreshTokenRepository.RotateRefreshTokenFunc = mockRotateRefreshToken
+	s.UserRepository.GetUserByIDFunc = mockGetUserByID
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode codes.Code
+	}{
+		{
+			name:     "rotated",
+			token:    "valid",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "missing token",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "reused token",
+			token:    "reused",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "deleted user",
+			token:    "orphan",
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.server.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: tc.token})
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				require.Nil(t, got)
+
+				return
+			}
+
+			require.Equal(t, "rotated", got.GetRefreshToken())
+			require.Equal(t, TestTime.Add(time.Hour), got.GetRefreshExpirationAt().AsTime())
+
+			payload, err := s.server.maker.VerifyToken(got.GetAccessToken())
+			require.NoError(t, err)
+			require.Equal(t, foundID, payload.UserID)
+			require.Equal(t, "found@gmail.com", payload.Username)
+		})
+	}
+}
+
+func TestGRPCServer_RevokeRefreshTokens(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var revoked []string
+
+	s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(userID int64, token string) error {
+		if userID != foundID {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "db error")
+		}
+
+		revoked = append(revoked, token)
+
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		req      *pb.RevokeRefreshTokensRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "one family",
+			req:      &pb.RevokeRefreshTokensRequest{UserId: foundID, RefreshToken: "valid"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "all tokens",
+			req:      &pb.RevokeRefreshTokensRequest{UserId: foundID},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "missing user",
+			req:      &pb.RevokeRefreshTokensRequest{RefreshToken: "valid"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "repository error",
+			req:      &pb.RevokeRefreshTokensRequest{UserId: notFoundID},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.server.RevokeRefreshTokens(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+
+	require.Equal(t, []string{"valid", ""}, revoked)
+}
+
+func TestGRPCServer_GetJWKS(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	rsp, err := s.server.GetJWKS(context.Background(), &pb.GetJWKSRequest{})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetKeys(), 1)
+
+	key := rsp.GetKeys()[0]
+	require.Equal(t, pkg.KeyID(s.server.maker.PublicKey), key.GetKid())
+	require.Equal(t, "RS256", key.GetAlg())
+	require.Equal(t, "AQAB", key.GetE())
+
+	// tokens name the key they are signed with
+	token, err := s.server.maker.CreateToken("found@gmail.com", foundID, repository.RoleUser, time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &pkg.Payload{})
+	require.NoError(t, err)
+	require.Equal(t, key.GetKid(), parsed.Header["kid"])
+}
@@ -2,7 +2,6 @@ package Grpc
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
@@ -37,6 +36,8 @@ func (s *GRPCServer) RegisterUser(
 		)
 	}
 
+	s.sendVerification(ctx, user)
+
 	return &pb.RegisterUserResponse{
 		Fullname:  user.FullName,
 		Email:     user.Email,
@@ -45,36 +46,54 @@ func (s *GRPCServer) RegisterUser(
 }
 
 func (s *GRPCServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
-	user, err := s.UserRepository.GetUser(ctx, req.GetEmail())
+	result, err := s.Logins.Login(ctx, req.GetEmail(), req.GetPassword(), req.GetClientIp())
 	if err != nil {
-		grpcCode := convertPkgError(pkg.ErrorCode(err))
+		return nil, status.Errorf(convertPkgError(pkg.ErrorCode(err)), "%v", pkg.ErrorMessage(err))
+	}
 
-		return nil, status.Errorf(
-			grpcCode,
-			"%v",
-			fmt.Sprintf("error on logging user: %v", pkg.ErrorMessage(err)),
-		)
+	// users with two-factor authentication get the token that completes their login
+	// instead of their tokens
+	if result.MFARequired {
+		return &pb.LoginUserResponse{
+			Data: &pb.RegisterUserResponse{
+				Fullname:  result.User.FullName,
+				Email:     result.User.Email,
+				CreatedAt: timestamppb.New(result.User.CreatedAt),
+			},
+			MfaRequired:     true,
+			MfaToken:        result.MFAToken,
+			MfaExpirationAt: timestamppb.New(result.MFAExpiresAt),
+		}, nil
 	}
 
-	err = pkg.ComparePasswordAndHash(user.Password, req.GetPassword())
+	return s.loginResponse(ctx, result.User)
+}
+
+// loginResponse issues the access and refresh tokens of a user who logged in.
+func (s *GRPCServer) loginResponse(ctx context.Context, user *repository.User) (*pb.LoginUserResponse, error) {
+	accessToken, err := s.maker.CreateToken(user.Email, user.ID, user.Role, s.config.TOKEN_DURATION)
 	if err != nil {
 		grpcCode := convertPkgError(pkg.ErrorCode(err))
 
 		return nil, status.Errorf(
 			grpcCode,
 			"%v",
-			fmt.Sprintf("Error comparing passwords: %v", pkg.ErrorMessage(err)),
+			fmt.Sprintf("Error creating token: %v", pkg.ErrorMessage(err)),
 		)
 	}
 
-	accessToken, err := s.maker.CreateToken(user.Email, user.ID, s.config.TOKEN_DURATION)
+	refreshToken, refreshPayload, err := s.RefreshTokenRepository.CreateRefreshToken(
+		ctx,
+		user.ID,
+		time.Now().Add(s.config.REFRESH_TOKEN_DURATION),
+	)
 	if err != nil {
 		grpcCode := convertPkgError(pkg.ErrorCode(err))
 
 		return nil, status.Errorf(
 			grpcCode,
 			"%v",
-			fmt.Sprintf("Error creating token: %v", pkg.ErrorMessage(err)),
+			fmt.Sprintf("Error creating refresh token: %v", pkg.ErrorMessage(err)),
 		)
 	}
 
@@ -86,13 +105,15 @@ func (s *GRPCServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*
 			Email:     user.Email,
 			CreatedAt: timestamppb.New(user.CreatedAt),
 		},
+		RefreshToken:        refreshToken,
+		RefreshExpirationAt: timestamppb.New(refreshPayload.ExpiresAt),
 	}, nil
 }
 
 func (s *GRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
 	user, err := s.UserRepository.GetUser(ctx, req.GetEmail())
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
 			return nil, status.Errorf(codes.NotFound, "user not found")
 		}
 
@@ -105,6 +126,7 @@ func (s *GRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.G
 		PaydUsernameKey: user.PaydUsernameKey,
 		PaydPasswordKey: user.PaydPasswordKey,
 		PaydAccountId:   user.PaydAccountID,
+		EmailVerified:   user.EmailVerified(),
 	}, nil
 }
 
@@ -122,6 +144,10 @@ func convertPkgError(err string) codes.Code {
 		return codes.Unimplemented
 	case pkg.AUTHENTICATION_ERROR:
 		return codes.Unauthenticated
+	case pkg.PERMISSION_ERROR:
+		return codes.PermissionDenied
+	case pkg.RATE_LIMIT_ERROR:
+		return codes.ResourceExhausted
 	default:
 		return codes.Internal
 	}
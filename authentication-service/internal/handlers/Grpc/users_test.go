@@ -2,7 +2,6 @@ package Grpc
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"math/rand/v2"
 	"testing"
@@ -12,7 +11,9 @@ import (
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -22,6 +23,8 @@ var (
 	authorizedEmail         = "login_success"
 	unauthorizedEmail       = "login_fail"
 	notFoundEmail           = "no_user"
+	disabledEmail           = "login_disabled"
+	unverifiedEmail         = "login_unverified"
 	foundID           int64 = 32
 	notFoundID        int64 = 33
 	errorID           int64 = 34
@@ -98,10 +101,11 @@ func mockGetUser(email string) (*repository.User, error) {
 			PaydUsernameKey: "user_key",
 			PaydPasswordKey: "pass_key",
 			PaydAccountID:   "account_id",
+			EmailVerifiedAt: &TestTime,
 			CreatedAt:       TestTime,
 		}, nil
 	} else if email == "notfound@gmail.com" {
-		return nil, sql.ErrNoRows
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
 	}
 
 	return nil, errors.New("internal error")
@@ -127,6 +131,7 @@ func TestGRPCServer_GetUser(t *testing.T) {
 				PaydUsernameKey: "user_key",
 				PaydPasswordKey: "pass_key",
 				PaydAccountId:   "account_id",
+				EmailVerified:   true,
 			},
 			wantErr: false,
 		},
@@ -170,6 +175,23 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 		rsp := randomRepoUser()
 		rsp.Password = "UNAUTHORIZED"
 
+		return rsp, nil
+	} else if email == disabledEmail {
+		hashPassword, _ := pkg.GenerateHashPassword("password", 10)
+		disabledAt := TestTime
+
+		rsp := randomRepoUser()
+		rsp.Password = hashPassword
+		rsp.DisabledAt = &disabledAt
+
+		return rsp, nil
+	} else if email == unverifiedEmail {
+		hashPassword, _ := pkg.GenerateHashPassword("password", 10)
+
+		rsp := randomRepoUser()
+		rsp.Password = hashPassword
+		rsp.EmailVerifiedAt = nil
+
 		return rsp, nil
 	}
 
@@ -179,7 +201,10 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 func TestGRPCServer_LoginUser(t *testing.T) {
 	s := NewTestGRPCServer()
 
+	s.server.config.REQUIRE_VERIFIED_EMAIL = true
+	s.server.Logins = s.newLogins(s.server.config)
 	s.UserRepository.GetUserFunc = mockGetUserFunc
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = mockCreateRefreshToken
 
 	tests := []struct {
 		name    string
@@ -205,16 +230,104 @@ func TestGRPCServer_LoginUser(t *testing.T) {
 			want:    &pb.LoginUserResponse{},
 			wantErr: true,
 		},
+		{
+			name:    "Disabled account",
+			args:    &pb.LoginUserRequest{Email: disabledEmail, Password: "password"},
+			want:    &pb.LoginUserResponse{},
+			wantErr: true,
+		},
+		{
+			name:    "Unverified email",
+			args:    &pb.LoginUserRequest{Email: unverifiedEmail, Password: "password"},
+			want:    &pb.LoginUserResponse{},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := s.server.LoginUser(context.Background(), tc.args)
+			got, err := s.server.LoginUser(context.Background(), tc.args)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("login user error = %v, wantErr %v", err, tc.wantErr)
 			}
+
+			if !tc.wantErr && got.GetRefreshToken() != "refresh-token" {
+				t.Errorf("login user refresh token = %q, want %q", got.GetRefreshToken(), "refresh-token")
+			}
+		})
+	}
+}
+
+func TestGRPCServer_LoginUserLockout(t *testing.T) {
+	s := NewTestGRPCServer()
+
+	var (
+		failures []string
+		cleared  []string
+		events   []repository.AuditEvent
+	)
+
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		if email == notFoundEmail {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return mockGetUserFunc(email)
+	}
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = mockCreateRefreshToken
+	s.LoginAttemptRepository.CheckLoginFunc = func(email, _ string) error {
+		if email == "locked" {
+			return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed login attempts, try again later")
+		}
+
+		return nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, ip string) (bool, error) {
+		require.Equal(t, "10.0.0.1", ip)
+
+		failures = append(failures, email)
+
+		// the wrong password is the one locking the account out
+		return email == unauthorizedEmail, nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+	s.AuditRepository.RecordAuditEventFunc = func(event repository.AuditEvent) (*repository.AuditEvent, error) {
+		events = append(events, event)
+
+		return &event, nil
+	}
+
+	login := func(email string) error {
+		_, err := s.server.LoginUser(context.Background(), &pb.LoginUserRequest{
+			Email:    email,
+			Password: "password",
+			ClientIp: "10.0.0.1",
 		})
+
+		return err
 	}
+
+	// unknown emails and wrong passwords are answered alike
+	unknownErr := login(notFoundEmail)
+	wrongErr := login(unauthorizedEmail)
+	require.Equal(t, codes.Unauthenticated, status.Code(unknownErr))
+	require.Equal(t, status.Convert(unknownErr).Message(), status.Convert(wrongErr).Message())
+	require.Equal(t, []string{notFoundEmail, unauthorizedEmail}, failures)
+
+	require.Len(t, events, 1)
+	require.Equal(t, repository.ActionUserLock, events[0].Action)
+	require.Equal(t, events[0].ActorID, events[0].TargetUserID)
+
+	// locked out emails are refused before the password is compared
+	require.Equal(t, codes.ResourceExhausted, status.Code(login("locked")))
+	require.Len(t, failures, 2)
+
+	require.NoError(t, login(authorizedEmail))
+	require.Len(t, cleared, 1)
 }
 
 func TestGRPCServer_convertPkgError(t *testing.T) {
@@ -253,6 +366,16 @@ func TestGRPCServer_convertPkgError(t *testing.T) {
 			err:  pkg.AUTHENTICATION_ERROR,
 			want: codes.Unauthenticated,
 		},
+		{
+			name: "permission_error",
+			err:  pkg.PERMISSION_ERROR,
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "rate_limit_error",
+			err:  pkg.RATE_LIMIT_ERROR,
+			want: codes.ResourceExhausted,
+		},
 		{
 			name: "default",
 			err:  "system_error",
@@ -292,6 +415,7 @@ func randomRepoUser() *repository.User {
 		PaydAccountID:   gofakeit.UUID(),
 		PaydUsernameKey: gofakeit.UUID(),
 		PaydPasswordKey: gofakeit.UUID(),
+		EmailVerifiedAt: &TestTime,
 		CreatedAt:       TestTime,
 	}
 }
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type forgotPasswordRequest struct {
+	Email string `binding:"required" json:"email"`
+}
+
+type forgotPasswordResponse struct{}
+
+type resetPasswordRequest struct {
+	Token    string `binding:"required" json:"token"`
+	Password string `binding:"required" json:"password"`
+}
+
+type resetPasswordResponse struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// handleForgotPassword answers the same whether the email belongs to a user or not, so
+// that it can not be used to find out who has an account.
+func (s *HTTPServer) handleForgotPassword(ctx *gin.Context) {
+	if s.Emails == nil || s.PasswordResetRepository == nil {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"status_code": http.StatusNotImplemented, "message": "password reset is not enabled"})
+
+		return
+	}
+
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	user, err := s.UserRepository.GetUser(ctx.Request.Context(), req.Email)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			ctx.JSON(http.StatusOK, forgotPasswordResponse{})
+
+			return
+		}
+
+		statusCode := convertPkgError(pkg.ErrorCode(err))
+		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
+
+		return
+	}
+
+	if user.Disabled() {
+		ctx.JSON(http.StatusOK, forgotPasswordResponse{})
+
+		return
+	}
+
+	token, err := s.PasswordResetRepository.CreatePasswordResetToken(
+		ctx.Request.Context(),
+		user.ID,
+		time.Now().Add(s.config.PASSWORD_RESET_TOKEN_DURATION),
+	)
+	if err != nil {
+		statusCode := convertPkgError(pkg.ErrorCode(err))
+		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
+
+		return
+	}
+
+	if err := s.Emails.SendPasswordReset(ctx.Request.Context(), user.Email, token); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+
+	ctx.JSON(http.StatusOK, forgotPasswordResponse{})
+}
+
+// handleResetPassword sets a new password with a reset token, and revokes the refresh
+// tokens of the user for every session to log in again with it.
+func (s *HTTPServer) handleResetPassword(ctx *gin.Context) {
+	if s.PasswordResetRepository == nil {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"status_code": http.StatusNotImplemented, "message": "password reset is not enabled"})
+
+		return
+	}
+
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	user, err := s.PasswordResetRepository.ResetPassword(ctx.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		statusCode := convertPkgError(pkg.ErrorCode(err))
+		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
+
+		return
+	}
+
+	if err := s.RefreshTokenRepository.RevokeRefreshTokens(ctx.Request.Context(), user.ID, ""); err != nil {
+		statusCode := convertPkgError(pkg.ErrorCode(err))
+		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resetPasswordResponse{UserID: user.ID, Email: user.Email})
+}
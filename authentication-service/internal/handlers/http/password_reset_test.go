@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPServer_HandleForgotPassword(t *testing.T) {
+	s := NewTestHTTPServer()
+
+	var sent []mailer.Message
+
+	s.server.Emails = mailer.NewEmails(&mock.MockMailer{SendFunc: func(msg mailer.Message) error {
+		sent = append(sent, msg)
+
+		return nil
+	}}, nil, pkg.Config{PASSWORD_RESET_URL: "http://localhost:8080/password/reset"})
+
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		switch email {
+		case "found@gmail.com":
+			return &repository.User{ID: 1, Email: email}, nil
+		case "disabled@gmail.com":
+			return &repository.User{ID: 2, Email: email, DisabledAt: &TestTime}, nil
+		}
+
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+	}
+	s.PasswordResetRepository.CreatePasswordResetTokenFunc = func(int64, time.Time) (string, error) {
+		return "reset-token", nil
+	}
+
+	tests := []struct {
+		name       string
+		email      string
+		statusCode int
+		wantSent   bool
+	}{
+		{
+			name:       "sent",
+			email:      "found@gmail.com",
+			statusCode: http.StatusOK,
+			wantSent:   true,
+		},
+		{
+			name:       "unknown email",
+			email:      "unknown@gmail.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "disabled account",
+			email:      "disabled@gmail.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "bad request",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sent = nil
+
+			b, err := json.Marshal(forgotPasswordRequest{Email: tc.email})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.statusCode, w.Code)
+
+			if tc.wantSent {
+				require.Len(t, sent, 1)
+				require.Contains(t, sent[0].Body, "token=reset-token")
+			} else {
+				require.Empty(t, sent)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_HandleResetPassword(t *testing.T) {
+	s := NewTestHTTPServer()
+
+	s.PasswordResetRepository.ResetPasswordFunc = func(token string, _ string) (*repository.User, error) {
+		if token != "reset-token" {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid or expired reset token")
+		}
+
+		return &repository.User{ID: 7, Email: "found@gmail.com"}, nil
+	}
+
+	var revoked []int64
+
+	s.RefreshTokenRepository.RevokeRefreshTokensFunc = func(userID int64, _ string) error {
+		revoked = append(revoked, userID)
+
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		payload    resetPasswordRequest
+		statusCode int
+	}{
+		{
+			name:       "reset",
+			payload:    resetPasswordRequest{Token: "reset-token", Password: "new password"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "used token",
+			payload:    resetPasswordRequest{Token: "used-token", Password: "new password"},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "no password",
+			payload:    resetPasswordRequest{Token: "reset-token"},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.payload)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.statusCode, w.Code)
+
+			if tc.statusCode == http.StatusOK {
+				require.JSONEq(t, `{"user_id":7,"email":"found@gmail.com"}`, w.Body.String())
+			}
+		})
+	}
+
+	require.Equal(t, []int64{7}, revoked)
+}
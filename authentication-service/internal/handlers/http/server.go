@@ -1,19 +1,41 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
 	"github.com/gin-gonic/gin"
 )
 
 type HTTPServer struct {
 	router *gin.Engine
+	server *http.Server
 	config pkg.Config
 	maker  pkg.JWTMaker
 
-	UserRepository repository.UserRepository
+	HealthChecker           *health.Checker
+	UserRepository          repository.UserRepository
+	RefreshTokenRepository  repository.RefreshTokenRepository
+	PasswordResetRepository repository.PasswordResetRepository
+
+	// Logins checks the passwords of users logging in, holds back those that failed
+	// too often, and asks those with two-factor authentication for a code.
+	Logins *login.Logins
+
+	// Emails sends the verification emails of new users and the password reset emails,
+	// none are sent when it is nil.
+	Emails *mailer.Emails
 }
 
 func NewHTTPServer(config pkg.Config, tokenMaker pkg.JWTMaker) *HTTPServer {
@@ -25,19 +47,74 @@ func NewHTTPServer(config pkg.Config, tokenMaker pkg.JWTMaker) *HTTPServer {
 }
 
 func (s *HTTPServer) setRoutes() {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logging.GinMiddleware())
+	r.Use(tracing.GinMiddleware())
+	r.Use(metrics.GinMiddleware())
+	r.Use(deadlineMiddleware())
 
 	r.GET("/healthcheck", s.handleHealthCheck)
+	r.GET("/.well-known/jwks.json", s.handleJWKS)
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/livez", func(ctx *gin.Context) { s.HealthChecker.Live(ctx.Writer, ctx.Request) })
+	r.GET("/readyz", func(ctx *gin.Context) { s.HealthChecker.Ready(ctx.Writer, ctx.Request) })
 	r.POST("/auth/register", s.handleRegisterUser)
 	r.POST("/auth/login", s.handleLoginUser)
+	r.POST("/auth/password/forgot", s.handleForgotPassword)
+	r.POST("/auth/password/reset", s.handleResetPassword)
 
 	s.router = r
+	s.server = &http.Server{
+		Addr:              s.config.HTTP_PORT,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// requestTimeoutHeader carries the milliseconds the caller gives a request to be served in.
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// deadlineMiddleware bounds the request context by the timeout its caller sent, so that
+// the work on it stops once nobody waits for the response anymore.
+func deadlineMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ms, err := strconv.ParseInt(ctx.GetHeader(requestTimeoutHeader), 10, 64)
+		if err != nil || ms <= 0 {
+			ctx.Next()
+
+			return
+		}
+
+		c, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(ms)*time.Millisecond)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(c)
+
+		ctx.Next()
+	}
 }
 
 func (s *HTTPServer) handleHealthCheck(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
 
+// handleJWKS publishes the public keys tokens are verified with. Verifiers may cache them
+// for a while, and fetch them again on seeing a token signed with a key they do not know.
+func (s *HTTPServer) handleJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, s.maker.JWKS())
+}
+
 func (s *HTTPServer) Start() error {
-	return s.router.Run(s.config.HTTP_PORT)
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops accepting connections and waits for the requests being served to finish.
+func (s *HTTPServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
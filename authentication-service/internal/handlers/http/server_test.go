@@ -1,18 +1,30 @@
 package http
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 )
 
 type TestHTTPServer struct {
-	server         *HTTPServer
-	UserRepository mock.MockUsersRepositry
+	server                  *HTTPServer
+	UserRepository          mock.MockUsersRepositry
+	RefreshTokenRepository  mock.MockRefreshTokenRepository
+	PasswordResetRepository mock.MockPasswordResetRepository
+	AuditRepository         mock.MockAuditRepository
+	LoginAttemptRepository  mock.MockLoginAttemptRepository
+	MFARepository           mock.MockMFARepository
 }
 
 func NewTestHTTPServer() *TestHTTPServer {
@@ -27,12 +39,136 @@ func NewTestHTTPServer() *TestHTTPServer {
 
 	s := &TestHTTPServer{
 		server: NewHTTPServer(
-			pkg.Config{HTTP_PORT: "0.0.0.0:5050", TOKEN_DURATION: time.Second},
+			pkg.Config{HTTP_PORT: "0.0.0.0:5050", TOKEN_DURATION: time.Second, PASSWORD_RESET_TOKEN_DURATION: 30 * time.Minute, MFA_CHALLENGE_DURATION: 5 * time.Minute},
 			pkg.JWTMaker{PublicKey: publicKey, PrivateKey: privateKey},
 		),
 	}
 
 	s.server.UserRepository = &s.UserRepository
+	s.server.RefreshTokenRepository = &s.RefreshTokenRepository
+	s.server.PasswordResetRepository = &s.PasswordResetRepository
+	s.server.Logins = s.newLogins(s.server.config)
+
+	// every login is let through unless a test says otherwise
+	s.LoginAttemptRepository.CheckLoginFunc = func(_, _ string) error {
+		return nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(_, _ string) (bool, error) {
+		return false, nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(_ string) error {
+		return nil
+	}
+	s.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return false, nil
+	}
 
 	return s
 }
+
+// newLogins checks logins against the mocked repositories of s, under config.
+func (s *TestHTTPServer) newLogins(config pkg.Config) *login.Logins {
+	logins := login.NewLogins(config)
+	logins.UserRepository = &s.UserRepository
+	logins.AuditRepository = &s.AuditRepository
+	logins.LoginAttemptRepository = &s.LoginAttemptRepository
+	logins.MFARepository = &s.MFARepository
+
+	return logins
+}
+
+func TestHTTPServer_Stop(t *testing.T) {
+	s := NewTestHTTPServer()
+	s.server.server.Addr = "127.0.0.1:0"
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.server.Start()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.server.Stop(ctx); err != nil {
+		t.Errorf("HTTPServer.Stop() error = %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("HTTPServer.Start() error = %v after stop", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("HTTPServer.Start() did not return after stop")
+	}
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		timeout     string
+		wantBounded bool
+	}{
+		{
+			name:        "timeout sent",
+			timeout:     "1500",
+			wantBounded: true,
+		},
+		{
+			name:    "no timeout",
+			timeout: "",
+		},
+		{
+			name:    "malformed timeout",
+			timeout: "soon",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				deadline time.Time
+				bounded  bool
+			)
+
+			r := gin.New()
+			r.Use(deadlineMiddleware())
+			r.GET("/", func(ctx *gin.Context) {
+				deadline, bounded = ctx.Request.Context().Deadline()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(requestTimeoutHeader, tc.timeout)
+
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			require.Equal(t, tc.wantBounded, bounded)
+
+			if tc.wantBounded {
+				require.WithinDuration(t, time.Now().Add(1500*time.Millisecond), deadline, 100*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_HandleJWKS(t *testing.T) {
+	s := NewTestHTTPServer()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Header().Get("Cache-Control"))
+
+	var jwks pkg.JWKSet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, pkg.KeyID(s.server.maker.PublicKey), jwks.Keys[0].KeyID)
+	require.Equal(t, "RSA", jwks.Keys[0].KeyType)
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -32,7 +33,7 @@ func (s *HTTPServer) handleRegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	rsp, err := s.UserRepository.CreateUser(ctx, repository.User{
+	rsp, err := s.UserRepository.CreateUser(ctx.Request.Context(), repository.User{
 		FullName:        req.FullName,
 		Email:           req.Email,
 		Password:        req.Password,
@@ -48,6 +49,13 @@ func (s *HTTPServer) handleRegisterUser(ctx *gin.Context) {
 		return
 	}
 
+	if s.Emails != nil {
+		// a failed email is only logged: the user can ask for it again
+		if err := s.Emails.SendVerification(ctx.Request.Context(), rsp.ID, rsp.Email); err != nil {
+			slog.ErrorContext(ctx.Request.Context(), "failed to send verification email", "user_id", rsp.ID, "error", err)
+		}
+	}
+
 	ctx.JSON(http.StatusOK, registerUserResponse{
 		FullName:  rsp.FullName,
 		Email:     rsp.Email,
@@ -58,14 +66,23 @@ func (s *HTTPServer) handleRegisterUser(ctx *gin.Context) {
 type LoginUserRequest struct {
 	Email    string `binding:"required" json:"email"`
 	Password string `binding:"required" json:"password"`
+	ClientIP string `json:"client_ip"`
 }
 
 type LoginUserResponse struct {
-	AccessToken  string    `json:"access_token"`
-	ExpirationAt time.Time `json:"expiration_at"`
-	FullName     string    `json:"full_name"`
-	Email        string    `json:"email"`
-	CreatedAt    time.Time `json:"created_at"`
+	AccessToken         string    `json:"access_token"`
+	ExpirationAt        time.Time `json:"expiration_at"`
+	RefreshToken        string    `json:"refresh_token"`
+	RefreshExpirationAt time.Time `json:"refresh_expiration_at"`
+	FullName            string    `json:"full_name"`
+	Email               string    `json:"email"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// set instead of the tokens for users with two-factor authentication, who complete
+	// the login by passing MFAToken with a code to the VerifyMFA RPC
+	MFARequired     bool       `json:"mfa_required,omitempty"`
+	MFAToken        string     `json:"mfa_token,omitempty"`
+	MFAExpirationAt *time.Time `json:"mfa_expiration_at,omitempty"`
 }
 
 func (s *HTTPServer) handleLoginUser(ctx *gin.Context) {
@@ -76,7 +93,7 @@ func (s *HTTPServer) handleLoginUser(ctx *gin.Context) {
 		return
 	}
 
-	rsp, err := s.UserRepository.GetUser(ctx, req.Email)
+	result, err := s.Logins.Login(ctx.Request.Context(), req.Email, req.Password, req.ClientIP)
 	if err != nil {
 		statusCode := convertPkgError(pkg.ErrorCode(err))
 		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
@@ -84,24 +101,48 @@ func (s *HTTPServer) handleLoginUser(ctx *gin.Context) {
 		return
 	}
 
-	err = pkg.ComparePasswordAndHash(rsp.Password, req.Password)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"status_code": http.StatusUnauthorized, "message": err.Error()})
+	rsp := result.User
+
+	if result.MFARequired {
+		ctx.JSON(http.StatusOK, LoginUserResponse{
+			FullName:        rsp.FullName,
+			Email:           rsp.Email,
+			CreatedAt:       rsp.CreatedAt,
+			MFARequired:     true,
+			MFAToken:        result.MFAToken,
+			MFAExpirationAt: &result.MFAExpiresAt,
+		})
 
 		return
 	}
 
-	accessToken, err := s.maker.CreateToken(rsp.Email, rsp.ID, s.config.TOKEN_DURATION)
+	accessToken, err := s.maker.CreateToken(rsp.Email, rsp.ID, rsp.Role, s.config.TOKEN_DURATION)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"status_code": http.StatusInternalServerError, "message": err.Error()})
+
+		return
+	}
+
+	refreshToken, refreshPayload, err := s.RefreshTokenRepository.CreateRefreshToken(
+		ctx.Request.Context(),
+		rsp.ID,
+		time.Now().Add(s.config.REFRESH_TOKEN_DURATION),
+	)
+	if err != nil {
+		statusCode := convertPkgError(pkg.ErrorCode(err))
+		ctx.JSON(statusCode, gin.H{"status_code": statusCode, "message": pkg.ErrorMessage(err)})
+
+		return
 	}
 
 	ctx.JSON(http.StatusOK, LoginUserResponse{
-		AccessToken:  accessToken,
-		ExpirationAt: time.Now().Add(s.config.TOKEN_DURATION),
-		FullName:     rsp.FullName,
-		Email:        rsp.Email,
-		CreatedAt:    rsp.CreatedAt,
+		AccessToken:         accessToken,
+		ExpirationAt:        time.Now().Add(s.config.TOKEN_DURATION),
+		RefreshToken:        refreshToken,
+		RefreshExpirationAt: refreshPayload.ExpiresAt,
+		FullName:            rsp.FullName,
+		Email:               rsp.Email,
+		CreatedAt:           rsp.CreatedAt,
 	})
 }
 
@@ -119,6 +160,10 @@ func convertPkgError(err string) int {
 		return http.StatusNotImplemented
 	case pkg.AUTHENTICATION_ERROR:
 		return http.StatusUnauthorized
+	case pkg.PERMISSION_ERROR:
+		return http.StatusForbidden
+	case pkg.RATE_LIMIT_ERROR:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
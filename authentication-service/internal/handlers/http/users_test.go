@@ -21,6 +21,8 @@ var (
 	authorizedEmail   = "login_success"
 	unauthorizedEmail = "login_fail"
 	notFoundEmail     = "no_user"
+	disabledEmail     = "login_disabled"
+	unverifiedEmail   = "login_unverified"
 	defaultPassword   = "password"
 )
 
@@ -158,17 +160,38 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 	if email == authorizedEmail {
 		hashPassword, _ := pkg.GenerateHashPassword(defaultPassword, 10)
 
+		return &repository.User{
+			FullName:        "Jane",
+			Email:           email,
+			Password:        hashPassword,
+			CreatedAt:       time.Now(),
+			EmailVerifiedAt: &TestTime,
+		}, nil
+	} else if email == unauthorizedEmail {
 		return &repository.User{
 			FullName:  "Jane",
 			Email:     email,
-			Password:  hashPassword,
+			Password:  "UNAUTHORIZED",
 			CreatedAt: time.Now(),
 		}, nil
-	} else if email == unauthorizedEmail {
+	} else if email == disabledEmail {
+		hashPassword, _ := pkg.GenerateHashPassword(defaultPassword, 10)
+		disabledAt := time.Now()
+
+		return &repository.User{
+			FullName:   "Jane",
+			Email:      email,
+			Password:   hashPassword,
+			CreatedAt:  time.Now(),
+			DisabledAt: &disabledAt,
+		}, nil
+	} else if email == unverifiedEmail {
+		hashPassword, _ := pkg.GenerateHashPassword(defaultPassword, 10)
+
 		return &repository.User{
 			FullName:  "Jane",
 			Email:     email,
-			Password:  "UNAUTHORIZED",
+			Password:  hashPassword,
 			CreatedAt: time.Now(),
 		}, nil
 	}
@@ -179,7 +202,12 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 func TestHTTPServer_HandleLoginUser(t *testing.T) {
 	s := NewTestHTTPServer()
 
+	s.server.config.REQUIRE_VERIFIED_EMAIL = true
+	s.server.Logins = s.newLogins(s.server.config)
 	s.UserRepository.GetUserFunc = mockGetUserFunc
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = func(userID int64, expiresAt time.Time) (string, *repository.RefreshToken, error) {
+		return "refresh-token", &repository.RefreshToken{UserID: userID, ExpiresAt: expiresAt}, nil
+	}
 
 	tests := []struct {
 		name       string
@@ -202,6 +230,22 @@ func TestHTTPServer_HandleLoginUser(t *testing.T) {
 			},
 			statusCode: http.StatusUnauthorized,
 		},
+		{
+			name: "disabled account",
+			payload: LoginUserRequest{
+				Email:    disabledEmail,
+				Password: defaultPassword,
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "unverified email",
+			payload: LoginUserRequest{
+				Email:    unverifiedEmail,
+				Password: defaultPassword,
+			},
+			statusCode: http.StatusForbidden,
+		},
 		{
 			name: "no user",
 			payload: LoginUserRequest{
@@ -227,10 +271,91 @@ func TestHTTPServer_HandleLoginUser(t *testing.T) {
 
 			s.server.router.ServeHTTP(w, req)
 			require.Equal(t, tc.statusCode, w.Code)
+
+			if tc.statusCode == http.StatusOK {
+				var rsp LoginUserResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+				require.Equal(t, "refresh-token", rsp.RefreshToken)
+			}
 		})
 	}
 }
 
+func TestHTTPServer_HandleLoginUserLockout(t *testing.T) {
+	s := NewTestHTTPServer()
+
+	var (
+		failures []string
+		cleared  []string
+		events   []repository.AuditEvent
+	)
+
+	s.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		if email == notFoundEmail {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+		}
+
+		return mockGetUserFunc(email)
+	}
+	s.RefreshTokenRepository.CreateRefreshTokenFunc = func(userID int64, expiresAt time.Time) (string, *repository.RefreshToken, error) {
+		return "refresh-token", &repository.RefreshToken{UserID: userID, ExpiresAt: expiresAt}, nil
+	}
+	s.LoginAttemptRepository.CheckLoginFunc = func(email, _ string) error {
+		if email == "locked" {
+			return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed login attempts, try again later")
+		}
+
+		return nil
+	}
+	s.LoginAttemptRepository.RecordLoginFailureFunc = func(email, ip string) (bool, error) {
+		require.Equal(t, "10.0.0.1", ip)
+
+		failures = append(failures, email)
+
+		// the wrong password is the one locking the account out
+		return email == unauthorizedEmail, nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+	s.AuditRepository.RecordAuditEventFunc = func(event repository.AuditEvent) (*repository.AuditEvent, error) {
+		events = append(events, event)
+
+		return &event, nil
+	}
+
+	login := func(email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		b, err := json.Marshal(LoginUserRequest{Email: email, Password: defaultPassword, ClientIP: "10.0.0.1"})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		s.server.router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// unknown emails and wrong passwords are answered alike
+	unknown := login(notFoundEmail)
+	wrong := login(unauthorizedEmail)
+	require.Equal(t, http.StatusUnauthorized, unknown.Code)
+	require.Equal(t, unknown.Body.String(), wrong.Body.String())
+	require.Equal(t, []string{notFoundEmail, unauthorizedEmail}, failures)
+
+	require.Len(t, events, 1)
+	require.Equal(t, repository.ActionUserLock, events[0].Action)
+
+	// locked out emails are refused before the password is compared
+	require.Equal(t, http.StatusTooManyRequests, login("locked").Code)
+	require.Len(t, failures, 2)
+
+	require.Equal(t, http.StatusOK, login(authorizedEmail).Code)
+	require.Equal(t, []string{authorizedEmail}, cleared)
+}
+
 func TestHTTPServer_ConvertPkgError(t *testing.T) {
 	tests := []struct {
 		name string
@@ -267,6 +392,16 @@ func TestHTTPServer_ConvertPkgError(t *testing.T) {
 			err:  pkg.AUTHENTICATION_ERROR,
 			want: http.StatusUnauthorized,
 		},
+		{
+			name: "permission_error",
+			err:  pkg.PERMISSION_ERROR,
+			want: http.StatusForbidden,
+		},
+		{
+			name: "rate_limit_error",
+			err:  pkg.RATE_LIMIT_ERROR,
+			want: http.StatusTooManyRequests,
+		},
 		{
 			name: "default",
 			err:  "system_error",
@@ -283,3 +418,42 @@ func TestHTTPServer_ConvertPkgError(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPServer_HandleLoginUserMFA(t *testing.T) {
+	s := NewTestHTTPServer()
+
+	var cleared []string
+
+	s.UserRepository.GetUserFunc = mockGetUserFunc
+	s.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return true, nil
+	}
+	s.MFARepository.CreateMFAChallengeFunc = func(_ int64, _ time.Time) (string, error) {
+		return "mfa-token", nil
+	}
+	s.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	b, err := json.Marshal(LoginUserRequest{Email: authorizedEmail, Password: defaultPassword})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(b))
+	require.NoError(t, err)
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var rsp LoginUserResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+
+	// the user gets a challenge to complete with a code instead of their tokens
+	require.True(t, rsp.MFARequired)
+	require.Equal(t, "mfa-token", rsp.MFAToken)
+	require.NotNil(t, rsp.MFAExpirationAt)
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+	require.Empty(t, cleared)
+}
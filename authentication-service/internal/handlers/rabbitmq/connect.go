@@ -2,22 +2,41 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
-	"log"
-	"math"
-	"time"
-
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
 )
 
 type RabbitConn struct {
-	conn   *amqp.Connection
+	Bus    bus.Bus
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
 	Config pkg.Config
 	Maker  pkg.JWTMaker
 
-	UserRepository repository.UserRepository
+	UserRepository          repository.UserRepository
+	RefreshTokenRepository  repository.RefreshTokenRepository
+	PasswordResetRepository repository.PasswordResetRepository
+
+	// Logins checks the passwords of users logging in, holds back those that failed
+	// too often, and asks those with two-factor authentication for a code.
+	Logins *login.Logins
+
+	// Emails sends the verification emails of new users and the password reset emails,
+	// none are sent when it is nil.
+	Emails *mailer.Emails
 }
 
 func NewRabbitConn(config pkg.Config, tokenMaker pkg.JWTMaker) *RabbitConn {
@@ -26,192 +45,233 @@ func NewRabbitConn(config pkg.Config, tokenMaker pkg.JWTMaker) *RabbitConn {
 	return rabbit
 }
 
-type Payload struct {
-	Name string `json:"name"`
-	Data any    `json:"data"`
-}
-
+// ConnectToRabbit sets up the bus the handlers are served on: RabbitMQ, or an
+// in-process bus when BUS_DRIVER is "memory" so that the service runs without a broker.
 func (r *RabbitConn) ConnectToRabbit() error {
-	count := 0
-	maxRetries := 12
+	if r.Config.BUS_DRIVER == "memory" {
+		r.Bus = bus.NewMemory()
 
-	var err error
+		return nil
+	}
 
-	var connection *amqp.Connection
+	conn, err := rabbit.Dial(r.Config.RABBITMQ_URL)
+	if err != nil {
+		return err
+	}
 
-	for {
-		connection, err = amqp.Dial(r.Config.RABBITMQ_URL)
-		if err != nil {
-			log.Println("failed to connect to rabbitmq", err)
+	r.Bus, err = bus.NewRabbitMQ(conn, bus.RabbitMQOptions{
+		Exchange:           r.Config.EXCH,
+		DeadLetterExchange: r.Config.DEAD_LETTER_EXCH,
+		Queue:              r.Config.AUTH_QUEUE_NAME,
+		Consumer:           r.Config.AUTH_CONSUMER_NAME,
+		Prefetch:           r.Config.CONSUMER_PREFETCH,
+		Workers:            r.Config.CONSUMER_WORKERS,
+	})
+	if err != nil {
+		_ = conn.Close()
 
-			if count > maxRetries {
-				return err
-			}
+		return err
+	}
 
-			count++
-			rollOff := time.Duration(math.Pow(float64(count), 2)) * time.Second
-			time.Sleep(rollOff)
+	return nil
+}
 
-			continue
-		}
+// Ready reports whether the bus is connected.
+func (r *RabbitConn) Ready(ctx context.Context) error {
+	if r.Bus == nil {
+		return errors.New("not connected")
+	}
 
-		log.Println("Connected to rabbitmq")
+	return r.Bus.Ready(ctx)
+}
 
-		break
+// Register registers the handler of the service for topics on the bus.
+func (r *RabbitConn) Register(topics []string) {
+	for _, topic := range topics {
+		r.Bus.Handle(topic, r.handleMessage)
 	}
+}
 
-	r.conn = connection
+// SetConsumer registers the handler for topics and serves the requests sent to them
+// until Shutdown is called.
+func (r *RabbitConn) SetConsumer(topics []string) error {
+	r.Register(topics)
 
-	return nil
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	r.mu.Lock()
+	r.cancel = cancel
+	r.done = done
+	r.mu.Unlock()
+
+	defer close(done)
+
+	slog.Info("listening to messages in authentication service", "queue", r.Config.AUTH_QUEUE_NAME, "workers", r.Config.CONSUMER_WORKERS)
+
+	return r.Bus.Serve(ctx)
 }
 
-func (r *RabbitConn) SetConsumer(topics []string) error {
-	ch, err := r.conn.Channel()
-	if err != nil {
-		return err
-	}
-	defer ch.Close()
-
-	q, err := ch.QueueDeclare(
-		r.Config.AUTH_QUEUE_NAME, // name
-		false,                    // durable
-		false,                    // delete when unused
-		false,                    // exclusive
-		false,                    // no-wait
-		nil,                      // arguments
-	)
-	if err != nil {
-		return err
-	}
+// Shutdown stops serving and waits for the requests being handled to be replied to
+// before closing the bus. Requests that were not acked by then are requeued by the
+// broker.
+func (r *RabbitConn) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
 
-	for _, topic := range topics {
-		if err := ch.QueueBind(
-			q.Name,        // queue name
-			topic,         // routing key
-			r.Config.EXCH, // exchange
-			false,
-			nil,
-		); err != nil {
-			return err
+	var err error
+
+	if cancel != nil {
+		cancel()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
 		}
 	}
 
-	messages, err := ch.Consume(
-		q.Name,                      // queue
-		r.Config.AUTH_CONSUMER_NAME, // consumer
-		false,                       // auto ack
-		false,                       // exclusive
-		false,                       // no local
-		false,                       // no wait
-		nil,                         // args
-	)
-	if err != nil {
-		return err
+	if r.Bus != nil {
+		if closeErr := r.Bus.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
 	}
 
-	forever := make(chan bool)
+	return err
+}
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+// handleMessage answers a request in the encoding it came in. Requests that can not be
+// decoded or are of an unknown type fail, and are dropped by the bus.
+func (r *RabbitConn) handleMessage(ctx context.Context, msg bus.Message) (bus.Message, error) {
+	msgCtx := logging.FromAMQP(ctx, msg.Headers, msg.CorrelationID)
 
-		for msg := range messages {
-			var payload Payload
+	// the sender stops waiting for the reply at the deadline, the work on it stops too
+	if !msg.Deadline.IsZero() {
+		var cancel context.CancelFunc
 
-			err := json.Unmarshal(msg.Body, &payload)
-			if err != nil {
-				log.Printf("failed to unmarshal message in auth rabbit: %s", err)
+		msgCtx, cancel = context.WithDeadline(msgCtx, msg.Deadline)
+		defer cancel()
+	}
 
-				_ = msg.Nack(false, true)
+	req, err := envelope.DecodeAs(msg.ContentType, msg.Body)
+	if err != nil {
+		slog.ErrorContext(msgCtx, "failed to decode message", "topic", msg.Topic, "error", err)
 
-				return
-			}
+		return bus.Message{}, err
+	}
 
-			response := r.DistributeTask(payload)
+	msgCtx, span := tracing.StartConsume(msgCtx, msg.Topic, msg.Headers)
+	defer span.End()
 
-			log.Printf("Message acknowledged from auth service: %v", msg.DeliveryTag)
-			_ = msg.Ack(false)
+	reply, ok := r.DistributeTask(msgCtx, req)
+	if !ok {
+		slog.ErrorContext(msgCtx, "unknown message", "topic", msg.Topic, "type", req.Type)
 
-			count := 0
-			maxRetries := 5
+		return bus.Message{}, fmt.Errorf("unknown message type %q", req.Type)
+	}
 
-			for {
-				err = ch.PublishWithContext(ctx,
-					r.Config.EXCH, // exchange
-					msg.ReplyTo,   // routing key
-					false,         // mandatory
-					false,         // immediate
-					amqp.Publishing{
-						ContentType:   "text/plain",
-						CorrelationId: msg.CorrelationId,
-						Body:          response,
-					},
-				)
-				if err == nil {
-					break
-				}
+	reply.RequestID = req.RequestID
 
-				count++
+	// answered in the encoding of the request so that callers not sending protobuf
+	// yet can read the reply
+	contentType := envelope.ContentTypeJSON
+	if msg.ContentType == envelope.ContentTypeProtobuf {
+		contentType = envelope.ContentTypeProtobuf
+	}
 
-				if count > maxRetries {
-					// log to failed to send response
-					log.Printf("failed to send response: %s", err)
+	response, err := envelope.EncodeAs(contentType, reply)
+	if err != nil {
+		slog.ErrorContext(msgCtx, "failed to encode response", "type", req.Type, "error", err)
 
-					return
-				}
-			}
-		}
-	}()
+		return bus.Message{}, err
+	}
 
-	log.Println("listening to messages in authentication service")
+	headers := tracing.AMQPHeaders(msgCtx)
+	logging.SetAMQPHeader(msgCtx, headers)
 
-	<-forever
+	slog.InfoContext(msgCtx, "message handled", "topic", msg.Topic, "type", req.Type)
 
-	return nil
+	return bus.Message{ContentType: contentType, Headers: headers, Body: response}, nil
 }
 
-func (r *RabbitConn) DistributeTask(payload Payload) []byte {
-	switch payload.Name {
-	case "register_user":
-		dataBytes, err := json.Marshal(payload.Data)
+// DistributeTask hands req to the handler of its type and returns the reply, in the
+// encoding of req, or false when the type is unknown.
+func (r *RabbitConn) DistributeTask(ctx context.Context, req envelope.Envelope) (envelope.Envelope, bool) {
+	switch req.Type {
+	case envelope.TypeRegisterUser:
+		var registerUserPayload RegisterUserRequest
+
+		err := decodeRequest(req, &registerUserPayload)
 		if err != nil {
 			return errorRabbitMQResponse(
-				pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal request: %v", err),
-			)
+				req.Type,
+				pkg.Errorf(pkg.INVALID_ERROR, "failed to unmarshal request: %v", err),
+			), true
 		}
 
-		var registerUserPayload RegisterUserRequest
+		rsp, pkgErr := r.HandleRegisterUser(ctx, registerUserPayload)
+		if pkgErr != nil {
+			return errorRabbitMQResponse(req.Type, pkgErr), true
+		}
+
+		return resultRabbitMQResponse(req, rsp), true
 
-		err = json.Unmarshal(dataBytes, &registerUserPayload)
+	case envelope.TypeLoginUser:
+		var loginUserPayload LoginUserRequest
+
+		err := decodeRequest(req, &loginUserPayload)
 		if err != nil {
 			return errorRabbitMQResponse(
-				pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal request: %v", err),
-			)
+				req.Type,
+				pkg.Errorf(pkg.INVALID_ERROR, "failed to unmarshal request: %v", err),
+			), true
+		}
+
+		rsp, pkgErr := r.HandleLoginUser(ctx, loginUserPayload)
+		if pkgErr != nil {
+			return errorRabbitMQResponse(req.Type, pkgErr), true
 		}
 
-		return r.HandleRegisterUser(registerUserPayload)
+		return resultRabbitMQResponse(req, rsp), true
 
-	case "login_user":
-		dataBytes, err := json.Marshal(payload.Data)
+	case envelope.TypeForgotPassword:
+		var forgotPasswordPayload ForgotPasswordRequest
+
+		err := decodeRequest(req, &forgotPasswordPayload)
 		if err != nil {
 			return errorRabbitMQResponse(
-				pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal request: %v", err),
-			)
+				req.Type,
+				pkg.Errorf(pkg.INVALID_ERROR, "failed to unmarshal request: %v", err),
+			), true
 		}
 
-		var loginUserPayload LoginUserRequest
+		rsp, pkgErr := r.HandleForgotPassword(ctx, forgotPasswordPayload)
+		if pkgErr != nil {
+			return errorRabbitMQResponse(req.Type, pkgErr), true
+		}
+
+		return resultRabbitMQResponse(req, rsp), true
 
-		err = json.Unmarshal(dataBytes, &loginUserPayload)
+	case envelope.TypeResetPassword:
+		var resetPasswordPayload ResetPasswordRequest
+
+		err := decodeRequest(req, &resetPasswordPayload)
 		if err != nil {
 			return errorRabbitMQResponse(
-				pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal request: %v", err),
-			)
+				req.Type,
+				pkg.Errorf(pkg.INVALID_ERROR, "failed to unmarshal request: %v", err),
+			), true
+		}
+
+		rsp, pkgErr := r.HandleResetPassword(ctx, resetPasswordPayload)
+		if pkgErr != nil {
+			return errorRabbitMQResponse(req.Type, pkgErr), true
 		}
 
-		return r.HandleLoginUser(loginUserPayload)
+		return resultRabbitMQResponse(req, rsp), true
 
 	default:
-		// log unknow message
-		return nil
+		return envelope.Envelope{}, false
 	}
 }
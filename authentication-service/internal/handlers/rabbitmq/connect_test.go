@@ -1,18 +1,31 @@
 package rabbitmq_test
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"testing"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/rabbitmq"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/login"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
 )
 
 type TestRabbitConn struct {
-	rabbitConn     *rabbitmq.RabbitConn
-	UserRepository mock.MockUsersRepositry
+	rabbitConn              *rabbitmq.RabbitConn
+	UserRepository          mock.MockUsersRepositry
+	RefreshTokenRepository  mock.MockRefreshTokenRepository
+	PasswordResetRepository mock.MockPasswordResetRepository
+	AuditRepository         mock.MockAuditRepository
+	LoginAttemptRepository  mock.MockLoginAttemptRepository
+	MFARepository           mock.MockMFARepository
 }
 
 func NewTestRabbitConn() *TestRabbitConn {
@@ -25,22 +38,182 @@ func NewTestRabbitConn() *TestRabbitConn {
 
 	r := TestRabbitConn{
 		rabbitConn: rabbitmq.NewRabbitConn(
-			pkg.Config{TOKEN_DURATION: time.Second},
+			pkg.Config{TOKEN_DURATION: time.Second, PASSWORD_RESET_TOKEN_DURATION: 30 * time.Minute, MFA_CHALLENGE_DURATION: 5 * time.Minute},
 			pkg.JWTMaker{PublicKey: publicKey, PrivateKey: privateKey},
 		),
 	}
 
 	r.rabbitConn.UserRepository = &r.UserRepository
+	r.rabbitConn.RefreshTokenRepository = &r.RefreshTokenRepository
+	r.rabbitConn.PasswordResetRepository = &r.PasswordResetRepository
+	r.rabbitConn.Logins = r.newLogins(r.rabbitConn.Config)
+
+	// every login is let through unless a test says otherwise
+	r.LoginAttemptRepository.CheckLoginFunc = func(_, _ string) error {
+		return nil
+	}
+	r.LoginAttemptRepository.RecordLoginFailureFunc = func(_, _ string) (bool, error) {
+		return false, nil
+	}
+	r.LoginAttemptRepository.ClearLoginFailuresFunc = func(_ string) error {
+		return nil
+	}
+	r.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return false, nil
+	}
 
 	return &r
 }
 
-// func TestRabbitConn_DistributeTask(t *testing.T) {
-// 	r := NewTestRabbitConn()
+// newLogins checks logins against the mocked repositories of r, under config.
+func (r *TestRabbitConn) newLogins(config pkg.Config) *login.Logins {
+	logins := login.NewLogins(config)
+	logins.UserRepository = &r.UserRepository
+	logins.AuditRepository = &r.AuditRepository
+	logins.LoginAttemptRepository = &r.LoginAttemptRepository
+	logins.MFARepository = &r.MFARepository
+
+	return logins
+}
+
+func TestRabbitConn_DistributeTask(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	r.UserRepository.CreateUserFunc = mockCreateUser
+
+	pbRequest, err := envelope.NewProto(envelope.TypeRegisterUser, &pb.RegisterUserRequest{
+		Fullname:           "Jane",
+		Email:              "jane@gmail.com",
+		Password:           "password",
+		PaydUsername:       "username",
+		PaydUsernameApiKey: "user_key",
+		PaydPasswordApiKey: "pass_key",
+		PaydAccountId:      "account_id",
+	})
+	require.NoError(t, err)
+
+	jsonRequest, err := envelope.New(envelope.TypeRegisterUser, rabbitmq.RegisterUserRequest{
+		FullName:        "Jane",
+		Email:           "jane@gmail.com",
+		Password:        "password",
+		PaydUsername:    "username",
+		PaydUsernameKey: "user_key",
+		PaydPasswordKey: "pass_key",
+		PaydAccountID:   "account_id",
+	})
+	require.NoError(t, err)
+
+	t.Run("protobuf request", func(t *testing.T) {
+		reply, ok := r.rabbitConn.DistributeTask(context.Background(), pbRequest)
+		require.True(t, ok)
+		require.NoError(t, reply.Err())
+		require.Equal(t, envelope.ContentTypeProtobuf, reply.ContentType)
+
+		var rsp pb.RegisterUserResponse
+		require.NoError(t, reply.Unmarshal(&rsp))
+		require.Equal(t, "jane@gmail.com", rsp.GetEmail())
+		require.Equal(t, TestTime, rsp.GetCreatedAt().AsTime())
+	})
+
+	t.Run("JSON request", func(t *testing.T) {
+		reply, ok := r.rabbitConn.DistributeTask(context.Background(), jsonRequest)
+		require.True(t, ok)
+		require.NoError(t, reply.Err())
+		require.Equal(t, envelope.ContentTypeJSON, reply.ContentType)
+
+		var rsp rabbitmq.RegisterUserResponse
+		require.NoError(t, reply.Unmarshal(&rsp))
+		require.Equal(t, "jane@gmail.com", rsp.Email)
+	})
+
+	t.Run("protobuf reset password", func(t *testing.T) {
+		r.PasswordResetRepository.ResetPasswordFunc = func(string, string) (*repository.User, error) {
+			return &repository.User{ID: 7, Email: "jane@gmail.com"}, nil
+		}
+		r.RefreshTokenRepository.RevokeRefreshTokensFunc = func(int64, string) error { return nil }
+
+		request, err := envelope.NewProto(envelope.TypeResetPassword, &pb.ResetPasswordRequest{
+			Token:    "reset-token",
+			Password: "new password",
+		})
+		require.NoError(t, err)
 
-// 	tests := []struct{
-// 		name string
-// 		payload rabbitmq.Payload
+		reply, ok := r.rabbitConn.DistributeTask(context.Background(), request)
+		require.True(t, ok)
+		require.NoError(t, reply.Err())
 
-// 	}{}
-// }
+		var rsp pb.ResetPasswordResponse
+		require.NoError(t, reply.Unmarshal(&rsp))
+		require.Equal(t, int64(7), rsp.GetUserId())
+		require.Equal(t, "jane@gmail.com", rsp.GetEmail())
+	})
+
+	t.Run("malformed protobuf", func(t *testing.T) {
+		reply, ok := r.rabbitConn.DistributeTask(context.Background(), envelope.Envelope{
+			Type:        envelope.TypeLoginUser,
+			ContentType: envelope.ContentTypeProtobuf,
+			Data:        []byte("not protobuf"),
+		})
+		require.True(t, ok)
+		require.Equal(t, envelope.CodeInvalid, reply.Error.Code)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, ok := r.rabbitConn.DistributeTask(context.Background(), envelope.Envelope{Type: "delete_user"})
+		require.False(t, ok)
+	})
+}
+
+func TestRabbitConn_MemoryBus(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	r.UserRepository.CreateUserFunc = mockCreateUser
+
+	r.rabbitConn.Bus = bus.NewMemory()
+	r.rabbitConn.Register([]string{"authentication.register_user"})
+
+	request, err := envelope.NewProto(envelope.TypeRegisterUser, &pb.RegisterUserRequest{
+		Fullname:           "Jane",
+		Email:              "jane@gmail.com",
+		Password:           "password",
+		PaydUsername:       "username",
+		PaydUsernameApiKey: "user_key",
+		PaydPasswordApiKey: "pass_key",
+		PaydAccountId:      "account_id",
+	})
+	require.NoError(t, err)
+
+	request.RequestID = "req-1"
+
+	body, err := envelope.EncodeProto(request)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := r.rabbitConn.Bus.Request(ctx, "authentication.register_user", bus.Message{
+		ContentType: envelope.ContentTypeProtobuf,
+		Body:        body,
+	})
+	require.NoError(t, err)
+	require.Equal(t, envelope.ContentTypeProtobuf, reply.ContentType)
+
+	decoded, err := envelope.DecodeAs(reply.ContentType, reply.Body)
+	require.NoError(t, err)
+	require.NoError(t, decoded.Err())
+	require.Equal(t, "req-1", decoded.RequestID)
+
+	var rsp pb.RegisterUserResponse
+	require.NoError(t, decoded.Unmarshal(&rsp))
+	require.Equal(t, "jane@gmail.com", rsp.GetEmail())
+
+	// malformed requests are dropped rather than answered
+	_, err = r.rabbitConn.Bus.Request(ctx, "authentication.register_user", bus.Message{
+		ContentType: envelope.ContentTypeProtobuf,
+		Body:        []byte("not protobuf"),
+	})
+	require.Error(t, err)
+
+	_, err = r.rabbitConn.Bus.Request(ctx, "authentication.delete_user", bus.Message{})
+	require.ErrorIs(t, err, bus.ErrNoHandler)
+}
@@ -2,7 +2,7 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
+	"log/slog"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
@@ -25,8 +25,8 @@ type RegisterUserResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-func (r *RabbitConn) HandleRegisterUser(req RegisterUserRequest) []byte {
-	ctx, cancel := context.WithTimeout(context.Background(), 42*time.Second)
+func (r *RabbitConn) HandleRegisterUser(ctx context.Context, req RegisterUserRequest) (*RegisterUserResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, 42*time.Second)
 	defer cancel()
 
 	user, err := r.UserRepository.CreateUser(ctx, repository.User{
@@ -39,79 +39,88 @@ func (r *RabbitConn) HandleRegisterUser(req RegisterUserRequest) []byte {
 		PaydPasswordKey: req.PaydPasswordKey,
 	})
 	if err != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.ErrorCode(err), "failed to create user: %v", pkg.ErrorMessage(err)),
-		)
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to create user: %v", pkg.ErrorMessage(err))
 	}
 
-	rsp := RegisterUserResponse{
+	if r.Emails != nil {
+		// a failed email is only logged: the user can ask for it again
+		if err := r.Emails.SendVerification(ctx, user.ID, user.Email); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return &RegisterUserResponse{
 		FullName:  user.FullName,
 		Email:     user.Email,
 		CreatedAt: user.CreatedAt,
-	}
-
-	rspByte, rspErr := json.Marshal(rsp)
-	if rspErr != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal response: %v", rspErr),
-		)
-	}
-
-	return rspByte
+	}, nil
 }
 
 type LoginUserRequest struct {
 	Email    string `binding:"required" json:"email"`
 	Password string `binding:"required" json:"password"`
+	ClientIP string `json:"client_ip"`
 }
 
 type LoginUserResponse struct {
-	AccessToken  string    `json:"access_token"`
-	ExpirationAt time.Time `json:"expiration_at"`
-	FullName     string    `json:"full_name"`
-	Email        string    `json:"email"`
-	CreatedAt    time.Time `json:"created_at"`
+	AccessToken         string    `json:"access_token"`
+	ExpirationAt        time.Time `json:"expiration_at"`
+	RefreshToken        string    `json:"refresh_token"`
+	RefreshExpirationAt time.Time `json:"refresh_expiration_at"`
+	FullName            string    `json:"full_name"`
+	Email               string    `json:"email"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// set instead of the tokens for users with two-factor authentication, who complete
+	// the login by passing MFAToken with a code to the VerifyMFA RPC
+	MFARequired     bool       `json:"mfa_required,omitempty"`
+	MFAToken        string     `json:"mfa_token,omitempty"`
+	MFAExpirationAt *time.Time `json:"mfa_expiration_at,omitempty"`
 }
 
-func (r *RabbitConn) HandleLoginUser(req LoginUserRequest) []byte {
-	ctx, cancel := context.WithTimeout(context.Background(), 42*time.Second)
+func (r *RabbitConn) HandleLoginUser(ctx context.Context, req LoginUserRequest) (*LoginUserResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, 42*time.Second)
 	defer cancel()
 
-	user, err := r.UserRepository.GetUser(ctx, req.Email)
+	result, err := r.Logins.Login(ctx, req.Email, req.Password, req.ClientIP)
 	if err != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.ErrorCode(err), "failed to login user: %v", pkg.ErrorMessage(err)),
-		)
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "%v", pkg.ErrorMessage(err))
 	}
 
-	err = pkg.ComparePasswordAndHash(user.Password, req.Password)
-	if err != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Error comparing passwords: %v", err),
-		)
+	user := result.User
+
+	if result.MFARequired {
+		return &LoginUserResponse{
+			FullName:        user.FullName,
+			Email:           user.Email,
+			CreatedAt:       user.CreatedAt,
+			MFARequired:     true,
+			MFAToken:        result.MFAToken,
+			MFAExpirationAt: &result.MFAExpiresAt,
+		}, nil
 	}
 
-	accessToken, err := r.Maker.CreateToken(user.Email, user.ID, r.Config.TOKEN_DURATION)
+	accessToken, err := r.Maker.CreateToken(user.Email, user.ID, user.Role, r.Config.TOKEN_DURATION)
 	if err != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Error creating token: %v", err),
-		)
-	}
-
-	rsp := LoginUserResponse{
-		AccessToken:  accessToken,
-		ExpirationAt: time.Now().Add(r.Config.TOKEN_DURATION),
-		FullName:     user.FullName,
-		Email:        user.Email,
-		CreatedAt:    user.CreatedAt,
+		return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Error creating token: %v", err)
 	}
 
-	rspByte, err := json.Marshal(rsp)
+	refreshToken, refreshPayload, err := r.RefreshTokenRepository.CreateRefreshToken(
+		ctx,
+		user.ID,
+		time.Now().Add(r.Config.REFRESH_TOKEN_DURATION),
+	)
 	if err != nil {
-		return errorRabbitMQResponse(
-			pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal response: %v", err),
-		)
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "Error creating refresh token: %v", pkg.ErrorMessage(err))
 	}
 
-	return rspByte
+	return &LoginUserResponse{
+		AccessToken:         accessToken,
+		ExpirationAt:        time.Now().Add(r.Config.TOKEN_DURATION),
+		RefreshToken:        refreshToken,
+		RefreshExpirationAt: refreshPayload.ExpiresAt,
+		FullName:            user.FullName,
+		Email:               user.Email,
+		CreatedAt:           user.CreatedAt,
+	}, nil
 }
@@ -1,16 +1,15 @@
 package rabbitmq_test
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
 	"testing"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/rabbitmq"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/stretchr/testify/require"
 )
 
 var TestTime = time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC)
@@ -70,9 +69,9 @@ func TestRabbitConn_HandleRegisterUser(t *testing.T) {
 		{
 			name:    "Missing values",
 			request: rabbitmq.RegisterUserRequest{},
-			want: rabbitmq.ErrorResponse{
-				StatusCode: 400,
-				Message:    "failed to create user: full_name is required",
+			want: pkg.Error{
+				Code:    pkg.INVALID_ERROR,
+				Message: "failed to create user: full_name is required",
 			},
 			wantErr: true,
 		},
@@ -80,25 +79,23 @@ func TestRabbitConn_HandleRegisterUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotBytes := r.rabbitConn.HandleRegisterUser(tt.request)
+			got, pkgErr := r.rabbitConn.HandleRegisterUser(context.Background(), tt.request)
 
 			if tt.wantErr {
-				var gotErrResp rabbitmq.ErrorResponse
-				if err := json.Unmarshal(gotBytes, &gotErrResp); err != nil {
-					t.Fatalf("failed to unmarshal error response: %v", err)
+				if pkgErr == nil {
+					t.Fatalf("got result %v, want error", got)
 				}
 
-				if gotErrResp != tt.want {
-					t.Errorf("got error response %v, want %v", gotErrResp, tt.want)
+				if *pkgErr != tt.want {
+					t.Errorf("got error response %v, want %v", *pkgErr, tt.want)
 				}
 			} else {
-				var gotSuccessResp rabbitmq.RegisterUserResponse
-				if err := json.Unmarshal(gotBytes, &gotSuccessResp); err != nil {
-					t.Fatalf("failed to unmarshal success response: %v", err)
+				if pkgErr != nil {
+					t.Fatalf("got error %v, want result", pkgErr)
 				}
 
-				if gotSuccessResp != tt.want {
-					t.Errorf("got success response %v, want %v", gotSuccessResp, tt.want)
+				if *got != tt.want {
+					t.Errorf("got success response %v, want %v", *got, tt.want)
 				}
 			}
 		})
@@ -118,8 +115,19 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 			PaydUsernameKey: "user_key",
 			PaydPasswordKey: "pass_key",
 			PaydAccountID:   "account_id",
+			EmailVerifiedAt: &TestTime,
 			CreatedAt:       TestTime,
 		}, nil
+	} else if email == "unverified" {
+		hashPassword, _ := pkg.GenerateHashPassword("password", 10)
+
+		return &repository.User{
+			ID:        32,
+			FullName:  "Jane",
+			Email:     "jane@gmail.com",
+			Password:  hashPassword,
+			CreatedAt: TestTime,
+		}, nil
 	} else if email == "unauthorized" {
 		hashPassword, _ := pkg.GenerateHashPassword("differen_hash", 10)
 
@@ -142,11 +150,17 @@ func mockGetUserFunc(email string) (*repository.User, error) {
 func TestRabbitConn_HandleLoginUser(t *testing.T) {
 	r := NewTestRabbitConn()
 
+	r.rabbitConn.Config.REQUIRE_VERIFIED_EMAIL = true
+	r.rabbitConn.Logins = r.newLogins(r.rabbitConn.Config)
 	r.UserRepository.GetUserFunc = mockGetUserFunc
+	r.RefreshTokenRepository.CreateRefreshTokenFunc = func(userID int64, expiresAt time.Time) (string, *repository.RefreshToken, error) {
+		return "refresh-token", &repository.RefreshToken{UserID: userID, ExpiresAt: expiresAt}, nil
+	}
 
 	accessToken, _ := r.rabbitConn.Maker.CreateToken(
 		"jane@gmail.com",
 		1,
+		repository.RoleUser,
 		r.rabbitConn.Config.TOKEN_DURATION,
 	)
 
@@ -170,21 +184,27 @@ func TestRabbitConn_HandleLoginUser(t *testing.T) {
 		{
 			name: "Wrong password",
 			args: rabbitmq.LoginUserRequest{Email: "unauthorized", Password: "password"},
-			want: rabbitmq.ErrorResponse{
-				StatusCode: 401,
-				Message: fmt.Sprintf(
-					"Error comparing passwords: %v",
-					bcrypt.ErrMismatchedHashAndPassword,
-				),
+			want: pkg.Error{
+				Code:    pkg.AUTHENTICATION_ERROR,
+				Message: "invalid email or password",
 			},
 			wantErr: true,
 		},
 		{
 			name: "No user found",
 			args: rabbitmq.LoginUserRequest{Email: "no_user", Password: "password"},
-			want: rabbitmq.ErrorResponse{
-				StatusCode: 404,
-				Message:    "failed to login user: error getting user: no_user",
+			want: pkg.Error{
+				Code:    pkg.AUTHENTICATION_ERROR,
+				Message: "invalid email or password",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unverified email",
+			args: rabbitmq.LoginUserRequest{Email: "unverified", Password: "password"},
+			want: pkg.Error{
+				Code:    pkg.PERMISSION_ERROR,
+				Message: "email address is not verified",
 			},
 			wantErr: true,
 		},
@@ -192,27 +212,117 @@ func TestRabbitConn_HandleLoginUser(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			gotBytes := r.rabbitConn.HandleLoginUser(tc.args)
+			got, pkgErr := r.rabbitConn.HandleLoginUser(context.Background(), tc.args)
 
 			if tc.wantErr {
-				var gotErrResp rabbitmq.ErrorResponse
-				if err := json.Unmarshal(gotBytes, &gotErrResp); err != nil {
-					t.Fatalf("failed to unmarshal error response: %v", err)
+				if pkgErr == nil {
+					t.Fatalf("got result %v, want error", got)
 				}
 
-				if gotErrResp != tc.want {
-					t.Errorf("got error response %v, want %v", gotErrResp, tc.want)
+				if *pkgErr != tc.want {
+					t.Errorf("got error response %v, want %v", *pkgErr, tc.want)
 				}
 			} else {
-				var gotSuccessResp rabbitmq.LoginUserResponse
-				if err := json.Unmarshal(gotBytes, &gotSuccessResp); err != nil {
-					t.Fatalf("failed to unmarshal success response: %v", err)
+				if pkgErr != nil {
+					t.Fatalf("got error %v, want result", pkgErr)
+				}
+
+				if got.Email != "jane@gmail.com" {
+					t.Errorf("got success response %v, want %v", got.AccessToken, accessToken)
 				}
 
-				if gotSuccessResp.Email != "jane@gmail.com" {
-					t.Errorf("got success response %v, want %v", gotSuccessResp.AccessToken, accessToken)
+				if got.RefreshToken != "refresh-token" {
+					t.Errorf("got refresh token %q, want %q", got.RefreshToken, "refresh-token")
 				}
 			}
 		})
 	}
 }
+
+func TestRabbitConn_HandleLoginUserLockout(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	var (
+		failures []string
+		events   []repository.AuditEvent
+	)
+
+	r.UserRepository.GetUserFunc = mockGetUserFunc
+	r.LoginAttemptRepository.CheckLoginFunc = func(email, _ string) error {
+		if email == "locked" {
+			return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed login attempts, try again later")
+		}
+
+		return nil
+	}
+	r.LoginAttemptRepository.RecordLoginFailureFunc = func(email, ip string) (bool, error) {
+		require.Equal(t, "10.0.0.1", ip)
+
+		failures = append(failures, email)
+
+		// the wrong password is the one locking the account out
+		return email == "unauthorized", nil
+	}
+	r.AuditRepository.RecordAuditEventFunc = func(event repository.AuditEvent) (*repository.AuditEvent, error) {
+		events = append(events, event)
+
+		return &event, nil
+	}
+
+	for _, email := range []string{"no_user", "unauthorized"} {
+		_, pkgErr := r.rabbitConn.HandleLoginUser(context.Background(), rabbitmq.LoginUserRequest{
+			Email:    email,
+			Password: "password",
+			ClientIP: "10.0.0.1",
+		})
+		require.Equal(t, pkg.AUTHENTICATION_ERROR, pkgErr.Code)
+	}
+
+	require.Equal(t, []string{"no_user", "unauthorized"}, failures)
+	require.Len(t, events, 1)
+	require.Equal(t, repository.ActionUserLock, events[0].Action)
+	require.Equal(t, int64(32), events[0].TargetUserID)
+
+	_, pkgErr := r.rabbitConn.HandleLoginUser(context.Background(), rabbitmq.LoginUserRequest{
+		Email:    "locked",
+		Password: "password",
+		ClientIP: "10.0.0.1",
+	})
+	require.Equal(t, pkg.RATE_LIMIT_ERROR, pkgErr.Code)
+	require.Len(t, failures, 2)
+}
+
+func TestRabbitConn_HandleLoginUserMFA(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	var cleared []string
+
+	r.UserRepository.GetUserFunc = mockGetUserFunc
+	r.MFARepository.MFAEnabledFunc = func(_ int64) (bool, error) {
+		return true, nil
+	}
+	r.MFARepository.CreateMFAChallengeFunc = func(userID int64, _ time.Time) (string, error) {
+		require.Equal(t, int64(32), userID)
+
+		return "mfa-token", nil
+	}
+	r.LoginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+
+	rsp, pkgErr := r.rabbitConn.HandleLoginUser(context.Background(), rabbitmq.LoginUserRequest{
+		Email:    "success",
+		Password: "password",
+	})
+	require.Nil(t, pkgErr)
+
+	// the user gets a challenge to complete with a code instead of their tokens
+	require.True(t, rsp.MFARequired)
+	require.Equal(t, "mfa-token", rsp.MFAToken)
+	require.NotNil(t, rsp.MFAExpirationAt)
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+	require.Empty(t, cleared)
+}
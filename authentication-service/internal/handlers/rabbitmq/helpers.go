@@ -1,49 +1,61 @@
 package rabbitmq
 
 import (
-	"encoding/json"
-	"log"
-	"net/http"
-
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
 )
 
-type ErrorResponse struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
+func errorRabbitMQResponse(msgType string, pkgErr *pkg.Error) envelope.Envelope {
+	return envelope.NewError(msgType, ConvertPkgError(pkgErr), pkgErr.Message)
 }
 
-func errorRabbitMQResponse(pkgErr *pkg.Error) []byte {
-	errorRsp := ErrorResponse{
-		StatusCode: ConvertPkgError(pkgErr),
-		Message:    pkgErr.Message,
+func resultRabbitMQResponse(req envelope.Envelope, rsp protoResponse) envelope.Envelope {
+	var (
+		reply envelope.Envelope
+		err   error
+	)
+
+	// protobuf requests get protobuf results, JSON ones still JSON
+	if req.ContentType == envelope.ContentTypeProtobuf {
+		reply, err = envelope.NewProto(req.Type, rsp.toProto())
+	} else {
+		reply, err = envelope.New(req.Type, rsp)
 	}
 
-	jsonResponse, err := json.Marshal(errorRsp)
 	if err != nil {
-		log.Printf("Failed to marshal error response: %v", err)
+		return errorRabbitMQResponse(req.Type, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal response: %v", err))
+	}
+
+	return reply
+}
 
-		return []byte(`{"status": false}`)
+func decodeRequest(req envelope.Envelope, v protoRequest) error {
+	if req.ContentType == envelope.ContentTypeProtobuf {
+		return v.unmarshalProto(req.Data)
 	}
 
-	return jsonResponse
+	return req.Unmarshal(v)
 }
 
-func ConvertPkgError(err *pkg.Error) int {
+func ConvertPkgError(err *pkg.Error) envelope.Code {
 	switch err.Code {
 	case pkg.ALREADY_EXISTS_ERROR:
-		return http.StatusConflict
+		return envelope.CodeAlreadyExists
 	case pkg.INTERNAL_ERROR:
-		return http.StatusInternalServerError
+		return envelope.CodeInternal
 	case pkg.INVALID_ERROR:
-		return http.StatusBadRequest
+		return envelope.CodeInvalid
 	case pkg.NOT_FOUND_ERROR:
-		return http.StatusNotFound
+		return envelope.CodeNotFound
 	case pkg.NOT_IMPLEMENTED_ERROR:
-		return http.StatusNotImplemented
+		return envelope.CodeNotImplemented
 	case pkg.AUTHENTICATION_ERROR:
-		return http.StatusUnauthorized
+		return envelope.CodeUnauthenticated
+	case pkg.PERMISSION_ERROR:
+		return envelope.CodePermissionDenied
+	case pkg.RATE_LIMIT_ERROR:
+		return envelope.CodeRateLimited
 	default:
-		return http.StatusInternalServerError
+		return envelope.CodeInternal
 	}
 }
@@ -1,67 +1,81 @@
 package rabbitmq_test
 
 import (
-	"net/http"
 	"testing"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/rabbitmq"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
 )
 
 func TestRabbitConn_convertPkgError(t *testing.T) {
 	tests := []struct {
 		name string
 		err  *pkg.Error
-		want int
+		want envelope.Code
 	}{
 		{
 			name: "already_exists",
 			err: &pkg.Error{
 				Code: pkg.ALREADY_EXISTS_ERROR,
 			},
-			want: http.StatusConflict,
+			want: envelope.CodeAlreadyExists,
 		},
 		{
 			name: "internal_error",
 			err: &pkg.Error{
 				Code: pkg.INTERNAL_ERROR,
 			},
-			want: http.StatusInternalServerError,
+			want: envelope.CodeInternal,
 		},
 		{
 			name: "invalid_error",
 			err: &pkg.Error{
 				Code: pkg.INVALID_ERROR,
 			},
-			want: http.StatusBadRequest,
+			want: envelope.CodeInvalid,
 		},
 		{
 			name: "not_found",
 			err: &pkg.Error{
 				Code: pkg.NOT_FOUND_ERROR,
 			},
-			want: http.StatusNotFound,
+			want: envelope.CodeNotFound,
 		},
 		{
 			name: "not_implemented",
 			err: &pkg.Error{
 				Code: pkg.NOT_IMPLEMENTED_ERROR,
 			},
-			want: http.StatusNotImplemented,
+			want: envelope.CodeNotImplemented,
 		},
 		{
 			name: "authentication_error",
 			err: &pkg.Error{
 				Code: pkg.AUTHENTICATION_ERROR,
 			},
-			want: http.StatusUnauthorized,
+			want: envelope.CodeUnauthenticated,
+		},
+		{
+			name: "permission_error",
+			err: &pkg.Error{
+				Code: pkg.PERMISSION_ERROR,
+			},
+			want: envelope.CodePermissionDenied,
+		},
+		{
+			name: "rate_limit_error",
+			err: &pkg.Error{
+				Code: pkg.RATE_LIMIT_ERROR,
+			},
+			want: envelope.CodeRateLimited,
 		},
 		{
 			name: "default",
 			err: &pkg.Error{
 				Code: "system_error",
 			},
-			want: http.StatusInternalServerError,
+			want: envelope.CodeInternal,
 		},
 	}
 
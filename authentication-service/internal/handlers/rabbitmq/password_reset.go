@@ -0,0 +1,94 @@
+package rabbitmq
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `binding:"required" json:"email"`
+}
+
+type ForgotPasswordResponse struct{}
+
+type ResetPasswordRequest struct {
+	Token    string `binding:"required" json:"token"`
+	Password string `binding:"required" json:"password"`
+}
+
+type ResetPasswordResponse struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// HandleForgotPassword answers the same whether the email belongs to a user or not, so
+// that it can not be used to find out who has an account.
+func (r *RabbitConn) HandleForgotPassword(ctx context.Context, req ForgotPasswordRequest) (*ForgotPasswordResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, 42*time.Second)
+	defer cancel()
+
+	if r.Emails == nil || r.PasswordResetRepository == nil {
+		return nil, pkg.Errorf(pkg.NOT_IMPLEMENTED_ERROR, "password reset is not enabled")
+	}
+
+	if req.Email == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "email is required")
+	}
+
+	user, err := r.UserRepository.GetUser(ctx, req.Email)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return &ForgotPasswordResponse{}, nil
+		}
+
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to get user: %v", pkg.ErrorMessage(err))
+	}
+
+	if user.Disabled() {
+		return &ForgotPasswordResponse{}, nil
+	}
+
+	token, err := r.PasswordResetRepository.CreatePasswordResetToken(
+		ctx,
+		user.ID,
+		time.Now().Add(r.Config.PASSWORD_RESET_TOKEN_DURATION),
+	)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to create reset token: %v", pkg.ErrorMessage(err))
+	}
+
+	if err := r.Emails.SendPasswordReset(ctx, user.Email, token); err != nil {
+		slog.ErrorContext(ctx, "failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+
+	return &ForgotPasswordResponse{}, nil
+}
+
+// HandleResetPassword sets a new password with a reset token, and revokes the refresh
+// tokens of the user for every session to log in again with it.
+func (r *RabbitConn) HandleResetPassword(ctx context.Context, req ResetPasswordRequest) (*ResetPasswordResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, 42*time.Second)
+	defer cancel()
+
+	if r.PasswordResetRepository == nil {
+		return nil, pkg.Errorf(pkg.NOT_IMPLEMENTED_ERROR, "password reset is not enabled")
+	}
+
+	if req.Token == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "token is required")
+	}
+
+	user, err := r.PasswordResetRepository.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to reset password: %v", pkg.ErrorMessage(err))
+	}
+
+	if err := r.RefreshTokenRepository.RevokeRefreshTokens(ctx, user.ID, ""); err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to revoke refresh tokens: %v", pkg.ErrorMessage(err))
+	}
+
+	return &ResetPasswordResponse{UserID: user.ID, Email: user.Email}, nil
+}
@@ -0,0 +1,117 @@
+package rabbitmq_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/handlers/rabbitmq"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRabbitConn_HandleForgotPassword(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	var sent []mailer.Message
+
+	r.rabbitConn.Emails = mailer.NewEmails(&mock.MockMailer{SendFunc: func(msg mailer.Message) error {
+		sent = append(sent, msg)
+
+		return nil
+	}}, nil, pkg.Config{PASSWORD_RESET_URL: "http://localhost:8080/password/reset"})
+
+	r.UserRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		switch email {
+		case "found@gmail.com":
+			return &repository.User{ID: 1, Email: email}, nil
+		case "disabled@gmail.com":
+			return &repository.User{ID: 2, Email: email, DisabledAt: &TestTime}, nil
+		}
+
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+	}
+	r.PasswordResetRepository.CreatePasswordResetTokenFunc = func(int64, time.Time) (string, error) {
+		return "reset-token", nil
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		wantCode string
+		wantSent bool
+	}{
+		{
+			name:     "sent",
+			email:    "found@gmail.com",
+			wantSent: true,
+		},
+		{
+			name:  "unknown email",
+			email: "unknown@gmail.com",
+		},
+		{
+			name:  "disabled account",
+			email: "disabled@gmail.com",
+		},
+		{
+			name:     "no email",
+			wantCode: pkg.INVALID_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sent = nil
+
+			_, err := r.rabbitConn.HandleForgotPassword(context.Background(), rabbitmq.ForgotPasswordRequest{Email: tc.email})
+			if tc.wantCode != "" {
+				require.NotNil(t, err)
+				require.Equal(t, tc.wantCode, err.Code)
+
+				return
+			}
+
+			require.Nil(t, err)
+
+			if tc.wantSent {
+				require.Len(t, sent, 1)
+				require.Contains(t, sent[0].Body, "token=reset-token")
+			} else {
+				require.Empty(t, sent)
+			}
+		})
+	}
+}
+
+func TestRabbitConn_HandleResetPassword(t *testing.T) {
+	r := NewTestRabbitConn()
+
+	r.PasswordResetRepository.ResetPasswordFunc = func(token string, _ string) (*repository.User, error) {
+		if token != "reset-token" {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid or expired reset token")
+		}
+
+		return &repository.User{ID: 7, Email: "found@gmail.com"}, nil
+	}
+
+	var revoked []int64
+
+	r.RefreshTokenRepository.RevokeRefreshTokensFunc = func(userID int64, _ string) error {
+		revoked = append(revoked, userID)
+
+		return nil
+	}
+
+	rsp, err := r.rabbitConn.HandleResetPassword(context.Background(), rabbitmq.ResetPasswordRequest{Token: "reset-token", Password: "new password"})
+	require.Nil(t, err)
+	require.Equal(t, &rabbitmq.ResetPasswordResponse{UserID: 7, Email: "found@gmail.com"}, rsp)
+	require.Equal(t, []int64{7}, revoked)
+
+	_, err = r.rabbitConn.HandleResetPassword(context.Background(), rabbitmq.ResetPasswordRequest{Token: "used-token", Password: "new password"})
+	require.NotNil(t, err)
+	require.Equal(t, pkg.INVALID_ERROR, err.Code)
+}
@@ -0,0 +1,120 @@
+package rabbitmq
+
+import (
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoRequest is a request that can be read from its protobuf form.
+type protoRequest interface {
+	unmarshalProto(data []byte) error
+}
+
+// protoResponse is a response that can be sent in its protobuf form.
+type protoResponse interface {
+	toProto() proto.Message
+}
+
+func (req *RegisterUserRequest) unmarshalProto(data []byte) error {
+	var msg pb.RegisterUserRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = RegisterUserRequest{
+		FullName:        msg.GetFullname(),
+		Email:           msg.GetEmail(),
+		Password:        msg.GetPassword(),
+		PaydUsername:    msg.GetPaydUsername(),
+		PaydAccountID:   msg.GetPaydAccountId(),
+		PaydUsernameKey: msg.GetPaydUsernameApiKey(),
+		PaydPasswordKey: msg.GetPaydPasswordApiKey(),
+	}
+
+	return nil
+}
+
+func (rsp *RegisterUserResponse) toProto() proto.Message {
+	return &pb.RegisterUserResponse{
+		Fullname:  rsp.FullName,
+		Email:     rsp.Email,
+		CreatedAt: timestamppb.New(rsp.CreatedAt),
+	}
+}
+
+func (req *LoginUserRequest) unmarshalProto(data []byte) error {
+	var msg pb.LoginUserRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = LoginUserRequest{
+		Email:    msg.GetEmail(),
+		Password: msg.GetPassword(),
+		ClientIP: msg.GetClientIp(),
+	}
+
+	return nil
+}
+
+func (rsp *LoginUserResponse) toProto() proto.Message {
+	data := &pb.RegisterUserResponse{
+		Fullname:  rsp.FullName,
+		Email:     rsp.Email,
+		CreatedAt: timestamppb.New(rsp.CreatedAt),
+	}
+
+	if rsp.MFARequired {
+		msg := &pb.LoginUserResponse{Data: data, MfaRequired: true, MfaToken: rsp.MFAToken}
+		if rsp.MFAExpirationAt != nil {
+			msg.MfaExpirationAt = timestamppb.New(*rsp.MFAExpirationAt)
+		}
+
+		return msg
+	}
+
+	return &pb.LoginUserResponse{
+		AccessToken:         rsp.AccessToken,
+		ExpirationAt:        timestamppb.New(rsp.ExpirationAt),
+		Data:                data,
+		RefreshToken:        rsp.RefreshToken,
+		RefreshExpirationAt: timestamppb.New(rsp.RefreshExpirationAt),
+	}
+}
+
+func (req *ForgotPasswordRequest) unmarshalProto(data []byte) error {
+	var msg pb.ForgotPasswordRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = ForgotPasswordRequest{Email: msg.GetEmail()}
+
+	return nil
+}
+
+func (rsp *ForgotPasswordResponse) toProto() proto.Message {
+	return &pb.ForgotPasswordResponse{}
+}
+
+func (req *ResetPasswordRequest) unmarshalProto(data []byte) error {
+	var msg pb.ResetPasswordRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = ResetPasswordRequest{
+		Token:    msg.GetToken(),
+		Password: msg.GetPassword(),
+	}
+
+	return nil
+}
+
+func (rsp *ResetPasswordResponse) toProto() proto.Message {
+	return &pb.ResetPasswordResponse{
+		UserId: rsp.UserID,
+		Email:  rsp.Email,
+	}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var entry map[string]any
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	return entry
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+
+	New(&buf).Info("registering user",
+		"request", map[string]any{
+			"email":             "john@doe.com",
+			"password":          "secret-password",
+			"payd_username_key": "username-key",
+		},
+	)
+
+	entry := decode(t, &buf)
+	require.Equal(t, map[string]any{
+		"email":             "john@doe.com",
+		"password":          redacted,
+		"payd_username_key": redacted,
+	}, entry["request"])
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+
+	defer slog.SetDefault(previous)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.AuthenticationService/LoginUser"}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		requestID func(t *testing.T, requestID string)
+	}{
+		{
+			name: "request id from metadata",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-1")),
+			requestID: func(t *testing.T, requestID string) {
+				require.Equal(t, "req-1", requestID)
+			},
+		},
+		{
+			name: "generated request id",
+			ctx:  context.Background(),
+			requestID: func(t *testing.T, requestID string) {
+				require.Len(t, requestID, 36)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			var requestID string
+
+			handler := func(ctx context.Context, _ any) (any, error) {
+				requestID = RequestID(ctx)
+
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			}
+
+			_, err := UnaryServerInterceptor()(tc.ctx, nil, info, handler)
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+			tc.requestID(t, requestID)
+
+			entry := decode(t, &buf)
+			require.Equal(t, requestID, entry["request_id"])
+			require.Equal(t, info.FullMethod, entry["method"])
+		})
+	}
+}
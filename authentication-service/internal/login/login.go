@@ -0,0 +1,162 @@
+// Package login holds the login policy the gRPC, HTTP and AMQP servers share: the
+// checking of passwords, the throttling and lockout of failed logins, and the codes
+// asked of users with two-factor authentication.
+package login
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+// Logins checks the logins of users, and holds back those that failed too often.
+type Logins struct {
+	config pkg.Config
+
+	UserRepository  repository.UserRepository
+	AuditRepository repository.AuditRepository
+
+	// LoginAttemptRepository limits the failed logins of each email and client address,
+	// logins are not limited when it is nil.
+	LoginAttemptRepository repository.LoginAttemptRepository
+
+	// MFARepository holds the TOTP secrets of users with two-factor authentication, who
+	// are asked for a code after their password. No code is asked for when it is nil.
+	MFARepository repository.MFARepository
+}
+
+func NewLogins(config pkg.Config) *Logins {
+	return &Logins{config: config}
+}
+
+// Result is a login whose password was right.
+type Result struct {
+	User *repository.User
+
+	// MFARequired is set for users with two-factor authentication, who complete the
+	// login by giving MFAToken with a code before MFAExpiresAt, instead of being issued
+	// their tokens.
+	MFARequired  bool
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
+// Login checks the password of the user of email logging in from ip. Unknown emails
+// fail as wrong passwords do, and take as long, and both count as failed logins. It
+// fails with AUTHENTICATION_ERROR for them, RATE_LIMIT_ERROR while the login is held
+// back, and PERMISSION_ERROR for users that can not log in.
+func (l *Logins) Login(ctx context.Context, email string, password string, ip string) (*Result, error) {
+	if err := l.CheckLogin(ctx, email, ip); err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to login user: %v", pkg.ErrorMessage(err))
+	}
+
+	user, err := l.UserRepository.GetUser(ctx, email)
+	if err != nil && pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to login user: %v", pkg.ErrorMessage(err))
+	}
+
+	if user == nil {
+		pkg.ComparePasswordAndDummyHash(password, l.config.HASH_COST)
+	} else {
+		err = pkg.ComparePasswordAndHash(user.Password, password)
+	}
+
+	if user == nil || err != nil {
+		l.RecordLoginFailure(ctx, user, email, ip)
+
+		return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid email or password")
+	}
+
+	mfaEnabled, err := l.MFAEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to login user: %v", pkg.ErrorMessage(err))
+	}
+
+	// the failures of users with two-factor authentication are forgotten once they
+	// give a code too, so that logging in again does not reset the codes guessed
+	if !mfaEnabled {
+		l.ClearLoginFailures(ctx, user.Email)
+	}
+
+	if user.Disabled() {
+		return nil, pkg.Errorf(pkg.PERMISSION_ERROR, "account is disabled")
+	}
+
+	if l.config.REQUIRE_VERIFIED_EMAIL && !user.EmailVerified() {
+		return nil, pkg.Errorf(pkg.PERMISSION_ERROR, "email address is not verified")
+	}
+
+	if !mfaEnabled {
+		return &Result{User: user}, nil
+	}
+
+	expiresAt := time.Now().Add(l.config.MFA_CHALLENGE_DURATION)
+
+	token, err := l.MFARepository.CreateMFAChallenge(ctx, user.ID, expiresAt)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.ErrorCode(err), "failed to login user: %v", pkg.ErrorMessage(err))
+	}
+
+	return &Result{User: user, MFARequired: true, MFAToken: token, MFAExpiresAt: expiresAt}, nil
+}
+
+// CheckLogin fails while the failed logins of email or ip hold them back.
+func (l *Logins) CheckLogin(ctx context.Context, email string, ip string) error {
+	if l.LoginAttemptRepository == nil {
+		return nil
+	}
+
+	return l.LoginAttemptRepository.CheckLogin(ctx, email, ip)
+}
+
+// RecordLoginFailure counts a failed login of email, and audits the lockout of the
+// account of user when the failure locks it. Failures to do so are only logged, the
+// login is refused all the same.
+func (l *Logins) RecordLoginFailure(ctx context.Context, user *repository.User, email string, ip string) {
+	if l.LoginAttemptRepository == nil {
+		return
+	}
+
+	locked, err := l.LoginAttemptRepository.RecordLoginFailure(ctx, email, ip)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record login failure", "error", err)
+
+		return
+	}
+
+	if !locked || user == nil || l.AuditRepository == nil {
+		return
+	}
+
+	_, err = l.AuditRepository.RecordAuditEvent(ctx, repository.AuditEvent{
+		ActorID:      user.ID,
+		Action:       repository.ActionUserLock,
+		TargetUserID: user.ID,
+		Reason:       "too many failed logins",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to audit account lockout", "user_id", user.ID, "error", err)
+	}
+}
+
+func (l *Logins) ClearLoginFailures(ctx context.Context, email string) {
+	if l.LoginAttemptRepository == nil {
+		return
+	}
+
+	if err := l.LoginAttemptRepository.ClearLoginFailures(ctx, email); err != nil {
+		slog.ErrorContext(ctx, "failed to clear login failures", "error", err)
+	}
+}
+
+// MFAEnabled reports whether the user completes their logins with a code.
+func (l *Logins) MFAEnabled(ctx context.Context, userID int64) (bool, error) {
+	if l.MFARepository == nil {
+		return false, nil
+	}
+
+	return l.MFARepository.MFAEnabled(ctx, userID)
+}
@@ -0,0 +1,165 @@
+package login
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogins_Login(t *testing.T) {
+	hash, err := pkg.GenerateHashPassword("password", 4)
+	require.NoError(t, err)
+
+	users := map[string]*repository.User{
+		"jane@gmail.com": {ID: 1, Email: "jane@gmail.com", Password: hash},
+		"mfa@gmail.com":  {ID: 2, Email: "mfa@gmail.com", Password: hash},
+	}
+
+	var (
+		userRepository         mock.MockUsersRepositry
+		auditRepository        mock.MockAuditRepository
+		loginAttemptRepository mock.MockLoginAttemptRepository
+		mfaRepository          mock.MockMFARepository
+
+		audited []repository.AuditEvent
+		cleared []string
+		locked  bool
+	)
+
+	userRepository.GetUserFunc = func(email string) (*repository.User, error) {
+		if user, ok := users[email]; ok {
+			return user, nil
+		}
+
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found")
+	}
+	auditRepository.RecordAuditEventFunc = func(event repository.AuditEvent) (*repository.AuditEvent, error) {
+		audited = append(audited, event)
+
+		return &event, nil
+	}
+	loginAttemptRepository.CheckLoginFunc = func(email, _ string) error {
+		if email == "locked@gmail.com" {
+			return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed logins")
+		}
+
+		return nil
+	}
+	loginAttemptRepository.RecordLoginFailureFunc = func(_, _ string) (bool, error) {
+		return locked, nil
+	}
+	loginAttemptRepository.ClearLoginFailuresFunc = func(email string) error {
+		cleared = append(cleared, email)
+
+		return nil
+	}
+	mfaRepository.MFAEnabledFunc = func(userID int64) (bool, error) {
+		return userID == 2, nil
+	}
+	mfaRepository.CreateMFAChallengeFunc = func(_ int64, _ time.Time) (string, error) {
+		return "mfa-token", nil
+	}
+
+	logins := NewLogins(pkg.Config{HASH_COST: 4, MFA_CHALLENGE_DURATION: 5 * time.Minute})
+	logins.UserRepository = &userRepository
+	logins.AuditRepository = &auditRepository
+	logins.LoginAttemptRepository = &loginAttemptRepository
+	logins.MFARepository = &mfaRepository
+
+	tests := []struct {
+		name        string
+		email       string
+		password    string
+		locked      bool
+		wantCode    string
+		wantMFA     bool
+		wantCleared bool
+		wantAudited bool
+	}{
+		{
+			name:        "right password",
+			email:       "jane@gmail.com",
+			password:    "password",
+			wantCleared: true,
+		},
+		{
+			name:     "two-factor authentication keeps the failures",
+			email:    "mfa@gmail.com",
+			password: "password",
+			wantMFA:  true,
+		},
+		{
+			name:     "wrong password",
+			email:    "jane@gmail.com",
+			password: "wrong",
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name:     "unknown email",
+			email:    "john@gmail.com",
+			password: "password",
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name:        "failure that locks the account is audited",
+			email:       "jane@gmail.com",
+			password:    "wrong",
+			locked:      true,
+			wantCode:    pkg.AUTHENTICATION_ERROR,
+			wantAudited: true,
+		},
+		{
+			name:     "held back",
+			email:    "locked@gmail.com",
+			password: "password",
+			wantCode: pkg.RATE_LIMIT_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			audited, cleared, locked = nil, nil, tc.locked
+
+			result, err := logins.Login(context.Background(), tc.email, tc.password, "10.0.0.1")
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.email, result.User.Email)
+				require.Equal(t, tc.wantMFA, result.MFARequired)
+
+				if tc.wantMFA {
+					require.Equal(t, "mfa-token", result.MFAToken)
+					require.WithinDuration(t, time.Now().Add(5*time.Minute), result.MFAExpiresAt, time.Second)
+				}
+			}
+
+			require.Equal(t, tc.wantCleared, len(cleared) == 1)
+
+			if tc.wantAudited {
+				require.Len(t, audited, 1)
+				require.Equal(t, repository.ActionUserLock, audited[0].Action)
+			} else {
+				require.Empty(t, audited)
+			}
+		})
+	}
+}
+
+func TestLogins_NotLimited(t *testing.T) {
+	logins := NewLogins(pkg.Config{})
+
+	require.NoError(t, logins.CheckLogin(context.Background(), "jane@gmail.com", "10.0.0.1"))
+	logins.RecordLoginFailure(context.Background(), nil, "jane@gmail.com", "10.0.0.1")
+	logins.ClearLoginFailures(context.Background(), "jane@gmail.com")
+
+	enabled, err := logins.MFAEnabled(context.Background(), 1)
+	require.NoError(t, err)
+	require.False(t, enabled)
+}
@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+// Emails writes the emails users are sent, each with a link carrying a token they
+// prove they received it with.
+type Emails struct {
+	mailer Mailer
+	tokens *pkg.EmailTokenMaker
+
+	// verificationURL is the page users confirm their email address on, given the
+	// token in its query.
+	verificationURL           string
+	verificationTokenDuration time.Duration
+
+	// passwordResetURL is the page users choose a new password on, given the reset
+	// token in its query.
+	passwordResetURL           string
+	passwordResetTokenDuration time.Duration
+}
+
+func NewEmails(mailer Mailer, tokens *pkg.EmailTokenMaker, config pkg.Config) *Emails {
+	return &Emails{
+		mailer:                     mailer,
+		tokens:                     tokens,
+		verificationURL:            config.EMAIL_VERIFICATION_URL,
+		verificationTokenDuration:  config.EMAIL_VERIFICATION_TOKEN_DURATION,
+		passwordResetURL:           config.PASSWORD_RESET_URL,
+		passwordResetTokenDuration: config.PASSWORD_RESET_TOKEN_DURATION,
+	}
+}
+
+// SendVerification sends the user the link confirming their email address.
+func (e *Emails) SendVerification(ctx context.Context, userID int64, email string) error {
+	token, err := e.tokens.CreateToken(pkg.EmailVerificationPurpose, userID, email, e.verificationTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(e.verificationURL, token)
+	if err != nil {
+		return err
+	}
+
+	return e.mailer.Send(ctx, Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Confirm the email address of your account by following this link:\n\n%s\n\n"+
+				"The link expires in %s. If you did not register, ignore this email.\n",
+			link,
+			e.verificationTokenDuration,
+		),
+	})
+}
+
+// SendPasswordReset sends the user the link setting a new password with token, a
+// password reset token issued to them.
+func (e *Emails) SendPasswordReset(ctx context.Context, email string, token string) error {
+	link, err := withToken(e.passwordResetURL, token)
+	if err != nil {
+		return err
+	}
+
+	return e.mailer.Send(ctx, Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Choose a new password for your account by following this link:\n\n%s\n\n"+
+				"The link expires in %s and works once. If you did not ask to reset your "+
+				"password, ignore this email: your password stays the same.\n",
+			link,
+			e.passwordResetTokenDuration,
+		),
+	})
+}
+
+// VerifyToken returns the payload of a token sent by email for purpose.
+func (e *Emails) VerifyToken(token string, purpose string) (*pkg.EmailPayload, error) {
+	return e.tokens.VerifyToken(token, purpose)
+}
+
+func withToken(base string, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid link url %q: %w", base, err)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes the emails to a file, one after the other, or to the log when it has
+// no path. It is meant for running the service locally.
+type LogMailer struct {
+	path string
+	from string
+
+	mu sync.Mutex
+}
+
+func NewLogMailer(path, from string) *LogMailer {
+	return &LogMailer{path: path, from: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if m.path == "" {
+		slog.InfoContext(ctx, "email", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening mail log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\n%s\n\n", time.Now().Format(time.RFC1123Z), format(m.from, msg))
+	if err != nil {
+		return fmt.Errorf("error writing mail log: %w", err)
+	}
+
+	return nil
+}
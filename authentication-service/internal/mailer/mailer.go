@@ -0,0 +1,35 @@
+// Package mailer sends the emails of the authentication service. Mail goes through the
+// Mailer interface: over SMTP in production, or written to a file or the log when
+// running locally, where the links in the emails can be followed by hand.
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer named by MAILER: "smtp", or "log", the default, which writes
+// the emails to MAIL_LOG_PATH or to the log when it is empty.
+func New(config pkg.Config) (Mailer, error) {
+	switch config.MAILER {
+	case "smtp":
+		return NewSMTPMailer(config.SMTP_ADDR, config.SMTP_USERNAME, config.SMTP_PASSWORD, config.MAIL_FROM)
+	case "log", "":
+		return NewLogMailer(config.MAIL_LOG_PATH, config.MAIL_FROM), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", config.MAILER)
+	}
+}
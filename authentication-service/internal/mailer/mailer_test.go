@@ -0,0 +1,202 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a Mailer keeping the messages it is given.
+type recorder struct {
+	sent []Message
+}
+
+func (r *recorder) Send(_ context.Context, msg Message) error {
+	r.sent = append(r.sent, msg)
+
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	m, err := New(pkg.Config{})
+	require.NoError(t, err)
+	require.IsType(t, &LogMailer{}, m)
+
+	m, err = New(pkg.Config{MAILER: "smtp", SMTP_ADDR: "localhost:25", MAIL_FROM: "no-reply@example.com"})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, m)
+
+	_, err = New(pkg.Config{MAILER: "smtp", SMTP_ADDR: "localhost"})
+	require.Error(t, err)
+
+	_, err = New(pkg.Config{MAILER: "carrier-pigeon"})
+	require.Error(t, err)
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+
+	m := NewLogMailer(path, "no-reply@example.com")
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "jane@gmail.com", Subject: "Hello", Body: "first"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "john@gmail.com", Subject: "Hello", Body: "second"}))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "To: jane@gmail.com")
+	require.Contains(t, string(b), "To: john@gmail.com")
+	require.Contains(t, string(b), "second")
+
+	require.NoError(t, NewLogMailer("", "no-reply@example.com").Send(context.Background(), Message{To: "jane@gmail.com"}))
+}
+
+// serveSMTP plays an SMTP server for a single email, returning what it was sent.
+func serveSMTP(t *testing.T, l net.Listener) <-chan string {
+	t.Helper()
+
+	received := make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var data strings.Builder
+
+		reply("220 localhost ready")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				data.WriteString(line)
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					if line == ".\r\n" {
+						break
+					}
+
+					data.WriteString(line)
+				}
+
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- data.String()
+
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return received
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := serveSMTP(t, l)
+
+	m, err := NewSMTPMailer(l.Addr().String(), "", "", "no-reply@example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = m.Send(ctx, Message{To: "jane@gmail.com", Subject: "Hello", Body: "line one\nline two"})
+	require.NoError(t, err)
+
+	data := <-received
+	require.Contains(t, data, "MAIL FROM:<no-reply@example.com>")
+	require.Contains(t, data, "RCPT TO:<jane@gmail.com>")
+	require.Contains(t, data, "Subject: Hello\r\n")
+	require.Contains(t, data, "line one\r\nline two")
+
+	// headers can not be injected through the recipient or the subject
+	err = m.Send(ctx, Message{To: "jane@gmail.com\r\nBcc: john@gmail.com", Subject: "Hello"})
+	require.Error(t, err)
+}
+
+func TestEmails_SendVerification(t *testing.T) {
+	tokens, err := pkg.NewEmailTokenMaker("an email token secret of 32 char")
+	require.NoError(t, err)
+
+	r := &recorder{}
+
+	e := NewEmails(r, tokens, pkg.Config{
+		EMAIL_VERIFICATION_URL:            "http://localhost:5000/verify-email",
+		EMAIL_VERIFICATION_TOKEN_DURATION: time.Hour,
+	})
+
+	require.NoError(t, e.SendVerification(context.Background(), 7, "jane@gmail.com"))
+	require.Len(t, r.sent, 1)
+	require.Equal(t, "jane@gmail.com", r.sent[0].To)
+
+	// the link in the email carries a token good for the user and their email
+	start := strings.Index(r.sent[0].Body, "http://")
+	require.NotEqual(t, -1, start)
+
+	link, err := url.Parse(strings.Fields(r.sent[0].Body[start:])[0])
+	require.NoError(t, err)
+	require.Equal(t, "/verify-email", link.Path)
+
+	payload, err := e.VerifyToken(link.Query().Get("token"), pkg.EmailVerificationPurpose)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), payload.UserID)
+	require.Equal(t, "jane@gmail.com", payload.Email)
+}
+
+func TestEmails_SendPasswordReset(t *testing.T) {
+	r := &recorder{}
+
+	e := NewEmails(r, nil, pkg.Config{
+		PASSWORD_RESET_URL:            "http://localhost:5000/reset-password?lang=en",
+		PASSWORD_RESET_TOKEN_DURATION: 30 * time.Minute,
+	})
+
+	require.NoError(t, e.SendPasswordReset(context.Background(), "jane@gmail.com", "reset token"))
+	require.Len(t, r.sent, 1)
+	require.Equal(t, "jane@gmail.com", r.sent[0].To)
+	require.Contains(t, r.sent[0].Body, "30m0s")
+
+	start := strings.Index(r.sent[0].Body, "http://")
+	require.NotEqual(t, -1, start)
+
+	link, err := url.Parse(strings.Fields(r.sent[0].Body[start:])[0])
+	require.NoError(t, err)
+	require.Equal(t, "/reset-password", link.Path)
+	require.Equal(t, "en", link.Query().Get("lang"))
+	require.Equal(t, "reset token", link.Query().Get("token"))
+}
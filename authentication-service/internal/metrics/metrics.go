@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "auth"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests handled by the authentication service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	grpcServerHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "handled_total",
+		Help:      "Total number of gRPC calls completed by the server, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcServerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "handling_seconds",
+		Help:      "Duration of gRPC calls handled by the server.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// Handler exposes the registered metrics in the prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// GinMiddleware records the duration and status of every request served by the router.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequestDuration.WithLabelValues(
+			ctx.Request.Method,
+			route,
+			strconv.Itoa(ctx.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// UnaryServerInterceptor records the outcome and latency of every unary gRPC call.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+
+		rsp, err := handler(ctx, req)
+
+		grpcServerDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		grpcServerHandled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+		return rsp, err
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func scrape(t *testing.T) string {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/metrics", Handler())
+
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return w.Body.String()
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.POST("/auth/login", func(ctx *gin.Context) {
+		ctx.Status(http.StatusUnauthorized)
+	})
+
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodPost, "/auth/login", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	require.Contains(t, scrape(t), `auth_http_request_duration_seconds_count{method="POST",route="/auth/login",status="401"} 1`)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.authenticationService/LoginUser"}
+
+	handler := func(_ context.Context, _ any) (any, error) {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	_, err := interceptor(context.Background(), nil, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	require.Contains(t, scrape(t), `auth_grpc_server_handled_total{code="Unauthenticated",method="/pb.authenticationService/LoginUser"} 1`)
+}
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.APIKeyRepository = (*MockAPIKeyRepository)(nil)
+
+type MockAPIKeyRepository struct {
+	CreateAPIKeyFunc func(repository.APIKey) (string, *repository.APIKey, error)
+	ListAPIKeysFunc  func(int64) ([]repository.APIKey, error)
+	RevokeAPIKeyFunc func(int64, int64) error
+	VerifyAPIKeyFunc func(string) (*repository.APIKey, error)
+}
+
+func (r *MockAPIKeyRepository) CreateAPIKey(
+	_ context.Context,
+	apiKey repository.APIKey,
+) (string, *repository.APIKey, error) {
+	return r.CreateAPIKeyFunc(apiKey)
+}
+
+func (r *MockAPIKeyRepository) ListAPIKeys(_ context.Context, userID int64) ([]repository.APIKey, error) {
+	return r.ListAPIKeysFunc(userID)
+}
+
+func (r *MockAPIKeyRepository) RevokeAPIKey(_ context.Context, userID, id int64) error {
+	return r.RevokeAPIKeyFunc(userID, id)
+}
+
+func (r *MockAPIKeyRepository) VerifyAPIKey(_ context.Context, key string) (*repository.APIKey, error) {
+	return r.VerifyAPIKeyFunc(key)
+}
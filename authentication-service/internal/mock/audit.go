@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.AuditRepository = (*MockAuditRepository)(nil)
+
+type MockAuditRepository struct {
+	RecordAuditEventFunc func(repository.AuditEvent) (*repository.AuditEvent, error)
+}
+
+func (a *MockAuditRepository) RecordAuditEvent(
+	_ context.Context,
+	event repository.AuditEvent,
+) (*repository.AuditEvent, error) {
+	return a.RecordAuditEventFunc(event)
+}
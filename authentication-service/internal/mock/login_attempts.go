@@ -0,0 +1,27 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.LoginAttemptRepository = (*MockLoginAttemptRepository)(nil)
+
+type MockLoginAttemptRepository struct {
+	CheckLoginFunc         func(string, string) error
+	RecordLoginFailureFunc func(string, string) (bool, error)
+	ClearLoginFailuresFunc func(string) error
+}
+
+func (r *MockLoginAttemptRepository) CheckLogin(_ context.Context, email string, ip string) error {
+	return r.CheckLoginFunc(email, ip)
+}
+
+func (r *MockLoginAttemptRepository) RecordLoginFailure(_ context.Context, email string, ip string) (bool, error) {
+	return r.RecordLoginFailureFunc(email, ip)
+}
+
+func (r *MockLoginAttemptRepository) ClearLoginFailures(_ context.Context, email string) error {
+	return r.ClearLoginFailuresFunc(email)
+}
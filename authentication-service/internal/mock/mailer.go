@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/mailer"
+)
+
+var _ mailer.Mailer = (*MockMailer)(nil)
+
+type MockMailer struct {
+	SendFunc func(mailer.Message) error
+}
+
+func (m *MockMailer) Send(_ context.Context, msg mailer.Message) error {
+	return m.SendFunc(msg)
+}
@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.MFARepository = (*MockMFARepository)(nil)
+
+type MockMFARepository struct {
+	EnrollMFAFunc            func(int64) (*repository.MFAEnrollment, error)
+	ActivateMFAFunc          func(int64, string) (time.Time, error)
+	MFAEnabledFunc           func(int64) (bool, error)
+	DisableMFAFunc           func(int64, string) error
+	VerifyMFACodeFunc        func(int64, string) error
+	CreateMFAChallengeFunc   func(int64, time.Time) (string, error)
+	GetMFAChallengeFunc      func(string) (int64, error)
+	CompleteMFAChallengeFunc func(string, string) (int64, error)
+}
+
+func (r *MockMFARepository) EnrollMFA(_ context.Context, userID int64) (*repository.MFAEnrollment, error) {
+	return r.EnrollMFAFunc(userID)
+}
+
+func (r *MockMFARepository) ActivateMFA(_ context.Context, userID int64, code string) (time.Time, error) {
+	return r.ActivateMFAFunc(userID, code)
+}
+
+func (r *MockMFARepository) MFAEnabled(_ context.Context, userID int64) (bool, error) {
+	return r.MFAEnabledFunc(userID)
+}
+
+func (r *MockMFARepository) DisableMFA(_ context.Context, userID int64, code string) error {
+	return r.DisableMFAFunc(userID, code)
+}
+
+func (r *MockMFARepository) VerifyMFACode(_ context.Context, userID int64, code string) error {
+	return r.VerifyMFACodeFunc(userID, code)
+}
+
+func (r *MockMFARepository) CreateMFAChallenge(
+	_ context.Context,
+	userID int64,
+	expiresAt time.Time,
+) (string, error) {
+	return r.CreateMFAChallengeFunc(userID, expiresAt)
+}
+
+func (r *MockMFARepository) GetMFAChallenge(_ context.Context, token string) (int64, error) {
+	return r.GetMFAChallengeFunc(token)
+}
+
+func (r *MockMFARepository) CompleteMFAChallenge(_ context.Context, token string, code string) (int64, error) {
+	return r.CompleteMFAChallengeFunc(token, code)
+}
@@ -0,0 +1,31 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.PasswordResetRepository = (*MockPasswordResetRepository)(nil)
+
+type MockPasswordResetRepository struct {
+	CreatePasswordResetTokenFunc func(int64, time.Time) (string, error)
+	ResetPasswordFunc            func(string, string) (*repository.User, error)
+}
+
+func (r *MockPasswordResetRepository) CreatePasswordResetToken(
+	_ context.Context,
+	userID int64,
+	expiresAt time.Time,
+) (string, error) {
+	return r.CreatePasswordResetTokenFunc(userID, expiresAt)
+}
+
+func (r *MockPasswordResetRepository) ResetPassword(
+	_ context.Context,
+	token string,
+	password string,
+) (*repository.User, error) {
+	return r.ResetPasswordFunc(token, password)
+}
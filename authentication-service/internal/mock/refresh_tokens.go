@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+)
+
+var _ repository.RefreshTokenRepository = (*MockRefreshTokenRepository)(nil)
+
+type MockRefreshTokenRepository struct {
+	CreateRefreshTokenFunc  func(int64, time.Time) (string, *repository.RefreshToken, error)
+	RotateRefreshTokenFunc  func(string) (string, *repository.RefreshToken, error)
+	RevokeRefreshTokensFunc func(int64, string) error
+}
+
+func (r *MockRefreshTokenRepository) CreateRefreshToken(
+	_ context.Context,
+	userID int64,
+	expiresAt time.Time,
+) (string, *repository.RefreshToken, error) {
+	return r.CreateRefreshTokenFunc(userID, expiresAt)
+}
+
+func (r *MockRefreshTokenRepository) RotateRefreshToken(
+	_ context.Context,
+	token string,
+) (string, *repository.RefreshToken, error) {
+	return r.RotateRefreshTokenFunc(token)
+}
+
+func (r *MockRefreshTokenRepository) RevokeRefreshTokens(_ context.Context, userID int64, token string) error {
+	return r.RevokeRefreshTokensFunc(userID, token)
+}
@@ -12,6 +12,8 @@ type MockUsersRepositry struct {
 	CreateUserFunc  func(repository.User) (*repository.User, error)
 	GetUserFunc     func(string) (*repository.User, error)
 	GetUserByIDFunc func(int64) (*repository.User, error)
+	DisableUserFunc func(int64) (*repository.User, error)
+	VerifyEmailFunc func(int64, string) (*repository.User, error)
 }
 
 func (u *MockUsersRepositry) GetUser(_ context.Context, email string) (*repository.User, error) {
@@ -25,3 +27,11 @@ func (u *MockUsersRepositry) CreateUser(_ context.Context, user repository.User)
 func (u *MockUsersRepositry) GetUserByID(_ context.Context, id int64) (*repository.User, error) {
 	return u.GetUserByIDFunc(id)
 }
+
+func (u *MockUsersRepositry) DisableUser(_ context.Context, id int64) (*repository.User, error) {
+	return u.DisableUserFunc(id)
+}
+
+func (u *MockUsersRepositry) VerifyEmail(_ context.Context, id int64, email string) (*repository.User, error) {
+	return u.VerifyEmailFunc(id, email)
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+type APIKeyRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewAPIKeyService(db *Store) *APIKeyRepository {
+	queries := generated.New(db.conn)
+
+	return &APIKeyRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *APIKeyRepository) CreateAPIKey(ctx context.Context, apiKey repository.APIKey) (string, *repository.APIKey, error) {
+	if err := apiKey.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	key, prefix, err := pkg.NewAPIKey()
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+	}
+
+	params := generated.CreateAPIKeyParams{
+		UserID:     apiKey.UserID,
+		Name:       apiKey.Name,
+		Prefix:     prefix,
+		KeyHash:    pkg.HashAPIKey(key),
+		Scopes:     apiKey.Scopes,
+		AllowedIps: apiKey.AllowedIPs,
+	}
+
+	if apiKey.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *apiKey.ExpiresAt, Valid: true}
+	}
+
+	created, err := s.queries.CreateAPIKey(ctx, params)
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating api key: %s", err)
+	}
+
+	return key, apiKeyFromRow(created), nil
+}
+
+func (s *APIKeyRepository) ListAPIKeys(ctx context.Context, userID int64) ([]repository.APIKey, error) {
+	rows, err := s.queries.ListUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing api keys: %s", err)
+	}
+
+	apiKeys := make([]repository.APIKey, 0, len(rows))
+	for _, row := range rows {
+		apiKeys = append(apiKeys, *apiKeyFromRow(row))
+	}
+
+	return apiKeys, nil
+}
+
+func (s *APIKeyRepository) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	rows, err := s.queries.RevokeAPIKey(ctx, generated.RevokeAPIKeyParams{ID: id, UserID: userID})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error revoking api key: %s", err)
+	}
+
+	if rows == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "api key not found")
+	}
+
+	return nil
+}
+
+func (s *APIKeyRepository) VerifyAPIKey(ctx context.Context, key string) (*repository.APIKey, error) {
+	row, err := s.queries.GetAPIKeyByHash(ctx, pkg.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid api key")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting api key: %s", err)
+	}
+
+	if row.Revoked {
+		return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "api key has been revoked")
+	}
+
+	apiKey := apiKeyFromRow(row)
+	if apiKey.Expired(time.Now()) {
+		return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "api key has expired")
+	}
+
+	// the time of use is only recorded once a minute, and a failure to record it does
+	// not fail the request
+	if err := s.queries.TouchAPIKey(ctx, row.ID); err != nil {
+		slog.WarnContext(ctx, "failed to record api key use", "api_key_id", row.ID, "error", err)
+	}
+
+	return apiKey, nil
+}
+
+func apiKeyFromRow(row generated.ApiKey) *repository.APIKey {
+	apiKey := &repository.APIKey{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Scopes:     row.Scopes,
+		AllowedIPs: row.AllowedIps,
+		CreatedAt:  row.CreatedAt,
+	}
+
+	if row.ExpiresAt.Valid {
+		apiKey.ExpiresAt = &row.ExpiresAt.Time
+	}
+
+	if row.LastUsedAt.Valid {
+		apiKey.LastUsedAt = &row.LastUsedAt.Time
+	}
+
+	return apiKey
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestAPIKeyRepository() *APIKeyRepository {
+	store := NewStore(pkg.Config{})
+	store.conn = nil
+
+	return NewAPIKeyService(store)
+}
+
+func TestAPIKeyRepository_CreateAPIKey(t *testing.T) {
+	s := NewTestAPIKeyRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	apiKey := repository.APIKey{
+		UserID:     7,
+		Name:       "billing",
+		Scopes:     []string{repository.ScopePaymentsRead},
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		ExpiresAt:  &expiresAt,
+	}
+
+	var params generated.CreateAPIKeyParams
+
+	mockQueries.EXPECT().CreateAPIKey(gomock.Any(), gomock.AssignableToTypeOf(generated.CreateAPIKeyParams{})).
+		DoAndReturn(func(_ context.Context, arg generated.CreateAPIKeyParams) (generated.ApiKey, error) {
+			params = arg
+
+			return generated.ApiKey{
+				ID:         1,
+				UserID:     arg.UserID,
+				Name:       arg.Name,
+				Prefix:     arg.Prefix,
+				KeyHash:    arg.KeyHash,
+				Scopes:     arg.Scopes,
+				AllowedIps: arg.AllowedIps,
+				ExpiresAt:  arg.ExpiresAt,
+				CreatedAt:  TestTime,
+			}, nil
+		}).Times(1)
+
+	key, got, err := s.CreateAPIKey(context.Background(), apiKey)
+	require.NoError(t, err)
+	require.True(t, pkg.IsAPIKey(key))
+
+	// only the hash of the key is stored, and the start of it to tell keys apart
+	require.Equal(t, pkg.HashAPIKey(key), params.KeyHash)
+	require.Equal(t, key[:len(params.Prefix)], params.Prefix)
+	require.Equal(t, pgtype.Timestamptz{Time: expiresAt, Valid: true}, params.ExpiresAt)
+
+	require.Equal(t, int64(1), got.ID)
+	require.Equal(t, params.Prefix, got.Prefix)
+	require.Equal(t, apiKey.Scopes, got.Scopes)
+	require.Equal(t, apiKey.AllowedIPs, got.AllowedIPs)
+	require.Equal(t, expiresAt, *got.ExpiresAt)
+	require.Nil(t, got.LastUsedAt)
+
+	// invalid keys are not stored
+	invalid := apiKey
+	invalid.Scopes = []string{"payments:refund"}
+
+	_, got, err = s.CreateAPIKey(context.Background(), invalid)
+	require.Error(t, err)
+	require.Equal(t, pkg.INVALID_ERROR, pkg.ErrorCode(err))
+	require.Nil(t, got)
+
+	mockQueries.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).
+		Return(generated.ApiKey{}, errors.New("db error")).Times(1)
+
+	_, _, err = s.CreateAPIKey(context.Background(), apiKey)
+	require.Error(t, err)
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestAPIKeyRepository_VerifyAPIKey(t *testing.T) {
+	s := NewTestAPIKeyRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	key := pkg.APIKeyPrefix + "secret"
+
+	stored := generated.ApiKey{
+		ID:        1,
+		UserID:    7,
+		Name:      "billing",
+		Prefix:    key[:8],
+		KeyHash:   pkg.HashAPIKey(key),
+		Scopes:    []string{repository.ScopePaymentsInitiate},
+		CreatedAt: TestTime,
+	}
+
+	tests := []struct {
+		name       string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Eq(stored.KeyHash)).
+					Return(stored, nil).Times(1)
+				mockQueries.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(stored.ID)).
+					Return(nil).Times(1)
+			},
+		},
+		{
+			name: "use not recorded",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(stored, nil).Times(1)
+				mockQueries.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error")).Times(1)
+			},
+		},
+		{
+			name: "unknown key",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(generated.ApiKey{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "revoked key",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				revoked := stored
+				revoked.Revoked = true
+
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(revoked, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "expired key",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				expired := stored
+				expired.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(expired, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "db error",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(generated.ApiKey{}, errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockQueries)
+
+			got, err := s.VerifyAPIKey(context.Background(), key)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+				require.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, stored.UserID, got.UserID)
+			require.Equal(t, stored.Scopes, got.Scopes)
+		})
+	}
+}
+
+func TestAPIKeyRepository_RevokeAPIKey(t *testing.T) {
+	s := NewTestAPIKeyRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	mockQueries.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Eq(generated.RevokeAPIKeyParams{ID: 1, UserID: 7})).
+		Return(int64(1), nil).Times(1)
+
+	require.NoError(t, s.RevokeAPIKey(context.Background(), 7, 1))
+
+	// keys of other users, and keys already revoked, are not found
+	mockQueries.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Eq(generated.RevokeAPIKeyParams{ID: 1, UserID: 8})).
+		Return(int64(0), nil).Times(1)
+
+	err := s.RevokeAPIKey(context.Background(), 8, 1)
+	require.Error(t, err)
+	require.Equal(t, pkg.NOT_FOUND_ERROR, pkg.ErrorCode(err))
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+type AuditRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewAuditService(db *Store) *AuditRepository {
+	queries := generated.New(db.conn)
+
+	return &AuditRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *AuditRepository) RecordAuditEvent(ctx context.Context, event repository.AuditEvent) (*repository.AuditEvent, error) {
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	row, err := s.queries.CreateAuditEvent(ctx, generated.CreateAuditEventParams{
+		ActorID:      event.ActorID,
+		Action:       event.Action,
+		TargetUserID: event.TargetUserID,
+		Reason:       event.Reason,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error recording audit event: %s", err)
+	}
+
+	return &repository.AuditEvent{
+		ID:           row.ID,
+		ActorID:      row.ActorID,
+		Action:       row.Action,
+		TargetUserID: row.TargetUserID,
+		Reason:       row.Reason,
+		CreatedAt:    row.CreatedAt,
+	}, nil
+}
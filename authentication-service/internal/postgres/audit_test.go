@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestAuditRepository() *AuditRepository {
+	store := NewStore(pkg.Config{})
+	store.conn = nil
+
+	return NewAuditService(store)
+}
+
+func TestAuditRepository_RecordAuditEvent(t *testing.T) {
+	s := NewTestAuditRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	event := repository.AuditEvent{
+		ActorID:      1,
+		Action:       repository.ActionTransactionsView,
+		TargetUserID: 7,
+		Reason:       "ticket 42",
+	}
+
+	mockQueries.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Eq(generated.CreateAuditEventParams{
+		ActorID:      1,
+		Action:       repository.ActionTransactionsView,
+		TargetUserID: 7,
+		Reason:       "ticket 42",
+	})).
+		Return(generated.AuditLog{
+			ID:           3,
+			ActorID:      1,
+			Action:       repository.ActionTransactionsView,
+			TargetUserID: 7,
+			Reason:       "ticket 42",
+			CreatedAt:    TestTime,
+		}, nil).
+		Times(1)
+
+	got, err := s.RecordAuditEvent(context.Background(), event)
+	require.NoError(t, err)
+
+	event.ID, event.CreatedAt = 3, TestTime
+	require.Equal(t, &event, got)
+
+	mockQueries.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).
+		Return(generated.AuditLog{}, errors.New("db error")).
+		Times(1)
+
+	_, err = s.RecordAuditEvent(context.Background(), event)
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+
+	for _, invalid := range []repository.AuditEvent{
+		{Action: repository.ActionUserLookup, TargetUserID: 7},
+		{ActorID: 1, Action: "user.delete", TargetUserID: 7},
+		{ActorID: 1, Action: repository.ActionUserLookup},
+		{ActorID: 1, Action: repository.ActionTransactionsView, TargetUserID: 7},
+		{ActorID: 1, Action: repository.ActionUserDisable, TargetUserID: 7},
+		{ActorID: 1, Action: repository.ActionUserUnlock, TargetUserID: 7},
+	} {
+		_, err := s.RecordAuditEvent(context.Background(), invalid)
+		require.Equal(t, pkg.INVALID_ERROR, pkg.ErrorCode(err), invalid)
+	}
+
+	// lookups can go without a reason
+	mockQueries.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).
+		Return(generated.AuditLog{ID: 4}, nil).
+		Times(1)
+
+	_, err = s.RecordAuditEvent(context.Background(), repository.AuditEvent{
+		ActorID:      1,
+		Action:       repository.ActionUserLookup,
+		TargetUserID: 7,
+	})
+	require.NoError(t, err)
+
+	// lockouts are recorded with the locked user as the actor
+	mockQueries.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Eq(generated.CreateAuditEventParams{
+		ActorID:      7,
+		Action:       repository.ActionUserLock,
+		TargetUserID: 7,
+		Reason:       "too many failed logins",
+	})).
+		Return(generated.AuditLog{ID: 5}, nil).
+		Times(1)
+
+	_, err = s.RecordAuditEvent(context.Background(), repository.AuditEvent{
+		ActorID:      7,
+		Action:       repository.ActionUserLock,
+		TargetUserID: 7,
+		Reason:       "too many failed logins",
+	})
+	require.NoError(t, err)
+}
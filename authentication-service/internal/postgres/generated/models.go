@@ -5,17 +5,101 @@
 package generated
 
 import (
+	"net/netip"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID         int64              `json:"id"`
+	UserID     int64              `json:"user_id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	KeyHash    string             `json:"key_hash"`
+	Scopes     []string           `json:"scopes"`
+	AllowedIps []netip.Prefix     `json:"allowed_ips"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	Revoked    bool               `json:"revoked"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID           int64     `json:"id"`
+	ActorID      int64     `json:"actor_id"`
+	Action       string    `json:"action"`
+	TargetUserID int64     `json:"target_user_id"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	Scope         string             `json:"scope"`
+	Subject       string             `json:"subject"`
+	Failures      int32              `json:"failures"`
+	LastFailureAt time.Time          `json:"last_failure_at"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID        int64              `json:"id"`
+	UserID    int64              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	Attempts  int32              `json:"attempts"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64              `json:"id"`
+	UserID    int64              `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64              `json:"id"`
+	UserID    int64              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	FamilyID  uuid.UUID `json:"family_id"`
+	TokenHash string    `json:"token_hash"`
+	Used      bool      `json:"used"`
+	Revoked   bool      `json:"revoked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type User struct {
-	ID              int64     `json:"id"`
-	FullName        string    `json:"full_name"`
-	Email           string    `json:"email"`
-	Password        string    `json:"password"`
-	PaydUsername    string    `json:"payd_username"`
-	PaydAccountID   string    `json:"payd_account_id"`
-	PaydUsernameKey string    `json:"payd_username_key"`
-	PaydPasswordKey string    `json:"payd_password_key"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              int64              `json:"id"`
+	FullName        string             `json:"full_name"`
+	Email           string             `json:"email"`
+	Password        string             `json:"password"`
+	PaydUsername    string             `json:"payd_username"`
+	PaydAccountID   string             `json:"payd_account_id"`
+	PaydUsernameKey string             `json:"payd_username_key"`
+	PaydPasswordKey string             `json:"payd_password_key"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Role            string             `json:"role"`
+	DisabledAt      pgtype.Timestamptz `json:"disabled_at"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+}
+
+type UserMfa struct {
+	UserID       int64              `json:"user_id"`
+	Secret       string             `json:"secret"`
+	EnabledAt    pgtype.Timestamptz `json:"enabled_at"`
+	LastUsedStep int64              `json:"last_used_step"`
+	CreatedAt    time.Time          `json:"created_at"`
 }
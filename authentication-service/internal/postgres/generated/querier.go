@@ -6,12 +6,50 @@ package generated
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	CountMFAChallengeAttempt(ctx context.Context, tokenHash string) (MfaChallenge, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditLog, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
+	CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteLoginAttempt(ctx context.Context, arg DeleteLoginAttemptParams) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID int64) error
+	DeleteStaleLoginAttempts(ctx context.Context, lastFailureAt time.Time) error
+	DeleteUserMFA(ctx context.Context, userID int64) error
+	DeleteUserMFAChallenges(ctx context.Context, userID int64) error
+	DisableUser(ctx context.Context, id int64) (User, error)
+	EnableUserMFA(ctx context.Context, arg EnableUserMFAParams) (UserMfa, error)
+	EnrollUserMFA(ctx context.Context, arg EnrollUserMFAParams) (UserMfa, error)
+	ExpireUserPasswordResetTokens(ctx context.Context, userID int64) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetLoginAttempt(ctx context.Context, arg GetLoginAttemptParams) (LoginAttempt, error)
+	GetMFAChallenge(ctx context.Context, tokenHash string) (MfaChallenge, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
 	GetUser(ctx context.Context, id int64) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserMFA(ctx context.Context, userID int64) (UserMfa, error)
+	ListUserAPIKeys(ctx context.Context, userID int64) ([]ApiKey, error)
+	LockLoginAttempt(ctx context.Context, arg LockLoginAttemptParams) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (int64, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+	TouchAPIKey(ctx context.Context, id int64) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UseMFAChallenge(ctx context.Context, id int64) (int64, error)
+	UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (int64, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	UseRefreshToken(ctx context.Context, id int64) (int64, error)
+	UseUserMFAStep(ctx context.Context, arg UseUserMFAStepParams) (int64, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -15,7 +15,7 @@ INSERT INTO users (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at
+RETURNING id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at, email_verified_at
 `
 
 type CreateUserParams struct {
@@ -49,12 +49,42 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PaydUsernameKey,
 		&i.PaydPasswordKey,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at, email_verified_at
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRow(ctx, disableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.Email,
+		&i.Password,
+		&i.PaydUsername,
+		&i.PaydAccountID,
+		&i.PaydUsernameKey,
+		&i.PaydPasswordKey,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at FROM users
+SELECT id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at FROM users
 WHERE id = $1
 LIMIT 1
 `
@@ -72,12 +102,15 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.PaydUsernameKey,
 		&i.PaydPasswordKey,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at FROM users
+SELECT id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -95,6 +128,73 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.PaydUsernameKey,
 		&i.PaydPasswordKey,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET password = $2
+WHERE id = $1
+RETURNING id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at, email_verified_at
+`
+
+type UpdateUserPasswordParams struct {
+	ID       int64  `json:"id"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.ID, arg.Password)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.Email,
+		&i.Password,
+		&i.PaydUsername,
+		&i.PaydAccountID,
+		&i.PaydUsernameKey,
+		&i.PaydPasswordKey,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1 AND email = $2
+RETURNING id, full_name, email, password, payd_username, payd_account_id, payd_username_key, payd_password_key, created_at, role, disabled_at, email_verified_at
+`
+
+type VerifyUserEmailParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.Email,
+		&i.Password,
+		&i.PaydUsername,
+		&i.PaydAccountID,
+		&i.PaydUsernameKey,
+		&i.PaydPasswordKey,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+// the scopes failed logins are counted in
+const (
+	scopeEmail = "email"
+	scopeIP    = "ip"
+)
+
+type LoginAttemptRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewLoginAttemptService(db *Store) *LoginAttemptRepository {
+	queries := generated.New(db.conn)
+
+	return &LoginAttemptRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *LoginAttemptRepository) CheckLogin(ctx context.Context, email string, ip string) error {
+	now := time.Now()
+
+	attempt, err := s.getLoginAttempt(ctx, scopeEmail, normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+
+	if attempt != nil {
+		if attempt.LockedUntil.Valid && attempt.LockedUntil.Time.After(now) {
+			return errTooManyLoginAttempts()
+		}
+
+		// the failures of a lockout that ended are not waited for
+		if !attempt.LockedUntil.Valid && now.Before(attempt.LastFailureAt.Add(s.failureDelay(attempt.Failures))) {
+			return errTooManyLoginAttempts()
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	attempt, err = s.getLoginAttempt(ctx, scopeIP, ip)
+	if err != nil {
+		return err
+	}
+
+	if attempt != nil && attempt.LockedUntil.Valid && attempt.LockedUntil.Time.After(now) {
+		return errTooManyLoginAttempts()
+	}
+
+	return nil
+}
+
+func (s *LoginAttemptRepository) RecordLoginFailure(ctx context.Context, email string, ip string) (bool, error) {
+	// failures older than the lockout duration are not counted anymore, and nothing
+	// else removes those of addresses and emails that never log in
+	err := s.queries.DeleteStaleLoginAttempts(ctx, time.Now().Add(-s.db.config.LOGIN_LOCKOUT_DURATION))
+	if err != nil {
+		return false, pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting stale login attempts: %s", err)
+	}
+
+	locked, err := s.recordLoginFailure(ctx, scopeEmail, normalizeEmail(email), s.db.config.LOGIN_MAX_ATTEMPTS)
+	if err != nil {
+		return false, err
+	}
+
+	if ip != "" {
+		if _, err := s.recordLoginFailure(ctx, scopeIP, ip, s.db.config.LOGIN_IP_MAX_ATTEMPTS); err != nil {
+			return false, err
+		}
+	}
+
+	return locked, nil
+}
+
+func (s *LoginAttemptRepository) ClearLoginFailures(ctx context.Context, email string) error {
+	err := s.queries.DeleteLoginAttempt(ctx, generated.DeleteLoginAttemptParams{
+		Scope:   scopeEmail,
+		Subject: normalizeEmail(email),
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error clearing login failures: %s", err)
+	}
+
+	return nil
+}
+
+func (s *LoginAttemptRepository) getLoginAttempt(
+	ctx context.Context,
+	scope string,
+	subject string,
+) (*generated.LoginAttempt, error) {
+	attempt, err := s.queries.GetLoginAttempt(ctx, generated.GetLoginAttemptParams{
+		Scope:   scope,
+		Subject: subject,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting login attempts: %s", err)
+	}
+
+	return &attempt, nil
+}
+
+// recordLoginFailure counts a failure of subject and locks it out once it reaches
+// maxAttempts, which never happens when it is 0. Failures further apart than the
+// lockout duration are not counted together.
+func (s *LoginAttemptRepository) recordLoginFailure(
+	ctx context.Context,
+	scope string,
+	subject string,
+	maxAttempts int,
+) (bool, error) {
+	lockout := s.db.config.LOGIN_LOCKOUT_DURATION
+
+	attempt, err := s.queries.RecordLoginFailure(ctx, generated.RecordLoginFailureParams{
+		Scope:       scope,
+		Subject:     subject,
+		ResetBefore: time.Now().Add(-lockout),
+	})
+	if err != nil {
+		return false, pkg.Errorf(pkg.INTERNAL_ERROR, "error recording login failure: %s", err)
+	}
+
+	if maxAttempts == 0 || int(attempt.Failures) < maxAttempts {
+		return false, nil
+	}
+
+	// only the failure that locks subject out reports it, so that it is audited once
+	locked, err := s.queries.LockLoginAttempt(ctx, generated.LockLoginAttemptParams{
+		Scope:       scope,
+		Subject:     subject,
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(lockout), Valid: true},
+	})
+	if err != nil {
+		return false, pkg.Errorf(pkg.INTERNAL_ERROR, "error locking login: %s", err)
+	}
+
+	return locked > 0, nil
+}
+
+// failureDelay is how long an email waits after its failures-th failed login in a row
+// before it can log in again, doubling with each failure up to the lockout duration.
+func (s *LoginAttemptRepository) failureDelay(failures int32) time.Duration {
+	delay := s.db.config.LOGIN_FAILURE_DELAY
+	if delay <= 0 || failures <= 0 {
+		return 0
+	}
+
+	for i := int32(1); i < failures && delay < s.db.config.LOGIN_LOCKOUT_DURATION; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.db.config.LOGIN_LOCKOUT_DURATION)
+}
+
+func errTooManyLoginAttempts() error {
+	return pkg.Errorf(pkg.RATE_LIMIT_ERROR, "too many failed login attempts, try again later")
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestLoginAttemptRepository() *LoginAttemptRepository {
+	store := NewStore(pkg.Config{
+		LOGIN_MAX_ATTEMPTS:     5,
+		LOGIN_IP_MAX_ATTEMPTS:  20,
+		LOGIN_LOCKOUT_DURATION: 15 * time.Minute,
+		LOGIN_FAILURE_DELAY:    time.Second,
+	})
+	store.conn = nil
+
+	return NewLoginAttemptService(store)
+}
+
+func TestLoginAttemptRepository_CheckLogin(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		email   *generated.LoginAttempt
+		ip      *generated.LoginAttempt
+		wantErr string
+	}{
+		{
+			name: "no failures",
+		},
+		{
+			name:  "delay waited out",
+			email: &generated.LoginAttempt{Failures: 3, LastFailureAt: now.Add(-5 * time.Second)},
+			ip:    &generated.LoginAttempt{Failures: 3, LastFailureAt: now.Add(-5 * time.Second)},
+		},
+		{
+			name:    "delay not waited out",
+			email:   &generated.LoginAttempt{Failures: 3, LastFailureAt: now.Add(-3 * time.Second)},
+			wantErr: pkg.RATE_LIMIT_ERROR,
+		},
+		{
+			name: "email locked",
+			email: &generated.LoginAttempt{
+				Failures:      5,
+				LastFailureAt: now.Add(-time.Hour),
+				LockedUntil:   pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			},
+			wantErr: pkg.RATE_LIMIT_ERROR,
+		},
+		{
+			name: "email lock expired",
+			email: &generated.LoginAttempt{
+				Failures:      5,
+				LastFailureAt: now.Add(-time.Minute),
+				LockedUntil:   pgtype.Timestamptz{Time: now.Add(-time.Second), Valid: true},
+			},
+		},
+		{
+			name: "ip locked",
+			ip: &generated.LoginAttempt{
+				Failures:      20,
+				LastFailureAt: now,
+				LockedUntil:   pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			},
+			wantErr: pkg.RATE_LIMIT_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTestLoginAttemptRepository()
+
+			ctrl := gomock.NewController(t)
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+
+			s.queries = mockQueries
+
+			expect := func(scope, subject string, attempt *generated.LoginAttempt) {
+				call := mockQueries.EXPECT().GetLoginAttempt(gomock.Any(), gomock.Eq(generated.GetLoginAttemptParams{
+					Scope:   scope,
+					Subject: subject,
+				})).MaxTimes(1)
+
+				if attempt == nil {
+					call.Return(generated.LoginAttempt{}, pgx.ErrNoRows)
+				} else {
+					call.Return(*attempt, nil)
+				}
+			}
+
+			// emails are tracked however they are written
+			expect(scopeEmail, "user@example.com", tc.email)
+			expect(scopeIP, "10.0.0.1", tc.ip)
+
+			err := s.CheckLogin(context.Background(), " User@Example.com", "10.0.0.1")
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, tc.wantErr, pkg.ErrorCode(err))
+			}
+		})
+	}
+
+	s := NewTestLoginAttemptRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	mockQueries.EXPECT().GetLoginAttempt(gomock.Any(), gomock.Any()).
+		Return(generated.LoginAttempt{}, errors.New("db error")).Times(1)
+
+	err := s.CheckLogin(context.Background(), "user@example.com", "")
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestLoginAttemptRepository_RecordLoginFailure(t *testing.T) {
+	s := NewTestLoginAttemptRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	// failures that are not counted anymore are removed, whatever their scope
+	mockQueries.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, lastFailureAt time.Time) error {
+			require.WithinDuration(t, time.Now().Add(-15*time.Minute), lastFailureAt, time.Second)
+
+			return nil
+		}).Times(1)
+
+	// below the threshold nothing is locked
+	mockQueries.EXPECT().RecordLoginFailure(gomock.Any(), gomock.AssignableToTypeOf(generated.RecordLoginFailureParams{})).
+		DoAndReturn(func(_ context.Context, arg generated.RecordLoginFailureParams) (generated.LoginAttempt, error) {
+			require.WithinDuration(t, time.Now().Add(-15*time.Minute), arg.ResetBefore, time.Second)
+
+			return generated.LoginAttempt{Scope: arg.Scope, Subject: arg.Subject, Failures: 4}, nil
+		}).Times(2)
+
+	locked, err := s.RecordLoginFailure(context.Background(), "user@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	// the failure reaching the threshold locks the email
+	mockQueries.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	gomock.InOrder(
+		mockQueries.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).
+			Return(generated.LoginAttempt{Scope: scopeEmail, Subject: "user@example.com", Failures: 5}, nil).Times(1),
+		mockQueries.EXPECT().LockLoginAttempt(gomock.Any(), gomock.AssignableToTypeOf(generated.LockLoginAttemptParams{})).
+			DoAndReturn(func(_ context.Context, arg generated.LockLoginAttemptParams) (int64, error) {
+				require.Equal(t, scopeEmail, arg.Scope)
+				require.Equal(t, "user@example.com", arg.Subject)
+				require.WithinDuration(t, time.Now().Add(15*time.Minute), arg.LockedUntil.Time, time.Second)
+
+				return 1, nil
+			}).Times(1),
+	)
+
+	locked, err = s.RecordLoginFailure(context.Background(), "user@example.com", "")
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	// failures racing past the threshold do not report the lockout again
+	mockQueries.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockQueries.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).
+		Return(generated.LoginAttempt{Failures: 6}, nil).Times(1)
+	mockQueries.EXPECT().LockLoginAttempt(gomock.Any(), gomock.Any()).
+		Return(int64(0), nil).Times(1)
+
+	locked, err = s.RecordLoginFailure(context.Background(), "user@example.com", "")
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	mockQueries.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockQueries.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).
+		Return(generated.LoginAttempt{}, errors.New("db error")).Times(1)
+
+	_, err = s.RecordLoginFailure(context.Background(), "user@example.com", "")
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+
+	mockQueries.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).Return(errors.New("db error")).Times(1)
+
+	_, err = s.RecordLoginFailure(context.Background(), "user@example.com", "")
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestLoginAttemptRepository_ClearLoginFailures(t *testing.T) {
+	s := NewTestLoginAttemptRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	mockQueries.EXPECT().DeleteLoginAttempt(gomock.Any(), gomock.Eq(generated.DeleteLoginAttemptParams{
+		Scope:   scopeEmail,
+		Subject: "user@example.com",
+	})).Return(nil).Times(1)
+
+	require.NoError(t, s.ClearLoginFailures(context.Background(), "User@example.com"))
+
+	mockQueries.EXPECT().DeleteLoginAttempt(gomock.Any(), gomock.Any()).
+		Return(errors.New("db error")).Times(1)
+
+	err := s.ClearLoginFailures(context.Background(), "user@example.com")
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestLoginAttemptRepository_failureDelay(t *testing.T) {
+	s := NewTestLoginAttemptRepository()
+
+	require.Equal(t, time.Duration(0), s.failureDelay(0))
+	require.Equal(t, time.Second, s.failureDelay(1))
+	require.Equal(t, 8*time.Second, s.failureDelay(4))
+	require.Equal(t, 15*time.Minute, s.failureDelay(30))
+}
@@ -0,0 +1,351 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ repository.MFARepository = (*MFARepository)(nil)
+
+const (
+	// recoveryCodeCount is how many recovery codes an enrollment issues.
+	recoveryCodeCount = 10
+
+	// maxMFAChallengeAttempts is how many codes a challenge is checked against before it
+	// is given up, and the user has to give their password again.
+	maxMFAChallengeAttempts = 5
+)
+
+type MFARepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewMFAService(db *Store) *MFARepository {
+	queries := generated.New(db.conn)
+
+	return &MFARepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *MFARepository) EnrollMFA(ctx context.Context, userID int64) (*repository.MFAEnrollment, error) {
+	secret, err := pkg.NewTOTPSecret()
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+	}
+
+	encryptedSecret, err := pkg.Encrypt(secret, []byte(s.db.config.ENCRYPTION_KEY))
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error encrypting totp secret: %s", err)
+	}
+
+	// an enabled secret is left as it is, and no row is returned
+	_, err = s.queries.EnrollUserMFA(ctx, generated.EnrollUserMFAParams{
+		UserID: userID,
+		Secret: encryptedSecret,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "two-factor authentication is already enabled")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error enrolling mfa: %s", err)
+	}
+
+	if err := s.queries.DeleteMFARecoveryCodes(ctx, userID); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting recovery codes: %s", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := pkg.NewRecoveryCode()
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+		}
+
+		err = s.queries.CreateMFARecoveryCode(ctx, generated.CreateMFARecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: pkg.HashRecoveryCode(code),
+		})
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating recovery code: %s", err)
+		}
+
+		codes[i] = code
+	}
+
+	return &repository.MFAEnrollment{
+		Secret:        secret,
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *MFARepository) ActivateMFA(ctx context.Context, userID int64, code string) (time.Time, error) {
+	mfa, err := s.getUserMFA(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if mfa == nil {
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "enroll in two-factor authentication first")
+	}
+
+	if mfa.EnabledAt.Valid {
+		return time.Time{}, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "two-factor authentication is already enabled")
+	}
+
+	secret, err := s.decryptSecret(mfa)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	step, ok := pkg.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return time.Time{}, errInvalidMFACode(pkg.INVALID_ERROR)
+	}
+
+	// the code activating the secret is spent, so that it can not also log in
+	enabled, err := s.queries.EnableUserMFA(ctx, generated.EnableUserMFAParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errInvalidMFACode(pkg.INVALID_ERROR)
+		}
+
+		return time.Time{}, pkg.Errorf(pkg.INTERNAL_ERROR, "error enabling mfa: %s", err)
+	}
+
+	return enabled.EnabledAt.Time, nil
+}
+
+func (s *MFARepository) MFAEnabled(ctx context.Context, userID int64) (bool, error) {
+	mfa, err := s.getUserMFA(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return mfa != nil && mfa.EnabledAt.Valid, nil
+}
+
+func (s *MFARepository) DisableMFA(ctx context.Context, userID int64, code string) error {
+	mfa, err := s.getUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if mfa == nil || !mfa.EnabledAt.Valid {
+		return pkg.Errorf(pkg.INVALID_ERROR, "two-factor authentication is not enabled")
+	}
+
+	ok, err := s.checkCode(ctx, mfa, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errInvalidMFACode(pkg.INVALID_ERROR)
+	}
+
+	if err := s.queries.DeleteUserMFA(ctx, userID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error disabling mfa: %s", err)
+	}
+
+	if err := s.queries.DeleteMFARecoveryCodes(ctx, userID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting recovery codes: %s", err)
+	}
+
+	if err := s.queries.DeleteUserMFAChallenges(ctx, userID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting mfa challenges: %s", err)
+	}
+
+	return nil
+}
+
+func (s *MFARepository) VerifyMFACode(ctx context.Context, userID int64, code string) error {
+	mfa, err := s.getUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if mfa == nil || !mfa.EnabledAt.Valid {
+		return pkg.Errorf(pkg.INVALID_ERROR, "two-factor authentication is not enabled")
+	}
+
+	ok, err := s.checkCode(ctx, mfa, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errInvalidMFACode(pkg.AUTHENTICATION_ERROR)
+	}
+
+	return nil
+}
+
+func (s *MFARepository) CreateMFAChallenge(ctx context.Context, userID int64, expiresAt time.Time) (string, error) {
+	// challenge tokens are as random as refresh tokens, and are stored the same way
+	token, err := pkg.NewRefreshToken()
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+	}
+
+	_, err = s.queries.CreateMFAChallenge(ctx, generated.CreateMFAChallengeParams{
+		UserID:    userID,
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "error creating mfa challenge: %s", err)
+	}
+
+	return token, nil
+}
+
+func (s *MFARepository) GetMFAChallenge(ctx context.Context, token string) (int64, error) {
+	challenge, err := s.queries.GetMFAChallenge(ctx, pkg.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errInvalidMFAChallenge()
+		}
+
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting mfa challenge: %s", err)
+	}
+
+	if challenge.Attempts >= maxMFAChallengeAttempts {
+		return 0, errInvalidMFAChallenge()
+	}
+
+	return challenge.UserID, nil
+}
+
+func (s *MFARepository) CompleteMFAChallenge(ctx context.Context, token string, code string) (int64, error) {
+	// the attempt is counted before the code is checked, so that concurrent guesses can
+	// not get past the limit
+	challenge, err := s.queries.CountMFAChallengeAttempt(ctx, pkg.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errInvalidMFAChallenge()
+		}
+
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "error counting mfa attempt: %s", err)
+	}
+
+	if challenge.Attempts > maxMFAChallengeAttempts {
+		return 0, errInvalidMFAChallenge()
+	}
+
+	mfa, err := s.getUserMFA(ctx, challenge.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	// two-factor authentication was turned off since the challenge was issued
+	if mfa == nil || !mfa.EnabledAt.Valid {
+		return 0, errInvalidMFAChallenge()
+	}
+
+	ok, err := s.checkCode(ctx, mfa, code)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, errInvalidMFACode(pkg.AUTHENTICATION_ERROR)
+	}
+
+	used, err := s.queries.UseMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "error using mfa challenge: %s", err)
+	}
+
+	if used == 0 {
+		return 0, errInvalidMFAChallenge()
+	}
+
+	return challenge.UserID, nil
+}
+
+// checkCode reports whether code is a TOTP code of the secret of mfa, never accepted
+// before, or a recovery code of its user yet to be used, and spends it.
+func (s *MFARepository) checkCode(ctx context.Context, mfa *generated.UserMfa, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, nil
+	}
+
+	// recovery codes are longer than TOTP codes, whichever way they are typed
+	if len(code) != pkg.TOTPDigits {
+		used, err := s.queries.UseMFARecoveryCode(ctx, generated.UseMFARecoveryCodeParams{
+			UserID:   mfa.UserID,
+			CodeHash: pkg.HashRecoveryCode(code),
+		})
+		if err != nil {
+			return false, pkg.Errorf(pkg.INTERNAL_ERROR, "error using recovery code: %s", err)
+		}
+
+		return used > 0, nil
+	}
+
+	secret, err := s.decryptSecret(mfa)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := pkg.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// only a step later than the last one accepted is, so that a code can not be replayed
+	used, err := s.queries.UseUserMFAStep(ctx, generated.UseUserMFAStepParams{
+		UserID:       mfa.UserID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return false, pkg.Errorf(pkg.INTERNAL_ERROR, "error using totp code: %s", err)
+	}
+
+	return used > 0, nil
+}
+
+func (s *MFARepository) getUserMFA(ctx context.Context, userID int64) (*generated.UserMfa, error) {
+	mfa, err := s.queries.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting mfa: %s", err)
+	}
+
+	return &mfa, nil
+}
+
+func (s *MFARepository) decryptSecret(mfa *generated.UserMfa) (string, error) {
+	secret, err := pkg.Decrypt(mfa.Secret, []byte(s.db.config.ENCRYPTION_KEY))
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "error decrypting totp secret: %s", err)
+	}
+
+	return secret, nil
+}
+
+func errInvalidMFAChallenge() error {
+	return pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid or expired mfa token")
+}
+
+func errInvalidMFACode(code string) error {
+	return pkg.Errorf(code, "invalid code")
+}
@@ -0,0 +1,451 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testEncryptionKey = "12345678901234567890123456789012"
+
+func NewTestMFARepository() *MFARepository {
+	store := NewStore(pkg.Config{ENCRYPTION_KEY: testEncryptionKey})
+	store.conn = nil
+
+	return NewMFAService(store)
+}
+
+// testUserMFA returns the enabled secret of user 7, encrypted as it is stored, and the
+// secret itself.
+func testUserMFA(t *testing.T, enabled bool) (generated.UserMfa, string) {
+	t.Helper()
+
+	secret, err := pkg.NewTOTPSecret()
+	require.NoError(t, err)
+
+	encrypted, err := pkg.Encrypt(secret, []byte(testEncryptionKey))
+	require.NoError(t, err)
+
+	return generated.UserMfa{
+		UserID:    7,
+		Secret:    encrypted,
+		EnabledAt: pgtype.Timestamptz{Time: TestTime, Valid: enabled},
+		CreatedAt: TestTime,
+	}, secret
+}
+
+func TestMFARepository_EnrollMFA(t *testing.T) {
+	s := NewTestMFARepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	var (
+		stored string
+		hashes []string
+	)
+
+	gomock.InOrder(
+		mockQueries.EXPECT().EnrollUserMFA(gomock.Any(), gomock.AssignableToTypeOf(generated.EnrollUserMFAParams{})).
+			DoAndReturn(func(_ context.Context, arg generated.EnrollUserMFAParams) (generated.UserMfa, error) {
+				stored = arg.Secret
+
+				return generated.UserMfa{UserID: arg.UserID, Secret: arg.Secret}, nil
+			}).Times(1),
+		mockQueries.EXPECT().DeleteMFARecoveryCodes(gomock.Any(), gomock.Eq(int64(7))).
+			Return(nil).Times(1),
+		mockQueries.EXPECT().CreateMFARecoveryCode(gomock.Any(), gomock.AssignableToTypeOf(generated.CreateMFARecoveryCodeParams{})).
+			DoAndReturn(func(_ context.Context, arg generated.CreateMFARecoveryCodeParams) error {
+				hashes = append(hashes, arg.CodeHash)
+
+				return nil
+			}).Times(recoveryCodeCount),
+	)
+
+	enrollment, err := s.EnrollMFA(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, enrollment.RecoveryCodes, recoveryCodeCount)
+
+	// the secret is stored encrypted, and the recovery codes hashed
+	require.NotEqual(t, enrollment.Secret, stored)
+
+	decrypted, err := pkg.Decrypt(stored, []byte(testEncryptionKey))
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, decrypted)
+
+	for i, code := range enrollment.RecoveryCodes {
+		require.Equal(t, pkg.HashRecoveryCode(code), hashes[i])
+	}
+
+	// an enabled secret is not replaced
+	mockQueries.EXPECT().EnrollUserMFA(gomock.Any(), gomock.Any()).
+		Return(generated.UserMfa{}, pgx.ErrNoRows).Times(1)
+
+	_, err = s.EnrollMFA(context.Background(), 7)
+	require.Error(t, err)
+	require.Equal(t, pkg.ALREADY_EXISTS_ERROR, pkg.ErrorCode(err))
+}
+
+func TestMFARepository_ActivateMFA(t *testing.T) {
+	pending, secret := testUserMFA(t, false)
+	enabled, _ := testUserMFA(t, true)
+
+	code, err := pkg.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		code       string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name: "success",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+					Return(pending, nil).Times(1)
+				mockQueries.EXPECT().EnableUserMFA(gomock.Any(), gomock.AssignableToTypeOf(generated.EnableUserMFAParams{})).
+					DoAndReturn(func(_ context.Context, arg generated.EnableUserMFAParams) (generated.UserMfa, error) {
+						// the code is spent along with the activation
+						require.Equal(t, int64(7), arg.UserID)
+						require.InDelta(t, pkg.TOTPStep(time.Now()), arg.LastUsedStep, 1)
+
+						return generated.UserMfa{EnabledAt: pgtype.Timestamptz{Time: TestTime, Valid: true}}, nil
+					}).Times(1)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "000000",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(pending, nil).Times(1)
+			},
+			wantCode: pkg.INVALID_ERROR,
+		},
+		{
+			name: "not enrolled",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(generated.UserMfa{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.INVALID_ERROR,
+		},
+		{
+			name: "already enabled",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(enabled, nil).Times(1)
+			},
+			wantCode: pkg.ALREADY_EXISTS_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTestMFARepository()
+
+			ctrl := gomock.NewController(t)
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+
+			s.queries = mockQueries
+
+			tc.buildStubs(mockQueries)
+
+			enabledAt, err := s.ActivateMFA(context.Background(), 7, tc.code)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, TestTime, enabledAt)
+		})
+	}
+}
+
+func TestMFARepository_DisableMFA(t *testing.T) {
+	s := NewTestMFARepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	mfa, secret := testUserMFA(t, true)
+
+	code, err := pkg.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	// a wrong code does not disable it
+	mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+		Return(mfa, nil).Times(1)
+
+	err = s.DisableMFA(context.Background(), 7, "000000")
+	require.Error(t, err)
+	require.Equal(t, pkg.INVALID_ERROR, pkg.ErrorCode(err))
+
+	gomock.InOrder(
+		mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+			Return(mfa, nil).Times(1),
+		mockQueries.EXPECT().UseUserMFAStep(gomock.Any(), gomock.AssignableToTypeOf(generated.UseUserMFAStepParams{})).
+			DoAndReturn(func(_ context.Context, arg generated.UseUserMFAStepParams) (int64, error) {
+				require.Equal(t, int64(7), arg.UserID)
+				require.InDelta(t, pkg.TOTPStep(time.Now()), arg.LastUsedStep, 1)
+
+				return 1, nil
+			}).Times(1),
+		mockQueries.EXPECT().DeleteUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+			Return(nil).Times(1),
+		mockQueries.EXPECT().DeleteMFARecoveryCodes(gomock.Any(), gomock.Eq(int64(7))).
+			Return(nil).Times(1),
+		mockQueries.EXPECT().DeleteUserMFAChallenges(gomock.Any(), gomock.Eq(int64(7))).
+			Return(nil).Times(1),
+	)
+
+	require.NoError(t, s.DisableMFA(context.Background(), 7, code))
+
+	mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+		Return(generated.UserMfa{}, pgx.ErrNoRows).Times(1)
+
+	err = s.DisableMFA(context.Background(), 7, code)
+	require.Error(t, err)
+	require.Equal(t, pkg.INVALID_ERROR, pkg.ErrorCode(err))
+}
+
+func TestMFARepository_VerifyMFACode(t *testing.T) {
+	s := NewTestMFARepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	mfa, secret := testUserMFA(t, true)
+
+	code, err := pkg.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+		Return(mfa, nil).Times(1)
+
+	err = s.VerifyMFACode(context.Background(), 7, "000000")
+	require.Error(t, err)
+	require.Equal(t, pkg.AUTHENTICATION_ERROR, pkg.ErrorCode(err))
+
+	gomock.InOrder(
+		mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+			Return(mfa, nil).Times(1),
+		mockQueries.EXPECT().UseUserMFAStep(gomock.Any(), gomock.AssignableToTypeOf(generated.UseUserMFAStepParams{})).
+			Return(int64(1), nil).Times(1),
+	)
+
+	require.NoError(t, s.VerifyMFACode(context.Background(), 7, code))
+
+	// the code was spent
+	gomock.InOrder(
+		mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+			Return(mfa, nil).Times(1),
+		mockQueries.EXPECT().UseUserMFAStep(gomock.Any(), gomock.Any()).
+			Return(int64(0), nil).Times(1),
+	)
+
+	err = s.VerifyMFACode(context.Background(), 7, code)
+	require.Equal(t, pkg.AUTHENTICATION_ERROR, pkg.ErrorCode(err))
+
+	// recovery codes are spent the same way
+	mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+		Return(mfa, nil).Times(1)
+	mockQueries.EXPECT().UseMFARecoveryCode(gomock.Any(), gomock.Eq(generated.UseMFARecoveryCodeParams{
+		UserID:   7,
+		CodeHash: pkg.HashRecoveryCode("abcde-fghij"),
+	})).Return(int64(1), nil).Times(1)
+
+	require.NoError(t, s.VerifyMFACode(context.Background(), 7, "abcde-fghij"))
+
+	notEnabled, _ := testUserMFA(t, false)
+
+	mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+		Return(notEnabled, nil).Times(1)
+
+	err = s.VerifyMFACode(context.Background(), 7, code)
+	require.Equal(t, pkg.INVALID_ERROR, pkg.ErrorCode(err))
+}
+
+func TestMFARepository_CreateMFAChallenge(t *testing.T) {
+	s := NewTestMFARepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	expiresAt := TestTime.Add(5 * time.Minute)
+
+	var params generated.CreateMFAChallengeParams
+
+	mockQueries.EXPECT().CreateMFAChallenge(gomock.Any(), gomock.AssignableToTypeOf(generated.CreateMFAChallengeParams{})).
+		DoAndReturn(func(_ context.Context, arg generated.CreateMFAChallengeParams) (generated.MfaChallenge, error) {
+			params = arg
+
+			return generated.MfaChallenge{ID: 1, UserID: arg.UserID}, nil
+		}).Times(1)
+
+	token, err := s.CreateMFAChallenge(context.Background(), 7, expiresAt)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// only the hash of the token is stored
+	require.Equal(t, generated.CreateMFAChallengeParams{
+		UserID:    7,
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	}, params)
+}
+
+func TestMFARepository_CompleteMFAChallenge(t *testing.T) {
+	mfa, secret := testUserMFA(t, true)
+
+	code, err := pkg.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	token := "challenge-token"
+	challenge := generated.MfaChallenge{ID: 3, UserID: 7, Attempts: 1}
+
+	tests := []struct {
+		name       string
+		code       string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name: "totp code",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Eq(pkg.HashRefreshToken(token))).
+					Return(challenge, nil).Times(1)
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(int64(7))).
+					Return(mfa, nil).Times(1)
+				mockQueries.EXPECT().UseUserMFAStep(gomock.Any(), gomock.Any()).
+					Return(int64(1), nil).Times(1)
+				mockQueries.EXPECT().UseMFAChallenge(gomock.Any(), gomock.Eq(int64(3))).
+					Return(int64(1), nil).Times(1)
+			},
+		},
+		{
+			name: "recovery code",
+			code: "ABCDE-FGHIJ",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(challenge, nil).Times(1)
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(mfa, nil).Times(1)
+				mockQueries.EXPECT().UseMFARecoveryCode(gomock.Any(), gomock.Eq(generated.UseMFARecoveryCodeParams{
+					UserID:   7,
+					CodeHash: pkg.HashRecoveryCode("abcdefghij"),
+				})).
+					Return(int64(1), nil).Times(1)
+				mockQueries.EXPECT().UseMFAChallenge(gomock.Any(), gomock.Any()).
+					Return(int64(1), nil).Times(1)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "000000",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(challenge, nil).Times(1)
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(mfa, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "replayed code",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(challenge, nil).Times(1)
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(mfa, nil).Times(1)
+				mockQueries.EXPECT().UseUserMFAStep(gomock.Any(), gomock.Any()).
+					Return(int64(0), nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "too many attempts",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(generated.MfaChallenge{ID: 3, UserID: 7, Attempts: maxMFAChallengeAttempts + 1}, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "used or expired token",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(generated.MfaChallenge{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "mfa disabled since",
+			code: code,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().CountMFAChallengeAttempt(gomock.Any(), gomock.Any()).
+					Return(challenge, nil).Times(1)
+				mockQueries.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).
+					Return(generated.UserMfa{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTestMFARepository()
+
+			ctrl := gomock.NewController(t)
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+
+			s.queries = mockQueries
+
+			tc.buildStubs(mockQueries)
+
+			userID, err := s.CompleteMFAChallenge(context.Background(), token, tc.code)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, int64(7), userID)
+		})
+	}
+}
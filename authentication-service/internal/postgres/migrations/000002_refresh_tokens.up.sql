@@ -0,0 +1,12 @@
+CREATE TABLE "refresh_tokens" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "family_id" uuid NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "used" boolean NOT NULL DEFAULT false,
+    "revoked" boolean NOT NULL DEFAULT false,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "refresh_tokens" ("family_id");
\ No newline at end of file
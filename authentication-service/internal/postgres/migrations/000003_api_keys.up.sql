@@ -0,0 +1,15 @@
+CREATE TABLE "api_keys" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "name" varchar NOT NULL,
+    "prefix" varchar NOT NULL,
+    "key_hash" varchar UNIQUE NOT NULL,
+    "scopes" varchar[] NOT NULL,
+    "allowed_ips" cidr[] NOT NULL DEFAULT '{}',
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "api_keys" ("user_id");
@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user' CHECK ("role" IN ('user', 'support', 'admin'));
+ALTER TABLE "users" ADD COLUMN "disabled_at" timestamptz;
+
+CREATE TABLE "audit_log" (
+    "id" bigserial PRIMARY KEY,
+    "actor_id" bigint NOT NULL REFERENCES "users" ("id"),
+    "action" varchar NOT NULL,
+    "target_user_id" bigint NOT NULL REFERENCES "users" ("id"),
+    "reason" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "audit_log" ("actor_id");
+CREATE INDEX ON "audit_log" ("target_user_id");
@@ -0,0 +1,4 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz;
+
+-- the users registered before emails were verified keep their access
+UPDATE "users" SET "email_verified_at" = "created_at";
@@ -0,0 +1,10 @@
+CREATE TABLE "password_reset_tokens" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "password_reset_tokens" ("user_id");
@@ -0,0 +1,10 @@
+CREATE TABLE "login_attempts" (
+    "scope" varchar NOT NULL CHECK ("scope" IN ('email', 'ip')),
+    "subject" varchar NOT NULL,
+    "failures" int NOT NULL DEFAULT 0,
+    "last_failure_at" timestamptz NOT NULL DEFAULT now(),
+    "locked_until" timestamptz,
+    PRIMARY KEY ("scope", "subject")
+);
+
+CREATE INDEX ON "login_attempts" ("last_failure_at");
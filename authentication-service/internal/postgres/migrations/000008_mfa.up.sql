@@ -0,0 +1,31 @@
+-- the TOTP secret of a user, encrypted with ENCRYPTION_KEY. It is pending until
+-- enabled_at is set, once the user proved they added it with a code of it.
+CREATE TABLE "user_mfa" (
+    "user_id" bigint PRIMARY KEY REFERENCES "users" ("id") ON DELETE CASCADE,
+    "secret" varchar NOT NULL,
+    "enabled_at" timestamptz,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "code_hash" varchar NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX ON "mfa_recovery_codes" ("user_id", "code_hash");
+
+CREATE TABLE "mfa_challenges" (
+    "id" bigserial PRIMARY KEY,
+    "user_id" bigint NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "attempts" int NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "mfa_challenges" ("user_id");
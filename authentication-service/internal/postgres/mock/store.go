@@ -12,8 +12,10 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	generated "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,6 +42,110 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// CountMFAChallengeAttempt mocks base method.
+func (m *MockQuerier) CountMFAChallengeAttempt(arg0 context.Context, arg1 string) (generated.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountMFAChallengeAttempt", arg0, arg1)
+	ret0, _ := ret[0].(generated.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountMFAChallengeAttempt indicates an expected call of CountMFAChallengeAttempt.
+func (mr *MockQuerierMockRecorder) CountMFAChallengeAttempt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountMFAChallengeAttempt", reflect.TypeOf((*MockQuerier)(nil).CountMFAChallengeAttempt), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockQuerier) CreateAPIKey(arg0 context.Context, arg1 generated.CreateAPIKeyParams) (generated.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(generated.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockQuerierMockRecorder) CreateAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockQuerier)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockQuerier) CreateAuditEvent(arg0 context.Context, arg1 generated.CreateAuditEventParams) (generated.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", arg0, arg1)
+	ret0, _ := ret[0].(generated.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockQuerierMockRecorder) CreateAuditEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockQuerier)(nil).CreateAuditEvent), arg0, arg1)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockQuerier) CreateMFAChallenge(arg0 context.Context, arg1 generated.CreateMFAChallengeParams) (generated.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", arg0, arg1)
+	ret0, _ := ret[0].(generated.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockQuerierMockRecorder) CreateMFAChallenge(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).CreateMFAChallenge), arg0, arg1)
+}
+
+// CreateMFARecoveryCode mocks base method.
+func (m *MockQuerier) CreateMFARecoveryCode(arg0 context.Context, arg1 generated.CreateMFARecoveryCodeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMFARecoveryCode indicates an expected call of CreateMFARecoveryCode.
+func (mr *MockQuerierMockRecorder) CreateMFARecoveryCode(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFARecoveryCode", reflect.TypeOf((*MockQuerier)(nil).CreateMFARecoveryCode), arg0, arg1)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockQuerier) CreatePasswordResetToken(arg0 context.Context, arg1 generated.CreatePasswordResetTokenParams) (generated.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", arg0, arg1)
+	ret0, _ := ret[0].(generated.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockQuerierMockRecorder) CreatePasswordResetToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockQuerier)(nil).CreatePasswordResetToken), arg0, arg1)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockQuerier) CreateRefreshToken(arg0 context.Context, arg1 generated.CreateRefreshTokenParams) (generated.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(generated.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockQuerierMockRecorder) CreateRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockQuerier)(nil).CreateRefreshToken), arg0, arg1)
+}
+
 // CreateUser mocks base method.
 func (m *MockQuerier) CreateUser(arg0 context.Context, arg1 generated.CreateUserParams) (generated.User, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +161,195 @@ func (mr *MockQuerierMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockQuerier)(nil).CreateUser), arg0, arg1)
 }
 
+// DeleteLoginAttempt mocks base method.
+func (m *MockQuerier) DeleteLoginAttempt(arg0 context.Context, arg1 generated.DeleteLoginAttemptParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginAttempt indicates an expected call of DeleteLoginAttempt.
+func (mr *MockQuerierMockRecorder) DeleteLoginAttempt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginAttempt", reflect.TypeOf((*MockQuerier)(nil).DeleteLoginAttempt), arg0, arg1)
+}
+
+// DeleteMFARecoveryCodes mocks base method.
+func (m *MockQuerier) DeleteMFARecoveryCodes(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFARecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMFARecoveryCodes indicates an expected call of DeleteMFARecoveryCodes.
+func (mr *MockQuerierMockRecorder) DeleteMFARecoveryCodes(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFARecoveryCodes", reflect.TypeOf((*MockQuerier)(nil).DeleteMFARecoveryCodes), arg0, arg1)
+}
+
+// DeleteStaleLoginAttempts mocks base method.
+func (m *MockQuerier) DeleteStaleLoginAttempts(arg0 context.Context, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleLoginAttempts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStaleLoginAttempts indicates an expected call of DeleteStaleLoginAttempts.
+func (mr *MockQuerierMockRecorder) DeleteStaleLoginAttempts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleLoginAttempts", reflect.TypeOf((*MockQuerier)(nil).DeleteStaleLoginAttempts), arg0, arg1)
+}
+
+// DeleteUserMFA mocks base method.
+func (m *MockQuerier) DeleteUserMFA(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockQuerierMockRecorder) DeleteUserMFA(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockQuerier)(nil).DeleteUserMFA), arg0, arg1)
+}
+
+// DeleteUserMFAChallenges mocks base method.
+func (m *MockQuerier) DeleteUserMFAChallenges(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFAChallenges", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFAChallenges indicates an expected call of DeleteUserMFAChallenges.
+func (mr *MockQuerierMockRecorder) DeleteUserMFAChallenges(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFAChallenges", reflect.TypeOf((*MockQuerier)(nil).DeleteUserMFAChallenges), arg0, arg1)
+}
+
+// DisableUser mocks base method.
+func (m *MockQuerier) DisableUser(arg0 context.Context, arg1 int64) (generated.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", arg0, arg1)
+	ret0, _ := ret[0].(generated.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockQuerierMockRecorder) DisableUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockQuerier)(nil).DisableUser), arg0, arg1)
+}
+
+// EnableUserMFA mocks base method.
+func (m *MockQuerier) EnableUserMFA(arg0 context.Context, arg1 generated.EnableUserMFAParams) (generated.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(generated.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableUserMFA indicates an expected call of EnableUserMFA.
+func (mr *MockQuerierMockRecorder) EnableUserMFA(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserMFA", reflect.TypeOf((*MockQuerier)(nil).EnableUserMFA), arg0, arg1)
+}
+
+// EnrollUserMFA mocks base method.
+func (m *MockQuerier) EnrollUserMFA(arg0 context.Context, arg1 generated.EnrollUserMFAParams) (generated.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(generated.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollUserMFA indicates an expected call of EnrollUserMFA.
+func (mr *MockQuerierMockRecorder) EnrollUserMFA(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollUserMFA", reflect.TypeOf((*MockQuerier)(nil).EnrollUserMFA), arg0, arg1)
+}
+
+// ExpireUserPasswordResetTokens mocks base method.
+func (m *MockQuerier) ExpireUserPasswordResetTokens(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireUserPasswordResetTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireUserPasswordResetTokens indicates an expected call of ExpireUserPasswordResetTokens.
+func (mr *MockQuerierMockRecorder) ExpireUserPasswordResetTokens(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireUserPasswordResetTokens", reflect.TypeOf((*MockQuerier)(nil).ExpireUserPasswordResetTokens), arg0, arg1)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockQuerier) GetAPIKeyByHash(arg0 context.Context, arg1 string) (generated.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", arg0, arg1)
+	ret0, _ := ret[0].(generated.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockQuerierMockRecorder) GetAPIKeyByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockQuerier)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
+// GetLoginAttempt mocks base method.
+func (m *MockQuerier) GetLoginAttempt(arg0 context.Context, arg1 generated.GetLoginAttemptParams) (generated.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(generated.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempt indicates an expected call of GetLoginAttempt.
+func (mr *MockQuerierMockRecorder) GetLoginAttempt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempt", reflect.TypeOf((*MockQuerier)(nil).GetLoginAttempt), arg0, arg1)
+}
+
+// GetMFAChallenge mocks base method.
+func (m *MockQuerier) GetMFAChallenge(arg0 context.Context, arg1 string) (generated.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFAChallenge", arg0, arg1)
+	ret0, _ := ret[0].(generated.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFAChallenge indicates an expected call of GetMFAChallenge.
+func (mr *MockQuerierMockRecorder) GetMFAChallenge(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).GetMFAChallenge), arg0, arg1)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockQuerier) GetRefreshToken(arg0 context.Context, arg1 string) (generated.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(generated.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockQuerierMockRecorder) GetRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockQuerier)(nil).GetRefreshToken), arg0, arg1)
+}
+
 // GetUser mocks base method.
 func (m *MockQuerier) GetUser(arg0 context.Context, arg1 int64) (generated.User, error) {
 	m.ctrl.T.Helper()
@@ -84,3 +379,225 @@ func (mr *MockQuerierMockRecorder) GetUserByEmail(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockQuerier)(nil).GetUserByEmail), arg0, arg1)
 }
+
+// GetUserMFA mocks base method.
+func (m *MockQuerier) GetUserMFA(arg0 context.Context, arg1 int64) (generated.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(generated.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMFA indicates an expected call of GetUserMFA.
+func (mr *MockQuerierMockRecorder) GetUserMFA(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMFA", reflect.TypeOf((*MockQuerier)(nil).GetUserMFA), arg0, arg1)
+}
+
+// ListUserAPIKeys mocks base method.
+func (m *MockQuerier) ListUserAPIKeys(arg0 context.Context, arg1 int64) ([]generated.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserAPIKeys", arg0, arg1)
+	ret0, _ := ret[0].([]generated.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserAPIKeys indicates an expected call of ListUserAPIKeys.
+func (mr *MockQuerierMockRecorder) ListUserAPIKeys(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserAPIKeys", reflect.TypeOf((*MockQuerier)(nil).ListUserAPIKeys), arg0, arg1)
+}
+
+// LockLoginAttempt mocks base method.
+func (m *MockQuerier) LockLoginAttempt(arg0 context.Context, arg1 generated.LockLoginAttemptParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockLoginAttempt indicates an expected call of LockLoginAttempt.
+func (mr *MockQuerierMockRecorder) LockLoginAttempt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLoginAttempt", reflect.TypeOf((*MockQuerier)(nil).LockLoginAttempt), arg0, arg1)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockQuerier) RecordLoginFailure(arg0 context.Context, arg1 generated.RecordLoginFailureParams) (generated.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(generated.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockQuerierMockRecorder) RecordLoginFailure(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockQuerier)(nil).RecordLoginFailure), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockQuerier) RevokeAPIKey(arg0 context.Context, arg1 generated.RevokeAPIKeyParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockQuerierMockRecorder) RevokeAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockQuerier)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockQuerier) RevokeRefreshTokenFamily(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockQuerierMockRecorder) RevokeRefreshTokenFamily(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockQuerier)(nil).RevokeRefreshTokenFamily), arg0, arg1)
+}
+
+// RevokeUserRefreshTokens mocks base method.
+func (m *MockQuerier) RevokeUserRefreshTokens(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserRefreshTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserRefreshTokens indicates an expected call of RevokeUserRefreshTokens.
+func (mr *MockQuerierMockRecorder) RevokeUserRefreshTokens(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserRefreshTokens", reflect.TypeOf((*MockQuerier)(nil).RevokeUserRefreshTokens), arg0, arg1)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockQuerier) TouchAPIKey(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockQuerierMockRecorder) TouchAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockQuerier)(nil).TouchAPIKey), arg0, arg1)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockQuerier) UpdateUserPassword(arg0 context.Context, arg1 generated.UpdateUserPasswordParams) (generated.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", arg0, arg1)
+	ret0, _ := ret[0].(generated.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockQuerierMockRecorder) UpdateUserPassword(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockQuerier)(nil).UpdateUserPassword), arg0, arg1)
+}
+
+// UseMFAChallenge mocks base method.
+func (m *MockQuerier) UseMFAChallenge(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFAChallenge", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseMFAChallenge indicates an expected call of UseMFAChallenge.
+func (mr *MockQuerierMockRecorder) UseMFAChallenge(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).UseMFAChallenge), arg0, arg1)
+}
+
+// UseMFARecoveryCode mocks base method.
+func (m *MockQuerier) UseMFARecoveryCode(arg0 context.Context, arg1 generated.UseMFARecoveryCodeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseMFARecoveryCode indicates an expected call of UseMFARecoveryCode.
+func (mr *MockQuerierMockRecorder) UseMFARecoveryCode(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFARecoveryCode", reflect.TypeOf((*MockQuerier)(nil).UseMFARecoveryCode), arg0, arg1)
+}
+
+// UsePasswordResetToken mocks base method.
+func (m *MockQuerier) UsePasswordResetToken(arg0 context.Context, arg1 string) (generated.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsePasswordResetToken", arg0, arg1)
+	ret0, _ := ret[0].(generated.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsePasswordResetToken indicates an expected call of UsePasswordResetToken.
+func (mr *MockQuerierMockRecorder) UsePasswordResetToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePasswordResetToken", reflect.TypeOf((*MockQuerier)(nil).UsePasswordResetToken), arg0, arg1)
+}
+
+// UseRefreshToken mocks base method.
+func (m *MockQuerier) UseRefreshToken(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRefreshToken indicates an expected call of UseRefreshToken.
+func (mr *MockQuerierMockRecorder) UseRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRefreshToken", reflect.TypeOf((*MockQuerier)(nil).UseRefreshToken), arg0, arg1)
+}
+
+// UseUserMFAStep mocks base method.
+func (m *MockQuerier) UseUserMFAStep(arg0 context.Context, arg1 generated.UseUserMFAStepParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserMFAStep", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseUserMFAStep indicates an expected call of UseUserMFAStep.
+func (mr *MockQuerierMockRecorder) UseUserMFAStep(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserMFAStep", reflect.TypeOf((*MockQuerier)(nil).UseUserMFAStep), arg0, arg1)
+}
+
+// VerifyUserEmail mocks base method.
+func (m *MockQuerier) VerifyUserEmail(arg0 context.Context, arg1 generated.VerifyUserEmailParams) (generated.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyUserEmail", arg0, arg1)
+	ret0, _ := ret[0].(generated.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyUserEmail indicates an expected call of VerifyUserEmail.
+func (mr *MockQuerierMockRecorder) VerifyUserEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyUserEmail", reflect.TypeOf((*MockQuerier)(nil).VerifyUserEmail), arg0, arg1)
+}
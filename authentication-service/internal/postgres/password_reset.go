@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ repository.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+type PasswordResetRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewPasswordResetService(db *Store) *PasswordResetRepository {
+	queries := generated.New(db.conn)
+
+	return &PasswordResetRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *PasswordResetRepository) CreatePasswordResetToken(
+	ctx context.Context,
+	userID int64,
+	expiresAt time.Time,
+) (string, error) {
+	// reset tokens are as random as refresh tokens, and are stored the same way
+	token, err := pkg.NewRefreshToken()
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+	}
+
+	if err := s.queries.ExpireUserPasswordResetTokens(ctx, userID); err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "error expiring reset tokens: %s", err)
+	}
+
+	_, err = s.queries.CreatePasswordResetToken(ctx, generated.CreatePasswordResetTokenParams{
+		UserID:    userID,
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "error creating reset token: %s", err)
+	}
+
+	return token, nil
+}
+
+func (s *PasswordResetRepository) ResetPassword(
+	ctx context.Context,
+	token string,
+	password string,
+) (*repository.User, error) {
+	if password == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "password is required")
+	}
+
+	hashPassword, err := pkg.GenerateHashPassword(password, s.db.config.HASH_COST)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error hashing password: %s", err)
+	}
+
+	// using the token only succeeds once, so that two requests racing with the same
+	// token can not both reset the password
+	resetToken, err := s.queries.UsePasswordResetToken(ctx, pkg.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid or expired reset token")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error using reset token: %s", err)
+	}
+
+	user, err := s.queries.UpdateUserPassword(ctx, generated.UpdateUserPasswordParams{
+		ID:       resetToken.UserID,
+		Password: hashPassword,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found: %s", err)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating password: %s", err)
+	}
+
+	return userFromRow(user), nil
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestPasswordResetRepository() *PasswordResetRepository {
+	store := NewStore(pkg.Config{HASH_COST: 4})
+	store.conn = nil
+
+	return NewPasswordResetService(store)
+}
+
+func TestPasswordResetRepository_CreatePasswordResetToken(t *testing.T) {
+	s := NewTestPasswordResetRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	expiresAt := TestTime.Add(30 * time.Minute)
+
+	var params generated.CreatePasswordResetTokenParams
+
+	gomock.InOrder(
+		mockQueries.EXPECT().ExpireUserPasswordResetTokens(gomock.Any(), gomock.Eq(int64(7))).
+			Return(nil).Times(1),
+		mockQueries.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.AssignableToTypeOf(generated.CreatePasswordResetTokenParams{})).
+			DoAndReturn(func(_ context.Context, arg generated.CreatePasswordResetTokenParams) (generated.PasswordResetToken, error) {
+				params = arg
+
+				return generated.PasswordResetToken{ID: 1, UserID: arg.UserID, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+			}).Times(1),
+	)
+
+	token, err := s.CreatePasswordResetToken(context.Background(), 7, expiresAt)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// only the hash of the token is stored
+	require.Equal(t, pkg.HashRefreshToken(token), params.TokenHash)
+	require.Equal(t, int64(7), params.UserID)
+	require.Equal(t, expiresAt, params.ExpiresAt)
+
+	mockQueries.EXPECT().ExpireUserPasswordResetTokens(gomock.Any(), gomock.Any()).
+		Return(nil).Times(1)
+	mockQueries.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+		Return(generated.PasswordResetToken{}, errors.New("db error")).Times(1)
+
+	_, err = s.CreatePasswordResetToken(context.Background(), 7, expiresAt)
+	require.Error(t, err)
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestPasswordResetRepository_ResetPassword(t *testing.T) {
+	s := NewTestPasswordResetRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	token := "reset-token"
+
+	tests := []struct {
+		name       string
+		password   string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name:     "success",
+			password: "new password",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().UsePasswordResetToken(gomock.Any(), gomock.Eq(pkg.HashRefreshToken(token))).
+					Return(generated.PasswordResetToken{ID: 1, UserID: 7}, nil).Times(1)
+				mockQueries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.AssignableToTypeOf(generated.UpdateUserPasswordParams{})).
+					DoAndReturn(func(_ context.Context, arg generated.UpdateUserPasswordParams) (generated.User, error) {
+						require.Equal(t, int64(7), arg.ID)
+						require.NoError(t, pkg.ComparePasswordAndHash(arg.Password, "new password"))
+
+						return generated.User{ID: arg.ID, Email: "jane@gmail.com", Password: arg.Password}, nil
+					}).Times(1)
+			},
+		},
+		{
+			name:       "no password",
+			buildStubs: func(*mockdb.MockQuerier) {},
+			wantCode:   pkg.INVALID_ERROR,
+		},
+		{
+			name:     "used or expired token",
+			password: "new password",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().UsePasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(generated.PasswordResetToken{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.INVALID_ERROR,
+		},
+		{
+			name:     "db error",
+			password: "new password",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().UsePasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(generated.PasswordResetToken{ID: 1, UserID: 7}, nil).Times(1)
+				mockQueries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Return(generated.User{}, errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockQueries)
+
+			got, err := s.ResetPassword(context.Background(), token, tc.password)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+				require.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, int64(7), got.ID)
+			require.Equal(t, "jane@gmail.com", got.Email)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    user_id, name, prefix, key_hash, scopes, allowed_ips, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetAPIKeyByHash :one
+SELECT api_keys.* FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1 AND users.disabled_at IS NULL
+LIMIT 1;
+
+-- name: ListUserAPIKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1 AND revoked = false
+ORDER BY id;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked = true
+WHERE id = $1 AND user_id = $2 AND revoked = false;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
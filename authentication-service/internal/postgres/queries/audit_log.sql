@@ -0,0 +1,7 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_log (
+    actor_id, action, target_user_id, reason
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
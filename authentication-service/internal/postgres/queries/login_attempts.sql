@@ -0,0 +1,34 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE scope = $1 AND subject = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (
+    scope, subject, failures, last_failure_at
+) VALUES (
+    sqlc.arg(scope), sqlc.arg(subject), 1, now()
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET failures = CASE
+        WHEN login_attempts.last_failure_at < sqlc.arg(reset_before) OR login_attempts.locked_until <= now() THEN 1
+        ELSE login_attempts.failures + 1
+    END,
+    last_failure_at = now(),
+    locked_until = CASE
+        WHEN login_attempts.locked_until <= now() THEN NULL
+        ELSE login_attempts.locked_until
+    END
+RETURNING *;
+
+-- name: LockLoginAttempt :execrows
+UPDATE login_attempts
+SET locked_until = $3
+WHERE scope = $1 AND subject = $2 AND locked_until IS NULL;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE scope = $1 AND subject = $2;
+
+-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until <= now());
@@ -0,0 +1,75 @@
+-- name: EnrollUserMFA :one
+INSERT INTO user_mfa (
+    user_id, secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE user_mfa.enabled_at IS NULL
+RETURNING *;
+
+-- name: GetUserMFA :one
+SELECT * FROM user_mfa
+WHERE user_id = $1;
+
+-- name: EnableUserMFA :one
+UPDATE user_mfa
+SET enabled_at = now(),
+    last_used_step = $2
+WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $2
+RETURNING *;
+
+-- name: UseUserMFAStep :execrows
+UPDATE user_mfa
+SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteUserMFA :exec
+DELETE FROM user_mfa
+WHERE user_id = $1;
+
+-- name: CreateMFARecoveryCode :exec
+INSERT INTO mfa_recovery_codes (
+    user_id, code_hash
+) VALUES (
+    $1, $2
+);
+
+-- name: UseMFARecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+    user_id, token_hash, expires_at
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetMFAChallenge :one
+SELECT * FROM mfa_challenges
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now();
+
+-- name: CountMFAChallengeAttempt :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- name: UseMFAChallenge :execrows
+UPDATE mfa_challenges
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: DeleteUserMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE user_id = $1;
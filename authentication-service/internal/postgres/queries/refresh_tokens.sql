@@ -0,0 +1,27 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+    user_id, family_id, token_hash, expires_at
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1
+LIMIT 1;
+
+-- name: UseRefreshToken :execrows
+UPDATE refresh_tokens
+SET used = true
+WHERE id = $1 AND used = false AND revoked = false;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked = true
+WHERE family_id = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked = true
+WHERE user_id = $1 AND revoked = false;
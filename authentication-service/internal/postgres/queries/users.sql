@@ -14,4 +14,22 @@ LIMIT 1;
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1 AND email = $2
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET password = $2
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ repository.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+
+type RefreshTokenRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewRefreshTokenService(db *Store) *RefreshTokenRepository {
+	queries := generated.New(db.conn)
+
+	return &RefreshTokenRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (s *RefreshTokenRepository) CreateRefreshToken(
+	ctx context.Context,
+	userID int64,
+	expiresAt time.Time,
+) (string, *repository.RefreshToken, error) {
+	familyID, err := uuid.NewRandom()
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error generating token family: %s", err)
+	}
+
+	return s.createRefreshToken(ctx, userID, familyID, expiresAt)
+}
+
+func (s *RefreshTokenRepository) RotateRefreshToken(
+	ctx context.Context,
+	token string,
+) (string, *repository.RefreshToken, error) {
+	refreshToken, err := s.queries.GetRefreshToken(ctx, pkg.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid refresh token")
+		}
+
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting refresh token: %s", err)
+	}
+
+	if refreshToken.Revoked {
+		return "", nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "refresh token has been revoked")
+	}
+
+	if !refreshToken.ExpiresAt.After(time.Now()) {
+		return "", nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "refresh token has expired")
+	}
+
+	if refreshToken.Used {
+		return "", nil, s.revokeFamily(ctx, refreshToken.FamilyID)
+	}
+
+	// marking the token used only succeeds once, so that two requests racing with the
+	// same token can not both rotate it
+	rows, err := s.queries.UseRefreshToken(ctx, refreshToken.ID)
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error using refresh token: %s", err)
+	}
+
+	if rows == 0 {
+		return "", nil, s.revokeFamily(ctx, refreshToken.FamilyID)
+	}
+
+	return s.createRefreshToken(ctx, refreshToken.UserID, refreshToken.FamilyID, refreshToken.ExpiresAt)
+}
+
+func (s *RefreshTokenRepository) RevokeRefreshTokens(ctx context.Context, userID int64, token string) error {
+	if token == "" {
+		if err := s.queries.RevokeUserRefreshTokens(ctx, userID); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error revoking refresh tokens: %s", err)
+		}
+
+		return nil
+	}
+
+	refreshToken, err := s.queries.GetRefreshToken(ctx, pkg.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting refresh token: %s", err)
+	}
+
+	if refreshToken.UserID != userID || refreshToken.Revoked {
+		return nil
+	}
+
+	if err := s.queries.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error revoking refresh tokens: %s", err)
+	}
+
+	return nil
+}
+
+// revokeFamily revokes every token of a family once one of them is reused: either the
+// user or someone who stole the token used it first, and both have to log in again.
+func (s *RefreshTokenRepository) revokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := s.queries.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error revoking refresh tokens: %s", err)
+	}
+
+	return pkg.Errorf(pkg.AUTHENTICATION_ERROR, "refresh token reuse detected, log in again")
+}
+
+func (s *RefreshTokenRepository) createRefreshToken(
+	ctx context.Context,
+	userID int64,
+	familyID uuid.UUID,
+	expiresAt time.Time,
+) (string, *repository.RefreshToken, error) {
+	token, err := pkg.NewRefreshToken()
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "%s", err)
+	}
+
+	refreshToken, err := s.queries.CreateRefreshToken(ctx, generated.CreateRefreshTokenParams{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating refresh token: %s", err)
+	}
+
+	return token, &repository.RefreshToken{
+		ID:        refreshToken.ID,
+		UserID:    refreshToken.UserID,
+		FamilyID:  refreshToken.FamilyID,
+		ExpiresAt: refreshToken.ExpiresAt,
+		CreatedAt: refreshToken.CreatedAt,
+	}, nil
+}
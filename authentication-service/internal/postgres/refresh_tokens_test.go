@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestRefreshTokenRepository() *RefreshTokenRepository {
+	store := NewStore(pkg.Config{})
+	store.conn = nil
+
+	return NewRefreshTokenService(store)
+}
+
+func TestRefreshTokenRepository_CreateRefreshToken(t *testing.T) {
+	s := NewTestRefreshTokenRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	expiresAt := TestTime.Add(time.Hour)
+
+	var params generated.CreateRefreshTokenParams
+
+	mockQueries.EXPECT().CreateRefreshToken(gomock.Any(), gomock.AssignableToTypeOf(generated.CreateRefreshTokenParams{})).
+		DoAndReturn(func(_ context.Context, arg generated.CreateRefreshTokenParams) (generated.RefreshToken, error) {
+			params = arg
+
+			return generated.RefreshToken{
+				ID:        1,
+				UserID:    arg.UserID,
+				FamilyID:  arg.FamilyID,
+				TokenHash: arg.TokenHash,
+				ExpiresAt: arg.ExpiresAt,
+				CreatedAt: TestTime,
+			}, nil
+		}).Times(1)
+
+	token, got, err := s.CreateRefreshToken(context.Background(), 7, expiresAt)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// only the hash of the token is stored
+	require.Equal(t, pkg.HashRefreshToken(token), params.TokenHash)
+	require.NotEqual(t, uuid.Nil, params.FamilyID)
+
+	require.Equal(t, int64(7), got.UserID)
+	require.Equal(t, params.FamilyID, got.FamilyID)
+	require.Equal(t, expiresAt, got.ExpiresAt)
+
+	mockQueries.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+		Return(generated.RefreshToken{}, errors.New("db error")).Times(1)
+
+	_, got, err = s.CreateRefreshToken(context.Background(), 7, expiresAt)
+	require.Error(t, err)
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+	require.Nil(t, got)
+}
+
+func TestRefreshTokenRepository_RotateRefreshToken(t *testing.T) {
+	s := NewTestRefreshTokenRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	token := "refresh-token"
+
+	stored := generated.RefreshToken{
+		ID:        1,
+		UserID:    7,
+		FamilyID:  uuid.New(),
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: TestTime,
+	}
+
+	tests := []struct {
+		name       string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Eq(stored.TokenHash)).
+					Return(stored, nil).Times(1)
+				mockQueries.EXPECT().UseRefreshToken(gomock.Any(), gomock.Eq(stored.ID)).
+					Return(int64(1), nil).Times(1)
+				mockQueries.EXPECT().CreateRefreshToken(gomock.Any(), gomock.AssignableToTypeOf(generated.CreateRefreshTokenParams{})).
+					DoAndReturn(func(_ context.Context, arg generated.CreateRefreshTokenParams) (generated.RefreshToken, error) {
+						// the new token stays in the family, and ends with it
+						require.Equal(t, stored.UserID, arg.UserID)
+						require.Equal(t, stored.FamilyID, arg.FamilyID)
+						require.Equal(t, stored.ExpiresAt, arg.ExpiresAt)
+						require.NotEqual(t, stored.TokenHash, arg.TokenHash)
+
+						return generated.RefreshToken{ID: 2, UserID: arg.UserID, FamilyID: arg.FamilyID, ExpiresAt: arg.ExpiresAt}, nil
+					}).Times(1)
+			},
+		},
+		{
+			name: "unknown token",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(generated.RefreshToken{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "revoked token",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				revoked := stored
+				revoked.Revoked = true
+
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(revoked, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "expired token",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(expired, nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "reused token",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				used := stored
+				used.Used = true
+
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(used, nil).Times(1)
+				mockQueries.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), gomock.Eq(stored.FamilyID)).
+					Return(nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "token used concurrently",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(stored, nil).Times(1)
+				mockQueries.EXPECT().UseRefreshToken(gomock.Any(), gomock.Eq(stored.ID)).
+					Return(int64(0), nil).Times(1)
+				mockQueries.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), gomock.Eq(stored.FamilyID)).
+					Return(nil).Times(1)
+			},
+			wantCode: pkg.AUTHENTICATION_ERROR,
+		},
+		{
+			name: "db error",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(generated.RefreshToken{}, errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockQueries)
+
+			newToken, got, err := s.RotateRefreshToken(context.Background(), token)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+				require.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEqual(t, token, newToken)
+			require.Equal(t, stored.FamilyID, got.FamilyID)
+		})
+	}
+}
+
+func TestRefreshTokenRepository_RevokeRefreshTokens(t *testing.T) {
+	s := NewTestRefreshTokenRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	token := "refresh-token"
+
+	stored := generated.RefreshToken{
+		ID:        1,
+		UserID:    7,
+		FamilyID:  uuid.New(),
+		TokenHash: pkg.HashRefreshToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: TestTime,
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		buildStubs func(*mockdb.MockQuerier)
+		wantCode   string
+	}{
+		{
+			name:  "family of the token",
+			token: token,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Eq(stored.TokenHash)).
+					Return(stored, nil).Times(1)
+				mockQueries.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), gomock.Eq(stored.FamilyID)).
+					Return(nil).Times(1)
+			},
+		},
+		{
+			name: "all tokens of the user",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().RevokeUserRefreshTokens(gomock.Any(), gomock.Eq(stored.UserID)).
+					Return(nil).Times(1)
+			},
+		},
+		{
+			name:  "unknown token",
+			token: token,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(generated.RefreshToken{}, pgx.ErrNoRows).Times(1)
+			},
+		},
+		{
+			name:  "token of another user",
+			token: token,
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				other := stored
+				other.UserID = 8
+
+				mockQueries.EXPECT().GetRefreshToken(gomock.Any(), gomock.Any()).
+					Return(other, nil).Times(1)
+			},
+		},
+		{
+			name: "db error",
+			buildStubs: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().RevokeUserRefreshTokens(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockQueries)
+
+			err := s.RevokeRefreshTokens(context.Background(), stored.UserID, tc.token)
+			if tc.wantCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
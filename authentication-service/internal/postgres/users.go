@@ -2,11 +2,12 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/postgres/generated"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+	"github.com/jackc/pgx/v5"
 	"github.com/lib/pq"
 )
 
@@ -67,8 +68,10 @@ func (s *UserRepository) CreateUser(ctx context.Context, u repository.User) (*re
 	}
 
 	return &repository.User{
+		ID:        user.ID,
 		FullName:  user.FullName,
 		Email:     user.Email,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 	}, nil
 }
@@ -76,45 +79,76 @@ func (s *UserRepository) CreateUser(ctx context.Context, u repository.User) (*re
 func (s *UserRepository) GetUser(ctx context.Context, email string) (*repository.User, error) {
 	user, err := s.queries.GetUserByEmail(ctx, email)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found: %s", err)
 		}
 
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting user: %s", err)
 	}
 
-	return &repository.User{
-		ID:              user.ID,
-		FullName:        user.FullName,
-		Email:           user.Email,
-		Password:        user.Password,
-		PaydUsername:    user.PaydUsername,
-		PaydAccountID:   user.PaydAccountID,
-		PaydUsernameKey: user.PaydUsernameKey,
-		PaydPasswordKey: user.PaydPasswordKey,
-		CreatedAt:       user.CreatedAt,
-	}, nil
+	return userFromRow(user), nil
 }
 
 func (s *UserRepository) GetUserByID(ctx context.Context, id int64) (*repository.User, error) {
 	user, err := s.queries.GetUser(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found: %s", err)
 		}
 
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting user: %v", err)
 	}
 
-	return &repository.User{
-		ID:              user.ID,
-		FullName:        user.FullName,
-		Email:           user.Email,
-		Password:        user.Password,
-		PaydUsername:    user.PaydUsername,
-		PaydAccountID:   user.PaydAccountID,
-		PaydUsernameKey: user.PaydUsernameKey,
-		PaydPasswordKey: user.PaydPasswordKey,
-		CreatedAt:       user.CreatedAt,
-	}, nil
+	return userFromRow(user), nil
+}
+
+func (s *UserRepository) DisableUser(ctx context.Context, id int64) (*repository.User, error) {
+	user, err := s.queries.DisableUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found: %s", err)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error disabling user: %v", err)
+	}
+
+	return userFromRow(user), nil
+}
+
+func (s *UserRepository) VerifyEmail(ctx context.Context, id int64, email string) (*repository.User, error) {
+	user, err := s.queries.VerifyUserEmail(ctx, generated.VerifyUserEmailParams{ID: id, Email: email})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user not found: %s", err)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error verifying email: %v", err)
+	}
+
+	return userFromRow(user), nil
+}
+
+func userFromRow(row generated.User) *repository.User {
+	user := &repository.User{
+		ID:              row.ID,
+		FullName:        row.FullName,
+		Email:           row.Email,
+		Password:        row.Password,
+		PaydUsername:    row.PaydUsername,
+		PaydAccountID:   row.PaydAccountID,
+		PaydUsernameKey: row.PaydUsernameKey,
+		PaydPasswordKey: row.PaydPasswordKey,
+		Role:            row.Role,
+		CreatedAt:       row.CreatedAt,
+	}
+
+	if row.DisabledAt.Valid {
+		user.DisabledAt = &row.DisabledAt.Time
+	}
+
+	if row.EmailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &row.EmailVerifiedAt.Time
+	}
+
+	return user
 }
@@ -2,7 +2,6 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"math/rand/v2"
 	"testing"
@@ -13,6 +12,8 @@ import (
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -172,6 +173,7 @@ func TestUserRepository_GetUser(t *testing.T) {
 		name       string
 		buildStubs func(*mockdb.MockQuerier, repository.User)
 		wantErr    bool
+		wantCode   string
 	}{
 		{
 			name: "success",
@@ -187,9 +189,10 @@ func TestUserRepository_GetUser(t *testing.T) {
 			user: randomUser(),
 			buildStubs: func(mockQueries *mockdb.MockQuerier, req repository.User) {
 				mockQueries.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(req.Email)).
-					Return(generated.User{}, sql.ErrNoRows).Times(1)
+					Return(generated.User{}, pgx.ErrNoRows).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.NOT_FOUND_ERROR,
 		},
 		{
 			name: "db error",
@@ -198,7 +201,8 @@ func TestUserRepository_GetUser(t *testing.T) {
 				mockQueries.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(req.Email)).
 					Return(generated.User{}, errors.New("db error")).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.INTERNAL_ERROR,
 		},
 	}
 
@@ -214,6 +218,7 @@ func TestUserRepository_GetUser(t *testing.T) {
 				require.Equal(t, got, &tc.user)
 			} else {
 				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
 				require.Nil(t, got)
 				require.Equal(t, got, (*repository.User)(nil))
 			}
@@ -235,6 +240,7 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 		user       repository.User
 		buildStubs func(*mockdb.MockQuerier, repository.User)
 		wantErr    bool
+		wantCode   string
 	}{
 		{
 			name: "success",
@@ -250,9 +256,10 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 			user: randomUser(),
 			buildStubs: func(mockQueries *mockdb.MockQuerier, req repository.User) {
 				mockQueries.EXPECT().GetUser(gomock.Any(), gomock.Eq(req.ID)).
-					Return(generated.User{}, sql.ErrNoRows).Times(1)
+					Return(generated.User{}, pgx.ErrNoRows).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.NOT_FOUND_ERROR,
 		},
 		{
 			name: "db error",
@@ -261,7 +268,8 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 				mockQueries.EXPECT().GetUser(gomock.Any(), gomock.Eq(req.ID)).
 					Return(generated.User{}, errors.New("db error")).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.INTERNAL_ERROR,
 		},
 	}
 
@@ -277,6 +285,7 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 				require.Equal(t, got, &tc.user)
 			} else {
 				require.Error(t, err)
+				require.Equal(t, tc.wantCode, pkg.ErrorCode(err))
 				require.Nil(t, got)
 				require.Equal(t, got, (*repository.User)(nil))
 			}
@@ -284,6 +293,71 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 	}
 }
 
+func TestUserRepository_DisableUser(t *testing.T) {
+	s := NewTestUserRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	user := randomUser()
+	disabledAt := TestTime.Add(time.Hour)
+	user.DisabledAt = &disabledAt
+
+	mockQueries.EXPECT().DisableUser(gomock.Any(), gomock.Eq(user.ID)).
+		Return(repositoryUserToGenerated(user), nil).Times(1)
+
+	got, err := s.DisableUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, &user, got)
+	require.True(t, got.Disabled())
+
+	mockQueries.EXPECT().DisableUser(gomock.Any(), gomock.Eq(user.ID)).
+		Return(generated.User{}, pgx.ErrNoRows).Times(1)
+
+	_, err = s.DisableUser(context.Background(), user.ID)
+	require.Equal(t, pkg.NOT_FOUND_ERROR, pkg.ErrorCode(err))
+
+	mockQueries.EXPECT().DisableUser(gomock.Any(), gomock.Eq(user.ID)).
+		Return(generated.User{}, errors.New("db error")).Times(1)
+
+	_, err = s.DisableUser(context.Background(), user.ID)
+	require.Equal(t, pkg.INTERNAL_ERROR, pkg.ErrorCode(err))
+}
+
+func TestUserRepository_VerifyEmail(t *testing.T) {
+	s := NewTestUserRepository()
+
+	ctrl := gomock.NewController(t)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	s.queries = mockQueries
+
+	user := randomUser()
+	verifiedAt := TestTime.Add(time.Hour)
+	user.EmailVerifiedAt = &verifiedAt
+
+	params := generated.VerifyUserEmailParams{ID: user.ID, Email: user.Email}
+
+	mockQueries.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Eq(params)).
+		Return(repositoryUserToGenerated(user), nil).Times(1)
+
+	got, err := s.VerifyEmail(context.Background(), user.ID, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, &user, got)
+	require.True(t, got.EmailVerified())
+
+	// the email of the user is no longer the one the token was sent to
+	mockQueries.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Eq(params)).
+		Return(generated.User{}, pgx.ErrNoRows).Times(1)
+
+	_, err = s.VerifyEmail(context.Background(), user.ID, user.Email)
+	require.Equal(t, pkg.NOT_FOUND_ERROR, pkg.ErrorCode(err))
+}
+
 func randomUser() repository.User {
 	return repository.User{
 		ID:              int64(rand.IntN(100)),
@@ -294,12 +368,13 @@ func randomUser() repository.User {
 		PaydAccountID:   gofakeit.UUID(),
 		PaydUsernameKey: gofakeit.UUID(),
 		PaydPasswordKey: gofakeit.UUID(),
+		Role:            repository.RoleUser,
 		CreatedAt:       TestTime,
 	}
 }
 
 func repositoryUserToGenerated(user repository.User) generated.User {
-	return generated.User{
+	row := generated.User{
 		ID:              user.ID,
 		FullName:        user.FullName,
 		Email:           user.Email,
@@ -308,6 +383,17 @@ func repositoryUserToGenerated(user repository.User) generated.User {
 		PaydAccountID:   user.PaydAccountID,
 		PaydUsernameKey: user.PaydUsernameKey,
 		PaydPasswordKey: user.PaydPasswordKey,
+		Role:            user.Role,
 		CreatedAt:       user.CreatedAt,
 	}
+
+	if user.DisabledAt != nil {
+		row.DisabledAt = pgtype.Timestamptz{Time: *user.DisabledAt, Valid: true}
+	}
+
+	if user.EmailVerifiedAt != nil {
+		row.EmailVerifiedAt = pgtype.Timestamptz{Time: *user.EmailVerifiedAt, Valid: true}
+	}
+
+	return row
 }
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+// The scopes an API key can be granted, each allowing a group of gateway routes.
+const (
+	ScopePaymentsInitiate = "payments:initiate"
+	ScopePaymentsRead     = "payments:read"
+)
+
+var Scopes = []string{ScopePaymentsInitiate, ScopePaymentsRead}
+
+// APIKey is an API key as stored, without the key itself. Prefix is the start of the
+// key, shown to tell the keys of a user apart.
+type APIKey struct {
+	ID     int64    `json:"id"`
+	UserID int64    `json:"user_id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+
+	// AllowedIPs are the networks the key can be used from, any when empty.
+	AllowedIPs []netip.Prefix `json:"allowed_ips"`
+
+	// ExpiresAt is nil for keys that do not expire, LastUsedAt for keys never used.
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) Validate() error {
+	if k.UserID == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "user_id is required")
+	}
+	if k.Name == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "name is required")
+	}
+	if len(k.Scopes) == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "scopes are required")
+	}
+	for _, scope := range k.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return pkg.Errorf(pkg.INVALID_ERROR, "unknown scope %q", scope)
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return pkg.Errorf(pkg.INVALID_ERROR, "expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// Expired reports whether the key has expired at now.
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+type APIKeyRepository interface {
+	// CreateAPIKey stores a new API key for a user. It returns the key to hand to the
+	// user, which can not be retrieved afterwards.
+	CreateAPIKey(ctx context.Context, apiKey APIKey) (string, *APIKey, error)
+
+	// ListAPIKeys returns the API keys of a user that have not been revoked.
+	ListAPIKeys(ctx context.Context, userID int64) ([]APIKey, error)
+
+	// RevokeAPIKey revokes an API key of a user, failing with NOT_FOUND_ERROR when the
+	// user has no such key.
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+
+	// VerifyAPIKey returns the API key a client authenticated with and records its use.
+	// Unknown, revoked and expired keys fail with AUTHENTICATION_ERROR.
+	VerifyAPIKey(ctx context.Context, key string) (*APIKey, error)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
+)
+
+// The admin actions recorded in the audit log.
+const (
+	ActionUserLookup       = "user.lookup"
+	ActionUserDisable      = "user.disable"
+	ActionUserUnlock       = "user.unlock"
+	ActionTransactionsView = "transactions.view"
+)
+
+// ActionUserLock is recorded when failed logins lock an account out. No admin takes it,
+// so the user whose account is locked is recorded as the actor.
+const ActionUserLock = "user.lock"
+
+// ActionRoles are the roles each admin action requires.
+var ActionRoles = map[string]string{
+	ActionUserLookup:       RoleSupport,
+	ActionUserDisable:      RoleAdmin,
+	ActionUserUnlock:       RoleAdmin,
+	ActionTransactionsView: RoleSupport,
+}
+
+// reasonRequired lists the actions that can not be taken without a reason.
+var reasonRequired = []string{ActionUserDisable, ActionUserUnlock, ActionTransactionsView}
+
+// AuditEvent is an admin action taken by ActorID on the account of TargetUserID, or the
+// lockout of the account of TargetUserID.
+type AuditEvent struct {
+	ID           int64     `json:"id"`
+	ActorID      int64     `json:"actor_id"`
+	Action       string    `json:"action"`
+	TargetUserID int64     `json:"target_user_id"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (e *AuditEvent) Validate() error {
+	if e.ActorID == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "actor_id is required")
+	}
+
+	if _, ok := ActionRoles[e.Action]; !ok && e.Action != ActionUserLock {
+		return pkg.Errorf(pkg.INVALID_ERROR, "unknown action %q", e.Action)
+	}
+
+	if e.TargetUserID == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "target_user_id is required")
+	}
+
+	if e.Reason == "" && slices.Contains(reasonRequired, e.Action) {
+		return pkg.Errorf(pkg.INVALID_ERROR, "reason is required to %s", e.Action)
+	}
+
+	return nil
+}
+
+type AuditRepository interface {
+	// RecordAuditEvent writes an admin action to the audit log. Actions are recorded
+	// before they are taken, so that none goes unrecorded.
+	RecordAuditEvent(context.Context, AuditEvent) (*AuditEvent, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+)
+
+// LoginAttemptRepository tracks the failed logins of each email and each client address,
+// to slow down and then lock out password guessing. Emails are tracked whether or not
+// they belong to an account, so that lockouts do not tell which ones do.
+type LoginAttemptRepository interface {
+	// CheckLogin fails with RATE_LIMIT_ERROR while email or ip is locked out, or while
+	// email waits out the delay that follows each of its failed logins. An empty ip is
+	// not tracked.
+	CheckLogin(ctx context.Context, email string, ip string) error
+
+	// RecordLoginFailure counts a failed login of email from ip, locking either out once
+	// it failed too many times in a row. It reports whether email was locked out by it.
+	RecordLoginFailure(ctx context.Context, email string, ip string) (bool, error)
+
+	// ClearLoginFailures forgets the failed logins of email and lifts its lockout, after
+	// a login succeeds or when an admin unlocks the account.
+	ClearLoginFailures(ctx context.Context, email string) error
+}
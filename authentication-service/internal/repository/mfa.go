@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// MFAEnrollment is a TOTP secret issued to a user, with the recovery codes that stand in
+// for its codes when the authenticator is lost. Both are shown once.
+type MFAEnrollment struct {
+	Secret        string
+	RecoveryCodes []string
+}
+
+// MFARepository keeps the TOTP secrets of the users who turned on two-factor
+// authentication, and the challenges their logins are completed with.
+type MFARepository interface {
+	// EnrollMFA issues a new TOTP secret and recovery codes to the user, replacing any
+	// enrollment yet to be activated. It fails with ALREADY_EXISTS_ERROR while
+	// two-factor authentication is enabled.
+	EnrollMFA(ctx context.Context, userID int64) (*MFAEnrollment, error)
+
+	// ActivateMFA enables two-factor authentication once the user proves, with a code of
+	// the secret they enrolled, that they added it to their authenticator. It returns
+	// when it was enabled.
+	ActivateMFA(ctx context.Context, userID int64, code string) (time.Time, error)
+
+	// MFAEnabled reports whether the user has to complete their logins with a code.
+	MFAEnabled(ctx context.Context, userID int64) (bool, error)
+
+	// DisableMFA turns two-factor authentication off, given a current code or an unused
+	// recovery code, and forgets the secret, the recovery codes and open challenges.
+	DisableMFA(ctx context.Context, userID int64, code string) error
+
+	// VerifyMFACode checks code, a TOTP code or an unused recovery code, for a user
+	// proving who they are again, and spends it. It fails with INVALID_ERROR when
+	// two-factor authentication is not enabled and AUTHENTICATION_ERROR for a wrong code.
+	VerifyMFACode(ctx context.Context, userID int64, code string) error
+
+	// CreateMFAChallenge issues a token the user, having given their password, completes
+	// their login with until expiresAt.
+	CreateMFAChallenge(ctx context.Context, userID int64, expiresAt time.Time) (string, error)
+
+	// GetMFAChallenge returns the ID of the user of a challenge that can still be
+	// completed, failing with AUTHENTICATION_ERROR otherwise.
+	GetMFAChallenge(ctx context.Context, token string) (int64, error)
+
+	// CompleteMFAChallenge checks code, a TOTP code or an unused recovery code, against
+	// the challenge and spends it, returning the ID of its user. Each attempt counts, and
+	// a challenge is given up after a few wrong codes. A TOTP code is accepted once.
+	CompleteMFAChallenge(ctx context.Context, token string, code string) (int64, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type PasswordResetRepository interface {
+	// CreatePasswordResetToken issues a token letting the user set a new password until
+	// expiresAt, which replaces any token issued to them before. It returns the token
+	// to email the user.
+	CreatePasswordResetToken(ctx context.Context, userID int64, expiresAt time.Time) (string, error)
+
+	// ResetPassword sets the password of the user a reset token was issued to. A token
+	// can be used once, and not after it expired.
+	ResetPassword(ctx context.Context, token string, password string) (*User, error)
+}
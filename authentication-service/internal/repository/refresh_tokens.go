@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a refresh token as stored, without the token itself. Tokens rotated
+// from one another share a family, and its lifetime.
+type RefreshToken struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	FamilyID  uuid.UUID `json:"family_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RefreshTokenRepository interface {
+	// CreateRefreshToken starts a new family of refresh tokens for a user, which can be
+	// rotated until expiresAt. It returns the token to hand to the user.
+	CreateRefreshToken(ctx context.Context, userID int64, expiresAt time.Time) (string, *RefreshToken, error)
+
+	// RotateRefreshToken exchanges a refresh token for a new one of the same family. A
+	// token can be used once: using it again revokes its whole family.
+	RotateRefreshToken(ctx context.Context, token string) (string, *RefreshToken, error)
+
+	// RevokeRefreshTokens revokes the family of a user's refresh token, or all of the
+	// user's refresh tokens when token is empty. Tokens that are unknown or belong to
+	// someone else are ignored, so that logging out twice succeeds.
+	RevokeRefreshTokens(ctx context.Context, userID int64, token string) error
+}
@@ -2,11 +2,29 @@ package repository
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/authentication-service/pkg"
 )
 
+// The roles of a user, each allowed what the ones before it are.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+var Roles = []string{RoleUser, RoleSupport, RoleAdmin}
+
+// RoleAtLeast reports whether role is allowed what required is. Unknown roles are
+// allowed nothing.
+func RoleAtLeast(role, required string) bool {
+	have, want := slices.Index(Roles, role), slices.Index(Roles, required)
+
+	return have >= 0 && want >= 0 && have >= want
+}
+
 type User struct {
 	ID              int64     `json:"id"`
 	FullName        string    `json:"full_name"`
@@ -16,7 +34,24 @@ type User struct {
 	PaydAccountID   string    `json:"payd_account_id"`
 	PaydUsernameKey string    `json:"payd_username_key"`
 	PaydPasswordKey string    `json:"payd_password_key"`
+	Role            string    `json:"role"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	// DisabledAt is when an admin disabled the account, nil while it is enabled.
+	DisabledAt *time.Time `json:"disabled_at"`
+
+	// EmailVerifiedAt is when the user confirmed their email address, nil until then.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+}
+
+// Disabled reports whether the account is disabled, and can not log in.
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// EmailVerified reports whether the user confirmed their email address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 func (u *User) Validate() error {
@@ -49,4 +84,12 @@ type UserRepository interface {
 	CreateUser(context.Context, User) (*User, error)
 	GetUser(context.Context, string) (*User, error)
 	GetUserByID(context.Context, int64) (*User, error)
-}
\ No newline at end of file
+
+	// DisableUser disables the account of the user, keeping the time it was first
+	// disabled at when it already is.
+	DisableUser(context.Context, int64) (*User, error)
+
+	// VerifyEmail marks the email of the user verified, provided it still is email,
+	// keeping the time it was first verified at when it already is.
+	VerifyEmail(ctx context.Context, id int64, email string) (*User, error)
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const serviceName = "authentication-service"
+
+// Exporters supported by Setup.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+const instrumentationName = "github.com/EmilioCliff/payment-polling-app/authentication-service"
+
+// tracer is looked up on every use so spans follow the provider installed by Setup.
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+// Spans are sent over OTLP/gRPC to endpoint or printed to stdout depending on exporter,
+// any other value keeps propagating incoming context without recording spans.
+// The returned function flushes the pending spans and must be called before exiting.
+func Setup(ctx context.Context, exporter string, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		err          error
+	)
+
+	switch exporter {
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// GinMiddleware starts a server span for every request, continuing the trace of
+// the caller when it sent a traceparent header. Handlers get the span through
+// ctx.Request.Context().
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		c, span := tracer().Start(parent, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(c)
+
+		ctx.Next()
+
+		statusCode := ctx.Writer.Status()
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+}
+
+// UnaryServerInterceptor starts a server span for every unary call, continuing the
+// trace the client sent in the call metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		ctx, span := tracer().Start(ctx, info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(info.FullMethod)),
+		)
+		defer span.End()
+
+		rsp, err := handler(ctx, req)
+
+		st := status.Convert(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+		if err != nil {
+			span.SetStatus(codes.Error, st.Message())
+		}
+
+		return rsp, err
+	}
+}
+
+// StartConsume starts a consumer span for a message delivered with routingKey,
+// continuing the trace whose context the publisher put in headers.
+func StartConsume(ctx context.Context, routingKey string, headers amqp.Table) (context.Context, trace.Span) {
+	if headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(headers))
+	}
+
+	return tracer().Start(ctx, routingKey+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingRabbitmqDestinationRoutingKey(routingKey),
+		),
+	)
+}
+
+// AMQPHeaders returns message headers carrying the trace context of ctx.
+func AMQPHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+
+	return headers
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+
+	return value
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
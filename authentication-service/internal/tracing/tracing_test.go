@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestProvider(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	))
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.AuthenticationService/GetUser"}
+
+	handler := func(_ context.Context, _ any) (any, error) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	_, err := UnaryServerInterceptor()(ctx, nil, info, handler)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Equal(t, "user not found", spans[0].Status().Description)
+}
+
+func TestStartConsume(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	ctx, publisher := otel.Tracer("test").Start(context.Background(), "publish")
+	headers := AMQPHeaders(ctx)
+	publisher.End()
+
+	_, span := StartConsume(context.Background(), "authentication.login_user", headers)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "authentication.login_user process", spans[1].Name())
+	require.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix starts every API key, telling them apart from access tokens.
+	APIKeyPrefix = "ppk_"
+
+	// apiKeyShownLength is how much of a key is kept in the clear, for users to tell
+	// their keys apart.
+	apiKeyShownLength = len(APIKeyPrefix) + 8
+)
+
+// NewAPIKey returns a random API key and its prefix. Only its hash is stored, see
+// HashAPIKey.
+func NewAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("error generating api key: %s", err)
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, key[:apiKeyShownLength], nil
+}
+
+// IsAPIKey reports whether key looks like an API key rather than an access token.
+func IsAPIKey(key string) bool {
+	return strings.HasPrefix(key, APIKeyPrefix)
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up under. As for refresh
+// tokens, keys are random so a fast hash does not make them easier to guess.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
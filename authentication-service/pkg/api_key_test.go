@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	require.NoError(t, err)
+	require.Len(t, key, len(APIKeyPrefix)+43)
+	require.True(t, IsAPIKey(key))
+	require.Equal(t, key[:12], prefix)
+
+	other, _, err := NewAPIKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	require.NotEqual(t, HashAPIKey(key), HashAPIKey(other))
+	require.NotContains(t, HashAPIKey(key), key)
+
+	// access tokens are told apart from keys
+	require.False(t, IsAPIKey("eyJhbGciOiJSUzI1NiIsImtpZCI6ImtleSJ9"))
+}
@@ -7,22 +7,51 @@ import (
 )
 
 type Config struct {
-	HTTP_PORT          string        `mapstructure:"HTTP_PORT"`
-	GRPC_PORT          string        `mapstructure:"GRPC_PORT"`
-	POSTGRES_USER      string        `mapstructure:"POSTGRES_USER"`
-	POSTGRES_PASSWORD  string        `mapstructure:"POSTGRES_PASSWORD"`
-	POSTGRES_DB        string        `mapstructure:"POSTGRES_DB"`
-	DB_URL             string        `mapstructure:"DB_URL"`
-	MIGRATION_PATH     string        `mapstructure:"MIGRATION_PATH"`
-	HASH_COST          int           `mapstructure:"HASH_COST"`
-	TOKEN_DURATION     time.Duration `mapstructure:"TOKEN_DURATION"`
-	PRIVATE_KEY_PATH   string        `mapstructure:"PRIVATE_KEY_PATH"`
-	PUBLIC_KEY_PATH    string        `mapstructure:"PUBLIC_KEY_PATH"`
-	AUTH_QUEUE_NAME    string        `mapstructure:"AUTH_QUEUE_NAME"`
-	AUTH_CONSUMER_NAME string        `mapstructure:"AUTH_CONSUMER_NAME"`
-	RABBITMQ_URL       string        `mapstructure:"RABBITMQ_URL"`
-	EXCH               string        `mapstructure:"EXCH"`
-	ENCRYPTION_KEY     string        `mapstructure:"ENCRYPTION_KEY"`
+	HTTP_PORT              string        `mapstructure:"HTTP_PORT"`
+	GRPC_PORT              string        `mapstructure:"GRPC_PORT"`
+	POSTGRES_USER          string        `mapstructure:"POSTGRES_USER"`
+	POSTGRES_PASSWORD      string        `mapstructure:"POSTGRES_PASSWORD"`
+	POSTGRES_DB            string        `mapstructure:"POSTGRES_DB"`
+	DB_URL                 string        `mapstructure:"DB_URL"`
+	MIGRATION_PATH         string        `mapstructure:"MIGRATION_PATH"`
+	HASH_COST              int           `mapstructure:"HASH_COST"`
+	TOKEN_DURATION         time.Duration `mapstructure:"TOKEN_DURATION"`
+	REFRESH_TOKEN_DURATION time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	PRIVATE_KEY_PATH       string        `mapstructure:"PRIVATE_KEY_PATH"`
+	VERIFICATION_KEY_PATHS string        `mapstructure:"VERIFICATION_KEY_PATHS"`
+	AUTH_QUEUE_NAME        string        `mapstructure:"AUTH_QUEUE_NAME"`
+	AUTH_CONSUMER_NAME     string        `mapstructure:"AUTH_CONSUMER_NAME"`
+	RABBITMQ_URL           string        `mapstructure:"RABBITMQ_URL"`
+	EXCH                   string        `mapstructure:"EXCH"`
+	ENCRYPTION_KEY         string        `mapstructure:"ENCRYPTION_KEY"`
+	TRACING_EXPORTER       string        `mapstructure:"TRACING_EXPORTER"`
+	OTLP_ENDPOINT          string        `mapstructure:"OTLP_ENDPOINT"`
+	SHUTDOWN_TIMEOUT       time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	DEAD_LETTER_EXCH       string        `mapstructure:"DEAD_LETTER_EXCH"`
+	CONSUMER_WORKERS       int           `mapstructure:"CONSUMER_WORKERS"`
+	CONSUMER_PREFETCH      int           `mapstructure:"CONSUMER_PREFETCH"`
+	BUS_DRIVER             string        `mapstructure:"BUS_DRIVER"`
+
+	EMAIL_TOKEN_SECRET                string        `mapstructure:"EMAIL_TOKEN_SECRET"`
+	EMAIL_VERIFICATION_URL            string        `mapstructure:"EMAIL_VERIFICATION_URL"`
+	EMAIL_VERIFICATION_TOKEN_DURATION time.Duration `mapstructure:"EMAIL_VERIFICATION_TOKEN_DURATION"`
+	REQUIRE_VERIFIED_EMAIL            bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	PASSWORD_RESET_URL                string        `mapstructure:"PASSWORD_RESET_URL"`
+	PASSWORD_RESET_TOKEN_DURATION     time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	MAILER                            string        `mapstructure:"MAILER"`
+	MAIL_FROM                         string        `mapstructure:"MAIL_FROM"`
+	MAIL_LOG_PATH                     string        `mapstructure:"MAIL_LOG_PATH"`
+	SMTP_ADDR                         string        `mapstructure:"SMTP_ADDR"`
+	SMTP_USERNAME                     string        `mapstructure:"SMTP_USERNAME"`
+	SMTP_PASSWORD                     string        `mapstructure:"SMTP_PASSWORD"`
+
+	LOGIN_MAX_ATTEMPTS     int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LOGIN_IP_MAX_ATTEMPTS  int           `mapstructure:"LOGIN_IP_MAX_ATTEMPTS"`
+	LOGIN_LOCKOUT_DURATION time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LOGIN_FAILURE_DELAY    time.Duration `mapstructure:"LOGIN_FAILURE_DELAY"`
+
+	MFA_ISSUER             string        `mapstructure:"MFA_ISSUER"`
+	MFA_CHALLENGE_DURATION time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
 }
 
 // Loads app configuration from .env file.
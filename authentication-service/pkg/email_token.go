@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// EmailVerificationPurpose is the purpose of the tokens users confirm their email
+// address with.
+const EmailVerificationPurpose = "email_verification"
+
+// minEmailTokenSecretLength is the length of the shortest secret email tokens are
+// signed with, that of the HMAC-SHA256 key.
+const minEmailTokenSecretLength = 32
+
+var ErrInvalidPurpose = errors.New("token is not meant for this")
+
+// EmailPayload is the content of the tokens sent to users by email. The token is only
+// good for its purpose and for the email it was sent to.
+type EmailPayload struct {
+	Purpose string `json:"purpose"`
+	UserID  int64  `json:"user_id"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// EmailTokenMaker signs the tokens sent to users by email with a secret of the service.
+// They are signed with HMAC rather than the RSA key of the access tokens, so that no
+// email token can ever pass for an access token.
+type EmailTokenMaker struct {
+	secret []byte
+}
+
+func NewEmailTokenMaker(secret string) (*EmailTokenMaker, error) {
+	if len(secret) < minEmailTokenSecretLength {
+		return nil, fmt.Errorf("email token secret must be at least %d characters", minEmailTokenSecretLength)
+	}
+
+	return &EmailTokenMaker{secret: []byte(secret)}, nil
+}
+
+func (maker *EmailTokenMaker) CreateToken(purpose string, userID int64, email string, duration time.Duration) (string, error) {
+	claims := EmailPayload{
+		Purpose: purpose,
+		UserID:  userID,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "authApp",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(maker.secret)
+	if err != nil {
+		return "", fmt.Errorf("error signing email token: %s", err)
+	}
+
+	return token, nil
+}
+
+// VerifyToken returns the payload of token, failing with ErrTokenExpired once it has
+// expired and ErrInvalidPurpose when it was issued for another purpose.
+func (maker *EmailTokenMaker) VerifyToken(token string, purpose string) (*EmailPayload, error) {
+	keyFunc := func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+
+		return maker.secret, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &EmailPayload{}, keyFunc)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*EmailPayload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if payload.Issuer != "authApp" {
+		return nil, ErrInvalidIssuer
+	}
+
+	if payload.Purpose != purpose {
+		return nil, ErrInvalidPurpose
+	}
+
+	return payload, nil
+}
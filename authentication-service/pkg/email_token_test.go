@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testEmailTokenSecret = "an email token secret of 32 char"
+
+func TestEmailTokenMaker(t *testing.T) {
+	_, err := NewEmailTokenMaker("too short")
+	require.Error(t, err)
+
+	maker, err := NewEmailTokenMaker(testEmailTokenSecret)
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(EmailVerificationPurpose, 7, "jane@gmail.com", time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, EmailVerificationPurpose)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), payload.UserID)
+	require.Equal(t, "jane@gmail.com", payload.Email)
+
+	_, err = maker.VerifyToken(token, "password_reset")
+	require.ErrorIs(t, err, ErrInvalidPurpose)
+
+	expired, err := maker.CreateToken(EmailVerificationPurpose, 7, "jane@gmail.com", -time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(expired, EmailVerificationPurpose)
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	// tokens signed with another secret are not accepted
+	other, err := NewEmailTokenMaker("another email token secret, 32 ch")
+	require.NoError(t, err)
+
+	_, err = other.VerifyToken(token, EmailVerificationPurpose)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// nor unsigned ones
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, EmailPayload{Purpose: EmailVerificationPurpose}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(none, EmailVerificationPurpose)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
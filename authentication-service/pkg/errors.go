@@ -12,6 +12,8 @@ const (
 	NOT_FOUND_ERROR       = "not_found"
 	NOT_IMPLEMENTED_ERROR = "not_implemented"
 	AUTHENTICATION_ERROR  = "authentication"
+	PERMISSION_ERROR      = "permission"
+	RATE_LIMIT_ERROR      = "rate_limit"
 )
 
 type Error struct {
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// JWK is an RSA public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+// JWKSet is the set of keys tokens are verified with, as published at the JWKS endpoint.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK returns the JWK of an RSA public key used to sign tokens with RS256.
+func NewJWK(key *rsa.PublicKey) JWK {
+	return JWK{
+		KeyID:     KeyID(key),
+		KeyType:   "RSA",
+		Algorithm: "RS256",
+		Use:       "sig",
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// KeyID identifies a public key by its JWK thumbprint (RFC 7638), so that a key keeps its
+// ID wherever it is deployed without being named.
+func KeyID(key *rsa.PublicKey) string {
+	// the members of the thumbprint are marshalled in lexicographic order, which a map
+	// of strings gets from encoding/json
+	thumbprint, _ := json.Marshal(map[string]string{
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+
+	sum := sha256.Sum256(thumbprint)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS returns the keys the tokens of the maker are verified with, the signing key first.
+func (maker *JWTMaker) JWKS() JWKSet {
+	keys := maker.publicKeys()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, NewJWK(key))
+	}
+
+	return set
+}
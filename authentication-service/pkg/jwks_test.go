@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyID(t *testing.T) {
+	// the example of RFC 7638, section 3.1
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	require.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", KeyID(key))
+
+	jwk := NewJWK(key)
+	require.Equal(t, "AQAB", jwk.E)
+	require.Equal(t, KeyID(key), jwk.KeyID)
+	require.Equal(t, "RS256", jwk.Algorithm)
+}
+
+func TestJWTMaker_Rotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	oldMaker := &JWTMaker{PrivateKey: oldKey, PublicKey: &oldKey.PublicKey}
+
+	oldToken, err := oldMaker.CreateToken("user", 1, "user", time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Payload{})
+	require.NoError(t, err)
+	require.Equal(t, KeyID(&oldKey.PublicKey), parsed.Header["kid"])
+
+	// the new key signs, the old one still verifies what it signed
+	maker := &JWTMaker{
+		PrivateKey:       newKey,
+		PublicKey:        &newKey.PublicKey,
+		VerificationKeys: []*rsa.PublicKey{&oldKey.PublicKey},
+	}
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), payload.UserID)
+
+	newToken, err := maker.CreateToken("user", 1, "user", time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	jwks := maker.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, KeyID(&newKey.PublicKey), jwks.Keys[0].KeyID)
+	require.Equal(t, KeyID(&oldKey.PublicKey), jwks.Keys[1].KeyID)
+
+	// once the old key is retired its tokens are rejected
+	_, err = (&JWTMaker{PrivateKey: newKey, PublicKey: &newKey.PublicKey}).VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
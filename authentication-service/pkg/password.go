@@ -2,10 +2,16 @@ package pkg
 
 import (
 	"fmt"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
 func GenerateHashPassword(password string, cost int) (string, error) {
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
@@ -18,3 +24,14 @@ func GenerateHashPassword(password string, cost int) (string, error) {
 func ComparePasswordAndHash(hashPass string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashPass), []byte(password))
 }
+
+// ComparePasswordAndDummyHash compares password with the hash of no one's password, hashed
+// at cost. It takes as long as comparing it with the hash of a user, so that logging in
+// with an unknown email can not be told apart from a wrong password by the time it takes.
+func ComparePasswordAndDummyHash(password string, cost int) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not the password of anyone"), cost)
+	})
+
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
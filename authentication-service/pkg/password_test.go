@@ -5,6 +5,7 @@ import (
 
 	"github.com/brianvoe/gofakeit"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestPassword(t *testing.T) {
@@ -17,3 +18,11 @@ func TestPassword(t *testing.T) {
 	err = ComparePasswordAndHash(hashPassword, password)
 	require.NoError(t, err)
 }
+
+func TestComparePasswordAndDummyHash(t *testing.T) {
+	ComparePasswordAndDummyHash(gofakeit.Password(true, true, true, true, false, 8), 4)
+
+	cost, err := bcrypt.Cost(dummyHash)
+	require.NoError(t, err)
+	require.Equal(t, 4, cost)
+}
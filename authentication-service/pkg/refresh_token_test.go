@@ -0,0 +1,21 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRefreshToken(t *testing.T) {
+	token, err := NewRefreshToken()
+	require.NoError(t, err)
+	require.Len(t, token, 43)
+
+	other, err := NewRefreshToken()
+	require.NoError(t, err)
+	require.NotEqual(t, token, other)
+
+	require.Equal(t, HashRefreshToken(token), HashRefreshToken(token))
+	require.NotEqual(t, HashRefreshToken(token), HashRefreshToken(other))
+	require.NotContains(t, HashRefreshToken(token), token)
+}
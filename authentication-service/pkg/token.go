@@ -15,21 +15,32 @@ var (
 	ErrInvalidToken  = errors.New("token is invalid")
 	ErrInvalidIssuer = errors.New("invalid issuer")
 	ErrTokenExpired  = fmt.Errorf("token is expired")
+	ErrUnknownKey    = errors.New("token signed with an unknown key")
 )
 
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
 	UserID   int64     `json:"user_id"`
+	// Role is the role of the user when the token was issued, the gateway checks it
+	// per route.
+	Role string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type JWTMaker struct {
 	PublicKey  *rsa.PublicKey
 	PrivateKey *rsa.PrivateKey
+
+	// VerificationKeys are public keys that no longer sign tokens, or do not yet. The
+	// tokens they signed are still verified, and they are published along with the
+	// signing key so that verifiers know them before and after a rotation.
+	VerificationKeys []*rsa.PublicKey
 }
 
-func NewJWTMaker(privateKeyPath string, publicKeyPath string) (*JWTMaker, error) {
+// NewJWTMaker signs tokens with the private key at privateKeyPath, and verifies them
+// with its public key and the public keys at verificationKeyPaths.
+func NewJWTMaker(privateKeyPath string, verificationKeyPaths []string) (*JWTMaker, error) {
 	privateKeyBytes, err := os.ReadFile(privateKeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read private key: %s", err)
@@ -40,25 +51,29 @@ func NewJWTMaker(privateKeyPath string, publicKeyPath string) (*JWTMaker, error)
 		return nil, fmt.Errorf("failed to parse private key: %s", err)
 	}
 
-	publicKeyBytes, err := os.ReadFile(publicKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read public key: %s", err)
+	maker := &JWTMaker{
+		PrivateKey: privateKey,
+		PublicKey:  &privateKey.PublicKey,
 	}
 
-	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %s", err)
-	}
+	for _, path := range verificationKeyPaths {
+		publicKeyBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %s", err)
+		}
 
-	maker := &JWTMaker{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %s", path, err)
+		}
+
+		maker.VerificationKeys = append(maker.VerificationKeys, publicKey)
 	}
 
 	return maker, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, userID int64, duration time.Duration) (string, error) {
+func (maker *JWTMaker) CreateToken(username string, userID int64, role string, duration time.Duration) (string, error) {
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return "", fmt.Errorf("error generating token uuid")
@@ -68,6 +83,7 @@ func (maker *JWTMaker) CreateToken(username string, userID int64, duration time.
 		id,
 		username,
 		userID,
+		role,
 		jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -76,6 +92,8 @@ func (maker *JWTMaker) CreateToken(username string, userID int64, duration time.
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	jwtToken.Header["kid"] = KeyID(maker.PublicKey)
+
 	token, err := jwtToken.SignedString(maker.PrivateKey)
 
 	return token, err
@@ -88,7 +106,7 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 			return nil, ErrInvalidToken
 		}
 
-		return maker.PublicKey, nil
+		return maker.verificationKey(token.Header["kid"])
 	}
 
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
@@ -111,3 +129,29 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 
 	return payload, nil
 }
+
+// verificationKey returns the public key with the given key ID. Tokens issued before
+// they carried one are verified with the signing key.
+func (maker *JWTMaker) verificationKey(kid any) (*rsa.PublicKey, error) {
+	if kid == nil {
+		return maker.PublicKey, nil
+	}
+
+	id, ok := kid.(string)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	for _, key := range maker.publicKeys() {
+		if KeyID(key) == id {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// publicKeys returns the public key of the signing key followed by the verification keys.
+func (maker *JWTMaker) publicKeys() []*rsa.PublicKey {
+	return append([]*rsa.PublicKey{maker.PublicKey}, maker.VerificationKeys...)
+}
@@ -23,7 +23,7 @@ func TestTokenFunc(t *testing.T) {
 				userID := int64(1)
 				duration := time.Minute
 
-				token, err := maker.CreateToken(username, userID, duration)
+				token, err := maker.CreateToken(username, userID, "support", duration)
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
 
@@ -34,6 +34,7 @@ func TestTokenFunc(t *testing.T) {
 				require.NotZero(t, payload.ID)
 				require.Equal(t, username, payload.Username)
 				require.Equal(t, userID, payload.UserID)
+				require.Equal(t, "support", payload.Role)
 			},
 		},
 		{
@@ -42,7 +43,7 @@ func TestTokenFunc(t *testing.T) {
 				username := "Emilio Cliff"
 				duration := -time.Minute
 
-				token, err := maker.CreateToken(username, 1, duration)
+				token, err := maker.CreateToken(username, 1, "user", duration)
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
 
@@ -62,6 +63,7 @@ func TestTokenFunc(t *testing.T) {
 					uuid,
 					"username",
 					1,
+					"user",
 					jwt.RegisteredClaims{
 						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
 						IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -89,6 +91,7 @@ func TestTokenFunc(t *testing.T) {
 					uuid,
 					"username",
 					1,
+					"user",
 					jwt.RegisteredClaims{
 						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
 						IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP codes are RFC 6238 defaults, the only parameters every authenticator app
+// supports: six digits of HMAC-SHA1 over 30 second steps.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+
+	// totpSkew is how many steps before and after the current one codes are accepted
+	// from, for clocks out of sync and codes typed as they change.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random base32 encoded 160 bit secret, the size RFC 4226
+// recommends for HMAC-SHA1.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating totp secret: %s", err)
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth URI authenticator apps add the secret of account with,
+// usually shown as a QR code. The issuer names the service in the app, and may be empty.
+func TOTPURI(issuer string, account string, secret string) string {
+	label := account
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if issuer != "" {
+		label = issuer + ":" + account
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// TOTPStep returns the time step t falls in.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode returns the code of secret at time t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, TOTPStep(t)), nil
+}
+
+// ValidateTOTP reports whether code is the code of secret at time t, or of the step
+// just before or after it, and returns the step it matched. Callers keep the last step
+// a code was accepted for and refuse codes of that step or earlier, so that a code can
+// not be used twice.
+func ValidateTOTP(secret string, code string, t time.Time) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	step := TOTPStep(t)
+	for i := int64(-totpSkew); i <= totpSkew; i++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step+i)), []byte(code)) == 1 {
+			return step + i, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewRecoveryCode returns a random single use code, of 50 bits, that stands in for a
+// TOTP code when the authenticator is lost. Only its hash is stored, see
+// HashRecoveryCode.
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating recovery code: %s", err)
+	}
+
+	code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode returns the hash a recovery code is stored and looked up under,
+// whatever its case and the dashes and spaces it was typed with.
+func HashRecoveryCode(code string) string {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(code))
+
+	return HashRefreshToken(code)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %s", err)
+	}
+
+	return key, nil
+}
+
+// totpCode is the HOTP value (RFC 4226) of key at counter step.
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1_000_000)
+}
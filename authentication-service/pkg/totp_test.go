@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the secret of the RFC 6238 test vectors, "12345678901234567890", base32 encoded
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// the last six digits of the SHA1 test vectors of RFC 6238
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range tests {
+		code, err := TOTPCode(rfcTOTPSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, want, code, unix)
+	}
+
+	_, err := TOTPCode("not base32!", time.Now())
+	require.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	now := time.Unix(1_700_000_000, 0)
+
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+
+	// the code is still accepted a step later, and already a step before
+	step, ok = ValidateTOTP(secret, code, now.Add(TOTPPeriod))
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(-TOTPPeriod))
+	require.True(t, ok)
+
+	// but not further off
+	_, ok = ValidateTOTP(secret, code, now.Add(2*TOTPPeriod))
+	require.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, " "+code+" ", now)
+	require.True(t, ok)
+
+	_, ok = ValidateTOTP(secret, code[:5], now)
+	require.False(t, ok)
+
+	other, err := NewTOTPSecret()
+	require.NoError(t, err)
+
+	_, ok = ValidateTOTP(other, code, now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Payment Polling", "jane@gmail.com", rfcTOTPSecret))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/Payment Polling:jane@gmail.com", uri.Path)
+	require.Equal(t, rfcTOTPSecret, uri.Query().Get("secret"))
+	require.Equal(t, "Payment Polling", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+	require.Equal(t, "30", uri.Query().Get("period"))
+
+	uri, err = url.Parse(TOTPURI("", "jane@gmail.com", rfcTOTPSecret))
+	require.NoError(t, err)
+	require.Equal(t, "/jane@gmail.com", uri.Path)
+	require.False(t, uri.Query().Has("issuer"))
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+
+	other, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code, other)
+
+	// codes are looked up whatever the case and separators they are typed with
+	require.Equal(t, HashRecoveryCode(code), HashRecoveryCode(" "+code[:5]+code[6:]))
+	require.NotEqual(t, HashRecoveryCode(code), HashRecoveryCode(other))
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"strings"
+	"time"
 
 	_ "github.com/EmilioCliff/payment-polling-app/gateway-service/docs/statik"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/gRPC"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/http"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/jwks"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/rabbitmq"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/revocation"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	logging.Setup()
+
 	config, err := pkg.LoadConfig(".")
 	if err != nil {
 		log.Printf("Failed to load config: %v", err)
@@ -18,28 +34,21 @@ func main() {
 		return
 	}
 
-	maker, err := pkg.NewJWTMaker(config.PRIVATE_KEY_PATH, config.PUBLIC_KEY_PATH)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TRACING_EXPORTER, config.OTLP_ENDPOINT)
 	if err != nil {
-		log.Fatalf("Failed to create token maker: %v", err)
+		log.Fatalf("Failed to set up tracing: %v", err)
 	}
+	defer shutdownTracing(context.Background())
 
-	conn, err := rabbitmq.ConnectToRabit(config.RABBITMQ_URL)
+	messageBus, err := newBus(config)
 	if err != nil {
 		log.Printf("Failed to connect to RabbitMQ: %s", err)
 
 		return
 	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Printf("Failed to create gateway RabbitMQ channel: %s", err)
+	defer messageBus.Close()
 
-		return
-	}
-	defer ch.Close()
-
-	rabbitHandler := rabbitmq.NewRabbitService(ch, config)
+	rabbitHandler := rabbitmq.NewRabbitService(messageBus, config)
 
 	httpService := http.NewHTTPService(config)
 
@@ -52,7 +61,60 @@ func main() {
 		return
 	}
 
-	server := http.NewHttpServer(*maker)
+	err = rpcClient.StartPayments(config.PAYMENTS_GRPC_PORT)
+	if err != nil {
+		log.Fatalf("failed to get payments grpc client: %v", err)
+
+		return
+	}
+
+	checker := health.NewChecker()
+	checker.Add("rabbitmq", time.Second, messageBus.Ready)
+	checker.Add("auth_grpc", 2*time.Second, health.GRPCCheck(rpcClient.Conn()))
+	checker.Add("payments_grpc", 2*time.Second, health.GRPCCheck(rpcClient.PaymentsConn()))
+
+	// revocations are kept in Redis for every instance to see, or within the process when
+	// no Redis is configured
+	var revocationStore revocation.Store = revocation.NewMemoryStore()
+
+	if config.REDIS_ADDR != "" {
+		redisClient := redis.NewClient(&redis.Options{Addr: config.REDIS_ADDR})
+		defer redisClient.Close()
+
+		redisStore := revocation.NewRedisStore(redisClient)
+		checker.Add("redis", time.Second, redisStore.Ping)
+
+		revocationStore = redisStore
+	}
+
+	// the gateway starts without the signing keys if the authentication service is not up
+	// yet, they are fetched again with the first token
+	keys := jwks.NewCache(rpcClient.FetchJWKS, config.JWKS_MAX_AGE, config.JWKS_MIN_REFRESH_INTERVAL)
+	if err := keys.Refresh(context.Background()); err != nil {
+		log.Printf("Failed to fetch the signing keys: %v", err)
+	}
+
+	router, err := routing.NewFromConfig(config)
+	if err != nil {
+		log.Fatalf("invalid routes: %v", err)
+	}
+
+	server := http.NewHttpServer(pkg.NewJWTVerifier(keys))
+	server.HealthChecker = checker
+	server.Router = router
+	server.Revocations = revocation.NewChecker(revocationStore, config.REVOCATION_CACHE_TTL, config.TOKEN_DURATION)
+	server.APIKeys = apikeys.NewCache(rpcClient.VerifyAPIKey, config.API_KEY_CACHE_TTL)
+
+	if config.TRUSTED_PROXIES != "" {
+		proxies := strings.Split(config.TRUSTED_PROXIES, ",")
+		for i := range proxies {
+			proxies[i] = strings.TrimSpace(proxies[i])
+		}
+
+		if err := server.TrustProxies(proxies); err != nil {
+			log.Fatalf("invalid trusted proxies: %v", err)
+		}
+	}
 
 	// injecting applications dependencies
 	server.RabbitService = rabbitHandler
@@ -61,21 +123,41 @@ func main() {
 
 	readyCh := make(chan struct{}, 1)
 
-	// declares the gateway queue, binds topics, and starts consuming messages
-	go server.RabbitService.SetConsumer(
-		[]string{
-			"gateway.initiate_payment",
-			"gateway.poll_payments",
-			"gateway.register_user",
-			"gateway.login_user",
-		}, readyCh,
-	)
+	// declares the reply queue of this instance and starts consuming replies
+	go server.RabbitService.SetConsumer(readyCh)
 
-	log.Printf("Starting server on port: %s", config.SERVER_ADDRESS)
+	slog.Info("starting server", "address", config.SERVER_ADDRESS)
 
 	server.Start(config.SERVER_ADDRESS)
 }
 
+// newBus connects to RabbitMQ, or sets up an in-process bus when BUS_DRIVER is "memory".
+// The services are not in the process then, so the requests sent over it answer 503 and
+// the routes fall back to their next transport.
+func newBus(config pkg.Config) (bus.Bus, error) {
+	if config.BUS_DRIVER == "memory" {
+		return bus.NewMemory(), nil
+	}
+
+	conn, err := rabbit.Dial(config.RABBITMQ_URL)
+	if err != nil {
+		return nil, err
+	}
+
+	messageBus, err := bus.NewRabbitMQ(conn, bus.RabbitMQOptions{
+		Exchange:   config.EXCH,
+		Consumer:   config.GATEWAY_CONSUMER_NAME,
+		ReplyQueue: config.EXCLUSIVE_QUEUE_NAME,
+	})
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return messageBus, nil
+}
+
 // func connectToRabit(uri string) (*amqp.Connection, error) {
 // 	count := 0
 // 	maxRetries := 12
@@ -0,0 +1,146 @@
+// Package apikeys verifies the API keys server-to-server clients authenticate with. Keys
+// are issued and looked up by the authentication service; the answers are cached for a
+// while so that a client does not cost a call to the service on every request, and the
+// gateway enforces the addresses each key is allowed from.
+package apikeys
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+)
+
+// Key is an API key as verified by the authentication service.
+type Key struct {
+	ID     int64
+	UserID int64
+	Scopes []string
+
+	// AllowedIPs are the networks the key can be used from, any when empty.
+	AllowedIPs []netip.Prefix
+
+	// ExpiresAt is zero for keys that do not expire.
+	ExpiresAt time.Time
+}
+
+// Allows reports whether the key can be used from addr.
+func (k Key) Allows(addr netip.Addr) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr = addr.Unmap()
+
+	return slices.ContainsFunc(k.AllowedIPs, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+// Verifier asks the authentication service for the key, failing with
+// pkg.ErrInvalidAPIKey when it is unknown, revoked or expired.
+type Verifier func(ctx context.Context, key string) (Key, error)
+
+type entry struct {
+	key       Key
+	err       error
+	expiresAt time.Time
+}
+
+// Cache keeps the answers of the authentication service for ttl, so that a revoked key
+// is accepted for up to that long. Keys found invalid are cached as well: an API key
+// that is unknown, revoked or expired does not become valid later.
+type Cache struct {
+	verify Verifier
+	ttl    time.Duration
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[[sha256.Size]byte]entry
+	nextSweep time.Time
+}
+
+func NewCache(verify Verifier, ttl time.Duration) *Cache {
+	return &Cache{
+		verify:  verify,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[[sha256.Size]byte]entry),
+	}
+}
+
+// Verify returns the payload of a request authenticated with key from clientIP. It
+// fails with pkg.ErrInvalidAPIKey for keys that can not be used,
+// pkg.ErrAddressNotAllowed for keys that can not be used from clientIP, and
+// pkg.ErrAPIKeysUnavailable when the authentication service can not be asked.
+func (c *Cache) Verify(ctx context.Context, key string, clientIP netip.Addr) (*pkg.Payload, error) {
+	k, err := c.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.ExpiresAt.IsZero() && !c.now().Before(k.ExpiresAt) {
+		return nil, pkg.ErrInvalidAPIKey
+	}
+
+	if !k.Allows(clientIP) {
+		return nil, pkg.ErrAddressNotAllowed
+	}
+
+	return &pkg.Payload{
+		UserID:   k.UserID,
+		APIKeyID: k.ID,
+		Scopes:   k.Scopes,
+	}, nil
+}
+
+func (c *Cache) lookup(ctx context.Context, key string) (Key, error) {
+	// keys are held by their hash, the cache does not keep them in the clear
+	hash := sha256.Sum256([]byte(key))
+
+	c.mu.Lock()
+	cached, ok := c.entries[hash]
+	c.mu.Unlock()
+
+	if ok && c.now().Before(cached.expiresAt) {
+		return cached.key, cached.err
+	}
+
+	k, err := c.verify(ctx, key)
+	if err != nil && !errors.Is(err, pkg.ErrInvalidAPIKey) {
+		// the service could not tell, the answer is not cached
+		return Key{}, fmt.Errorf("%w: %w", pkg.ErrAPIKeysUnavailable, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	c.entries[hash] = entry{key: k, err: err, expiresAt: now.Add(c.ttl)}
+	c.sweep(now)
+
+	return k, err
+}
+
+// sweep drops the expired entries once in a while. The lock is held by the caller.
+func (c *Cache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+
+	c.nextSweep = now.Add(c.ttl)
+
+	for hash, cached := range c.entries {
+		if !now.Before(cached.expiresAt) {
+			delete(c.entries, hash)
+		}
+	}
+}
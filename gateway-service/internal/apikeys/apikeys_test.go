@@ -0,0 +1,133 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// server plays the authentication service, counting the keys verified.
+type server struct {
+	keys     map[string]Key
+	err      error
+	verified int
+}
+
+func (s *server) verify(_ context.Context, key string) (Key, error) {
+	s.verified++
+
+	if s.err != nil {
+		return Key{}, s.err
+	}
+
+	k, ok := s.keys[key]
+	if !ok {
+		return Key{}, pkg.ErrInvalidAPIKey
+	}
+
+	return k, nil
+}
+
+func TestKey_Allows(t *testing.T) {
+	key := Key{AllowedIPs: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}}
+
+	require.True(t, key.Allows(netip.MustParseAddr("10.1.2.3")))
+	require.True(t, key.Allows(netip.MustParseAddr("::ffff:10.1.2.3")))
+	require.True(t, key.Allows(netip.MustParseAddr("2001:db8::1")))
+	require.False(t, key.Allows(netip.MustParseAddr("192.168.1.1")))
+	require.False(t, key.Allows(netip.Addr{}))
+
+	require.True(t, Key{}.Allows(netip.MustParseAddr("192.168.1.1")))
+}
+
+func TestCache_Verify(t *testing.T) {
+	s := &server{keys: map[string]Key{
+		"ppk_valid": {
+			ID:         1,
+			UserID:     7,
+			Scopes:     []string{pkg.ScopePaymentsRead},
+			AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		},
+	}}
+	now := time.Now()
+
+	c := NewCache(s.verify, 30*time.Second)
+	c.now = func() time.Time { return now }
+
+	client := netip.MustParseAddr("10.1.2.3")
+
+	payload, err := c.Verify(context.Background(), "ppk_valid", client)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), payload.UserID)
+	require.Equal(t, int64(1), payload.APIKeyID)
+	require.True(t, payload.HasScope(pkg.ScopePaymentsRead))
+	require.False(t, payload.HasScope(pkg.ScopePaymentsInitiate))
+
+	_, err = c.Verify(context.Background(), "ppk_valid", netip.MustParseAddr("192.168.1.1"))
+	require.ErrorIs(t, err, pkg.ErrAddressNotAllowed)
+
+	_, err = c.Verify(context.Background(), "ppk_made-up", client)
+	require.ErrorIs(t, err, pkg.ErrInvalidAPIKey)
+
+	_, err = c.Verify(context.Background(), "ppk_made-up", client)
+	require.ErrorIs(t, err, pkg.ErrInvalidAPIKey)
+	require.Equal(t, 2, s.verified)
+
+	// a revoked key is accepted until the cached answer expires
+	delete(s.keys, "ppk_valid")
+
+	_, err = c.Verify(context.Background(), "ppk_valid", client)
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Second)
+
+	_, err = c.Verify(context.Background(), "ppk_valid", client)
+	require.ErrorIs(t, err, pkg.ErrInvalidAPIKey)
+	require.Equal(t, 3, s.verified)
+}
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Now()
+
+	s := &server{keys: map[string]Key{
+		"ppk_valid": {ID: 1, UserID: 7, ExpiresAt: now.Add(10 * time.Second)},
+	}}
+
+	c := NewCache(s.verify, 30*time.Second)
+	c.now = func() time.Time { return now }
+
+	_, err := c.Verify(context.Background(), "ppk_valid", netip.MustParseAddr("10.1.2.3"))
+	require.NoError(t, err)
+
+	// keys expire on time, whatever the answer cached
+	now = now.Add(10 * time.Second)
+
+	_, err = c.Verify(context.Background(), "ppk_valid", netip.MustParseAddr("10.1.2.3"))
+	require.ErrorIs(t, err, pkg.ErrInvalidAPIKey)
+	require.Equal(t, 1, s.verified)
+}
+
+func TestCache_Unavailable(t *testing.T) {
+	s := &server{err: errors.New("connection refused")}
+
+	c := NewCache(s.verify, 30*time.Second)
+
+	_, err := c.Verify(context.Background(), "ppk_valid", netip.MustParseAddr("10.1.2.3"))
+	require.ErrorIs(t, err, pkg.ErrAPIKeysUnavailable)
+
+	// failures are not cached
+	s.err = nil
+	s.keys = map[string]Key{"ppk_valid": {ID: 1, UserID: 7}}
+
+	_, err = c.Verify(context.Background(), "ppk_valid", netip.MustParseAddr("10.1.2.3"))
+	require.NoError(t, err)
+	require.Equal(t, 2, s.verified)
+}
@@ -0,0 +1,147 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/status"
+)
+
+func (g *GrpcClient) AdminGetUserViagRPC(
+	ctx context.Context,
+	req services.AdminGetUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.AdminGetUser(c, &pb.AdminGetUserRequest{
+		ActorId: actorID,
+		UserId:  req.UserID,
+		Email:   req.Email,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.AdminUserResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.AdminUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	user := adminUserFromPb(rsp.GetUser())
+
+	return http.StatusOK, services.AdminUserResponse{User: &user}
+}
+
+func (g *GrpcClient) DisableUserViagRPC(
+	ctx context.Context,
+	req services.DisableUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.DisableUser(c, &pb.DisableUserRequest{
+		ActorId: actorID,
+		UserId:  req.UserID,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.AdminUserResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.AdminUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	user := adminUserFromPb(rsp.GetUser())
+
+	return http.StatusOK, services.AdminUserResponse{User: &user}
+}
+
+// UnlockUserViagRPC lifts the lockout of the account of a user on behalf of actorID.
+func (g *GrpcClient) UnlockUserViagRPC(
+	ctx context.Context,
+	req services.UnlockUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.UnlockUser(c, &pb.UnlockUserRequest{
+		ActorId: actorID,
+		UserId:  req.UserID,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.AdminUserResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.AdminUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	user := adminUserFromPb(rsp.GetUser())
+
+	return http.StatusOK, services.AdminUserResponse{User: &user}
+}
+
+// RecordAuditEventViagRPC records an admin action the gateway takes on behalf of
+// actorID. The authentication service checks that the actor is allowed it.
+func (g *GrpcClient) RecordAuditEventViagRPC(
+	ctx context.Context,
+	req services.RecordAuditEventRequest,
+	actorID int64,
+) (int, services.RecordAuditEventResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.RecordAuditEvent(c, &pb.RecordAuditEventRequest{
+		ActorId:      actorID,
+		Action:       req.Action,
+		TargetUserId: req.TargetUserID,
+		Reason:       req.Reason,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.RecordAuditEventResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.RecordAuditEventResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.RecordAuditEventResponse{ID: rsp.GetId()}
+}
+
+func adminUserFromPb(user *pb.UserAccount) services.AdminUser {
+	return services.AdminUser{
+		ID:            user.GetId(),
+		FullName:      user.GetFullname(),
+		Email:         user.GetEmail(),
+		Role:          user.GetRole(),
+		PaydUsername:  user.GetPaydUsername(),
+		PaydAccountID: user.GetPaydAccountId(),
+		DisabledAt:    timeOrNil(user.GetDisabledAt()),
+		CreatedAt:     user.GetCreatedAt().AsTime(),
+	}
+}
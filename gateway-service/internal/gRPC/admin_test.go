@@ -0,0 +1,150 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGrpcClient_AdminGetUserViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		AdminGetUser(gomock.Any(), gomock.Eq(&pb.AdminGetUserRequest{ActorId: 1, Email: "jane@gmail.com", Reason: "ticket 42"})).
+		Return(&pb.AdminGetUserResponse{User: &pb.UserAccount{
+			Id:        7,
+			Fullname:  "Jane",
+			Email:     "jane@gmail.com",
+			Role:      "user",
+			CreatedAt: timestamppb.New(TestTime),
+		}}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.AdminGetUserViagRPC(
+		context.Background(),
+		services.AdminGetUserRequest{Email: "jane@gmail.com", Reason: "ticket 42"},
+		1,
+	)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.AdminUserResponse{User: &services.AdminUser{
+		ID:        7,
+		FullName:  "Jane",
+		Email:     "jane@gmail.com",
+		Role:      "user",
+		CreatedAt: TestTime,
+	}}, rsp)
+
+	mockCalls.EXPECT().
+		AdminGetUser(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.PermissionDenied, "not allowed to user.lookup")).
+		Times(1)
+
+	statusCode, rsp = g.client.AdminGetUserViagRPC(context.Background(), services.AdminGetUserRequest{UserID: 7}, 2)
+	require.Equal(t, http.StatusForbidden, statusCode)
+	require.Equal(t, "not allowed to user.lookup", rsp.Message)
+}
+
+func TestGrpcClient_DisableUserViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		DisableUser(gomock.Any(), gomock.Eq(&pb.DisableUserRequest{ActorId: 1, UserId: 7, Reason: "fraud"})).
+		Return(&pb.DisableUserResponse{User: &pb.UserAccount{
+			Id:         7,
+			DisabledAt: timestamppb.New(TestTime),
+			CreatedAt:  timestamppb.New(TestTime),
+		}}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.DisableUserViagRPC(context.Background(), services.DisableUserRequest{UserID: 7, Reason: "fraud"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, int64(7), rsp.User.ID)
+	require.Equal(t, TestTime, *rsp.User.DisabledAt)
+
+	mockCalls.EXPECT().
+		DisableUser(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.NotFound, "user not found")).
+		Times(1)
+
+	statusCode, rsp = g.client.DisableUserViagRPC(context.Background(), services.DisableUserRequest{UserID: 8, Reason: "fraud"}, 1)
+	require.Equal(t, http.StatusNotFound, statusCode)
+	require.Equal(t, "user not found", rsp.Message)
+}
+
+func TestGrpcClient_UnlockUserViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		UnlockUser(gomock.Any(), gomock.Eq(&pb.UnlockUserRequest{ActorId: 1, UserId: 7, Reason: "verified by phone"})).
+		Return(&pb.UnlockUserResponse{User: &pb.UserAccount{
+			Id:        7,
+			CreatedAt: timestamppb.New(TestTime),
+		}}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.UnlockUserViagRPC(context.Background(), services.UnlockUserRequest{UserID: 7, Reason: "verified by phone"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, int64(7), rsp.User.ID)
+
+	mockCalls.EXPECT().
+		UnlockUser(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.PermissionDenied, "not allowed to user.unlock")).
+		Times(1)
+
+	statusCode, rsp = g.client.UnlockUserViagRPC(context.Background(), services.UnlockUserRequest{UserID: 7, Reason: "verified by phone"}, 2)
+	require.Equal(t, http.StatusForbidden, statusCode)
+	require.Equal(t, "not allowed to user.unlock", rsp.Message)
+}
+
+func TestGrpcClient_RecordAuditEventViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	req := services.RecordAuditEventRequest{Action: services.ActionTransactionsView, TargetUserID: 7, Reason: "ticket 42"}
+
+	mockCalls.EXPECT().
+		RecordAuditEvent(gomock.Any(), gomock.Eq(&pb.RecordAuditEventRequest{
+			ActorId:      2,
+			Action:       services.ActionTransactionsView,
+			TargetUserId: 7,
+			Reason:       "ticket 42",
+		})).
+		Return(&pb.RecordAuditEventResponse{Id: 3}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.RecordAuditEventViagRPC(context.Background(), req, 2)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.RecordAuditEventResponse{ID: 3}, rsp)
+}
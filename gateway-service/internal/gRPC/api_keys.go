@@ -0,0 +1,167 @@
+package gRPC
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (g *GrpcClient) CreateAPIKeyViagRPC(
+	ctx context.Context,
+	req services.CreateAPIKeyRequest,
+	userID int64,
+) (int, services.CreateAPIKeyResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	pbReq := &pb.CreateAPIKeyRequest{
+		UserId:     userID,
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		AllowedIps: req.AllowedIPs,
+	}
+
+	if req.ExpiresAt != nil {
+		pbReq.ExpiresAt = timestamppb.New(*req.ExpiresAt)
+	}
+
+	rsp, err := g.authgRPClient.CreateAPIKey(c, pbReq)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.CreateAPIKeyResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.CreateAPIKeyResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	apiKey := apiKeyFromPb(rsp.GetApiKey())
+
+	return http.StatusOK, services.CreateAPIKeyResponse{Key: rsp.GetKey(), APIKey: &apiKey}
+}
+
+func (g *GrpcClient) ListAPIKeysViagRPC(ctx context.Context, userID int64) (int, services.ListAPIKeysResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.ListAPIKeys(c, &pb.ListAPIKeysRequest{UserId: userID})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.ListAPIKeysResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ListAPIKeysResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	apiKeys := make([]services.APIKey, 0, len(rsp.GetApiKeys()))
+	for _, apiKey := range rsp.GetApiKeys() {
+		apiKeys = append(apiKeys, apiKeyFromPb(apiKey))
+	}
+
+	return http.StatusOK, services.ListAPIKeysResponse{APIKeys: apiKeys}
+}
+
+func (g *GrpcClient) RevokeAPIKeyViagRPC(
+	ctx context.Context,
+	req services.RevokeAPIKeyRequest,
+	userID int64,
+) (int, services.RevokeAPIKeyResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	_, err := g.authgRPClient.RevokeAPIKey(c, &pb.RevokeAPIKeyRequest{UserId: userID, Id: req.ID})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.RevokeAPIKeyResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.RevokeAPIKeyResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.RevokeAPIKeyResponse{Message: "api key revoked"}
+}
+
+// VerifyAPIKey asks the authentication service for the API key a client authenticated
+// with. Keys the service does not accept fail with pkg.ErrInvalidAPIKey.
+func (g *GrpcClient) VerifyAPIKey(ctx context.Context, key string) (apikeys.Key, error) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.VerifyAPIKey(c, &pb.VerifyAPIKeyRequest{Key: key})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return apikeys.Key{}, fmt.Errorf("%w: %s", pkg.ErrInvalidAPIKey, status.Convert(err).Message())
+		}
+
+		return apikeys.Key{}, err
+	}
+
+	apiKey := rsp.GetApiKey()
+
+	k := apikeys.Key{
+		ID:     apiKey.GetId(),
+		UserID: apiKey.GetUserId(),
+		Scopes: apiKey.GetScopes(),
+	}
+
+	for _, value := range apiKey.GetAllowedIps() {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			// a restriction that can not be enforced fails closed
+			return apikeys.Key{}, fmt.Errorf("invalid allowed ip %q of api key %d: %w", value, k.ID, err)
+		}
+
+		k.AllowedIPs = append(k.AllowedIPs, prefix)
+	}
+
+	if apiKey.GetExpiresAt() != nil {
+		k.ExpiresAt = apiKey.GetExpiresAt().AsTime()
+	}
+
+	return k, nil
+}
+
+func apiKeyFromPb(apiKey *pb.APIKey) services.APIKey {
+	return services.APIKey{
+		ID:         apiKey.GetId(),
+		Name:       apiKey.GetName(),
+		Prefix:     apiKey.GetPrefix(),
+		Scopes:     apiKey.GetScopes(),
+		AllowedIPs: apiKey.GetAllowedIps(),
+		ExpiresAt:  timeOrNil(apiKey.GetExpiresAt()),
+		LastUsedAt: timeOrNil(apiKey.GetLastUsedAt()),
+		CreatedAt:  apiKey.GetCreatedAt().AsTime(),
+	}
+}
+
+func timeOrNil(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	value := t.AsTime()
+
+	return &value
+}
@@ -0,0 +1,169 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGrpcClient_CreateAPIKeyViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	expiresAt := TestTime.AddDate(1, 0, 0)
+
+	req := services.CreateAPIKeyRequest{
+		Name:       "backend",
+		Scopes:     []string{pkg.ScopePaymentsInitiate},
+		AllowedIPs: []string{"10.0.0.0/8"},
+		ExpiresAt:  &expiresAt,
+	}
+
+	mockCalls.EXPECT().
+		CreateAPIKey(gomock.Any(), gomock.Eq(&pb.CreateAPIKeyRequest{
+			UserId:     1,
+			Name:       req.Name,
+			Scopes:     req.Scopes,
+			AllowedIps: req.AllowedIPs,
+			ExpiresAt:  timestamppb.New(expiresAt),
+		})).
+		Return(&pb.CreateAPIKeyResponse{
+			Key: "ppk_key",
+			ApiKey: &pb.APIKey{
+				Id:         2,
+				UserId:     1,
+				Name:       req.Name,
+				Prefix:     "ppk_abcdefgh",
+				Scopes:     req.Scopes,
+				AllowedIps: req.AllowedIPs,
+				ExpiresAt:  timestamppb.New(expiresAt),
+				CreatedAt:  timestamppb.New(TestTime),
+			},
+		}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.CreateAPIKeyViagRPC(context.Background(), req, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.CreateAPIKeyResponse{
+		Key: "ppk_key",
+		APIKey: &services.APIKey{
+			ID:         2,
+			Name:       req.Name,
+			Prefix:     "ppk_abcdefgh",
+			Scopes:     req.Scopes,
+			AllowedIPs: req.AllowedIPs,
+			ExpiresAt:  &expiresAt,
+			CreatedAt:  TestTime,
+		},
+	}, rsp)
+
+	mockCalls.EXPECT().
+		CreateAPIKey(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.InvalidArgument, "unknown scope")).
+		Times(1)
+
+	statusCode, rsp = g.client.CreateAPIKeyViagRPC(context.Background(), req, 1)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "unknown scope", rsp.Message)
+}
+
+func TestGrpcClient_RevokeAPIKeyViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		RevokeAPIKey(gomock.Any(), gomock.Eq(&pb.RevokeAPIKeyRequest{UserId: 1, Id: 2})).
+		Return(&pb.RevokeAPIKeyResponse{}, nil).
+		Times(1)
+
+	statusCode, _ := g.client.RevokeAPIKeyViagRPC(context.Background(), services.RevokeAPIKeyRequest{ID: 2}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	mockCalls.EXPECT().
+		RevokeAPIKey(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.NotFound, "api key not found")).
+		Times(1)
+
+	statusCode, rsp := g.client.RevokeAPIKeyViagRPC(context.Background(), services.RevokeAPIKeyRequest{ID: 2}, 1)
+	require.Equal(t, http.StatusNotFound, statusCode)
+	require.Equal(t, "api key not found", rsp.Message)
+}
+
+func TestGrpcClient_VerifyAPIKey(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		VerifyAPIKey(gomock.Any(), gomock.Eq(&pb.VerifyAPIKeyRequest{Key: "ppk_key"})).
+		Return(&pb.VerifyAPIKeyResponse{ApiKey: &pb.APIKey{
+			Id:         2,
+			UserId:     1,
+			Scopes:     []string{pkg.ScopePaymentsRead},
+			AllowedIps: []string{"10.0.0.0/8"},
+			ExpiresAt:  timestamppb.New(TestTime),
+		}}, nil).
+		Times(1)
+
+	key, err := g.client.VerifyAPIKey(context.Background(), "ppk_key")
+	require.NoError(t, err)
+	require.Equal(t, apikeys.Key{
+		ID:         2,
+		UserID:     1,
+		Scopes:     []string{pkg.ScopePaymentsRead},
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		ExpiresAt:  TestTime,
+	}, key)
+
+	mockCalls.EXPECT().
+		VerifyAPIKey(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unauthenticated, "invalid api key")).
+		Times(1)
+
+	_, err = g.client.VerifyAPIKey(context.Background(), "ppk_key")
+	require.ErrorIs(t, err, pkg.ErrInvalidAPIKey)
+
+	mockCalls.EXPECT().
+		VerifyAPIKey(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unavailable, "connection refused")).
+		Times(1)
+
+	_, err = g.client.VerifyAPIKey(context.Background(), "ppk_key")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, pkg.ErrInvalidAPIKey)
+
+	// restrictions the gateway can not read are not ignored
+	mockCalls.EXPECT().
+		VerifyAPIKey(gomock.Any(), gomock.Any()).
+		Return(&pb.VerifyAPIKeyResponse{ApiKey: &pb.APIKey{Id: 2, AllowedIps: []string{"not an address"}}}, nil).
+		Times(1)
+
+	_, err = g.client.VerifyAPIKey(context.Background(), "ppk_key")
+	require.Error(t, err)
+}
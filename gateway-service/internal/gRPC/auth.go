@@ -3,15 +3,16 @@ package gRPC
 import (
 	"context"
 	"net/http"
-	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/jwks"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"google.golang.org/grpc/status"
 )
 
-func (g *GrpcClient) RegisterUserViagRPC(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
-	c, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func (g *GrpcClient) RegisterUserViagRPC(ctx context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
 	defer cancel()
 
 	rsp, err := g.authgRPClient.RegisterUser(c, &pb.RegisterUserRequest{
@@ -42,13 +43,14 @@ func (g *GrpcClient) RegisterUserViagRPC(req services.RegisterUserRequest) (int,
 	}
 }
 
-func (g *GrpcClient) LoginUserViagRPC(req services.LoginUserRequest) (int, services.LoginUserResponse) {
-	c, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func (g *GrpcClient) LoginUserViagRPC(ctx context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
 	defer cancel()
 
 	rsp, err := g.authgRPClient.LoginUser(c, &pb.LoginUserRequest{
 		Email:    req.Email,
 		Password: req.Password,
+		ClientIp: req.ClientIP,
 	})
 	if err != nil {
 		st, ok := status.FromError(err)
@@ -62,11 +64,205 @@ func (g *GrpcClient) LoginUserViagRPC(req services.LoginUserRequest) (int, servi
 		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	return http.StatusOK, services.LoginUserResponse{
-		AccessToken:  rsp.GetAccessToken(),
-		FullName:     rsp.GetData().GetFullname(),
-		Email:        rsp.GetData().GetEmail(),
-		ExpirationAt: rsp.GetExpirationAt().AsTime(),
-		CreatedAt:    rsp.GetData().GetCreatedAt().AsTime(),
+	return http.StatusOK, loginResponseFromPb(rsp)
+}
+
+// loginResponseFromPb maps the response of a login, or of the second step of one, which
+// carries either the tokens of the user or the challenge of their second factor.
+func loginResponseFromPb(rsp *pb.LoginUserResponse) services.LoginUserResponse {
+	loginRsp := services.LoginUserResponse{
+		FullName:  rsp.GetData().GetFullname(),
+		Email:     rsp.GetData().GetEmail(),
+		CreatedAt: rsp.GetData().GetCreatedAt().AsTime(),
+	}
+
+	if rsp.GetMfaRequired() {
+		loginRsp.MFARequired = true
+		loginRsp.MFAToken = rsp.GetMfaToken()
+		loginRsp.MFAExpirationAt = rsp.GetMfaExpirationAt().AsTime()
+
+		return loginRsp
+	}
+
+	loginRsp.AccessToken = rsp.GetAccessToken()
+	loginRsp.ExpirationAt = rsp.GetExpirationAt().AsTime()
+	loginRsp.RefreshToken = rsp.GetRefreshToken()
+
+	// left out by auth services that do not issue refresh tokens yet
+	if rsp.GetRefreshExpirationAt() != nil {
+		loginRsp.RefreshExpirationAt = rsp.GetRefreshExpirationAt().AsTime()
+	}
+
+	return loginRsp
+}
+
+func (g *GrpcClient) RefreshTokenViagRPC(ctx context.Context, req services.RefreshTokenRequest) (int, services.RefreshTokenResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.RefreshToken(c, &pb.RefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.RefreshTokenResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.RefreshTokenResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.RefreshTokenResponse{
+		AccessToken:         rsp.GetAccessToken(),
+		ExpirationAt:        rsp.GetExpirationAt().AsTime(),
+		RefreshToken:        rsp.GetRefreshToken(),
+		RefreshExpirationAt: rsp.GetRefreshExpirationAt().AsTime(),
+	}
+}
+
+// RevokeRefreshTokensViagRPC revokes the refresh token of the request, or every refresh
+// token of the user when the request has none.
+func (g *GrpcClient) RevokeRefreshTokensViagRPC(
+	ctx context.Context,
+	req services.LogoutRequest,
+	userID int64,
+) (int, services.LogoutResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	_, err := g.authgRPClient.RevokeRefreshTokens(c, &pb.RevokeRefreshTokensRequest{
+		UserId:       userID,
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.LogoutResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.LogoutResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.LogoutResponse{}
+}
+
+func (g *GrpcClient) VerifyEmailViagRPC(ctx context.Context, req services.VerifyEmailRequest) (int, services.VerifyEmailResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.VerifyEmail(c, &pb.VerifyEmailRequest{Token: req.Token})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.VerifyEmailResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.VerifyEmailResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.VerifyEmailResponse{
+		Email:      rsp.GetEmail(),
+		VerifiedAt: rsp.GetVerifiedAt().AsTime(),
+	}
+}
+
+func (g *GrpcClient) ResendVerificationEmailViagRPC(
+	ctx context.Context,
+	req services.ResendVerificationEmailRequest,
+) (int, services.ResendVerificationEmailResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	_, err := g.authgRPClient.ResendVerificationEmail(c, &pb.ResendVerificationEmailRequest{Email: req.Email})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.ResendVerificationEmailResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ResendVerificationEmailResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.ResendVerificationEmailResponse{}
+}
+
+func (g *GrpcClient) ForgotPasswordViagRPC(ctx context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	_, err := g.authgRPClient.ForgotPassword(c, &pb.ForgotPasswordRequest{Email: req.Email})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.ForgotPasswordResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
+
+	return http.StatusOK, services.ForgotPasswordResponse{}
+}
+
+func (g *GrpcClient) ResetPasswordViagRPC(ctx context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.ResetPassword(c, &pb.ResetPasswordRequest{Token: req.Token, Password: req.Password})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.ResetPasswordResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.ResetPasswordResponse{
+		UserID: rsp.GetUserId(),
+		Email:  rsp.GetEmail(),
+	}
+}
+
+// FetchJWKS fetches the public keys the authentication service signs access tokens with.
+func (g *GrpcClient) FetchJWKS(ctx context.Context) (jwks.Set, error) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.GetJWKS(c, &pb.GetJWKSRequest{})
+	if err != nil {
+		return jwks.Set{}, err
+	}
+
+	set := jwks.Set{Keys: make([]jwks.Key, 0, len(rsp.GetKeys()))}
+
+	for _, key := range rsp.GetKeys() {
+		set.Keys = append(set.Keys, jwks.Key{
+			KeyID:     key.GetKid(),
+			KeyType:   key.GetKty(),
+			Algorithm: key.GetAlg(),
+			Use:       key.GetUse(),
+			N:         key.GetN(),
+			E:         key.GetE(),
+		})
+	}
+
+	return set, nil
 }
@@ -1,13 +1,15 @@
 package gRPC
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/jwks"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
-	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mock"
+	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"github.com/brianvoe/gofakeit"
 	"github.com/stretchr/testify/require"
@@ -87,7 +89,7 @@ func TestGrpcClient_RegisterUserViagRPC(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.buildStubs(mockCalls)
 
-			statusCode, msg := g.client.RegisterUserViagRPC(req)
+			statusCode, msg := g.client.RegisterUserViagRPC(context.Background(), req)
 			require.Equal(t, statusCode, tc.wantStatusCode)
 
 			if !tc.wantErr {
@@ -111,6 +113,7 @@ func TestGrpcClient_LoginUserViagRPC(t *testing.T) {
 	req := services.LoginUserRequest{
 		Email:    gofakeit.Email(),
 		Password: gofakeit.Password(true, true, true, true, true, 7),
+		ClientIP: gofakeit.IPv4Address(),
 	}
 
 	grpcRsp := &pb.LoginUserResponse{
@@ -121,14 +124,18 @@ func TestGrpcClient_LoginUserViagRPC(t *testing.T) {
 			Email:     req.Email,
 			CreatedAt: timestamppb.New(TestTime),
 		},
+		RefreshToken:        "refresh-token",
+		RefreshExpirationAt: timestamppb.New(TestTime.Add(time.Hour)),
 	}
 
 	loginRsp := services.LoginUserResponse{
-		AccessToken:  "token",
-		ExpirationAt: TestTime,
-		FullName:     grpcRsp.Data.GetFullname(),
-		Email:        req.Email,
-		CreatedAt:    TestTime,
+		AccessToken:         "token",
+		ExpirationAt:        TestTime,
+		RefreshToken:        "refresh-token",
+		RefreshExpirationAt: TestTime.Add(time.Hour),
+		FullName:            grpcRsp.Data.GetFullname(),
+		Email:               req.Email,
+		CreatedAt:           TestTime,
 	}
 
 	tests := []struct {
@@ -180,7 +187,7 @@ func TestGrpcClient_LoginUserViagRPC(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.buildStubs(mockCalls)
 
-			statusCode, msg := g.client.LoginUserViagRPC(req)
+			statusCode, msg := g.client.LoginUserViagRPC(context.Background(), req)
 			require.Equal(t, statusCode, tc.wantStatusCode)
 
 			if !tc.wantErr {
@@ -192,6 +199,246 @@ func TestGrpcClient_LoginUserViagRPC(t *testing.T) {
 	}
 }
 
+func TestGrpcClient_RefreshTokenViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	req := services.RefreshTokenRequest{RefreshToken: "refresh-token"}
+
+	tests := []struct {
+		name           string
+		buildStubs     func(*grpcmock.MockAuthenticationServiceClient)
+		want           services.RefreshTokenResponse
+		wantStatusCode int
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockCalls *grpcmock.MockAuthenticationServiceClient) {
+				mockCalls.EXPECT().
+					RefreshToken(gomock.Any(), gomock.Eq(&pb.RefreshTokenRequest{RefreshToken: req.RefreshToken})).
+					Return(&pb.RefreshTokenResponse{
+						AccessToken:         "token",
+						ExpirationAt:        timestamppb.New(TestTime),
+						RefreshToken:        "rotated",
+						RefreshExpirationAt: timestamppb.New(TestTime.Add(time.Hour)),
+					}, nil).
+					Times(1)
+			},
+			want: services.RefreshTokenResponse{
+				AccessToken:         "token",
+				ExpirationAt:        TestTime,
+				RefreshToken:        "rotated",
+				RefreshExpirationAt: TestTime.Add(time.Hour),
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "reused token",
+			buildStubs: func(mockCalls *grpcmock.MockAuthenticationServiceClient) {
+				mockCalls.EXPECT().
+					RefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil, status.Errorf(codes.Unauthenticated, "refresh token reuse detected")).
+					Times(1)
+			},
+			want: services.RefreshTokenResponse{
+				Message:    "refresh token reuse detected",
+				StatusCode: http.StatusUnauthorized,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockCalls)
+
+			statusCode, rsp := g.client.RefreshTokenViagRPC(context.Background(), req)
+			require.Equal(t, tc.wantStatusCode, statusCode)
+			require.Equal(t, tc.want, rsp)
+		})
+	}
+}
+
+func TestGrpcClient_RevokeRefreshTokensViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		RevokeRefreshTokens(gomock.Any(), gomock.Eq(&pb.RevokeRefreshTokensRequest{UserId: 7, RefreshToken: "refresh-token"})).
+		Return(&pb.RevokeRefreshTokensResponse{}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.RevokeRefreshTokensViagRPC(context.Background(), services.LogoutRequest{RefreshToken: "refresh-token"}, 7)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.LogoutResponse{}, rsp)
+
+	mockCalls.EXPECT().
+		RevokeRefreshTokens(gomock.Any(), gomock.Eq(&pb.RevokeRefreshTokensRequest{UserId: 7})).
+		Return(nil, status.Errorf(codes.Internal, "error on revoke refresh tokens: db error")).
+		Times(1)
+
+	statusCode, rsp = g.client.RevokeRefreshTokensViagRPC(context.Background(), services.LogoutRequest{}, 7)
+	require.Equal(t, http.StatusInternalServerError, statusCode)
+	require.Equal(t, "error on revoke refresh tokens: db error", rsp.Message)
+}
+
+func TestGrpcClient_VerifyEmailViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		VerifyEmail(gomock.Any(), gomock.Eq(&pb.VerifyEmailRequest{Token: "valid"})).
+		Return(&pb.VerifyEmailResponse{Email: "jane@gmail.com", VerifiedAt: timestamppb.New(TestTime)}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.VerifyEmailViagRPC(context.Background(), services.VerifyEmailRequest{Token: "valid"})
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.VerifyEmailResponse{Email: "jane@gmail.com", VerifiedAt: TestTime}, rsp)
+
+	mockCalls.EXPECT().
+		VerifyEmail(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")).
+		Times(1)
+
+	statusCode, rsp = g.client.VerifyEmailViagRPC(context.Background(), services.VerifyEmailRequest{Token: "expired"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "invalid or expired verification token", rsp.Message)
+}
+
+func TestGrpcClient_ResendVerificationEmailViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		ResendVerificationEmail(gomock.Any(), gomock.Eq(&pb.ResendVerificationEmailRequest{Email: "jane@gmail.com"})).
+		Return(&pb.ResendVerificationEmailResponse{}, nil).
+		Times(1)
+
+	req := services.ResendVerificationEmailRequest{Email: "jane@gmail.com"}
+
+	statusCode, rsp := g.client.ResendVerificationEmailViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ResendVerificationEmailResponse{}, rsp)
+
+	mockCalls.EXPECT().
+		ResendVerificationEmail(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unavailable, "connection refused")).
+		Times(1)
+
+	statusCode, _ = g.client.ResendVerificationEmailViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+}
+
+func TestGrpcClient_ForgotPasswordViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		ForgotPassword(gomock.Any(), gomock.Eq(&pb.ForgotPasswordRequest{Email: "jane@gmail.com"})).
+		Return(&pb.ForgotPasswordResponse{}, nil).
+		Times(1)
+
+	req := services.ForgotPasswordRequest{Email: "jane@gmail.com"}
+
+	statusCode, rsp := g.client.ForgotPasswordViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ForgotPasswordResponse{}, rsp)
+
+	mockCalls.EXPECT().
+		ForgotPassword(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unavailable, "connection refused")).
+		Times(1)
+
+	statusCode, _ = g.client.ForgotPasswordViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+}
+
+func TestGrpcClient_ResetPasswordViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		ResetPassword(gomock.Any(), gomock.Eq(&pb.ResetPasswordRequest{Token: "valid", Password: "new password"})).
+		Return(&pb.ResetPasswordResponse{UserId: 1, Email: "jane@gmail.com"}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.ResetPasswordViagRPC(context.Background(), services.ResetPasswordRequest{Token: "valid", Password: "new password"})
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ResetPasswordResponse{UserID: 1, Email: "jane@gmail.com"}, rsp)
+
+	mockCalls.EXPECT().
+		ResetPassword(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.InvalidArgument, "invalid or expired reset token")).
+		Times(1)
+
+	statusCode, rsp = g.client.ResetPasswordViagRPC(context.Background(), services.ResetPasswordRequest{Token: "used", Password: "new password"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "invalid or expired reset token", rsp.Message)
+}
+
+func TestGrpcClient_FetchJWKS(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		GetJWKS(gomock.Any(), gomock.Any()).
+		Return(&pb.GetJWKSResponse{Keys: []*pb.JSONWebKey{
+			{Kid: "key-2", Kty: "RSA", Alg: "RS256", Use: "sig", N: "bW9kdWx1cw", E: "AQAB"},
+			{Kid: "key-1", Kty: "RSA", Alg: "RS256", Use: "sig", N: "b2xk", E: "AQAB"},
+		}}, nil).
+		Times(1)
+
+	set, err := g.client.FetchJWKS(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, jwks.Set{Keys: []jwks.Key{
+		{KeyID: "key-2", KeyType: "RSA", Algorithm: "RS256", Use: "sig", N: "bW9kdWx1cw", E: "AQAB"},
+		{KeyID: "key-1", KeyType: "RSA", Algorithm: "RS256", Use: "sig", N: "b2xk", E: "AQAB"},
+	}}, set)
+
+	mockCalls.EXPECT().
+		GetJWKS(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unavailable, "connection refused")).
+		Times(1)
+
+	_, err = g.client.FetchJWKS(context.Background())
+	require.Error(t, err)
+}
+
 func randomUser() services.RegisterUserRequest {
 	return services.RegisterUserRequest{
 		FullName:       gofakeit.Name(),
@@ -220,5 +467,6 @@ func loginRequestToPb(req services.LoginUserRequest) *pb.LoginUserRequest {
 	return &pb.LoginUserRequest{
 		Email:    req.Email,
 		Password: req.Password,
+		ClientIp: req.ClientIP,
 	}
 }
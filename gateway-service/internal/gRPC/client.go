@@ -1,13 +1,19 @@
 package gRPC
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 var _ services.GrpcInterface = (*GrpcClient)(nil)
@@ -16,8 +22,11 @@ var _ services.GrpcInterface = (*GrpcClient)(nil)
 type GrpcDialFunc func(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
 
 type GrpcClient struct {
-	authgRPClient pb.AuthenticationServiceClient
-	dialFunc      GrpcDialFunc
+	conn              *grpc.ClientConn
+	paymentsConn      *grpc.ClientConn
+	authgRPClient     pb.AuthenticationServiceClient
+	paymentsgRPClient pb.PaymentsServiceClient
+	dialFunc          GrpcDialFunc
 }
 
 func NewGrpcService() *GrpcClient {
@@ -27,28 +36,97 @@ func NewGrpcService() *GrpcClient {
 }
 
 func (g *GrpcClient) Start(grpcPort string) error {
-	gRPCconn, err := g.dialFunc(grpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	gRPCconn, err := g.dial(grpcPort)
 	if err != nil {
 		return err
 	}
 
+	g.conn = gRPCconn
 	g.authgRPClient = pb.NewAuthenticationServiceClient(gRPCconn)
 
 	return nil
 }
 
+// StartPayments opens the connection to the payments service.
+func (g *GrpcClient) StartPayments(grpcPort string) error {
+	gRPCconn, err := g.dial(grpcPort)
+	if err != nil {
+		return err
+	}
+
+	g.paymentsConn = gRPCconn
+	g.paymentsgRPClient = pb.NewPaymentsServiceClient(gRPCconn)
+
+	return nil
+}
+
+// Conn returns the connection to the authentication service opened by Start.
+func (g *GrpcClient) Conn() *grpc.ClientConn {
+	return g.conn
+}
+
+// PaymentsConn returns the connection to the payments service opened by StartPayments.
+func (g *GrpcClient) PaymentsConn() *grpc.ClientConn {
+	return g.paymentsConn
+}
+
+func (g *GrpcClient) dial(target string) (*grpc.ClientConn, error) {
+	return g.dialFunc(
+		target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(
+			logging.UnaryClientInterceptor(),
+			tracing.UnaryClientInterceptor(),
+			metrics.UnaryClientInterceptor(),
+			sentInterceptor(),
+		),
+	)
+}
+
+// sentInterceptor tells a call that failed as Unavailable before it got a connection,
+// which routing may send over another transport, from one whose connection went away
+// after it was sent. The service may have acted on the latter, so it is reported as
+// DeadlineExceeded, which gRPC allows for calls that may have completed.
+func sentInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		var p peer.Peer
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+		if status.Code(err) == codes.Unavailable && p.Addr != nil {
+			return status.Errorf(codes.DeadlineExceeded, "connection lost waiting for response: %s", status.Convert(err).Message())
+		}
+
+		return err
+	}
+}
+
 func grpcCodeConvert(code codes.Code) int {
 	switch code {
 	case codes.Internal:
 		return http.StatusInternalServerError
 	case codes.NotFound:
 		return http.StatusNotFound
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
 	case codes.AlreadyExists:
 		return http.StatusForbidden
 	case codes.Unauthenticated:
 		return http.StatusUnauthorized
 	case codes.PermissionDenied:
 		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
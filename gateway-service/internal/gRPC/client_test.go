@@ -1,13 +1,19 @@
 package gRPC
 
 import (
+	"context"
 	"errors"
+	"net"
 	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 type TestGrpcClient struct {
@@ -57,6 +63,11 @@ func TestGrpcClient_Start(t *testing.T) {
 			if (err != nil) != tc.wantErr {
 				t.Errorf("Start() error = %v, wantErr %v", err, tc.wantErr)
 			}
+
+			err = g.client.StartPayments(tc.port)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("StartPayments() error = %v, wantErr %v", err, tc.wantErr)
+			}
 		})
 	}
 }
@@ -92,9 +103,29 @@ func TestGrpcClient_grpcCodeConvert(t *testing.T) {
 			code:       codes.PermissionDenied,
 			statusCode: http.StatusForbidden,
 		},
+		{
+			name:       "invalid argument",
+			code:       codes.InvalidArgument,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unavailable",
+			code:       codes.Unavailable,
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "deadline exceeded",
+			code:       codes.DeadlineExceeded,
+			statusCode: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "resource exhausted",
+			code:       codes.ResourceExhausted,
+			statusCode: http.StatusTooManyRequests,
+		},
 		{
 			name:       "system error",
-			code:       codes.ResourceExhausted, // any code
+			code:       codes.Aborted, // any code
 			statusCode: http.StatusInternalServerError,
 		},
 	}
@@ -106,3 +137,54 @@ func TestGrpcClient_grpcCodeConvert(t *testing.T) {
 		})
 	}
 }
+
+// unavailableHealthServer answers every check as if the connection went away mid-call.
+type unavailableHealthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (unavailableHealthServer) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return nil, status.Error(codes.Unavailable, "transport is closing")
+}
+
+func TestSentInterceptor(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, unavailableHealthServer{})
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	dial := func(dialer func(context.Context, string) (net.Conn, error)) healthpb.HealthClient {
+		conn, err := grpc.NewClient(
+			"passthrough:///bufnet",
+			grpc.WithContextDialer(dialer),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(sentInterceptor()),
+		)
+		require.NoError(t, err)
+
+		t.Cleanup(func() { conn.Close() })
+
+		return healthpb.NewHealthClient(conn)
+	}
+
+	// the call was sent, the service may have acted on it
+	client := dial(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// the call never got a connection
+	client = dial(func(context.Context, string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,111 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc/status"
+)
+
+func (g *GrpcClient) VerifyMFAViagRPC(ctx context.Context, req services.VerifyMFARequest) (int, services.LoginUserResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.VerifyMFA(c, &pb.VerifyMFARequest{
+		MfaToken: req.MFAToken,
+		Code:     req.Code,
+		ClientIp: req.ClientIP,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.LoginUserResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, loginResponseFromPb(rsp)
+}
+
+func (g *GrpcClient) EnrollMFAViagRPC(
+	ctx context.Context,
+	req services.EnrollMFARequest,
+	userID int64,
+) (int, services.EnrollMFAResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.EnrollMFA(c, &pb.EnrollMFARequest{UserId: userID, Password: req.Password})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.EnrollMFAResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.EnrollMFAResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.EnrollMFAResponse{
+		Secret:        rsp.GetSecret(),
+		OtpauthURI:    rsp.GetOtpauthUri(),
+		RecoveryCodes: rsp.GetRecoveryCodes(),
+	}
+}
+
+func (g *GrpcClient) ActivateMFAViagRPC(
+	ctx context.Context,
+	req services.ActivateMFARequest,
+	userID int64,
+) (int, services.ActivateMFAResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.authgRPClient.ActivateMFA(c, &pb.ActivateMFARequest{UserId: userID, Code: req.Code})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.ActivateMFAResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ActivateMFAResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.ActivateMFAResponse{EnabledAt: rsp.GetEnabledAt().AsTime()}
+}
+
+func (g *GrpcClient) DisableMFAViagRPC(
+	ctx context.Context,
+	req services.DisableMFARequest,
+	userID int64,
+) (int, services.DisableMFAResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	_, err := g.authgRPClient.DisableMFA(c, &pb.DisableMFARequest{UserId: userID, Code: req.Code})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+			grpcMessage := st.Message()
+
+			return code, services.DisableMFAResponse{Message: grpcMessage, StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.DisableMFAResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, services.DisableMFAResponse{Message: "two-factor authentication disabled"}
+}
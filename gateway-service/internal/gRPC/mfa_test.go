@@ -0,0 +1,174 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGrpcClient_LoginUserViagRPCMFA(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		LoginUser(gomock.Any(), gomock.Any()).
+		Return(&pb.LoginUserResponse{
+			Data: &pb.RegisterUserResponse{
+				Fullname:  "Jane Doe",
+				Email:     "jane@gmail.com",
+				CreatedAt: timestamppb.New(TestTime),
+			},
+			MfaRequired:     true,
+			MfaToken:        "mfa-token",
+			MfaExpirationAt: timestamppb.New(TestTime),
+		}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.LoginUserViagRPC(context.Background(), services.LoginUserRequest{Email: "jane@gmail.com", Password: "password"})
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.LoginUserResponse{
+		FullName:        "Jane Doe",
+		Email:           "jane@gmail.com",
+		CreatedAt:       TestTime,
+		MFARequired:     true,
+		MFAToken:        "mfa-token",
+		MFAExpirationAt: TestTime,
+	}, rsp)
+}
+
+func TestGrpcClient_VerifyMFAViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	req := services.VerifyMFARequest{MFAToken: "mfa-token", Code: "123456", ClientIP: "192.0.2.1"}
+
+	mockCalls.EXPECT().
+		VerifyMFA(gomock.Any(), gomock.Eq(&pb.VerifyMFARequest{MfaToken: "mfa-token", Code: "123456", ClientIp: "192.0.2.1"})).
+		Return(&pb.LoginUserResponse{
+			AccessToken:  "token",
+			ExpirationAt: timestamppb.New(TestTime),
+			Data: &pb.RegisterUserResponse{
+				Email:     "jane@gmail.com",
+				CreatedAt: timestamppb.New(TestTime),
+			},
+			RefreshToken:        "refresh-token",
+			RefreshExpirationAt: timestamppb.New(TestTime),
+		}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.VerifyMFAViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, "token", rsp.AccessToken)
+	require.Equal(t, "refresh-token", rsp.RefreshToken)
+	require.False(t, rsp.MFARequired)
+
+	mockCalls.EXPECT().
+		VerifyMFA(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.Unauthenticated, "invalid code")).
+		Times(1)
+
+	statusCode, rsp = g.client.VerifyMFAViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusUnauthorized, statusCode)
+	require.Equal(t, "invalid code", rsp.Message)
+}
+
+func TestGrpcClient_EnrollMFAViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		EnrollMFA(gomock.Any(), gomock.Eq(&pb.EnrollMFARequest{UserId: 1, Password: "password"})).
+		Return(&pb.EnrollMFAResponse{
+			Secret:        "JBSWY3DPEHPK3PXP",
+			OtpauthUri:    "otpauth://totp/jane@gmail.com?secret=JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"abcde-fghij"},
+		}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.EnrollMFAViagRPC(context.Background(), services.EnrollMFARequest{Password: "password"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.EnrollMFAResponse{
+		Secret:        "JBSWY3DPEHPK3PXP",
+		OtpauthURI:    "otpauth://totp/jane@gmail.com?secret=JBSWY3DPEHPK3PXP",
+		RecoveryCodes: []string{"abcde-fghij"},
+	}, rsp)
+
+	mockCalls.EXPECT().
+		EnrollMFA(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.AlreadyExists, "two-factor authentication is already enabled")).
+		Times(1)
+
+	statusCode, rsp = g.client.EnrollMFAViagRPC(context.Background(), services.EnrollMFARequest{Password: "password"}, 1)
+	require.Equal(t, http.StatusForbidden, statusCode)
+	require.Equal(t, "two-factor authentication is already enabled", rsp.Message)
+}
+
+func TestGrpcClient_ActivateMFAViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		ActivateMFA(gomock.Any(), gomock.Eq(&pb.ActivateMFARequest{UserId: 1, Code: "123456"})).
+		Return(&pb.ActivateMFAResponse{EnabledAt: timestamppb.New(TestTime)}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.ActivateMFAViagRPC(context.Background(), services.ActivateMFARequest{Code: "123456"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ActivateMFAResponse{EnabledAt: TestTime}, rsp)
+}
+
+func TestGrpcClient_DisableMFAViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockAuthenticationServiceClient(ctrl)
+
+	g.client.authgRPClient = mockCalls
+
+	mockCalls.EXPECT().
+		DisableMFA(gomock.Any(), gomock.Eq(&pb.DisableMFARequest{UserId: 1, Code: "abcde-fghij"})).
+		Return(&pb.DisableMFAResponse{}, nil).
+		Times(1)
+
+	statusCode, _ := g.client.DisableMFAViagRPC(context.Background(), services.DisableMFARequest{Code: "abcde-fghij"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	mockCalls.EXPECT().
+		DisableMFA(gomock.Any(), gomock.Any()).
+		Return(nil, status.Errorf(codes.InvalidArgument, "invalid code")).
+		Times(1)
+
+	statusCode, rsp := g.client.DisableMFAViagRPC(context.Background(), services.DisableMFARequest{Code: "000000"}, 1)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "invalid code", rsp.Message)
+}
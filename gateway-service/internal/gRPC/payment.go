@@ -0,0 +1,168 @@
+package gRPC
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+)
+
+func (g *GrpcClient) InitiatePaymentViagRPC(
+	ctx context.Context,
+	req services.InitiatePaymentRequest,
+	userID int64,
+) (int, services.InitiatePaymentResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.paymentsgRPClient.InitiatePayment(c, &pb.InitiatePaymentRequest{
+		Email:       req.Email,
+		Action:      req.Action,
+		Amount:      req.Amount,
+		PhoneNumber: req.PhoneNumber,
+		NetworkCode: req.NetworkCode,
+		Narration:   req.Naration,
+		UserId:      userID,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+
+			return code, services.InitiatePaymentResponse{Message: st.Message(), StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.InitiatePaymentResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, initiatePaymentResponseFromPb(rsp)
+}
+
+func (g *GrpcClient) ConfirmWithdrawalViagRPC(
+	ctx context.Context,
+	req services.ConfirmWithdrawalRequest,
+	userID int64,
+) (int, services.InitiatePaymentResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.paymentsgRPClient.ConfirmWithdrawal(c, &pb.ConfirmWithdrawalRequest{
+		TransactionId: req.TransactionID,
+		UserId:        userID,
+		Password:      req.Password,
+		Code:          req.Code,
+		ClientIp:      req.ClientIP,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+
+			return code, services.InitiatePaymentResponse{Message: st.Message(), StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.InitiatePaymentResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, initiatePaymentResponseFromPb(rsp)
+}
+
+func initiatePaymentResponseFromPb(rsp *pb.InitiatePaymentResponse) services.InitiatePaymentResponse {
+	payment := services.InitiatePaymentResponse{
+		TransactionID:        rsp.GetTransactionId(),
+		PaymentStatus:        rsp.GetPaymentStatus(),
+		Action:               rsp.GetAction(),
+		ConfirmationRequired: rsp.GetConfirmationRequired(),
+	}
+
+	if rsp.GetConfirmationExpiresAt() != nil {
+		expiresAt := rsp.GetConfirmationExpiresAt().AsTime()
+		payment.ConfirmationExpiresAt = &expiresAt
+	}
+
+	return payment
+}
+
+func (g *GrpcClient) PollTransactionViagRPC(ctx context.Context, req services.PollingTransactionRequest, userID int64) (int, services.PollingTransactionResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.paymentsgRPClient.GetTransaction(c, &pb.PollingTransactionRequest{
+		UserId:        userID,
+		TransactionId: req.TransactionId,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+
+			return code, services.PollingTransactionResponse{Message: st.Message(), StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.PollingTransactionResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	transaction, err := transactionFromPb(rsp)
+	if err != nil {
+		return http.StatusInternalServerError, services.PollingTransactionResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	return http.StatusOK, transaction
+}
+
+func (g *GrpcClient) ListTransactionsViagRPC(ctx context.Context, req services.ListTransactionsRequest) (int, services.ListTransactionsResponse) {
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	rsp, err := g.paymentsgRPClient.ListTransactions(c, &pb.ListTransactionsRequest{
+		UserId: req.UserID,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok {
+			code := grpcCodeConvert(st.Code())
+
+			return code, services.ListTransactionsResponse{Message: st.Message(), StatusCode: code}
+		}
+
+		return http.StatusInternalServerError, services.ListTransactionsResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	transactions := make([]services.PollingTransactionResponse, 0, len(rsp.GetTransactions()))
+
+	for _, pbTransaction := range rsp.GetTransactions() {
+		transaction, err := transactionFromPb(pbTransaction)
+		if err != nil {
+			return http.StatusInternalServerError, services.ListTransactionsResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return http.StatusOK, services.ListTransactionsResponse{Transactions: transactions}
+}
+
+func transactionFromPb(rsp *pb.PollingTransactionResponse) (services.PollingTransactionResponse, error) {
+	transactionID, err := uuid.Parse(rsp.GetTransactionId())
+	if err != nil {
+		return services.PollingTransactionResponse{}, err
+	}
+
+	return services.PollingTransactionResponse{
+		TransactionID:      transactionID,
+		PaydTransactionRef: rsp.GetPaydTransactionRef(),
+		Remarks:            rsp.GetRemarks(),
+		Action:             rsp.GetAction(),
+		Amount:             rsp.GetAmount(),
+		PhoneNumber:        rsp.GetPhoneNumber(),
+		NetworkCode:        rsp.GetNetworkCode(),
+		Naration:           rsp.GetNarration(),
+		PaymentStatus:      rsp.GetPaymentStatus(),
+	}, nil
+}
@@ -0,0 +1,328 @@
+package gRPC
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	grpcmock "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGrpcClient_InitiatePaymentViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockPaymentsServiceClient(ctrl)
+
+	g.client.paymentsgRPClient = mockCalls
+
+	req := services.InitiatePaymentRequest{
+		Email:       "jane@gmail.com",
+		Action:      "withdrawal",
+		Amount:      200,
+		PhoneNumber: "0712345678",
+		NetworkCode: "63902",
+		Naration:    "narration",
+	}
+
+	pbReq := &pb.InitiatePaymentRequest{
+		Email:       req.Email,
+		Action:      req.Action,
+		Amount:      req.Amount,
+		PhoneNumber: req.PhoneNumber,
+		NetworkCode: req.NetworkCode,
+		Narration:   req.Naration,
+		UserId:      1,
+	}
+
+	transactionID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		buildStubs     func(*grpcmock.MockPaymentsServiceClient)
+		want           services.InitiatePaymentResponse
+		wantStatusCode int
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					InitiatePayment(gomock.Any(), gomock.Eq(pbReq)).
+					Return(&pb.InitiatePaymentResponse{TransactionId: transactionID, Action: req.Action}, nil).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{TransactionID: transactionID, Action: req.Action},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "invalid argument",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					InitiatePayment(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, status.Error(codes.InvalidArgument, "invalid action: refund")).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{Message: "invalid action: refund", StatusCode: http.StatusBadRequest},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "some unknown error",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					InitiatePayment(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, errors.New("some unknown error")).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{Message: "internal error", StatusCode: http.StatusInternalServerError},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockCalls)
+
+			statusCode, rsp := g.client.InitiatePaymentViagRPC(context.Background(), req, 1)
+			require.Equal(t, tc.wantStatusCode, statusCode)
+			require.Equal(t, tc.want, rsp)
+		})
+	}
+}
+
+func TestGrpcClient_InitiatePaymentViagRPCConfirmation(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockPaymentsServiceClient(ctrl)
+
+	g.client.paymentsgRPClient = mockCalls
+
+	transactionID := uuid.NewString()
+	expiresAt := time.Date(2024, time.September, 18, 12, 5, 0, 0, time.UTC)
+
+	mockCalls.EXPECT().InitiatePayment(gomock.Any(), gomock.Any()).Return(&pb.InitiatePaymentResponse{
+		TransactionId:         transactionID,
+		Action:                "withdrawal",
+		ConfirmationRequired:  true,
+		ConfirmationExpiresAt: timestamppb.New(expiresAt),
+	}, nil).Times(1)
+
+	statusCode, rsp := g.client.InitiatePaymentViagRPC(context.Background(), services.InitiatePaymentRequest{Action: "withdrawal"}, 1)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.True(t, rsp.ConfirmationRequired)
+	require.NotNil(t, rsp.ConfirmationExpiresAt)
+	require.Equal(t, expiresAt, *rsp.ConfirmationExpiresAt)
+}
+
+func TestGrpcClient_ConfirmWithdrawalViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockPaymentsServiceClient(ctrl)
+
+	g.client.paymentsgRPClient = mockCalls
+
+	transactionID := uuid.NewString()
+
+	req := services.ConfirmWithdrawalRequest{
+		TransactionID: transactionID,
+		Password:      "password",
+		ClientIP:      "10.0.0.1",
+	}
+
+	pbReq := &pb.ConfirmWithdrawalRequest{
+		TransactionId: transactionID,
+		UserId:        1,
+		Password:      "password",
+		ClientIp:      "10.0.0.1",
+	}
+
+	tests := []struct {
+		name           string
+		buildStubs     func(*grpcmock.MockPaymentsServiceClient)
+		want           services.InitiatePaymentResponse
+		wantStatusCode int
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					ConfirmWithdrawal(gomock.Any(), gomock.Eq(pbReq)).
+					Return(&pb.InitiatePaymentResponse{TransactionId: transactionID, Action: "withdrawal"}, nil).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{TransactionID: transactionID, Action: "withdrawal"},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "wrong password",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					ConfirmWithdrawal(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, status.Error(codes.Unauthenticated, "invalid password")).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{Message: "invalid password", StatusCode: http.StatusUnauthorized},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					ConfirmWithdrawal(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, status.Error(codes.NotFound, "withdrawal does not exist or expired")).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{Message: "withdrawal does not exist or expired", StatusCode: http.StatusNotFound},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "some unknown error",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					ConfirmWithdrawal(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, errors.New("some unknown error")).
+					Times(1)
+			},
+			want:           services.InitiatePaymentResponse{Message: "internal error", StatusCode: http.StatusInternalServerError},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockCalls)
+
+			statusCode, rsp := g.client.ConfirmWithdrawalViagRPC(context.Background(), req, 1)
+			require.Equal(t, tc.wantStatusCode, statusCode)
+			require.Equal(t, tc.want, rsp)
+		})
+	}
+}
+
+func TestGrpcClient_PollTransactionViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockPaymentsServiceClient(ctrl)
+
+	g.client.paymentsgRPClient = mockCalls
+
+	transactionID := uuid.New()
+
+	req := services.PollingTransactionRequest{TransactionId: transactionID.String()}
+	pbReq := &pb.PollingTransactionRequest{UserId: 1, TransactionId: transactionID.String()}
+
+	tests := []struct {
+		name           string
+		buildStubs     func(*grpcmock.MockPaymentsServiceClient)
+		want           services.PollingTransactionResponse
+		wantStatusCode int
+	}{
+		{
+			name: "success",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					GetTransaction(gomock.Any(), gomock.Eq(pbReq)).
+					Return(&pb.PollingTransactionResponse{
+						TransactionId: transactionID.String(),
+						Remarks:       "success",
+						Action:        "withdrawal",
+						Amount:        200,
+						PaymentStatus: true,
+					}, nil).
+					Times(1)
+			},
+			want: services.PollingTransactionResponse{
+				TransactionID: transactionID,
+				Remarks:       "success",
+				Action:        "withdrawal",
+				Amount:        200,
+				PaymentStatus: true,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "another user's transaction",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					GetTransaction(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, status.Error(codes.Unauthenticated, "cannot access this transaction")).
+					Times(1)
+			},
+			want:           services.PollingTransactionResponse{Message: "cannot access this transaction", StatusCode: http.StatusUnauthorized},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "payments unavailable",
+			buildStubs: func(mockCalls *grpcmock.MockPaymentsServiceClient) {
+				mockCalls.EXPECT().
+					GetTransaction(gomock.Any(), gomock.Eq(pbReq)).
+					Return(nil, status.Error(codes.Unavailable, "connection refused")).
+					Times(1)
+			},
+			want:           services.PollingTransactionResponse{Message: "connection refused", StatusCode: http.StatusServiceUnavailable},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockCalls)
+
+			statusCode, rsp := g.client.PollTransactionViagRPC(context.Background(), req, 1)
+			require.Equal(t, tc.wantStatusCode, statusCode)
+			require.Equal(t, tc.want, rsp)
+		})
+	}
+}
+
+func TestGrpcClient_ListTransactionsViagRPC(t *testing.T) {
+	g := NewTestGrpcClient()
+
+	ctrl := gomock.NewController(t)
+
+	mockCalls := grpcmock.NewMockPaymentsServiceClient(ctrl)
+
+	g.client.paymentsgRPClient = mockCalls
+
+	transactionID := uuid.New()
+
+	req := services.ListTransactionsRequest{UserID: 7, Limit: 10, Offset: 20}
+
+	mockCalls.EXPECT().
+		ListTransactions(gomock.Any(), gomock.Eq(&pb.ListTransactionsRequest{UserId: 7, Limit: 10, Offset: 20})).
+		Return(&pb.ListTransactionsResponse{Transactions: []*pb.PollingTransactionResponse{
+			{TransactionId: transactionID.String(), Action: "withdrawal", Amount: 200},
+		}}, nil).
+		Times(1)
+
+	statusCode, rsp := g.client.ListTransactionsViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ListTransactionsResponse{Transactions: []services.PollingTransactionResponse{
+		{TransactionID: transactionID, Action: "withdrawal", Amount: 200},
+	}}, rsp)
+
+	mockCalls.EXPECT().
+		ListTransactions(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.InvalidArgument, "limit and offset cannot be negative")).
+		Times(1)
+
+	statusCode, rsp = g.client.ListTransactionsViagRPC(context.Background(), req)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "limit and offset cannot be negative", rsp.Message)
+}
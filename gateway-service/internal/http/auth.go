@@ -2,47 +2,74 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 )
 
 var _ services.HttpInterface = (*HTTPService)(nil)
 
+// requestTimeoutHeader carries the milliseconds left to serve a request in, for the
+// service to stop working on it once the gateway has given up.
+const requestTimeoutHeader = "X-Request-Timeout"
+
 type HTTPService struct {
-	registerPath string
-	loginPath    string
-	config       pkg.Config
+	registerPath       string
+	loginPath          string
+	forgotPasswordPath string
+	resetPasswordPath  string
+	config             pkg.Config
+	client             *http.Client
 }
 
 func NewHTTPService(config pkg.Config) *HTTPService {
+	timeout := config.HTTP_CLIENT_TIMEOUT
+	if timeout <= 0 {
+		timeout = 2 * routing.DefaultTimeout
+	}
+
 	return &HTTPService{
-		config:       config,
-		registerPath: "auth/register",
-		loginPath:    "auth/login",
+		config:             config,
+		registerPath:       "auth/register",
+		loginPath:          "auth/login",
+		forgotPasswordPath: "auth/password/forgot",
+		resetPasswordPath:  "auth/password/reset",
+		client:             &http.Client{Timeout: timeout},
 	}
 }
 
-func (s *HTTPService) RegisterUserViaHttp(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+func (s *HTTPService) RegisterUserViaHttp(ctx context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.registerPath), bytes.NewBuffer(jsonData))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.registerPath), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	client := &http.Client{}
+	request.Header.Set(logging.RequestIDHeader, logging.RequestID(ctx))
+	tracing.InjectHTTP(ctx, request.Header)
+	setRequestTimeout(ctx, request.Header)
 
-	response, err := client.Do(request)
+	response, err := s.client.Do(request)
 	if err != nil {
-		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		statusCode, message := clientFailure(ctx, err)
+
+		return statusCode, services.RegisterUserResponse{Message: message, StatusCode: statusCode}
 	}
 	defer response.Body.Close()
 
@@ -65,22 +92,26 @@ func (s *HTTPService) RegisterUserViaHttp(req services.RegisterUserRequest) (int
 	return http.StatusOK, authServiceResponse
 }
 
-func (s *HTTPService) LoginUserViaHttp(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+func (s *HTTPService) LoginUserViaHttp(ctx context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.loginPath), bytes.NewBuffer(jsonData))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.loginPath), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	client := &http.Client{}
+	request.Header.Set(logging.RequestIDHeader, logging.RequestID(ctx))
+	tracing.InjectHTTP(ctx, request.Header)
+	setRequestTimeout(ctx, request.Header)
 
-	response, err := client.Do(request)
+	response, err := s.client.Do(request)
 	if err != nil {
-		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		statusCode, message := clientFailure(ctx, err)
+
+		return statusCode, services.LoginUserResponse{Message: message, StatusCode: statusCode}
 	}
 	defer response.Body.Close()
 
@@ -102,3 +133,112 @@ func (s *HTTPService) LoginUserViaHttp(req services.LoginUserRequest) (int, serv
 
 	return http.StatusOK, jsonFromAuthService
 }
+
+func (s *HTTPService) ForgotPasswordViaHttp(ctx context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.forgotPasswordPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	request.Header.Set(logging.RequestIDHeader, logging.RequestID(ctx))
+	tracing.InjectHTTP(ctx, request.Header)
+	setRequestTimeout(ctx, request.Header)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		statusCode, message := clientFailure(ctx, err)
+
+		return statusCode, services.ForgotPasswordResponse{Message: message, StatusCode: statusCode}
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	var authServiceResponse services.ForgotPasswordResponse
+
+	err = json.Unmarshal(responseBody, &authServiceResponse)
+	if err != nil {
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, services.ForgotPasswordResponse{Message: authServiceResponse.Message, StatusCode: authServiceResponse.StatusCode}
+	}
+
+	return http.StatusOK, authServiceResponse
+}
+
+func (s *HTTPService) ResetPasswordViaHttp(ctx context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/%s", s.config.AUTH_HTTP_PORT, s.resetPasswordPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	request.Header.Set(logging.RequestIDHeader, logging.RequestID(ctx))
+	tracing.InjectHTTP(ctx, request.Header)
+	setRequestTimeout(ctx, request.Header)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		statusCode, message := clientFailure(ctx, err)
+
+		return statusCode, services.ResetPasswordResponse{Message: message, StatusCode: statusCode}
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	var authServiceResponse services.ResetPasswordResponse
+
+	err = json.Unmarshal(responseBody, &authServiceResponse)
+	if err != nil {
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, services.ResetPasswordResponse{Message: authServiceResponse.Message, StatusCode: authServiceResponse.StatusCode}
+	}
+
+	return http.StatusOK, authServiceResponse
+}
+
+// setRequestTimeout tells the service how long it has left to serve the request in.
+func setRequestTimeout(ctx context.Context, header http.Header) {
+	if deadline, ok := ctx.Deadline(); ok {
+		header.Set(requestTimeoutHeader, strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10))
+	}
+}
+
+// clientFailure maps a request that got no response to the status and message the
+// client gets.
+func clientFailure(ctx context.Context, err error) (int, string) {
+	var netErr net.Error
+	if ctx.Err() != nil || errors.As(err, &netErr) && netErr.Timeout() {
+		return http.StatusGatewayTimeout, "timeout waiting for response. Try again"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		// the request did not reach the auth service
+		return http.StatusServiceUnavailable, "auth service unavailable"
+	}
+
+	// the connection went away after the request was sent, the service may have acted on it
+	return http.StatusBadGateway, "no response from auth service. The request may have been processed"
+}
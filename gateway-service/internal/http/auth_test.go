@@ -1,9 +1,11 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -89,7 +91,7 @@ func TestHTTPService_RegisterUserViaHttp(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCode, msg := s.RegisterUserViaHttp(tc.req)
+			statusCode, msg := s.RegisterUserViaHttp(context.Background(), tc.req)
 			require.Equal(t, statusCode, tc.wantStatusCode)
 			require.Equal(t, msg, tc.wantRsp)
 		})
@@ -174,7 +176,7 @@ func TestHTTPServer_LoginUserViaHttp(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCode, msg := s.LoginUserViaHttp(tc.req)
+			statusCode, msg := s.LoginUserViaHttp(context.Background(), tc.req)
 			require.Equal(t, statusCode, tc.wantStatusCode)
 			require.Equal(t, msg, tc.wantRsp)
 		})
@@ -192,3 +194,97 @@ func randomReq() services.RegisterUserRequest {
 		PasswordApiKey: gofakeit.UUID(),
 	}
 }
+
+func TestHTTPService_ResetPasswordViaHttp(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path != "/auth/password/reset" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		var req services.ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token != "valid" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(services.ResetPasswordResponse{
+				Message:    "invalid or expired reset token",
+				StatusCode: http.StatusBadRequest,
+			})
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(services.ResetPasswordResponse{UserID: 1, Email: "jane@gmail.com"})
+	}))
+	defer testServer.Close()
+
+	s := NewHTTPService(pkg.Config{
+		AUTH_HTTP_PORT: strings.TrimPrefix(testServer.URL, "http://"),
+	})
+
+	statusCode, rsp := s.ResetPasswordViaHttp(context.Background(), services.ResetPasswordRequest{Token: "valid", Password: "new password"})
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, services.ResetPasswordResponse{UserID: 1, Email: "jane@gmail.com"}, rsp)
+
+	statusCode, rsp = s.ResetPasswordViaHttp(context.Background(), services.ResetPasswordRequest{Token: "used", Password: "new password"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Equal(t, "invalid or expired reset token", rsp.Message)
+}
+
+func TestHTTPService_Deadline(t *testing.T) {
+	var gotTimeout string
+
+	// answers login after the deadline the gateway sent along
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTimeout = r.Header.Get(requestTimeoutHeader)
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer testServer.Close()
+
+	s := NewHTTPService(pkg.Config{
+		AUTH_HTTP_PORT: strings.TrimPrefix(testServer.URL, "http://"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	statusCode, rsp := s.LoginUserViaHttp(ctx, services.LoginUserRequest{Email: "jane@gmail.com", Password: "secret"})
+	require.Equal(t, http.StatusGatewayTimeout, statusCode)
+	require.Equal(t, http.StatusGatewayTimeout, rsp.StatusCode)
+
+	timeout, err := strconv.Atoi(gotTimeout)
+	require.NoError(t, err)
+	require.Greater(t, timeout, 0)
+	require.LessOrEqual(t, timeout, 100)
+
+	// nothing listens there anymore
+	testServer.Close()
+
+	statusCode, _ = s.LoginUserViaHttp(context.Background(), services.LoginUserRequest{Email: "jane@gmail.com", Password: "secret"})
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+}
+
+func TestHTTPService_ConnectionLost(t *testing.T) {
+	// reads the request, then drops the connection without answering
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+
+		conn.Close()
+	}))
+	defer testServer.Close()
+
+	s := NewHTTPService(pkg.Config{
+		AUTH_HTTP_PORT: strings.TrimPrefix(testServer.URL, "http://"),
+	})
+
+	statusCode, rsp := s.ResetPasswordViaHttp(context.Background(), services.ResetPasswordRequest{Token: "valid", Password: "new password"})
+	require.Equal(t, http.StatusBadGateway, statusCode)
+	require.Equal(t, http.StatusBadGateway, rsp.StatusCode)
+}
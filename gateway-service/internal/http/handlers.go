@@ -1,13 +1,24 @@
 package http
 
 import (
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// transportHeader tells which transport served the request.
+	transportHeader = "X-Transport"
+
+	unavailableMessage = "service unavailable. Try again"
+)
+
 func (s *HttpServer) handleRegisterUser(ctx *gin.Context) {
 	var req services.RegisterUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -16,10 +27,26 @@ func (s *HttpServer) handleRegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	// we can change the communication channel here ie
-	// statusCode, rsp := s.RabbitService.RegisterUserViaRabbit(req)
-	// statusCode, rsp := s.HTTPService.RegisterUserViaHttp(req)
-	statusCode, rsp := s.GRPCService.RegisterUserViagRPC(req)
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.RegisterUser, func(t routing.Transport) (int, services.RegisterUserResponse) {
+		switch t {
+		case routing.GRPC:
+			return s.GRPCService.RegisterUserViagRPC(c, req)
+		case routing.RabbitMQ:
+			return s.RabbitService.RegisterUserViaRabbit(c, req)
+		default:
+			return s.HTTPService.RegisterUserViaHttp(c, req)
+		}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
 	if statusCode != http.StatusOK {
 		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
 
@@ -37,29 +64,66 @@ func (s *HttpServer) handleLoginUser(ctx *gin.Context) {
 		return
 	}
 
-	// we can change the communication channel here ie
-	// statusCode, rsp := s.GrpcClient.LoginUserViagRPC(req)
-	// statuSCode, rsp := s.RabbitService.LoginUserViaRabbit(req)
-	statusCode, rsp := s.HTTPService.LoginUserViaHttp(req)
+	// failed logins are limited by the address of the connection, or the one the
+	// trusted proxies forwarded, never one the client claims
+	req.ClientIP = ctx.ClientIP()
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.LoginUser, func(t routing.Transport) (int, services.LoginUserResponse) {
+		switch t {
+		case routing.GRPC:
+			return s.GRPCService.LoginUserViagRPC(c, req)
+		case routing.RabbitMQ:
+			return s.RabbitService.LoginUserViaRabbit(c, req)
+		default:
+			return s.HTTPService.LoginUserViaHttp(c, req)
+		}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
 	if statusCode != http.StatusOK {
 		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
 
 		return
 	}
 
+	if rsp.MFARequired {
+		ctx.JSON(statusCode, mfaChallengeResponse(rsp))
+
+		return
+	}
+
 	ctx.JSON(statusCode, rsp)
 }
 
-func (s *HttpServer) handleInitiatePayment(ctx *gin.Context) {
-	var req services.InitiatePaymentRequest
+func (s *HttpServer) handleRefreshToken(ctx *gin.Context) {
+	var req services.RefreshTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
 
 		return
 	}
 
-	// implemented only RabbitMQ communication channel with payment service.
-	statusCode, rsp := s.RabbitService.InitiatePaymentViaRabbit(req)
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.RefreshToken, func(_ routing.Transport) (int, services.RefreshTokenResponse) {
+		return s.GRPCService.RefreshTokenViagRPC(c, req)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
 	if statusCode != http.StatusOK {
 		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
 
@@ -69,30 +133,876 @@ func (s *HttpServer) handleInitiatePayment(ctx *gin.Context) {
 	ctx.JSON(statusCode, rsp)
 }
 
-func (s *HttpServer) handlePaymentPolling(ctx *gin.Context) {
-	var req services.PollingTransactionRequest
-	if err := ctx.ShouldBindUri(&req); err != nil {
+// handleLogout revokes the access token of the request, and the refresh token of the
+// session when the body carries it.
+func (s *HttpServer) handleLogout(ctx *gin.Context) {
+	s.logout(ctx, false)
+}
+
+// handleLogoutAll revokes every access and refresh token of the user, logging them out
+// of every session.
+func (s *HttpServer) handleLogoutAll(ctx *gin.Context) {
+	s.logout(ctx, true)
+}
+
+func (s *HttpServer) logout(ctx *gin.Context, everywhere bool) {
+	var req services.LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	if s.Revocations == nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	if everywhere {
+		req.RefreshToken = ""
+	}
+
+	// the refresh tokens are revoked first, so that none can be used to get an access
+	// token past the revocation. Both are idempotent, a logout that failed is retried.
+	if everywhere || req.RefreshToken != "" {
+		transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.Logout, func(_ routing.Transport) (int, services.LogoutResponse) {
+			return s.GRPCService.RevokeRefreshTokensViagRPC(c, req, payload.UserID)
+		})
+		if err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+			return
+		}
+
+		ctx.Header(transportHeader, string(transport))
+
+		if statusCode != http.StatusOK {
+			ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+			return
+		}
+	}
+
+	var err error
+	if everywhere {
+		err = s.Revocations.RevokeUserTokens(c, payload.UserID)
+	} else {
+		err = s.Revocations.RevokeToken(c, payload)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c, "failed to revoke access token", "error", err)
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, services.LogoutResponse{Message: "logged out"})
+}
+
+// handleVerifyEmail confirms the email address of a user with the token of the link
+// they were sent, which is why it answers a GET.
+func (s *HttpServer) handleVerifyEmail(ctx *gin.Context) {
+	var req services.VerifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.EmailVerify, func(_ routing.Transport) (int, services.VerifyEmailResponse) {
+		return s.GRPCService.VerifyEmailViagRPC(c, req)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleResendVerificationEmail answers the same whether the email belongs to an
+// unverified user or not.
+func (s *HttpServer) handleResendVerificationEmail(ctx *gin.Context) {
+	var req services.ResendVerificationEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.EmailVerify, func(_ routing.Transport) (int, services.ResendVerificationEmailResponse) {
+		return s.GRPCService.ResendVerificationEmailViagRPC(c, req)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, services.ResendVerificationEmailResponse{
+		Message: "if the email belongs to an unverified account, a verification email was sent",
+	})
+}
+
+// handleForgotPassword answers the same whether the email belongs to a user or not.
+func (s *HttpServer) handleForgotPassword(ctx *gin.Context) {
+	var req services.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.PasswordReset, func(t routing.Transport) (int, services.ForgotPasswordResponse) {
+		switch t {
+		case routing.GRPC:
+			return s.GRPCService.ForgotPasswordViagRPC(c, req)
+		case routing.RabbitMQ:
+			return s.RabbitService.ForgotPasswordViaRabbit(c, req)
+		default:
+			return s.HTTPService.ForgotPasswordViaHttp(c, req)
+		}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, services.ForgotPasswordResponse{
+		Message: "if the email belongs to an account, a password reset email was sent",
+	})
+}
+
+// handleResetPassword sets a new password with the token of the link the user was
+// sent, logging them out of every session.
+func (s *HttpServer) handleResetPassword(ctx *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.PasswordReset, func(t routing.Transport) (int, services.ResetPasswordResponse) {
+		switch t {
+		case routing.GRPC:
+			return s.GRPCService.ResetPasswordViagRPC(c, req)
+		case routing.RabbitMQ:
+			return s.RabbitService.ResetPasswordViaRabbit(c, req)
+		default:
+			return s.HTTPService.ResetPasswordViaHttp(c, req)
+		}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	// the auth service revoked the refresh tokens. The reset token is spent, so a
+	// failure here is not retried: the access tokens left expire on their own.
+	if s.Revocations != nil {
+		if err := s.Revocations.RevokeUserTokens(c, rsp.UserID); err != nil {
+			slog.ErrorContext(c, "failed to revoke access tokens after password reset", "user_id", rsp.UserID, "error", err)
+		}
+	}
+
+	ctx.JSON(statusCode, services.ResetPasswordResponse{Message: "password reset, log in again"})
+}
+
+// handleVerifyMFA completes the login of a user with two-factor authentication, with
+// the token their password login returned and a code of their authenticator app or a
+// recovery code.
+func (s *HttpServer) handleVerifyMFA(ctx *gin.Context) {
+	var req services.VerifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	// wrong codes count as failed logins of the address, as for handleLoginUser
+	req.ClientIP = ctx.ClientIP()
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.MFA, func(_ routing.Transport) (int, services.LoginUserResponse) {
+		return s.GRPCService.VerifyMFAViagRPC(c, req)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleEnrollMFA starts two-factor authentication for the user, returning the secret
+// to add to their authenticator app and the recovery codes, which can not be shown
+// again. It is not enabled until a code is posted to /mfa/activate.
+func (s *HttpServer) handleEnrollMFA(ctx *gin.Context) {
+	var req services.EnrollMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.MFA, func(_ routing.Transport) (int, services.EnrollMFAResponse) {
+		return s.GRPCService.EnrollMFAViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleActivateMFA enables the two-factor authentication the user enrolled in, once
+// they prove their authenticator app has the secret.
+func (s *HttpServer) handleActivateMFA(ctx *gin.Context) {
+	var req services.ActivateMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.MFA, func(_ routing.Transport) (int, services.ActivateMFAResponse) {
+		return s.GRPCService.ActivateMFAViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleDisableMFA turns two-factor authentication off, with a code of the
+// authenticator app or a recovery code.
+func (s *HttpServer) handleDisableMFA(ctx *gin.Context) {
+	var req services.DisableMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
 
 		return
 	}
 
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.MFA, func(_ routing.Transport) (int, services.DisableMFAResponse) {
+		return s.GRPCService.DisableMFAViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// mfaChallengeResponse is the answer to the password login of a user with two-factor
+// authentication, which carries no tokens yet.
+func mfaChallengeResponse(rsp services.LoginUserResponse) services.MFAChallengeResponse {
+	return services.MFAChallengeResponse{
+		MFARequired:     true,
+		MFAToken:        rsp.MFAToken,
+		MFAExpirationAt: rsp.MFAExpirationAt,
+		Message:         "two-factor authentication required, post a code with the mfa token to /login/mfa",
+	}
+}
+
+// sessionPayload returns the payload of the access token of the request, writing the
+// error response when there is none.
+func sessionPayload(ctx *gin.Context) (*pkg.Payload, bool) {
 	value, exists := ctx.Get(authorizationPayloadKey)
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, pkg.ErrorResponse("Missing token payload", http.StatusUnauthorized))
 
-		return
+		return nil, false
 	}
 
 	payload, ok := value.(*pkg.Payload)
 	if !ok {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "type assertion failed"})
 
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func (s *HttpServer) handleInitiatePayment(ctx *gin.Context) {
+	var req services.InitiatePaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	// the payments service only pays from the account of email when it belongs to the
+	// user of the session, or to the owner of the API key
+	payload, ok := sessionPayload(ctx)
+	if !ok {
 		return
 	}
 
-	// implemented only RabbitMQ communication channel with payment service.
-	statusCode, rsp := s.RabbitService.PollTransactionViaRabbit(req, payload.UserID)
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.InitiatePayment, func(t routing.Transport) (int, services.InitiatePaymentResponse) {
+		if t == routing.GRPC {
+			return s.GRPCService.InitiatePaymentViagRPC(c, req, payload.UserID)
+		}
+
+		return s.RabbitService.InitiatePaymentViaRabbit(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleConfirmWithdrawal sends a withdrawal held back for being above the threshold of
+// the payments service, once the user confirms it with their password, or a code when
+// they use two-factor authentication.
+func (s *HttpServer) handleConfirmWithdrawal(ctx *gin.Context) {
+	var uri services.PollingTransactionRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	var req services.ConfirmWithdrawalRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	if req.Password == "" && req.Code == "" {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("password or code is required", http.StatusBadRequest))
+
+		return
+	}
+
+	req.TransactionID = uri.TransactionId
+	req.ClientIP = ctx.ClientIP()
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.ConfirmWithdrawal, func(_ routing.Transport) (int, services.InitiatePaymentResponse) {
+		return s.GRPCService.ConfirmWithdrawalViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+func (s *HttpServer) handlePaymentPolling(ctx *gin.Context) {
+	var req services.PollingTransactionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.PollTransaction, func(t routing.Transport) (int, services.PollingTransactionResponse) {
+		if t == routing.GRPC {
+			return s.GRPCService.PollTransactionViagRPC(c, req, payload.UserID)
+		}
+
+		return s.RabbitService.PollTransactionViaRabbit(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleCreateAPIKey issues an API key for the user. The key is in the response only, it
+// can not be shown again.
+func (s *HttpServer) handleCreateAPIKey(ctx *gin.Context) {
+	var req services.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.APIKeys, func(_ routing.Transport) (int, services.CreateAPIKeyResponse) {
+		return s.GRPCService.CreateAPIKeyViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+func (s *HttpServer) handleListAPIKeys(ctx *gin.Context) {
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.APIKeys, func(_ routing.Transport) (int, services.ListAPIKeysResponse) {
+		return s.GRPCService.ListAPIKeysViagRPC(c, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleRevokeAPIKey revokes an API key of the user. Gateways keep accepting it for up to
+// API_KEY_CACHE_TTL, the time their cached answer lives.
+func (s *HttpServer) handleRevokeAPIKey(ctx *gin.Context) {
+	var req services.RevokeAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.APIKeys, func(_ routing.Transport) (int, services.RevokeAPIKeyResponse) {
+		return s.GRPCService.RevokeAPIKeyViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleAdminGetUser looks up a user by id for support staff.
+func (s *HttpServer) handleAdminGetUser(ctx *gin.Context) {
+	var uri services.AdminUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	var req services.AdminGetUserRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	req.UserID = uri.ID
+	req.Email = ""
+
+	s.adminGetUser(ctx, req)
+}
+
+// handleAdminFindUser looks up a user by email for support staff.
+func (s *HttpServer) handleAdminFindUser(ctx *gin.Context) {
+	var req services.AdminGetUserRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil || req.Email == "" {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	s.adminGetUser(ctx, req)
+}
+
+func (s *HttpServer) adminGetUser(ctx *gin.Context, req services.AdminGetUserRequest) {
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.Admin, func(_ routing.Transport) (int, services.AdminUserResponse) {
+		return s.GRPCService.AdminGetUserViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleAdminListTransactions lists the transactions of a user for support staff. The
+// view is recorded in the audit log first, and the transactions are not shown when it
+// can not be.
+func (s *HttpServer) handleAdminListTransactions(ctx *gin.Context) {
+	var uri services.AdminUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	var req services.AdminTransactionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	event := services.RecordAuditEventRequest{
+		Action:       services.ActionTransactionsView,
+		TargetUserID: uri.ID,
+		Reason:       req.Reason,
+	}
+
+	transport, statusCode, audit, err := routing.Do(c, s.Router, routing.Admin, func(_ routing.Transport) (int, services.RecordAuditEventResponse) {
+		return s.GRPCService.RecordAuditEventViagRPC(c, event, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(audit.Message, audit.StatusCode))
+
+		return
+	}
+
+	list := services.ListTransactionsRequest{UserID: uri.ID, Limit: req.Limit, Offset: req.Offset}
+
+	_, statusCode, rsp, err := routing.Do(c, s.Router, routing.Admin, func(_ routing.Transport) (int, services.ListTransactionsResponse) {
+		return s.GRPCService.ListTransactionsViagRPC(c, list)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleDisableUser disables the account of a user, for admins. The authentication
+// service revokes their refresh tokens, and their access tokens are revoked here.
+func (s *HttpServer) handleDisableUser(ctx *gin.Context) {
+	var uri services.AdminUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	var req services.DisableUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	req.UserID = uri.ID
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	if s.Revocations == nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.Admin, func(_ routing.Transport) (int, services.AdminUserResponse) {
+		return s.GRPCService.DisableUserViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
+	if statusCode != http.StatusOK {
+		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
+
+		return
+	}
+
+	// disabling is idempotent, a request that failed here is retried
+	if err := s.Revocations.RevokeUserTokens(c, req.UserID); err != nil {
+		slog.ErrorContext(c, "failed to revoke access tokens of disabled user", "error", err)
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.JSON(statusCode, rsp)
+}
+
+// handleUnlockUser lifts the lockout failed logins put on the account of a user, for
+// admins.
+func (s *HttpServer) handleUnlockUser(ctx *gin.Context) {
+	var uri services.AdminUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	var req services.UnlockUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, pkg.ErrorResponse("Invalid request", http.StatusBadRequest))
+
+		return
+	}
+
+	req.UserID = uri.ID
+
+	payload, ok := sessionPayload(ctx)
+	if !ok {
+		return
+	}
+
+	c := ctx.Request.Context()
+
+	transport, statusCode, rsp, err := routing.Do(c, s.Router, routing.Admin, func(_ routing.Transport) (int, services.AdminUserResponse) {
+		return s.GRPCService.UnlockUserViagRPC(c, req, payload.UserID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+		return
+	}
+
+	ctx.Header(transportHeader, string(transport))
+
 	if statusCode != http.StatusOK {
 		ctx.JSON(statusCode, pkg.ErrorResponse(rsp.Message, rsp.StatusCode))
 
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,8 +10,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 	"github.com/brianvoe/gofakeit"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,30 +34,71 @@ func mockRegisterUserViaRabbit(_ services.RegisterUserRequest) (int, services.Re
 func TestHttpServer_handleRegisterUser(t *testing.T) {
 	s := NewTestHttpServer()
 
-	// change here to the communication channel you are using
-	s.GrpcService.RegisterUserViagRPCFunc = mockRegisterUserViagRPC
+	s.HTTPService.RegisterUserViaHttpFunc = mockRegisterUserViaHttp
+	s.RabbitService.RegisterUserViaRabbitFunc = mockRegisterUserViaRabbit
 
 	req := randomReq()
 
 	tests := []struct {
-		name string
-		req  any
-		want int
+		name          string
+		req           any
+		transports    []routing.Transport
+		grpcStatus    int
+		want          int
+		wantTransport string
 	}{
 		{
-			name: "success",
-			req:  req,
-			want: http.StatusOK,
+			name:          "success",
+			req:           req,
+			want:          http.StatusOK,
+			wantTransport: "grpc",
 		},
 		{
 			name: "missing arg",
 			req:  services.LoginUserRequest{},
 			want: http.StatusBadRequest,
 		},
+		{
+			name:          "falls back when grpc is unavailable",
+			req:           req,
+			transports:    []routing.Transport{routing.GRPC, routing.RabbitMQ, routing.HTTP},
+			grpcStatus:    http.StatusServiceUnavailable,
+			want:          http.StatusOK,
+			wantTransport: "rabbitmq",
+		},
+		{
+			name:          "does not fall back on a timeout",
+			req:           req,
+			transports:    []routing.Transport{routing.GRPC, routing.HTTP},
+			grpcStatus:    http.StatusGatewayTimeout,
+			want:          http.StatusGatewayTimeout,
+			wantTransport: "grpc",
+		},
+		{
+			name:          "configured primary",
+			req:           req,
+			transports:    []routing.Transport{routing.HTTP},
+			want:          http.StatusOK,
+			wantTransport: "http",
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			routes := routing.DefaultRoutes()
+			if tc.transports != nil {
+				routes[routing.RegisterUser] = tc.transports
+			}
+
+			s.server.Router = routing.New(routes, 5, time.Minute)
+
+			s.GrpcService.RegisterUserViagRPCFunc = mockRegisterUserViagRPC
+			if tc.grpcStatus != 0 {
+				s.GrpcService.RegisterUserViagRPCFunc = func(_ services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+					return tc.grpcStatus, services.RegisterUserResponse{Message: "grpc failed", StatusCode: tc.grpcStatus}
+				}
+			}
+
 			w := httptest.NewRecorder()
 
 			b, err := json.Marshal(tc.req)
@@ -63,6 +109,7 @@ func TestHttpServer_handleRegisterUser(t *testing.T) {
 
 			s.server.router.ServeHTTP(w, req)
 			require.Equal(t, tc.want, w.Code)
+			require.Equal(t, tc.wantTransport, w.Header().Get(transportHeader))
 		})
 	}
 }
@@ -82,7 +129,6 @@ func mockLoginUserViaRabbit(_ services.LoginUserRequest) (int, services.LoginUse
 func TestHttpServer_handleLoginUser(t *testing.T) {
 	s := NewTestHttpServer()
 
-	// change here to the communication channel you are using
 	s.HTTPService.LoginUserViaHttpFunc = mockLoginUserViaHttp
 
 	req := services.LoginUserRequest{
@@ -119,22 +165,219 @@ func TestHttpServer_handleLoginUser(t *testing.T) {
 
 			s.server.router.ServeHTTP(w, req)
 			require.Equal(t, tc.want, w.Code)
+
+			if tc.want == http.StatusOK {
+				require.Equal(t, "http", w.Header().Get(transportHeader))
+			}
 		})
 	}
 }
 
-func mockInitiatePaymentViaRabbit(_ services.InitiatePaymentRequest) (int, services.InitiatePaymentResponse) {
-	return http.StatusOK, services.InitiatePaymentResponse{Message: "success"}
+func TestHttpServer_handleLoginUserClientIP(t *testing.T) {
+	s := NewTestHttpServer()
+
+	var got services.LoginUserRequest
+
+	s.HTTPService.LoginUserViaHttpFunc = func(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+		got = req
+
+		return http.StatusTooManyRequests, services.LoginUserResponse{
+			Message:    "too many failed login attempts, try again later",
+			StatusCode: http.StatusTooManyRequests,
+		}
+	}
+
+	w := httptest.NewRecorder()
+
+	// the address the client claims is not the one failed logins are limited by
+	req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(
+		`{"email":"jane@gmail.com","password":"password","client_ip":"203.0.113.9"}`,
+	))
+	require.NoError(t, err)
+
+	req.RemoteAddr = "192.0.2.1:40000"
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "192.0.2.1", got.ClientIP)
 }
 
-func TestHttpServer_handleInitiatePayment(t *testing.T) {
+func TestHttpServer_handleRefreshToken(t *testing.T) {
 	s := NewTestHttpServer()
 
-	accessToken, err := s.server.maker.CreateToken("user", 1, time.Minute)
-	require.NoError(t, err)
+	s.GrpcService.RefreshTokenViagRPCFunc = func(req services.RefreshTokenRequest) (int, services.RefreshTokenResponse) {
+		if req.RefreshToken != "valid" {
+			return http.StatusUnauthorized, services.RefreshTokenResponse{
+				Message:    "refresh token reuse detected",
+				StatusCode: http.StatusUnauthorized,
+			}
+		}
 
-	// change here to the communication channel you are using
-	s.RabbitService.InitiatePaymentViaRabbitFunc = mockInitiatePaymentViaRabbit
+		return http.StatusOK, services.RefreshTokenResponse{AccessToken: "token", RefreshToken: "rotated"}
+	}
+
+	tests := []struct {
+		name string
+		req  any
+		want int
+	}{
+		{
+			name: "success",
+			req:  services.RefreshTokenRequest{RefreshToken: "valid"},
+			want: http.StatusOK,
+		},
+		{
+			name: "reused token",
+			req:  services.RefreshTokenRequest{RefreshToken: "reused"},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "missing token",
+			req:  services.RefreshTokenRequest{},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			b, err := json.Marshal(tc.req)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.want == http.StatusOK {
+				require.Equal(t, "grpc", w.Header().Get(transportHeader))
+
+				var rsp services.RefreshTokenResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+				require.Equal(t, "rotated", rsp.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestHttpServer_handleVerifyEmail(t *testing.T) {
+	s := NewTestHttpServer()
+
+	s.GrpcService.VerifyEmailViagRPCFunc = func(req services.VerifyEmailRequest) (int, services.VerifyEmailResponse) {
+		if req.Token != "valid" {
+			return http.StatusBadRequest, services.VerifyEmailResponse{
+				Message:    "invalid or expired verification token",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+
+		return http.StatusOK, services.VerifyEmailResponse{Email: "jane@gmail.com", VerifiedAt: time.Now()}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "success",
+			query: "?token=valid",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "expired token",
+			query: "?token=expired",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "missing token",
+			query: "",
+			want:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/verify-email"+tc.query, nil)
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.want == http.StatusOK {
+				var rsp services.VerifyEmailResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+				require.Equal(t, "jane@gmail.com", rsp.Email)
+			}
+		})
+	}
+}
+
+func TestHttpServer_handleResendVerificationEmail(t *testing.T) {
+	s := NewTestHttpServer()
+
+	var resent []string
+
+	s.GrpcService.ResendVerificationEmailViagRPCFunc = func(req services.ResendVerificationEmailRequest) (int, services.ResendVerificationEmailResponse) {
+		resent = append(resent, req.Email)
+
+		return http.StatusOK, services.ResendVerificationEmailResponse{}
+	}
+
+	tests := []struct {
+		name string
+		req  any
+		want int
+	}{
+		{
+			name: "success",
+			req:  services.ResendVerificationEmailRequest{Email: "jane@gmail.com"},
+			want: http.StatusOK,
+		},
+		{
+			name: "not an email",
+			req:  services.ResendVerificationEmailRequest{Email: "jane"},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "missing email",
+			req:  services.ResendVerificationEmailRequest{},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			b, err := json.Marshal(tc.req)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/verify-email/resend", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+		})
+	}
+
+	require.Equal(t, []string{"jane@gmail.com"}, resent)
+}
+
+func TestHttpServer_handleForgotPassword(t *testing.T) {
+	s := NewTestHttpServer()
+
+	var requested []string
+
+	s.GrpcService.ForgotPasswordViagRPCFunc = func(req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+		requested = append(requested, req.Email)
+
+		return http.StatusOK, services.ForgotPasswordResponse{}
+	}
 
 	tests := []struct {
 		name string
@@ -143,25 +386,493 @@ func TestHttpServer_handleInitiatePayment(t *testing.T) {
 	}{
 		{
 			name: "success",
-			req: services.InitiatePaymentRequest{
-				Email:       gofakeit.Email(),
-				Action:      "withdrawal",
-				Amount:      200,
-				PhoneNumber: "phone_number",
-				NetworkCode: "63902",
-				Naration:    "narration",
+			req:  services.ForgotPasswordRequest{Email: "jane@gmail.com"},
+			want: http.StatusOK,
+		},
+		{
+			name: "not an email",
+			req:  services.ForgotPasswordRequest{Email: "jane"},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			b, err := json.Marshal(tc.req)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+		})
+	}
+
+	require.Equal(t, []string{"jane@gmail.com"}, requested)
+}
+
+func TestHttpServer_handleResetPassword(t *testing.T) {
+	s := NewTestHttpServer()
+
+	s.GrpcService.ResetPasswordViagRPCFunc = func(req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+		if req.Token != "reset-token" {
+			return http.StatusBadRequest, services.ResetPasswordResponse{
+				Message:    "invalid or expired reset token",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+
+		return http.StatusOK, services.ResetPasswordResponse{UserID: 1, Email: "jane@gmail.com"}
+	}
+
+	token, err := s.signer.CreateToken("user", 1, time.Minute)
+	require.NoError(t, err)
+
+	send := func(body services.ResetPasswordRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		s.server.router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := send(services.ResetPasswordRequest{Token: "used-token", Password: "new password"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = send(services.ResetPasswordRequest{Token: "reset-token"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = send(services.ResetPasswordRequest{Token: "reset-token", Password: "new password"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), "user_id")
+
+	// the access tokens issued before the reset no longer work
+	w = httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodPost, "/logout", nil)
+	require.NoError(t, err)
+
+	req.Header.Set(authorizationHeaderKey, "Bearer "+token)
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHttpServer_handleLoginUserMFA(t *testing.T) {
+	s := NewTestHttpServer()
+
+	expiresAt := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+
+	s.HTTPService.LoginUserViaHttpFunc = func(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+		return http.StatusOK, services.LoginUserResponse{
+			Email:           req.Email,
+			MFARequired:     true,
+			MFAToken:        "mfa-token",
+			MFAExpirationAt: expiresAt,
+		}
+	}
+
+	var got services.VerifyMFARequest
+
+	s.GrpcService.VerifyMFAViagRPCFunc = func(req services.VerifyMFARequest) (int, services.LoginUserResponse) {
+		got = req
+
+		if req.Code != "123456" {
+			return http.StatusUnauthorized, services.LoginUserResponse{Message: "invalid code", StatusCode: http.StatusUnauthorized}
+		}
+
+		return http.StatusOK, services.LoginUserResponse{AccessToken: "access-token", RefreshToken: "refresh-token"}
+	}
+
+	// the password login returns the challenge, not tokens
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"email":"jane@gmail.com","password":"password"}`))
+	require.NoError(t, err)
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var challenge map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	require.Equal(t, true, challenge["mfa_required"])
+	require.Equal(t, "mfa-token", challenge["mfa_token"])
+	require.NotContains(t, challenge, "access_token")
+	require.NotContains(t, challenge, "expiration_at")
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "valid code",
+			body: `{"mfa_token":"mfa-token","code":"123456","client_ip":"203.0.113.9"}`,
+			want: http.StatusOK,
+		},
+		{
+			name: "wrong code",
+			body: `{"mfa_token":"mfa-token","code":"000000"}`,
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "missing token",
+			body: `{"code":"123456"}`,
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			req.RemoteAddr = "192.0.2.1:40000"
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.want == http.StatusOK {
+				var rsp services.LoginUserResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+				require.Equal(t, "access-token", rsp.AccessToken)
+				require.Equal(t, "grpc", w.Header().Get(transportHeader))
+				require.Equal(t, "192.0.2.1", got.ClientIP)
+			}
+		})
+	}
+}
+
+func TestHttpServer_handleMFA(t *testing.T) {
+	s := NewTestHttpServer()
+
+	accessToken, err := s.signer.CreateToken("user", 1, time.Minute)
+	require.NoError(t, err)
+
+	s.GrpcService.EnrollMFAViagRPCFunc = func(req services.EnrollMFARequest, userID int64) (int, services.EnrollMFAResponse) {
+		if req.Password != "password" || userID != 1 {
+			return http.StatusUnauthorized, services.EnrollMFAResponse{Message: "invalid password", StatusCode: http.StatusUnauthorized}
+		}
+
+		return http.StatusOK, services.EnrollMFAResponse{
+			Secret:        "JBSWY3DPEHPK3PXP",
+			OtpauthURI:    "otpauth://totp/jane@gmail.com?secret=JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"abcde-fghij"},
+		}
+	}
+	s.GrpcService.ActivateMFAViagRPCFunc = func(req services.ActivateMFARequest, userID int64) (int, services.ActivateMFAResponse) {
+		if req.Code != "123456" {
+			return http.StatusBadRequest, services.ActivateMFAResponse{Message: "invalid code", StatusCode: http.StatusBadRequest}
+		}
+
+		return http.StatusOK, services.ActivateMFAResponse{EnabledAt: time.Now()}
+	}
+	s.GrpcService.DisableMFAViagRPCFunc = func(req services.DisableMFARequest, userID int64) (int, services.DisableMFAResponse) {
+		return http.StatusOK, services.DisableMFAResponse{Message: "two-factor authentication disabled"}
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		body  string
+		token bool
+		want  int
+		check func(t *testing.T, body []byte)
+	}{
+		{
+			name:  "enroll",
+			path:  "/mfa/enroll",
+			body:  `{"password":"password"}`,
+			token: true,
+			want:  http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var rsp services.EnrollMFAResponse
+				require.NoError(t, json.Unmarshal(body, &rsp))
+				require.Equal(t, "JBSWY3DPEHPK3PXP", rsp.Secret)
+				require.Len(t, rsp.RecoveryCodes, 1)
 			},
+		},
+		{
+			name:  "enroll with wrong password",
+			path:  "/mfa/enroll",
+			body:  `{"password":"wrong"}`,
+			token: true,
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name: "enroll without token",
+			path: "/mfa/enroll",
+			body: `{"password":"password"}`,
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:  "activate",
+			path:  "/mfa/activate",
+			body:  `{"code":"123456"}`,
+			token: true,
+			want:  http.StatusOK,
+		},
+		{
+			name:  "activate with wrong code",
+			path:  "/mfa/activate",
+			body:  `{"code":"000000"}`,
+			token: true,
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "disable",
+			path:  "/mfa/disable",
+			body:  `{"code":"123456"}`,
+			token: true,
+			want:  http.StatusOK,
+		},
+		{
+			name:  "disable without code",
+			path:  "/mfa/disable",
+			body:  `{}`,
+			token: true,
+			want:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			if tc.token {
+				req.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+			}
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.check != nil {
+				tc.check(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestHttpServer_handleLogout(t *testing.T) {
+	type revoke struct {
+		refreshToken string
+		userID       int64
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		grpcStatus int
+		want       int
+		wantRevoke []revoke
+		// whether the access token of the session is revoked, and one issued before it
+		wantRevoked, wantOtherRevoked bool
+	}{
+		{
+			name:        "access token only",
+			path:        "/logout",
+			want:        http.StatusOK,
+			wantRevoked: true,
+		},
+		{
+			name:        "with the refresh token",
+			path:        "/logout",
+			body:        `{"refresh_token":"refresh-token"}`,
+			want:        http.StatusOK,
+			wantRevoke:  []revoke{{refreshToken: "refresh-token", userID: 1}},
+			wantRevoked: true,
+		},
+		{
+			name:             "everywhere",
+			path:             "/logout/all",
+			body:             `{"refresh_token":"refresh-token"}`,
+			want:             http.StatusOK,
+			wantRevoke:       []revoke{{userID: 1}},
+			wantRevoked:      true,
+			wantOtherRevoked: true,
+		},
+		{
+			name:       "refresh tokens not revoked",
+			path:       "/logout/all",
+			grpcStatus: http.StatusInternalServerError,
+			want:       http.StatusInternalServerError,
+			wantRevoke: []revoke{{userID: 1}},
+		},
+		{
+			name: "malformed body",
+			path: "/logout",
+			body: `{`,
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTestHttpServer()
+
+			var revoked []revoke
+
+			s.GrpcService.RevokeRefreshTokensViagRPCFunc = func(req services.LogoutRequest, userID int64) (int, services.LogoutResponse) {
+				revoked = append(revoked, revoke{refreshToken: req.RefreshToken, userID: userID})
+
+				if tc.grpcStatus != 0 {
+					return tc.grpcStatus, services.LogoutResponse{Message: "db error", StatusCode: tc.grpcStatus}
+				}
+
+				return http.StatusOK, services.LogoutResponse{}
+			}
+
+			other, err := s.signer.CreateToken("user", 1, time.Minute)
+			require.NoError(t, err)
+
+			token, err := s.signer.CreateToken("user", 1, time.Minute)
+			require.NoError(t, err)
+
+			send := func(token, path, body string) int {
+				w := httptest.NewRecorder()
+
+				req, err := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+				require.NoError(t, err)
+
+				req.Header.Set(authorizationHeaderKey, "Bearer "+token)
+
+				s.server.router.ServeHTTP(w, req)
+
+				return w.Code
+			}
+
+			require.Equal(t, tc.want, send(token, tc.path, tc.body))
+			require.Equal(t, tc.wantRevoke, revoked)
+
+			if tc.wantRevoked {
+				require.Equal(t, http.StatusUnauthorized, send(token, "/logout", ""))
+			} else {
+				require.Equal(t, http.StatusOK, send(token, "/logout", ""))
+			}
+
+			if tc.wantOtherRevoked {
+				require.Equal(t, http.StatusUnauthorized, send(other, "/logout", ""))
+			} else {
+				require.Equal(t, http.StatusOK, send(other, "/logout", ""))
+			}
+		})
+	}
+}
+
+func mockInitiatePaymentViaRabbit(_ services.InitiatePaymentRequest, userID int64) (int, services.InitiatePaymentResponse) {
+	if userID != 1 {
+		return http.StatusForbidden, services.InitiatePaymentResponse{Message: "cannot initiate payments for another account", StatusCode: http.StatusForbidden}
+	}
+
+	return http.StatusOK, services.InitiatePaymentResponse{Message: "success"}
+}
+
+func TestHttpServer_handleInitiatePayment(t *testing.T) {
+	s := NewTestHttpServer()
+
+	accessToken, err := s.signer.CreateToken("user", 1, time.Minute)
+	require.NoError(t, err)
+
+	s.RabbitService.InitiatePaymentViaRabbitFunc = mockInitiatePaymentViaRabbit
+	s.GrpcService.InitiatePaymentViagRPCFunc = func(req services.InitiatePaymentRequest, userID int64) (int, services.InitiatePaymentResponse) {
+		return http.StatusServiceUnavailable, services.InitiatePaymentResponse{Message: "payments unavailable"}
+	}
+
+	// keys are sent on as the user who owns them, who may only pay from their own account
+	s.server.APIKeys = apikeys.NewCache(func(_ context.Context, key string) (apikeys.Key, error) {
+		switch key {
+		case "ppk_owner":
+			return apikeys.Key{ID: 1, UserID: 1, Scopes: []string{pkg.ScopePaymentsInitiate}}, nil
+		case "ppk_other":
+			return apikeys.Key{ID: 2, UserID: 7, Scopes: []string{pkg.ScopePaymentsInitiate}}, nil
+		}
+
+		return apikeys.Key{}, pkg.ErrInvalidAPIKey
+	}, time.Minute)
+
+	validReq := services.InitiatePaymentRequest{
+		Email:       gofakeit.Email(),
+		Action:      "withdrawal",
+		Amount:      200,
+		PhoneNumber: "phone_number",
+		NetworkCode: "63902",
+		Naration:    "narration",
+	}
+
+	tests := []struct {
+		name          string
+		transports    []routing.Transport
+		authorization string
+		req           any
+		want          int
+	}{
+		{
+			name: "success",
+			req:  validReq,
 			want: http.StatusOK,
 		},
+		{
+			name:          "api key of the account",
+			authorization: "Bearer ppk_owner",
+			req:           validReq,
+			want:          http.StatusOK,
+		},
+		{
+			name:          "api key of another account",
+			authorization: "Bearer ppk_other",
+			req:           validReq,
+			want:          http.StatusForbidden,
+		},
+		{
+			name:          "unknown api key",
+			authorization: "Bearer ppk_made-up",
+			req:           validReq,
+			want:          http.StatusUnauthorized,
+		},
 		{
 			name: "missing arg",
 			req:  services.RegisterUserRequest{},
 			want: http.StatusBadRequest,
 		},
+		{
+			name:       "via grpc",
+			transports: []routing.Transport{routing.GRPC},
+			req:        validReq,
+			want:       http.StatusServiceUnavailable,
+		},
+		{
+			name:       "falls back to rabbitmq",
+			transports: []routing.Transport{routing.GRPC, routing.RabbitMQ},
+			req:        validReq,
+			want:       http.StatusOK,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			routes := routing.DefaultRoutes()
+			if tc.transports != nil {
+				routes[routing.InitiatePayment] = tc.transports
+				routes[routing.PollTransaction] = tc.transports
+			}
+
+			s.server.Router = routing.New(routes, 5, time.Minute)
+
 			w := httptest.NewRecorder()
 
 			b, err := json.Marshal(tc.req)
@@ -170,7 +881,13 @@ func TestHttpServer_handleInitiatePayment(t *testing.T) {
 			req, err := http.NewRequest(http.MethodPost, "/payments/initiate", bytes.NewBuffer(b))
 			require.NoError(t, err)
 
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			authorization := tc.authorization
+			if authorization == "" {
+				authorization = fmt.Sprintf("Bearer %s", accessToken)
+			}
+
+			req.RemoteAddr = "10.1.2.3:1234"
+			req.Header.Set("Authorization", authorization)
 
 			s.server.router.ServeHTTP(w, req)
 			require.Equal(t, tc.want, w.Code)
@@ -186,29 +903,148 @@ func mockPollTransactionViaRabbit(req services.PollingTransactionRequest, userID
 	return http.StatusOK, services.PollingTransactionResponse{Message: "success"}
 }
 
+func TestHttpServer_handleConfirmWithdrawal(t *testing.T) {
+	s := NewTestHttpServer()
+
+	accessToken, err := s.signer.CreateToken("user", 1, time.Minute)
+	require.NoError(t, err)
+
+	transactionID := uuid.NewString()
+
+	var got services.ConfirmWithdrawalRequest
+
+	s.GrpcService.ConfirmWithdrawalViagRPCFunc = func(req services.ConfirmWithdrawalRequest, userID int64) (int, services.InitiatePaymentResponse) {
+		got = req
+
+		if req.TransactionID != transactionID || userID != 1 {
+			return http.StatusNotFound, services.InitiatePaymentResponse{Message: "withdrawal does not exist or expired", StatusCode: http.StatusNotFound}
+		}
+
+		if req.Password != "password" && req.Code != "123456" {
+			return http.StatusUnauthorized, services.InitiatePaymentResponse{Message: "invalid password", StatusCode: http.StatusUnauthorized}
+		}
+
+		return http.StatusOK, services.InitiatePaymentResponse{TransactionID: transactionID, Action: "withdrawal"}
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		body  string
+		token bool
+		want  int
+	}{
+		{
+			name:  "with password",
+			path:  "/payments/confirm/" + transactionID,
+			body:  `{"password":"password"}`,
+			token: true,
+			want:  http.StatusOK,
+		},
+		{
+			name:  "with code",
+			path:  "/payments/confirm/" + transactionID,
+			body:  `{"code":"123456"}`,
+			token: true,
+			want:  http.StatusOK,
+		},
+		{
+			name:  "wrong password",
+			path:  "/payments/confirm/" + transactionID,
+			body:  `{"password":"wrong"}`,
+			token: true,
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "expired",
+			path:  "/payments/confirm/" + uuid.NewString(),
+			body:  `{"password":"password"}`,
+			token: true,
+			want:  http.StatusNotFound,
+		},
+		{
+			name:  "without credentials",
+			path:  "/payments/confirm/" + transactionID,
+			body:  `{}`,
+			token: true,
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "without token",
+			path: "/payments/confirm/" + transactionID,
+			body: `{"password":"password"}`,
+			want: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			if tc.token {
+				req.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+			}
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.want == http.StatusOK {
+				require.Equal(t, "10.0.0.1", got.ClientIP)
+				require.Equal(t, string(routing.GRPC), w.Header().Get(transportHeader))
+			}
+		})
+	}
+}
+
 func TestHttpServer_handlePaymentPolling(t *testing.T) {
 	s := NewTestHttpServer()
 
-	accessToken, err := s.server.maker.CreateToken("user", 1, time.Minute)
+	accessToken, err := s.signer.CreateToken("user", 1, time.Minute)
 	require.NoError(t, err)
 
-	// change here to the communication channel you are using
 	s.RabbitService.PollTransactionViaRabbitFunc = mockPollTransactionViaRabbit
+	s.GrpcService.PollTransactionViagRPCFunc = func(req services.PollingTransactionRequest, userID int64) (int, services.PollingTransactionResponse) {
+		if userID != 1 {
+			return http.StatusUnauthorized, services.PollingTransactionResponse{Message: "cannot access this transaction"}
+		}
+
+		return http.StatusNotFound, services.PollingTransactionResponse{Message: "transaction does not exist"}
+	}
 
 	tests := []struct {
-		name string
-		path string
-		want int
+		name       string
+		transports []routing.Transport
+		path       string
+		want       int
 	}{
 		{
 			name: "success",
 			path: "/payments/status/123",
 			want: http.StatusOK,
 		},
+		{
+			name:       "via grpc",
+			transports: []routing.Transport{routing.GRPC, routing.RabbitMQ},
+			path:       "/payments/status/123",
+			want:       http.StatusNotFound,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			routes := routing.DefaultRoutes()
+			if tc.transports != nil {
+				routes[routing.InitiatePayment] = tc.transports
+				routes[routing.PollTransaction] = tc.transports
+			}
+
+			s.server.Router = routing.New(routes, 5, time.Minute)
+
 			w := httptest.NewRecorder()
 
 			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
@@ -221,3 +1057,294 @@ func TestHttpServer_handlePaymentPolling(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpServer_handleAPIKeys(t *testing.T) {
+	s := NewTestHttpServer()
+
+	accessToken, err := s.signer.CreateToken("user", 1, time.Minute)
+	require.NoError(t, err)
+
+	s.GrpcService.CreateAPIKeyViagRPCFunc = func(req services.CreateAPIKeyRequest, userID int64) (int, services.CreateAPIKeyResponse) {
+		if req.Scopes[0] != "payments:read" {
+			return http.StatusBadRequest, services.CreateAPIKeyResponse{Message: "unknown scope", StatusCode: http.StatusBadRequest}
+		}
+
+		return http.StatusOK, services.CreateAPIKeyResponse{
+			Key:    "ppk_key",
+			APIKey: &services.APIKey{ID: userID, Name: req.Name, Scopes: req.Scopes},
+		}
+	}
+	s.GrpcService.ListAPIKeysViagRPCFunc = func(userID int64) (int, services.ListAPIKeysResponse) {
+		return http.StatusOK, services.ListAPIKeysResponse{APIKeys: []services.APIKey{{ID: 1, Name: "backend"}}}
+	}
+	s.GrpcService.RevokeAPIKeyViagRPCFunc = func(req services.RevokeAPIKeyRequest, userID int64) (int, services.RevokeAPIKeyResponse) {
+		if req.ID != 1 || userID != 1 {
+			return http.StatusNotFound, services.RevokeAPIKeyResponse{Message: "api key not found", StatusCode: http.StatusNotFound}
+		}
+
+		return http.StatusOK, services.RevokeAPIKeyResponse{Message: "api key revoked"}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+		check  func(t *testing.T, body []byte)
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/api-keys",
+			body:   `{"name":"backend","scopes":["payments:read"]}`,
+			want:   http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var rsp services.CreateAPIKeyResponse
+				require.NoError(t, json.Unmarshal(body, &rsp))
+				require.Equal(t, "ppk_key", rsp.Key)
+				require.Equal(t, "backend", rsp.APIKey.Name)
+			},
+		},
+		{
+			name:   "create without scopes",
+			method: http.MethodPost,
+			path:   "/api-keys",
+			body:   `{"name":"backend"}`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "create with unknown scope",
+			method: http.MethodPost,
+			path:   "/api-keys",
+			body:   `{"name":"backend","scopes":["payments:refund"]}`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/api-keys",
+			want:   http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var rsp services.ListAPIKeysResponse
+				require.NoError(t, json.Unmarshal(body, &rsp))
+				require.Len(t, rsp.APIKeys, 1)
+			},
+		},
+		{
+			name:   "revoke",
+			method: http.MethodDelete,
+			path:   "/api-keys/1",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "revoke unknown key",
+			method: http.MethodDelete,
+			path:   "/api-keys/2",
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "revoke invalid id",
+			method: http.MethodDelete,
+			path:   "/api-keys/abc",
+			want:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+
+			if tc.check != nil {
+				tc.check(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestHttpServer_handleAdmin(t *testing.T) {
+	s := NewTestHttpServer()
+
+	userToken, err := s.signer.CreateToken("user", 7, time.Minute)
+	require.NoError(t, err)
+
+	supportToken, err := s.signer.CreateRoleToken("support", 2, pkg.RoleSupport, time.Minute)
+	require.NoError(t, err)
+
+	adminToken, err := s.signer.CreateRoleToken("admin", 1, pkg.RoleAdmin, time.Minute)
+	require.NoError(t, err)
+
+	var audited []services.RecordAuditEventRequest
+
+	s.GrpcService.AdminGetUserViagRPCFunc = func(req services.AdminGetUserRequest, actorID int64) (int, services.AdminUserResponse) {
+		if req.UserID != 7 && req.Email != "jane@gmail.com" {
+			return http.StatusNotFound, services.AdminUserResponse{Message: "user not found", StatusCode: http.StatusNotFound}
+		}
+
+		return http.StatusOK, services.AdminUserResponse{User: &services.AdminUser{ID: 7, Email: "jane@gmail.com"}}
+	}
+	s.GrpcService.RecordAuditEventViagRPCFunc = func(req services.RecordAuditEventRequest, actorID int64) (int, services.RecordAuditEventResponse) {
+		audited = append(audited, req)
+
+		return http.StatusOK, services.RecordAuditEventResponse{ID: int64(len(audited))}
+	}
+	s.GrpcService.ListTransactionsViagRPCFunc = func(req services.ListTransactionsRequest) (int, services.ListTransactionsResponse) {
+		require.Equal(t, services.ListTransactionsRequest{UserID: 7, Limit: 10}, req)
+
+		return http.StatusOK, services.ListTransactionsResponse{Transactions: []services.PollingTransactionResponse{{Action: "withdrawal"}}}
+	}
+	s.GrpcService.DisableUserViagRPCFunc = func(req services.DisableUserRequest, actorID int64) (int, services.AdminUserResponse) {
+		return http.StatusOK, services.AdminUserResponse{User: &services.AdminUser{ID: req.UserID, DisabledAt: &TestTime}}
+	}
+	s.GrpcService.UnlockUserViagRPCFunc = func(req services.UnlockUserRequest, actorID int64) (int, services.AdminUserResponse) {
+		require.Equal(t, services.UnlockUserRequest{UserID: 7, Reason: "verified by phone"}, req)
+		require.Equal(t, int64(1), actorID)
+
+		return http.StatusOK, services.AdminUserResponse{User: &services.AdminUser{ID: req.UserID}}
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		token     string
+		want      int
+		wantAudit int
+	}{
+		{
+			name:   "user by id",
+			method: http.MethodGet,
+			path:   "/admin/users/7",
+			token:  supportToken,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "user by email",
+			method: http.MethodGet,
+			path:   "/admin/users?email=jane@gmail.com&reason=ticket+42",
+			token:  supportToken,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "no email",
+			method: http.MethodGet,
+			path:   "/admin/users",
+			token:  supportToken,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "user not found",
+			method: http.MethodGet,
+			path:   "/admin/users/8",
+			token:  adminToken,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "not a support",
+			method: http.MethodGet,
+			path:   "/admin/users/7",
+			token:  userToken,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:      "transactions",
+			method:    http.MethodGet,
+			path:      "/admin/users/7/transactions?reason=ticket+42&limit=10",
+			token:     supportToken,
+			want:      http.StatusOK,
+			wantAudit: 1,
+		},
+		{
+			name:   "transactions without a reason",
+			method: http.MethodGet,
+			path:   "/admin/users/7/transactions",
+			token:  supportToken,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "support can not disable",
+			method: http.MethodPost,
+			path:   "/admin/users/7/disable",
+			body:   `{"reason":"fraud"}`,
+			token:  supportToken,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "disable without a reason",
+			method: http.MethodPost,
+			path:   "/admin/users/7/disable",
+			body:   `{}`,
+			token:  adminToken,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "support can not unlock",
+			method: http.MethodPost,
+			path:   "/admin/users/7/unlock",
+			body:   `{"reason":"verified by phone"}`,
+			token:  supportToken,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "unlock without a reason",
+			method: http.MethodPost,
+			path:   "/admin/users/7/unlock",
+			body:   `{}`,
+			token:  adminToken,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "unlock",
+			method: http.MethodPost,
+			path:   "/admin/users/7/unlock",
+			body:   `{"reason":"verified by phone"}`,
+			token:  adminToken,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "disable",
+			method: http.MethodPost,
+			path:   "/admin/users/7/disable",
+			body:   `{"reason":"fraud"}`,
+			token:  adminToken,
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			audited = nil
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tc.token))
+
+			s.server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.want, w.Code)
+			require.Len(t, audited, tc.wantAudit)
+		})
+	}
+
+	// the access tokens of the disabled user are revoked
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodPost, "/logout", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+
+	s.server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
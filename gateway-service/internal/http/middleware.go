@@ -1,10 +1,16 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"strings"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +20,10 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authenticationMiddleware(tokenMaker pkg.JWTMaker) gin.HandlerFunc {
+// authenticationMiddleware authenticates the request by the access token or the API key
+// in its Authorization header, both sent as Bearer. API keys are only accepted when the
+// server has APIKeys.
+func (s *HttpServer) authenticationMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizatonHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizatonHeader) == 0 {
@@ -41,14 +50,174 @@ func authenticationMiddleware(tokenMaker pkg.JWTMaker) gin.HandlerFunc {
 
 		accessToken := fields[1]
 
-		payload, err := tokenMaker.VerifyToken(accessToken)
-		if err != nil {
+		var (
+			payload *pkg.Payload
+			err     error
+		)
+
+		if pkg.IsAPIKey(accessToken) {
+			payload, err = s.verifyAPIKey(ctx, accessToken)
+		} else {
+			payload, err = s.verifier.VerifyToken(ctx.Request.Context(), accessToken)
+		}
+
+		switch {
+		case errors.Is(err, pkg.ErrAddressNotAllowed):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status_code": http.StatusForbidden, "message": err.Error()})
+
+			return
+		case errors.Is(err, pkg.ErrKeysUnavailable), errors.Is(err, pkg.ErrAPIKeysUnavailable):
+			slog.ErrorContext(ctx.Request.Context(), "failed to verify token", "error", err)
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+			return
+		case err != nil:
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status_code": http.StatusUnauthorized, "message": err.Error()})
 
 			return
 		}
 
 		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Request = ctx.Request.WithContext(logging.WithUserID(ctx.Request.Context(), payload.UserID))
+		ctx.Next()
+	}
+}
+
+func (s *HttpServer) verifyAPIKey(ctx *gin.Context, key string) (*pkg.Payload, error) {
+	if s.APIKeys == nil {
+		return nil, pkg.ErrInvalidAPIKey
+	}
+
+	clientIP, err := netip.ParseAddr(ctx.ClientIP())
+	if err != nil {
+		return nil, pkg.ErrAddressNotAllowed
+	}
+
+	return s.APIKeys.Verify(ctx.Request.Context(), key, clientIP)
+}
+
+// requireScope lets through the requests allowed scope: those of the user themselves,
+// and those of API keys granted it.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "type assertion failed"})
+
+			return
+		}
+
+		if !payload.HasScope(scope) {
+			err := fmt.Errorf("api key is missing the %s scope", scope)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status_code": http.StatusForbidden, "message": err.Error()})
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireSession lets through the requests authenticated by an access token, keeping
+// API keys away from the routes that manage the user's credentials.
+func requireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "type assertion failed"})
+
+			return
+		}
+
+		if payload.APIKeyID != 0 {
+			err := errors.New("api keys can not be used for this route, log in instead")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status_code": http.StatusForbidden, "message": err.Error()})
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireRole lets through the requests of users with role or a role above it. The role
+// is the one the access token was issued with; the services check it again against the
+// user's account.
+func requireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "type assertion failed"})
+
+			return
+		}
+
+		if !payload.HasRole(role) {
+			err := fmt.Errorf("the %s role is required for this route", role)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status_code": http.StatusForbidden, "message": err.Error()})
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// revocationMiddleware rejects the access tokens revoked by a logout, once
+// authenticationMiddleware has verified them. Revocations are not checked when the
+// server has no Revocations.
+func (s *HttpServer) revocationMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.Revocations == nil {
+			ctx.Next()
+
+			return
+		}
+
+		payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "type assertion failed"})
+
+			return
+		}
+
+		// API keys are revoked by the authentication service, not by a logout
+		if payload.APIKeyID != 0 {
+			ctx.Next()
+
+			return
+		}
+
+		revoked, err := s.Revocations.Revoked(ctx.Request.Context(), payload)
+		if err != nil {
+			slog.ErrorContext(ctx.Request.Context(), "failed to check token revocation", "error", err)
+
+			// a token that can not be checked is not trusted
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, pkg.ErrorResponse(unavailableMessage, http.StatusServiceUnavailable))
+
+			return
+		}
+
+		if revoked {
+			err := errors.New("token has been revoked")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status_code": http.StatusUnauthorized, "message": err.Error()})
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// budget bounds the request context by the timeout of route, so that the calls to the
+// services stop once it is spent and stop as well when the client goes away.
+func (s *HttpServer) budget(route routing.Route) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c, cancel := context.WithTimeout(ctx.Request.Context(), s.Router.Timeout(route))
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(c)
+
 		ctx.Next()
 	}
 }
@@ -1,28 +1,34 @@
 package http
 
 import (
-	"crypto/rand"
+	"context"
 	"crypto/rsa"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/revocation"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func addAuthorization(
 	t *testing.T,
 	req *http.Request,
-	tokenMaker pkg.JWTMaker,
+	signer *pkg.TestSigner,
 	authorizationType string,
 	username string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, 1, duration)
+	token, err := signer.CreateToken(username, 1, duration)
 	require.NoError(t, err)
 
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
@@ -32,13 +38,13 @@ func addAuthorization(
 func TestAuthenticationMiddleware(t *testing.T) {
 	testCases := []struct {
 		name             string
-		setAuthorization func(req *http.Request, t *testing.T, tokenMaker pkg.JWTMaker)
+		setAuthorization func(req *http.Request, t *testing.T, signer *pkg.TestSigner)
 		checkResponse    func(t *testing.T, recorder httptest.ResponseRecorder)
 	}{
 		{
 			name: "valid_token",
-			setAuthorization: func(req *http.Request, t *testing.T, tokenMaker pkg.JWTMaker) {
-				addAuthorization(t, req, tokenMaker, "Bearer", "user", time.Minute)
+			setAuthorization: func(req *http.Request, t *testing.T, signer *pkg.TestSigner) {
+				addAuthorization(t, req, signer, "Bearer", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -46,7 +52,7 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		},
 		{
 			name: "missing_authorization_header",
-			setAuthorization: func(_ *http.Request, _ *testing.T, _ pkg.JWTMaker) {
+			setAuthorization: func(_ *http.Request, _ *testing.T, _ *pkg.TestSigner) {
 			},
 			checkResponse: func(t *testing.T, recorder httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -54,8 +60,8 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		},
 		{
 			name: "unsuported_authorization",
-			setAuthorization: func(req *http.Request, t *testing.T, tokenMaker pkg.JWTMaker) {
-				addAuthorization(t, req, tokenMaker, "Unsupported", "user", time.Minute)
+			setAuthorization: func(req *http.Request, t *testing.T, signer *pkg.TestSigner) {
+				addAuthorization(t, req, signer, "Unsupported", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -63,8 +69,8 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		},
 		{
 			name: "missing_authorization_type",
-			setAuthorization: func(req *http.Request, t *testing.T, tokenMaker pkg.JWTMaker) {
-				addAuthorization(t, req, tokenMaker, "", "user", time.Minute)
+			setAuthorization: func(req *http.Request, t *testing.T, signer *pkg.TestSigner) {
+				addAuthorization(t, req, signer, "", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -72,8 +78,8 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		},
 		{
 			name: "expired_token",
-			setAuthorization: func(req *http.Request, t *testing.T, tokenMaker pkg.JWTMaker) {
-				addAuthorization(t, req, tokenMaker, "Bearer", "user", -time.Minute)
+			setAuthorization: func(req *http.Request, t *testing.T, signer *pkg.TestSigner) {
+				addAuthorization(t, req, signer, "Bearer", "user", -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -83,20 +89,12 @@ func TestAuthenticationMiddleware(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-			if err != nil {
-				panic(err)
-			}
+			signer := pkg.NewTestSigner()
 
-			publicKey := &privateKey.PublicKey
-
-			testServer := NewHttpServer(pkg.JWTMaker{
-				PublicKey:  publicKey,
-				PrivateKey: privateKey,
-			})
+			testServer := NewHttpServer(pkg.NewJWTVerifier(signer.Keys()))
 			testServer.router.GET(
 				"/test-auth",
-				authenticationMiddleware(testServer.maker),
+				testServer.authenticationMiddleware(),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -106,9 +104,210 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, "/test-auth", nil)
 			require.NoError(t, err)
 
-			tc.setAuthorization(request, t, testServer.maker)
+			tc.setAuthorization(request, t, signer)
 			testServer.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, *recorder)
 		})
 	}
 }
+
+// downKeys is a key source whose keys can not be fetched.
+type downKeys struct{}
+
+func (downKeys) PublicKey(context.Context, string) (*rsa.PublicKey, error) {
+	return nil, fmt.Errorf("%w: connection refused", pkg.ErrKeysUnavailable)
+}
+
+func TestAuthenticationMiddleware_KeysUnavailable(t *testing.T) {
+	testServer := NewHttpServer(pkg.NewJWTVerifier(downKeys{}))
+	testServer.router.GET("/test-auth", testServer.authenticationMiddleware(), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/test-auth", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, pkg.NewTestSigner(), "Bearer", "user", time.Minute)
+	testServer.router.ServeHTTP(recorder, request)
+
+	// the token may well be valid, the client is told to retry rather than to log in again
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestAuthenticationMiddleware_APIKeys(t *testing.T) {
+	keys := map[string]apikeys.Key{
+		"ppk_reader": {
+			ID:         1,
+			UserID:     7,
+			Scopes:     []string{pkg.ScopePaymentsRead},
+			AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		},
+	}
+
+	var down bool
+
+	s := NewTestHttpServer()
+	s.server.APIKeys = apikeys.NewCache(func(_ context.Context, key string) (apikeys.Key, error) {
+		if down {
+			return apikeys.Key{}, errors.New("connection refused")
+		}
+
+		k, ok := keys[key]
+		if !ok {
+			return apikeys.Key{}, pkg.ErrInvalidAPIKey
+		}
+
+		return k, nil
+	}, time.Minute)
+
+	ok := func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+		ctx.JSON(http.StatusOK, gin.H{"user_id": payload.UserID})
+	}
+
+	auth := s.server.router.Group("/").Use(s.server.authenticationMiddleware(), s.server.revocationMiddleware())
+	auth.GET("/test-read", requireScope(pkg.ScopePaymentsRead), ok)
+	auth.GET("/test-initiate", requireScope(pkg.ScopePaymentsInitiate), ok)
+	auth.GET("/test-session", requireSession(), ok)
+
+	send := func(path, authorization, remoteAddr string) int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		request.RemoteAddr = remoteAddr
+		request.Header.Set(authorizationHeaderKey, authorization)
+		s.server.router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	for _, tc := range []struct {
+		name          string
+		path          string
+		authorization string
+		remoteAddr    string
+		want          int
+	}{
+		{name: "valid key", path: "/test-read", authorization: "Bearer ppk_reader", remoteAddr: "10.1.2.3:1234", want: http.StatusOK},
+		{name: "unknown key", path: "/test-read", authorization: "Bearer ppk_made-up", remoteAddr: "10.1.2.3:1234", want: http.StatusUnauthorized},
+		{name: "address not allowed", path: "/test-read", authorization: "Bearer ppk_reader", remoteAddr: "192.168.1.1:1234", want: http.StatusForbidden},
+		{name: "missing scope", path: "/test-initiate", authorization: "Bearer ppk_reader", remoteAddr: "10.1.2.3:1234", want: http.StatusForbidden},
+		{name: "session only", path: "/test-session", authorization: "Bearer ppk_reader", remoteAddr: "10.1.2.3:1234", want: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, send(tc.path, tc.authorization, tc.remoteAddr))
+		})
+	}
+
+	// the X-Forwarded-For header is not trusted unless the proxy is
+	forwarded := func(path string) int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		request.RemoteAddr = "192.168.1.1:1234"
+		request.Header.Set("X-Forwarded-For", "10.1.2.3")
+		request.Header.Set(authorizationHeaderKey, "Bearer ppk_reader")
+		s.server.router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, forwarded("/test-read"))
+
+	require.NoError(t, s.server.TrustProxies([]string{"192.168.1.1"}))
+	require.Equal(t, http.StatusOK, forwarded("/test-read"))
+
+	// access tokens carry every scope
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/test-initiate", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, s.signer, "Bearer", "user", time.Minute)
+	s.server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// keys that are not cached can not be checked while the service is down
+	down = true
+	keys["ppk_other"] = apikeys.Key{ID: 2, UserID: 7}
+
+	require.Equal(t, http.StatusServiceUnavailable, send("/test-read", "Bearer ppk_other", "10.1.2.3:1234"))
+
+	// and are rejected by a server without API keys
+	s.server.APIKeys = nil
+	require.Equal(t, http.StatusUnauthorized, send("/test-read", "Bearer ppk_reader", "10.1.2.3:1234"))
+}
+
+// downStore is a revocation store that can not be reached.
+type downStore struct {
+	*revocation.MemoryStore
+}
+
+func (downStore) Lookup(context.Context, uuid.UUID, int64) (bool, time.Time, error) {
+	return false, time.Time{}, errors.New("connection refused")
+}
+
+func TestHttpServer_revocationMiddleware(t *testing.T) {
+	s := NewTestHttpServer()
+
+	s.server.router.GET("/test-revocation", s.server.authenticationMiddleware(), s.server.revocationMiddleware(), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	send := func() int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/test-revocation", nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, s.signer, "Bearer", "user", time.Minute)
+		s.server.router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, send())
+
+	require.NoError(t, s.server.Revocations.RevokeUserTokens(context.Background(), 1))
+	require.Equal(t, http.StatusUnauthorized, send())
+
+	// tokens are not trusted while the revocations can not be checked
+	s.server.Revocations = revocation.NewChecker(downStore{revocation.NewMemoryStore()}, time.Second, time.Minute)
+	require.Equal(t, http.StatusServiceUnavailable, send())
+
+	s.server.Revocations = nil
+	require.Equal(t, http.StatusOK, send())
+}
+
+func TestHttpServer_budget(t *testing.T) {
+	s := NewTestHttpServer()
+
+	router, err := routing.NewFromConfig(pkg.Config{TIMEOUT_POLL_TRANSACTION: time.Second})
+	require.NoError(t, err)
+
+	s.server.Router = router
+
+	var remaining time.Duration
+
+	r := gin.New()
+	r.GET("/", s.server.budget(routing.PollTransaction), func(ctx *gin.Context) {
+		deadline, ok := ctx.Request.Context().Deadline()
+		require.True(t, ok)
+
+		remaining = time.Until(deadline)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Greater(t, remaining, 900*time.Millisecond)
+	require.LessOrEqual(t, remaining, time.Second)
+
+	// routes without a budget of their own get the default
+	r.GET("/login", s.server.budget(routing.LoginUser), func(ctx *gin.Context) {
+		deadline, _ := ctx.Request.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/login", nil))
+	require.Greater(t, remaining, routing.DefaultTimeout-100*time.Millisecond)
+}
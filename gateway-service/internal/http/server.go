@@ -3,30 +3,51 @@ package http
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/apikeys"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/metrics"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/revocation"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
 	"github.com/gin-gonic/gin"
 	"github.com/rakyll/statik/fs"
 )
 
 type HttpServer struct {
-	router *gin.Engine
-	maker  pkg.JWTMaker
+	router   *gin.Engine
+	verifier *pkg.JWTVerifier
+
+	HealthChecker *health.Checker
+
+	// Router picks the transport of each route, DefaultRoutes unless replaced.
+	Router *routing.Router
+
+	// Revocations tells the access tokens revoked by a logout apart, and revokes them.
+	Revocations *revocation.Checker
+
+	// APIKeys verifies the API keys of server-to-server clients. Requests with an API
+	// key are rejected when it is nil.
+	APIKeys *apikeys.Cache
 
 	HTTPService   services.HttpInterface
 	RabbitService services.RabbitInterface
 	GRPCService   services.GrpcInterface
 }
 
-func NewHttpServer(maker pkg.JWTMaker) *HttpServer {
+func NewHttpServer(verifier *pkg.JWTVerifier) *HttpServer {
 	server := &HttpServer{
-		maker: maker,
+		verifier: verifier,
+		Router:   routing.New(routing.DefaultRoutes(), 5, 30*time.Second),
 	}
 
 	server.setRoutes()
@@ -35,9 +56,21 @@ func NewHttpServer(maker pkg.JWTMaker) *HttpServer {
 }
 
 func (s *HttpServer) setRoutes() {
-	r := gin.Default()
+	r := gin.New()
+
+	// the client address, which API keys can be restricted to, is the one of the
+	// connection unless proxies are trusted, see TrustProxies
+	if err := r.SetTrustedProxies(nil); err != nil {
+		log.Fatalf("cannot set trusted proxies: %v", err)
+	}
+
+	r.Use(gin.Recovery())
+	r.Use(logging.GinMiddleware())
+	r.Use(tracing.GinMiddleware())
+	r.Use(metrics.GinMiddleware())
 
-	auth := r.Group("/").Use(authenticationMiddleware(s.maker)) // requires access token
+	auth := r.Group("/").Use(s.authenticationMiddleware(), s.revocationMiddleware()) // requires access token or api key
+	admin := r.Group("/admin").Use(s.authenticationMiddleware(), s.revocationMiddleware(), requireSession(), requireRole(pkg.RoleSupport))
 
 	statikFs, err := fs.New()
 	if err != nil {
@@ -45,15 +78,44 @@ func (s *HttpServer) setRoutes() {
 	}
 
 	r.StaticFS("/swagger", statikFs)
-
-	r.POST("/register", s.handleRegisterUser)
-	r.POST("/login", s.handleLoginUser)
-	auth.POST("/payments/initiate", s.handleInitiatePayment)
-	auth.GET("/payments/status/:id", s.handlePaymentPolling)
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/livez", func(ctx *gin.Context) { s.HealthChecker.Live(ctx.Writer, ctx.Request) })
+	r.GET("/readyz", func(ctx *gin.Context) { s.HealthChecker.Ready(ctx.Writer, ctx.Request) })
+
+	r.POST("/register", s.budget(routing.RegisterUser), s.handleRegisterUser)
+	r.POST("/login", s.budget(routing.LoginUser), s.handleLoginUser)
+	r.POST("/login/mfa", s.budget(routing.MFA), s.handleVerifyMFA)
+	r.POST("/token/refresh", s.budget(routing.RefreshToken), s.handleRefreshToken)
+	r.GET("/verify-email", s.budget(routing.EmailVerify), s.handleVerifyEmail)
+	r.POST("/verify-email/resend", s.budget(routing.EmailVerify), s.handleResendVerificationEmail)
+	r.POST("/password/forgot", s.budget(routing.PasswordReset), s.handleForgotPassword)
+	r.POST("/password/reset", s.budget(routing.PasswordReset), s.handleResetPassword)
+	auth.POST("/logout", requireSession(), s.budget(routing.Logout), s.handleLogout)
+	auth.POST("/logout/all", requireSession(), s.budget(routing.Logout), s.handleLogoutAll)
+	auth.POST("/api-keys", requireSession(), s.budget(routing.APIKeys), s.handleCreateAPIKey)
+	auth.GET("/api-keys", requireSession(), s.budget(routing.APIKeys), s.handleListAPIKeys)
+	auth.DELETE("/api-keys/:id", requireSession(), s.budget(routing.APIKeys), s.handleRevokeAPIKey)
+	auth.POST("/mfa/enroll", requireSession(), s.budget(routing.MFA), s.handleEnrollMFA)
+	auth.POST("/mfa/activate", requireSession(), s.budget(routing.MFA), s.handleActivateMFA)
+	auth.POST("/mfa/disable", requireSession(), s.budget(routing.MFA), s.handleDisableMFA)
+	auth.POST("/payments/initiate", requireScope(pkg.ScopePaymentsInitiate), s.budget(routing.InitiatePayment), s.handleInitiatePayment)
+	auth.POST("/payments/confirm/:id", requireSession(), s.budget(routing.ConfirmWithdrawal), s.handleConfirmWithdrawal)
+	auth.GET("/payments/status/:id", requireScope(pkg.ScopePaymentsRead), s.budget(routing.PollTransaction), s.handlePaymentPolling)
+	admin.GET("/users", s.budget(routing.Admin), s.handleAdminFindUser)
+	admin.GET("/users/:id", s.budget(routing.Admin), s.handleAdminGetUser)
+	admin.GET("/users/:id/transactions", s.budget(routing.Admin), s.handleAdminListTransactions)
+	admin.POST("/users/:id/disable", requireRole(pkg.RoleAdmin), s.budget(routing.Admin), s.handleDisableUser)
+	admin.POST("/users/:id/unlock", requireRole(pkg.RoleAdmin), s.budget(routing.Admin), s.handleUnlockUser)
 
 	s.router = r
 }
 
+// TrustProxies trusts the X-Forwarded-For header set by the given proxies, addresses or
+// networks, for the client address. It is not trusted otherwise.
+func (s *HttpServer) TrustProxies(proxies []string) error {
+	return s.router.SetTrustedProxies(proxies)
+}
+
 func (s *HttpServer) Start(addr string) {
 	srv := &http.Server{
 		Addr:    addr,
@@ -80,7 +142,7 @@ func (s *HttpServer) Start(addr string) {
 
 	select {
 	case <-ctx.Done():
-		log.Println("timeout of 5 seconds.")
+		slog.Info("timeout of 5 seconds.")
 	}
-	log.Println("Server exiting")
+	slog.Info("Server exiting")
 }
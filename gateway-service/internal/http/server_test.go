@@ -1,17 +1,18 @@
 package http
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
+	"time"
 
 	_ "github.com/EmilioCliff/payment-polling-app/gateway-service/docs/statik"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/revocation"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
 	"github.com/gin-gonic/gin"
 )
 
 type TestHttpServer struct {
 	server *HttpServer
+	signer *pkg.TestSigner
 
 	GrpcService   mock.MockGrpcService
 	HTTPService   mock.MockHttpService
@@ -21,23 +22,17 @@ type TestHttpServer struct {
 func NewTestHttpServer() *TestHttpServer {
 	gin.SetMode(gin.TestMode)
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		panic(err)
-	}
-
-	publicKey := &privateKey.PublicKey
+	signer := pkg.NewTestSigner()
 
 	s := &TestHttpServer{
-		server: NewHttpServer(pkg.JWTMaker{
-			PublicKey:  publicKey,
-			PrivateKey: privateKey,
-		}),
+		server: NewHttpServer(pkg.NewJWTVerifier(signer.Keys())),
+		signer: signer,
 	}
 
 	s.server.HTTPService = &s.HTTPService
 	s.server.GRPCService = &s.GrpcService
 	s.server.RabbitService = &s.RabbitService
+	s.server.Revocations = revocation.NewChecker(revocation.NewMemoryStore(), time.Second, time.Minute)
 
 	return s
 }
@@ -0,0 +1,228 @@
+// Package jwks keeps the public keys of the authentication service the gateway verifies
+// access tokens with. The key set is fetched from the service and cached, and fetched
+// again when it gets old or a token names a key it does not have, which is how the
+// gateway follows a rotation of the signing keys without being redeployed.
+package jwks
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+)
+
+// Key is an RSA public key in the JSON Web Key format of RFC 7517.
+type Key struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+// Set is a JSON Web Key Set, the signing key first.
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// PublicKey returns the RSA public key of k.
+func (k Key) PublicKey() (*rsa.PublicKey, error) {
+	if k.KeyType != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid key")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// Fetcher fetches the key set of the authentication service.
+type Fetcher func(ctx context.Context) (Set, error)
+
+var _ pkg.KeySource = (*Cache)(nil)
+
+// Cache is the key set of the authentication service as last fetched.
+type Cache struct {
+	fetch Fetcher
+
+	// maxAge is how long a key set is used before it is fetched again, so that retired
+	// keys stop being accepted.
+	maxAge time.Duration
+
+	// minInterval is how long to wait between two fetches for unknown keys, so that
+	// tokens naming made up keys can not have the gateway hammer the service.
+	minInterval time.Duration
+
+	now func() time.Time
+
+	// fetching is held while the key set is fetched, so that requests waiting on a
+	// fetch share it.
+	fetching sync.Mutex
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	signing   *rsa.PublicKey
+	fetchedAt time.Time
+
+	// triedAt is when a fetch last finished, and err how it failed if it did.
+	triedAt time.Time
+	err     error
+}
+
+func NewCache(fetch Fetcher, maxAge, minInterval time.Duration) *Cache {
+	return &Cache{
+		fetch:       fetch,
+		maxAge:      maxAge,
+		minInterval: minInterval,
+		now:         time.Now,
+		keys:        make(map[string]*rsa.PublicKey),
+	}
+}
+
+// PublicKey returns the key with the given ID, fetching the key set again when it has
+// no such key or it is too old. Tokens without a key ID, issued before keys had one,
+// are verified with the signing key.
+func (c *Cache) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	key, fetchedAt, triedAt := c.lookup(kid)
+
+	now := c.now()
+
+	if key != nil && now.Sub(fetchedAt) < c.maxAge {
+		return key, nil
+	}
+
+	// fetches are spaced out, whether they failed or found no such key
+	if !triedAt.IsZero() && now.Sub(triedAt) < c.minInterval {
+		switch {
+		case key != nil:
+			return key, nil
+		case fetchedAt.IsZero():
+			return nil, pkg.ErrKeysUnavailable
+		default:
+			return nil, pkg.ErrUnknownKey
+		}
+	}
+
+	if err := c.refresh(ctx, triedAt); err != nil {
+		// an old key set is still better than none while the service can not be reached
+		if key != nil {
+			slog.WarnContext(ctx, "failed to refresh the signing keys", "error", err)
+
+			return key, nil
+		}
+
+		return nil, err
+	}
+
+	key, _, _ = c.lookup(kid)
+	if key == nil {
+		return nil, pkg.ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// Refresh fetches the key set, as done when the gateway starts so that the first
+// requests do not wait for it.
+func (c *Cache) Refresh(ctx context.Context) error {
+	_, _, triedAt := c.lookup("")
+
+	return c.refresh(ctx, triedAt)
+}
+
+// refresh fetches the key set, unless a fetch was tried since seen: the requests that
+// waited on it share its outcome.
+func (c *Cache) refresh(ctx context.Context, seen time.Time) error {
+	c.fetching.Lock()
+	defer c.fetching.Unlock()
+
+	c.mu.RLock()
+	triedAt, err := c.triedAt, c.err
+	c.mu.RUnlock()
+
+	if triedAt.After(seen) {
+		return err
+	}
+
+	keys, signing, err := c.fetchKeys(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.triedAt = c.now()
+	c.err = err
+
+	if err != nil {
+		return err
+	}
+
+	c.keys = keys
+	c.signing = signing
+	c.fetchedAt = c.triedAt
+
+	return nil
+}
+
+func (c *Cache) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, *rsa.PublicKey, error) {
+	set, err := c.fetch(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", pkg.ErrKeysUnavailable, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+
+	var signing *rsa.PublicKey
+
+	for _, k := range set.Keys {
+		key, err := k.PublicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "ignoring signing key", "kid", k.KeyID, "error", err)
+
+			continue
+		}
+
+		if signing == nil {
+			signing = key
+		}
+
+		keys[k.KeyID] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("%w: none of them is usable", pkg.ErrKeysUnavailable)
+	}
+
+	return keys, signing, nil
+}
+
+func (c *Cache) lookup(kid string) (*rsa.PublicKey, time.Time, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if kid == "" {
+		return c.signing, c.fetchedAt, c.triedAt
+	}
+
+	return c.keys[kid], c.fetchedAt, c.triedAt
+}
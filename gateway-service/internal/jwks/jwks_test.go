@@ -0,0 +1,189 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T, kid string) (Key, *rsa.PublicKey) {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	publicKey := &privateKey.PublicKey
+
+	return Key{
+		KeyID:     kid,
+		KeyType:   "RSA",
+		Algorithm: "RS256",
+		Use:       "sig",
+		N:         base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+	}, publicKey
+}
+
+// server plays the authentication service, publishing keys and counting the fetches.
+type server struct {
+	mu      sync.Mutex
+	set     Set
+	err     error
+	fetches int
+}
+
+func (s *server) fetch(_ context.Context) (Set, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fetches++
+
+	return s.set, s.err
+}
+
+func TestKey_PublicKey(t *testing.T) {
+	key, publicKey := newKey(t, "key-1")
+
+	got, err := key.PublicKey()
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(got))
+
+	for _, invalid := range []Key{
+		{KeyType: "EC", N: key.N, E: key.E},
+		{KeyType: "RSA", N: "not base64!", E: key.E},
+		{KeyType: "RSA", N: key.N, E: ""},
+		{KeyType: "RSA", E: key.E},
+	} {
+		_, err := invalid.PublicKey()
+		require.Error(t, err)
+	}
+}
+
+func TestCache_Rotation(t *testing.T) {
+	oldKey, oldPublicKey := newKey(t, "old")
+	newKey, newPublicKey := newKey(t, "new")
+
+	s := &server{set: Set{Keys: []Key{oldKey}}}
+	now := time.Now()
+
+	c := NewCache(s.fetch, 10*time.Minute, 30*time.Second)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Refresh(context.Background()))
+
+	got, err := c.PublicKey(context.Background(), "old")
+	require.NoError(t, err)
+	require.True(t, oldPublicKey.Equal(got))
+
+	// tokens from before keys had IDs are verified with the signing key
+	got, err = c.PublicKey(context.Background(), "")
+	require.NoError(t, err)
+	require.True(t, oldPublicKey.Equal(got))
+
+	// the service starts signing with a new key, the gateway learns about it from the
+	// first token naming it
+	s.set = Set{Keys: []Key{newKey, oldKey}}
+	now = now.Add(time.Minute)
+
+	got, err = c.PublicKey(context.Background(), "new")
+	require.NoError(t, err)
+	require.True(t, newPublicKey.Equal(got))
+	require.Equal(t, 2, s.fetches)
+
+	// known keys are served from the cache
+	_, err = c.PublicKey(context.Background(), "old")
+	require.NoError(t, err)
+	require.Equal(t, 2, s.fetches)
+
+	// unknown keys do not trigger a fetch more than once in a while
+	_, err = c.PublicKey(context.Background(), "made-up")
+	require.ErrorIs(t, err, pkg.ErrUnknownKey)
+
+	_, err = c.PublicKey(context.Background(), "made-up")
+	require.ErrorIs(t, err, pkg.ErrUnknownKey)
+	require.Equal(t, 2, s.fetches)
+
+	// the retired key stops being accepted once the key set is fetched again
+	s.set = Set{Keys: []Key{newKey}}
+	now = now.Add(10 * time.Minute)
+
+	_, err = c.PublicKey(context.Background(), "old")
+	require.ErrorIs(t, err, pkg.ErrUnknownKey)
+	require.Equal(t, 3, s.fetches)
+}
+
+func TestCache_Unavailable(t *testing.T) {
+	key, publicKey := newKey(t, "key-1")
+
+	s := &server{err: errors.New("connection refused")}
+	now := time.Now()
+
+	c := NewCache(s.fetch, 10*time.Minute, 30*time.Second)
+	c.now = func() time.Time { return now }
+
+	_, err := c.PublicKey(context.Background(), "key-1")
+	require.ErrorIs(t, err, pkg.ErrKeysUnavailable)
+
+	_, err = c.PublicKey(context.Background(), "key-1")
+	require.ErrorIs(t, err, pkg.ErrKeysUnavailable)
+	require.Equal(t, 1, s.fetches)
+
+	s.set, s.err = Set{Keys: []Key{key}}, nil
+	now = now.Add(30 * time.Second)
+
+	got, err := c.PublicKey(context.Background(), "key-1")
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(got))
+
+	// an old key set is used while the service can not be reached
+	s.err = errors.New("connection refused")
+	now = now.Add(time.Hour)
+
+	got, err = c.PublicKey(context.Background(), "key-1")
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(got))
+	require.Equal(t, 3, s.fetches)
+
+	// a key set without usable keys is not used
+	s.set, s.err = Set{Keys: []Key{{KeyID: "key-2", KeyType: "EC"}}}, nil
+
+	require.ErrorIs(t, c.Refresh(context.Background()), pkg.ErrKeysUnavailable)
+
+	got, err = c.PublicKey(context.Background(), "key-1")
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(got))
+}
+
+func TestCache_ConcurrentFetch(t *testing.T) {
+	key, _ := newKey(t, "key-1")
+
+	s := &server{set: Set{Keys: []Key{key}}}
+
+	c := NewCache(s.fetch, 10*time.Minute, 30*time.Second)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.PublicKey(context.Background(), "key-1")
+			require.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 1, s.fetches)
+}
@@ -0,0 +1,220 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader carries the request ID in HTTP requests and responses, AMQP message
+// headers and, lower-cased, gRPC metadata.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDMetadataKey = "x-request-id"
+	maxRequestIDLength   = 64
+	redacted             = "[REDACTED]"
+)
+
+// Attribute keys that hold credentials. Any attribute, or JSON field of a logged value,
+// whose name contains one of them is replaced with "[REDACTED]".
+var sensitiveKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"apikey",
+	"username_key",
+	"private_key",
+	"encryption_key",
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// Setup makes a JSON logger writing to stdout the default for both slog and the
+// standard log package.
+func Setup() {
+	slog.SetDefault(New(os.Stdout))
+}
+
+// New returns a JSON logger that redacts credentials and adds the request ID and user
+// ID stored in the context of every record.
+func New(w io.Writer) *slog.Logger {
+	return slog.New(contextHandler{
+		Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{ReplaceAttr: redact}),
+	})
+}
+
+// WithRequestID returns a copy of ctx carrying requestID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+
+	return requestID
+}
+
+// WithUserID returns a copy of ctx carrying the ID of the authenticated user.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// GinMiddleware assigns every request an ID, taken from the X-Request-ID header when
+// the caller sent a usable one, echoes it in the response and logs the request once
+// it has been served.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Header(RequestIDHeader, requestID)
+		ctx.Request = ctx.Request.WithContext(WithRequestID(ctx.Request.Context(), requestID))
+
+		ctx.Next()
+
+		slog.InfoContext(ctx.Request.Context(), "request served",
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", ctx.Writer.Status(),
+			"duration", time.Since(start).String(),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
+
+// UnaryClientInterceptor sends the request ID of the call context in the metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if requestID := RequestID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, requestID)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// SetAMQPHeader adds the request ID of ctx to the headers of an outgoing message.
+func SetAMQPHeader(ctx context.Context, headers amqp.Table) {
+	if requestID := RequestID(ctx); requestID != "" {
+		headers[RequestIDHeader] = requestID
+	}
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		if requestID := RequestID(ctx); requestID != "" {
+			record.AddAttrs(slog.String("request_id", requestID))
+		}
+
+		if userID, ok := ctx.Value(userIDKey).(int64); ok {
+			record.AddAttrs(slog.Int64("user_id", userID))
+		}
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	if attr.Value.Kind() != slog.KindAny {
+		return attr
+	}
+
+	if _, ok := attr.Value.Any().(error); ok {
+		return attr
+	}
+
+	return slog.Any(attr.Key, redactValue(attr.Value.Any()))
+}
+
+// redactValue round-trips v through JSON, which is how the handler would print it,
+// so that credentials in nested fields can be found by name.
+func redactValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v
+	}
+
+	return redactJSON(decoded)
+}
+
+func redactJSON(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if isSensitive(key) {
+				value[key] = redacted
+			} else {
+				value[key] = redactJSON(item)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redactJSON(item)
+		}
+	}
+
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
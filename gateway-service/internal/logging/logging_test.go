@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var entry map[string]any
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	return entry
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := New(&buf)
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), 32)
+
+	logger.InfoContext(ctx, "logging in",
+		"password", "secret-password",
+		"request", struct {
+			Email          string `json:"email"`
+			PasswordApiKey string `json:"password_api_key"`
+		}{Email: "john@doe.com", PasswordApiKey: "api-key"},
+	)
+
+	entry := decode(t, &buf)
+	require.Equal(t, "req-1", entry["request_id"])
+	require.Equal(t, float64(32), entry["user_id"])
+	require.Equal(t, redacted, entry["password"])
+	require.Equal(t, map[string]any{"email": "john@doe.com", "password_api_key": redacted}, entry["request"])
+	require.NotContains(t, buf.String(), "api-key")
+}
+
+func TestGinMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+
+	defer slog.SetDefault(previous)
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		requestID string
+		check     func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "uses incoming request id",
+			requestID: "gateway-test-id",
+			check: func(t *testing.T, requestID string) {
+				require.Equal(t, "gateway-test-id", requestID)
+			},
+		},
+		{
+			name:      "generates request id",
+			requestID: "",
+			check: func(t *testing.T, requestID string) {
+				require.Len(t, requestID, 36)
+			},
+		},
+		{
+			name:      "replaces oversized request id",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+			check: func(t *testing.T, requestID string) {
+				require.Len(t, requestID, 36)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			var handlerRequestID string
+
+			r := gin.New()
+			r.Use(GinMiddleware())
+			r.GET("/ping", func(ctx *gin.Context) {
+				handlerRequestID = RequestID(ctx.Request.Context())
+				ctx.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/ping", nil)
+			require.NoError(t, err)
+
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+
+			r.ServeHTTP(w, req)
+
+			tc.check(t, handlerRequestID)
+			require.Equal(t, handlerRequestID, w.Header().Get(RequestIDHeader))
+			require.Equal(t, handlerRequestID, decode(t, &buf)["request_id"])
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var outgoing metadata.MD
+
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+
+		return nil
+	}
+
+	ctx := WithRequestID(context.Background(), "req-2")
+
+	err := UnaryClientInterceptor()(ctx, "/pb.AuthenticationService/LoginUser", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-2"}, outgoing.Get(RequestIDHeader))
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "gateway"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests handled by the gateway.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	grpcClientHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "handled_total",
+		Help:      "Total number of gRPC calls completed by the gateway, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcClientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "handling_seconds",
+		Help:      "Duration of gRPC calls made by the gateway.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	amqpReplyDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "reply_duration_seconds",
+		Help:      "Time between publishing an AMQP request and receiving its reply.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"routing_key"})
+
+	amqpReplyTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "reply_timeouts_total",
+		Help:      "Total number of AMQP requests that timed out waiting for a reply.",
+	}, []string{"routing_key"})
+
+	amqpPendingReplies = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "pending_replies",
+		Help:      "Number of AMQP requests currently waiting for a reply.",
+	})
+)
+
+// Handler exposes the registered metrics in the prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// GinMiddleware records the duration and status of every request served by the router.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequestDuration.WithLabelValues(
+			ctx.Request.Method,
+			route,
+			strconv.Itoa(ctx.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// UnaryClientInterceptor records the outcome and latency of every unary gRPC call.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		grpcClientDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		grpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+
+		return err
+	}
+}
+
+// ObserveAMQPReply records the time taken to receive a reply for a request published on routingKey.
+func ObserveAMQPReply(routingKey string, start time.Time) {
+	amqpReplyDuration.WithLabelValues(routingKey).Observe(time.Since(start).Seconds())
+}
+
+// AMQPReplyTimeout counts a request published on routingKey that never received a reply.
+func AMQPReplyTimeout(routingKey string) {
+	amqpReplyTimeouts.WithLabelValues(routingKey).Inc()
+}
+
+// AMQPReplyPending tracks a new entry in the response map.
+func AMQPReplyPending() {
+	amqpPendingReplies.Inc()
+}
+
+// AMQPReplyDone tracks an entry removed from the response map.
+func AMQPReplyDone() {
+	amqpPendingReplies.Dec()
+}
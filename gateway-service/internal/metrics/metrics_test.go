@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func scrape(t *testing.T, r *gin.Engine) string {
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return w.Body.String()
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.GET("/metrics", Handler())
+	r.GET("/ping/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/ping/32", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTeapot, w.Code)
+
+	body := scrape(t, r)
+	require.Contains(t, body, `gateway_http_request_duration_seconds_count{method="GET",route="/ping/:id",status="418"} 1`)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := UnaryClientInterceptor()
+
+	invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		return status.Error(codes.NotFound, "user not found")
+	}
+
+	err := interceptor(context.Background(), "/pb.authenticationService/GetUser", nil, nil, nil, invoker)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/metrics", Handler())
+
+	body := scrape(t, r)
+	require.Contains(t, body, `gateway_grpc_client_handled_total{code="NotFound",method="/pb.authenticationService/GetUser"} 1`)
+}
+
+func TestAMQPReplyMetrics(t *testing.T) {
+	AMQPReplyPending()
+	ObserveAMQPReply("payments.poll_payments", time.Now())
+	AMQPReplyDone()
+
+	AMQPReplyTimeout("payments.poll_payments")
+
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/metrics", Handler())
+
+	body := scrape(t, r)
+	require.Contains(t, body, `gateway_amqp_reply_duration_seconds_count{routing_key="payments.poll_payments"} 1`)
+	require.Contains(t, body, `gateway_amqp_reply_timeouts_total{routing_key="payments.poll_payments"} 1`)
+	require.Contains(t, body, `gateway_amqp_pending_replies 0`)
+}
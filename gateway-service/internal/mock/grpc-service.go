@@ -1,20 +1,185 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 )
 
 var _ services.GrpcInterface = (*MockGrpcService)(nil)
 
 type MockGrpcService struct {
-	RegisterUserViagRPCFunc func(services.RegisterUserRequest) (int, services.RegisterUserResponse)
-	LoginUserViagRPCFunc    func(services.LoginUserRequest) (int, services.LoginUserResponse)
+	RegisterUserViagRPCFunc            func(services.RegisterUserRequest) (int, services.RegisterUserResponse)
+	LoginUserViagRPCFunc               func(services.LoginUserRequest) (int, services.LoginUserResponse)
+	RefreshTokenViagRPCFunc            func(services.RefreshTokenRequest) (int, services.RefreshTokenResponse)
+	RevokeRefreshTokensViagRPCFunc     func(services.LogoutRequest, int64) (int, services.LogoutResponse)
+	VerifyEmailViagRPCFunc             func(services.VerifyEmailRequest) (int, services.VerifyEmailResponse)
+	ResendVerificationEmailViagRPCFunc func(services.ResendVerificationEmailRequest) (int, services.ResendVerificationEmailResponse)
+	ForgotPasswordViagRPCFunc          func(services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse)
+	ResetPasswordViagRPCFunc           func(services.ResetPasswordRequest) (int, services.ResetPasswordResponse)
+	VerifyMFAViagRPCFunc               func(services.VerifyMFARequest) (int, services.LoginUserResponse)
+	EnrollMFAViagRPCFunc               func(services.EnrollMFARequest, int64) (int, services.EnrollMFAResponse)
+	ActivateMFAViagRPCFunc             func(services.ActivateMFARequest, int64) (int, services.ActivateMFAResponse)
+	DisableMFAViagRPCFunc              func(services.DisableMFARequest, int64) (int, services.DisableMFAResponse)
+	CreateAPIKeyViagRPCFunc            func(services.CreateAPIKeyRequest, int64) (int, services.CreateAPIKeyResponse)
+	ListAPIKeysViagRPCFunc             func(int64) (int, services.ListAPIKeysResponse)
+	RevokeAPIKeyViagRPCFunc            func(services.RevokeAPIKeyRequest, int64) (int, services.RevokeAPIKeyResponse)
+	AdminGetUserViagRPCFunc            func(services.AdminGetUserRequest, int64) (int, services.AdminUserResponse)
+	DisableUserViagRPCFunc             func(services.DisableUserRequest, int64) (int, services.AdminUserResponse)
+	UnlockUserViagRPCFunc              func(services.UnlockUserRequest, int64) (int, services.AdminUserResponse)
+	RecordAuditEventViagRPCFunc        func(services.RecordAuditEventRequest, int64) (int, services.RecordAuditEventResponse)
+	InitiatePaymentViagRPCFunc         func(services.InitiatePaymentRequest, int64) (int, services.InitiatePaymentResponse)
+	ConfirmWithdrawalViagRPCFunc       func(services.ConfirmWithdrawalRequest, int64) (int, services.InitiatePaymentResponse)
+	PollTransactionViagRPCFunc         func(services.PollingTransactionRequest, int64) (int, services.PollingTransactionResponse)
+	ListTransactionsViagRPCFunc        func(services.ListTransactionsRequest) (int, services.ListTransactionsResponse)
 }
 
-func (m *MockGrpcService) RegisterUserViagRPC(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+func (m *MockGrpcService) RegisterUserViagRPC(_ context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
 	return m.RegisterUserViagRPCFunc(req)
 }
 
-func (m *MockGrpcService) LoginUserViagRPC(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+func (m *MockGrpcService) LoginUserViagRPC(_ context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
 	return m.LoginUserViagRPCFunc(req)
 }
+
+func (m *MockGrpcService) RefreshTokenViagRPC(_ context.Context, req services.RefreshTokenRequest) (int, services.RefreshTokenResponse) {
+	return m.RefreshTokenViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) RevokeRefreshTokensViagRPC(
+	_ context.Context,
+	req services.LogoutRequest,
+	userID int64,
+) (int, services.LogoutResponse) {
+	return m.RevokeRefreshTokensViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) VerifyEmailViagRPC(_ context.Context, req services.VerifyEmailRequest) (int, services.VerifyEmailResponse) {
+	return m.VerifyEmailViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) ResendVerificationEmailViagRPC(
+	_ context.Context,
+	req services.ResendVerificationEmailRequest,
+) (int, services.ResendVerificationEmailResponse) {
+	return m.ResendVerificationEmailViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) ForgotPasswordViagRPC(_ context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	return m.ForgotPasswordViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) ResetPasswordViagRPC(_ context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	return m.ResetPasswordViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) VerifyMFAViagRPC(_ context.Context, req services.VerifyMFARequest) (int, services.LoginUserResponse) {
+	return m.VerifyMFAViagRPCFunc(req)
+}
+
+func (m *MockGrpcService) EnrollMFAViagRPC(
+	_ context.Context,
+	req services.EnrollMFARequest,
+	userID int64,
+) (int, services.EnrollMFAResponse) {
+	return m.EnrollMFAViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) ActivateMFAViagRPC(
+	_ context.Context,
+	req services.ActivateMFARequest,
+	userID int64,
+) (int, services.ActivateMFAResponse) {
+	return m.ActivateMFAViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) DisableMFAViagRPC(
+	_ context.Context,
+	req services.DisableMFARequest,
+	userID int64,
+) (int, services.DisableMFAResponse) {
+	return m.DisableMFAViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) CreateAPIKeyViagRPC(
+	_ context.Context,
+	req services.CreateAPIKeyRequest,
+	userID int64,
+) (int, services.CreateAPIKeyResponse) {
+	return m.CreateAPIKeyViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) ListAPIKeysViagRPC(_ context.Context, userID int64) (int, services.ListAPIKeysResponse) {
+	return m.ListAPIKeysViagRPCFunc(userID)
+}
+
+func (m *MockGrpcService) RevokeAPIKeyViagRPC(
+	_ context.Context,
+	req services.RevokeAPIKeyRequest,
+	userID int64,
+) (int, services.RevokeAPIKeyResponse) {
+	return m.RevokeAPIKeyViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) AdminGetUserViagRPC(
+	_ context.Context,
+	req services.AdminGetUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	return m.AdminGetUserViagRPCFunc(req, actorID)
+}
+
+func (m *MockGrpcService) DisableUserViagRPC(
+	_ context.Context,
+	req services.DisableUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	return m.DisableUserViagRPCFunc(req, actorID)
+}
+
+func (m *MockGrpcService) UnlockUserViagRPC(
+	_ context.Context,
+	req services.UnlockUserRequest,
+	actorID int64,
+) (int, services.AdminUserResponse) {
+	return m.UnlockUserViagRPCFunc(req, actorID)
+}
+
+func (m *MockGrpcService) RecordAuditEventViagRPC(
+	_ context.Context,
+	req services.RecordAuditEventRequest,
+	actorID int64,
+) (int, services.RecordAuditEventResponse) {
+	return m.RecordAuditEventViagRPCFunc(req, actorID)
+}
+
+func (m *MockGrpcService) InitiatePaymentViagRPC(
+	_ context.Context,
+	req services.InitiatePaymentRequest,
+	userID int64,
+) (int, services.InitiatePaymentResponse) {
+	return m.InitiatePaymentViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) ConfirmWithdrawalViagRPC(
+	_ context.Context,
+	req services.ConfirmWithdrawalRequest,
+	userID int64,
+) (int, services.InitiatePaymentResponse) {
+	return m.ConfirmWithdrawalViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) PollTransactionViagRPC(
+	_ context.Context,
+	req services.PollingTransactionRequest,
+	userID int64,
+) (int, services.PollingTransactionResponse) {
+	return m.PollTransactionViagRPCFunc(req, userID)
+}
+
+func (m *MockGrpcService) ListTransactionsViagRPC(
+	_ context.Context,
+	req services.ListTransactionsRequest,
+) (int, services.ListTransactionsResponse) {
+	return m.ListTransactionsViagRPCFunc(req)
+}
@@ -1,20 +1,32 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 )
 
 var _ services.HttpInterface = (*MockHttpService)(nil)
 
 type MockHttpService struct {
-	RegisterUserViaHttpFunc func(services.RegisterUserRequest) (int, services.RegisterUserResponse)
-	LoginUserViaHttpFunc    func(services.LoginUserRequest) (int, services.LoginUserResponse)
+	RegisterUserViaHttpFunc   func(services.RegisterUserRequest) (int, services.RegisterUserResponse)
+	LoginUserViaHttpFunc      func(services.LoginUserRequest) (int, services.LoginUserResponse)
+	ForgotPasswordViaHttpFunc func(services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse)
+	ResetPasswordViaHttpFunc  func(services.ResetPasswordRequest) (int, services.ResetPasswordResponse)
 }
 
-func (m *MockHttpService) RegisterUserViaHttp(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+func (m *MockHttpService) RegisterUserViaHttp(_ context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
 	return m.RegisterUserViaHttpFunc(req)
 }
 
-func (m *MockHttpService) LoginUserViaHttp(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+func (m *MockHttpService) LoginUserViaHttp(_ context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
 	return m.LoginUserViaHttpFunc(req)
 }
+
+func (m *MockHttpService) ForgotPasswordViaHttp(_ context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	return m.ForgotPasswordViaHttpFunc(req)
+}
+
+func (m *MockHttpService) ResetPasswordViaHttp(_ context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	return m.ResetPasswordViaHttpFunc(req)
+}
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 )
 
@@ -9,31 +11,46 @@ var _ services.RabbitInterface = (*MockRabbitMQService)(nil)
 type MockRabbitMQService struct {
 	RegisterUserViaRabbitFunc    func(services.RegisterUserRequest) (int, services.RegisterUserResponse)
 	LoginUserViaRabbitFunc       func(services.LoginUserRequest) (int, services.LoginUserResponse)
-	InitiatePaymentViaRabbitFunc func(services.InitiatePaymentRequest) (int, services.InitiatePaymentResponse)
+	ForgotPasswordViaRabbitFunc  func(services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse)
+	ResetPasswordViaRabbitFunc   func(services.ResetPasswordRequest) (int, services.ResetPasswordResponse)
+	InitiatePaymentViaRabbitFunc func(services.InitiatePaymentRequest, int64) (int, services.InitiatePaymentResponse)
 	PollTransactionViaRabbitFunc func(services.PollingTransactionRequest, int64) (int, services.PollingTransactionResponse)
 
-	SetConsumerFunc func(topics []string) error
+	SetConsumerFunc func() error
 }
 
-func (m *MockRabbitMQService) RegisterUserViaRabbit(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+func (m *MockRabbitMQService) RegisterUserViaRabbit(_ context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
 	return m.RegisterUserViaRabbitFunc(req)
 }
 
-func (m *MockRabbitMQService) LoginUserViaRabbit(req services.LoginUserRequest) (int, services.LoginUserResponse) {
+func (m *MockRabbitMQService) LoginUserViaRabbit(_ context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
 	return m.LoginUserViaRabbitFunc(req)
 }
 
-func (m *MockRabbitMQService) InitiatePaymentViaRabbit(req services.InitiatePaymentRequest) (int, services.InitiatePaymentResponse) {
-	return m.InitiatePaymentViaRabbitFunc(req)
+func (m *MockRabbitMQService) ForgotPasswordViaRabbit(_ context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	return m.ForgotPasswordViaRabbitFunc(req)
+}
+
+func (m *MockRabbitMQService) ResetPasswordViaRabbit(_ context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	return m.ResetPasswordViaRabbitFunc(req)
+}
+
+func (m *MockRabbitMQService) InitiatePaymentViaRabbit(
+	_ context.Context,
+	req services.InitiatePaymentRequest,
+	userID int64,
+) (int, services.InitiatePaymentResponse) {
+	return m.InitiatePaymentViaRabbitFunc(req, userID)
 }
 
 func (m *MockRabbitMQService) PollTransactionViaRabbit(
+	_ context.Context,
 	req services.PollingTransactionRequest,
 	userID int64,
 ) (int, services.PollingTransactionResponse) {
 	return m.PollTransactionViaRabbitFunc(req, userID)
 }
 
-func (m *MockRabbitMQService) SetConsumer(topics []string, _ chan struct{}) error {
-	return m.SetConsumerFunc(topics)
+func (m *MockRabbitMQService) SetConsumer(_ chan struct{}) error {
+	return m.SetConsumerFunc()
 }
@@ -2,154 +2,86 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
-	"github.com/google/uuid"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 )
 
-func (r *RabbitHandler) RegisterUserViaRabbit(req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
-	dataBytes, err := json.Marshal(req)
+func (r *RabbitHandler) RegisterUserViaRabbit(ctx context.Context, req services.RegisterUserRequest) (int, services.RegisterUserResponse) {
+	request, err := envelope.NewProto(envelope.TypeRegisterUser, &pb.RegisterUserRequest{
+		Fullname:           req.FullName,
+		Email:              req.Email,
+		Password:           req.Password,
+		PaydUsername:       req.PaydUsername,
+		PaydPasswordApiKey: req.PasswordApiKey,
+		PaydUsernameApiKey: req.UsernameApiKey,
+		PaydAccountId:      req.PaydAccountID,
+	})
 	if err != nil {
 		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	payload := services.Payload{
-		Name: "register_user",
-		Data: dataBytes,
-	}
+	var authResp services.RegisterUserResponse
 
-	payloadRabitData, err := json.Marshal(payload)
-	if err != nil {
-		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	if status, code, message := r.request(ctx, "authentication.register_user", request, &authResp); status != http.StatusOK {
+		return status, services.RegisterUserResponse{Message: message, StatusCode: code}
 	}
 
-	correlationID := uuid.New().String()
-
-	responseChannel := make(chan amqp.Delivery, 1)
-	defer close(responseChannel)
-
-	r.RspMap.Set(correlationID, responseChannel)
-	defer r.RspMap.Delete(correlationID)
-
-	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return http.StatusOK, authResp
+}
 
-	err = r.Channel.PublishWithContext(c,
-		r.config.EXCH,                  // exchange
-		"authentication.register_user", // routing key
-		false,                          // mandatory
-		false,                          // immediate
-		amqp.Publishing{
-			ContentType:   "text/plain",
-			CorrelationId: correlationID,
-			ReplyTo:       "gateway.register_user",
-			Body:          payloadRabitData,
-		})
+func (r *RabbitHandler) LoginUserViaRabbit(ctx context.Context, req services.LoginUserRequest) (int, services.LoginUserResponse) {
+	request, err := envelope.NewProto(envelope.TypeLoginUser, &pb.LoginUserRequest{
+		Email:    req.Email,
+		Password: req.Password,
+		ClientIp: req.ClientIP,
+	})
 	if err != nil {
-		return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	select {
-	case msg := <-responseChannel:
-		if msg.CorrelationId == correlationID {
-			var authResp services.RegisterUserResponse
-
-			err := json.Unmarshal(msg.Body, &authResp)
-			if err != nil {
-				return http.StatusInternalServerError, services.RegisterUserResponse{
-					Message:    "internal error",
-					StatusCode: http.StatusInternalServerError,
-				}
-			}
-
-			if authResp.Message != "" {
-				return authResp.StatusCode, services.RegisterUserResponse{Message: authResp.Message, StatusCode: authResp.StatusCode}
-			}
-
-			return http.StatusOK, authResp
-		}
-	case <-time.After(5 * time.Second):
-		return http.StatusRequestTimeout, services.RegisterUserResponse{
-			Message:    "timeout waiting for response. Try again",
-			StatusCode: http.StatusInternalServerError,
-		}
+	var loginResp services.LoginUserResponse
+
+	if status, code, message := r.request(ctx, "authentication.login_user", request, &loginResp); status != http.StatusOK {
+		return status, services.LoginUserResponse{Message: message, StatusCode: code}
 	}
 
-	return http.StatusInternalServerError, services.RegisterUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	return http.StatusOK, loginResp
 }
 
-func (r *RabbitHandler) LoginUserViaRabbit(req services.LoginUserRequest) (int, services.LoginUserResponse) {
-	dataBytes, err := json.Marshal(req)
+func (r *RabbitHandler) ForgotPasswordViaRabbit(ctx context.Context, req services.ForgotPasswordRequest) (int, services.ForgotPasswordResponse) {
+	request, err := envelope.NewProto(envelope.TypeForgotPassword, &pb.ForgotPasswordRequest{
+		Email: req.Email,
+	})
 	if err != nil {
-		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		return http.StatusInternalServerError, services.ForgotPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	payload := services.Payload{
-		Name: "login_user",
-		Data: dataBytes,
-	}
+	var forgotResp services.ForgotPasswordResponse
 
-	payloadRabitData, err := json.Marshal(payload)
-	if err != nil {
-		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	if status, code, message := r.request(ctx, "authentication.forgot_password", request, &forgotResp); status != http.StatusOK {
+		return status, services.ForgotPasswordResponse{Message: message, StatusCode: code}
 	}
 
-	correlationID := uuid.New().String()
-
-	responseChannel := make(chan amqp.Delivery, 1)
-	defer close(responseChannel)
-
-	r.RspMap.Set(correlationID, responseChannel)
-	defer r.RspMap.Delete(correlationID)
-
-	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return http.StatusOK, forgotResp
+}
 
-	err = r.Channel.PublishWithContext(c,
-		r.config.EXCH,               // exchange
-		"authentication.login_user", // routing key
-		false,                       // mandatory
-		false,                       // immediate
-		amqp.Publishing{
-			ContentType:   "text/plain",
-			CorrelationId: correlationID,
-			ReplyTo:       "gateway.login_user",
-			Body:          payloadRabitData,
-		})
+func (r *RabbitHandler) ResetPasswordViaRabbit(ctx context.Context, req services.ResetPasswordRequest) (int, services.ResetPasswordResponse) {
+	request, err := envelope.NewProto(envelope.TypeResetPassword, &pb.ResetPasswordRequest{
+		Token:    req.Token,
+		Password: req.Password,
+	})
 	if err != nil {
-		return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+		return http.StatusInternalServerError, services.ResetPasswordResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
 	}
 
-	select {
-	case msg := <-responseChannel:
-		if msg.CorrelationId == correlationID {
-			var loginResp services.LoginUserResponse
-
-			err := json.Unmarshal(msg.Body, &loginResp)
-			if err != nil {
-				return http.StatusInternalServerError, services.LoginUserResponse{
-					Message:    "internal error",
-					StatusCode: http.StatusInternalServerError,
-				}
-			}
-
-			if loginResp.Message != "" {
-				return loginResp.StatusCode, services.LoginUserResponse{Message: loginResp.Message, StatusCode: loginResp.StatusCode}
-			}
-
-			return http.StatusOK, loginResp
-		}
-
-	case <-time.After(5 * time.Second):
-		return http.StatusRequestTimeout, services.LoginUserResponse{
-			Message:    "timeout waiting for response. Try again",
-			StatusCode: http.StatusInternalServerError,
-		}
+	var resetResp services.ResetPasswordResponse
+
+	if status, code, message := r.request(ctx, "authentication.reset_password", request, &resetResp); status != http.StatusOK {
+		return status, services.ResetPasswordResponse{Message: message, StatusCode: code}
 	}
 
-	return http.StatusInternalServerError, services.LoginUserResponse{Message: "internal error", StatusCode: http.StatusInternalServerError}
+	return http.StatusOK, resetResp
 }
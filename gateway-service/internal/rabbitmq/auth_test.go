@@ -1,36 +1,44 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
-	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
 	"github.com/brianvoe/gofakeit"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/require"
 )
 
 var TestTime = time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC)
 
-func TestRabbitHandler_RegisterUserViaRabbit(t *testing.T) {
-	pkg.SkipCI(t)
+// respondWith returns a handler replying body after delay, as a service that has not
+// moved to protobuf yet does.
+func respondWith(body []byte, delay time.Duration) bus.Handler {
+	return func(_ context.Context, _ bus.Message) (bus.Message, error) {
+		time.Sleep(delay)
 
-	testRabbit, err := NewTestRabbitHandler()
-	require.NoError(t, err)
+		return bus.Message{ContentType: envelope.ContentTypeJSON, Body: body}, nil
+	}
+}
+
+// newMemoryHandler returns a handler sending its requests over an in-process bus, to h
+// when it is not nil.
+func newMemoryHandler(topic string, h bus.Handler) *RabbitHandler {
+	b := bus.NewMemory()
 
-	defer func() {
-		// close the channel and terminate the container
-		testRabbit.rabbit.Channel.Close()
+	if h != nil {
+		b.Handle(topic, h)
+	}
 
-		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
-			t.Fatalf("failed to terminate container: %s", err)
-		}
-	}()
+	return NewRabbitService(b, testConfig)
+}
 
+func TestRabbitHandler_RegisterUserViaRabbit(t *testing.T) {
 	req := randomReq()
 
 	rsp := services.RegisterUserResponse{
@@ -44,69 +52,47 @@ func TestRabbitHandler_RegisterUserViaRabbit(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		mockDelivery   amqp.Delivery
+		handler        bus.Handler
+		timeout        time.Duration
 		expectedStatus int
 		expectedMsg    services.RegisterUserResponse
-		sleep          time.Duration
 	}{
 		{
-			name: "success",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "success",
+			handler:        respondWith(rspBytes, 0),
+			timeout:        time.Second,
 			expectedStatus: http.StatusOK,
 			expectedMsg:    rsp,
-			sleep:          100 * time.Millisecond,
 		},
 		{
-			name: "time out",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "time out",
+			handler:        respondWith(rspBytes, 200*time.Millisecond),
+			timeout:        50 * time.Millisecond,
 			expectedStatus: http.StatusRequestTimeout,
 			expectedMsg: services.RegisterUserResponse{
 				Message:    "timeout waiting for response. Try again",
 				StatusCode: http.StatusInternalServerError,
 			},
-			sleep: 6 * time.Second,
+		},
+		{
+			name:           "service down",
+			timeout:        time.Second,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedMsg: services.RegisterUserResponse{
+				Message:    "service unavailable. Try again",
+				StatusCode: http.StatusServiceUnavailable,
+			},
 		},
 	}
 
-	for index, tc := range tests {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCodeChan := make(chan int, 1)
-			defer close(statusCodeChan)
-
-			msgChan := make(chan services.RegisterUserResponse, 1)
-			defer close(msgChan)
+			r := newMemoryHandler("authentication.register_user", tc.handler)
 
-			go func(statusCodeChan chan int, msgChan chan services.RegisterUserResponse) {
-				statusCode, msg := testRabbit.rabbit.RegisterUserViaRabbit(req)
-				statusCodeChan <- statusCode
-				msgChan <- msg
-			}(statusCodeChan, msgChan)
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
 
-			// sleep so that the goroutine can send the message
-			time.Sleep(tc.sleep)
-
-			var i int
-
-			testRabbit.rabbit.RspMap.mu.RLock()
-			for correlationID, responseChannel := range testRabbit.rabbit.RspMap.data {
-				if i == index {
-					log.Printf("CorrelationID for test case '%s': %s", tc.name, correlationID)
-					tc.mockDelivery.CorrelationId = correlationID
-					responseChannel <- tc.mockDelivery
-				}
-
-				i++
-			}
-			testRabbit.rabbit.RspMap.mu.RUnlock()
-
-			code := <-statusCodeChan
-			msg := <-msgChan
+			code, msg := r.RegisterUserViaRabbit(ctx, req)
 
 			require.Equal(t, tc.expectedStatus, code)
 			require.Equal(t, tc.expectedMsg, msg)
@@ -115,20 +101,6 @@ func TestRabbitHandler_RegisterUserViaRabbit(t *testing.T) {
 }
 
 func TestRabbitHandler_LoginUserViaRabbit(t *testing.T) {
-	pkg.SkipCI(t)
-
-	testRabbit, err := NewTestRabbitHandler()
-	require.NoError(t, err)
-
-	defer func() {
-		// close the channel and terminate the container
-		testRabbit.rabbit.Channel.Close()
-
-		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
-			t.Fatalf("failed to terminate container: %s", err)
-		}
-	}()
-
 	req := services.LoginUserRequest{
 		Email:    gofakeit.Email(),
 		Password: gofakeit.Password(true, true, true, true, true, 7),
@@ -146,69 +118,61 @@ func TestRabbitHandler_LoginUserViaRabbit(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		mockDelivery   amqp.Delivery
+		handler        bus.Handler
+		timeout        time.Duration
 		expectedStatus int
 		expectedMsg    services.LoginUserResponse
-		sleep          time.Duration
 	}{
 		{
-			name: "success",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "success",
+			handler:        respondWith(rspBytes, 0),
+			timeout:        time.Second,
 			expectedStatus: http.StatusOK,
 			expectedMsg:    rsp,
-			sleep:          100 * time.Millisecond,
 		},
 		{
-			name: "time out",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
+			name: "error reply",
+			handler: func(_ context.Context, _ bus.Message) (bus.Message, error) {
+				body, err := envelope.EncodeProto(envelope.NewError(envelope.TypeLoginUser, envelope.CodeNotFound, "user not found"))
+
+				return bus.Message{ContentType: envelope.ContentTypeProtobuf, Body: body}, err
+			},
+			timeout:        time.Second,
+			expectedStatus: http.StatusNotFound,
+			expectedMsg: services.LoginUserResponse{
+				Message:    "user not found",
+				StatusCode: http.StatusNotFound,
 			},
+		},
+		{
+			name:           "time out",
+			handler:        respondWith(rspBytes, 200*time.Millisecond),
+			timeout:        50 * time.Millisecond,
 			expectedStatus: http.StatusRequestTimeout,
 			expectedMsg: services.LoginUserResponse{
 				Message:    "timeout waiting for response. Try again",
 				StatusCode: http.StatusInternalServerError,
 			},
-			sleep: 6 * time.Second,
+		},
+		{
+			name:           "service down",
+			timeout:        time.Second,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedMsg: services.LoginUserResponse{
+				Message:    "service unavailable. Try again",
+				StatusCode: http.StatusServiceUnavailable,
+			},
 		},
 	}
 
-	for index, tc := range tests {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCodeChan := make(chan int, 1)
-			defer close(statusCodeChan)
-
-			msgChan := make(chan services.LoginUserResponse, 1)
-			defer close(msgChan)
-
-			go func(statusCodeChan chan int, msgChan chan services.LoginUserResponse) {
-				statusCode, msg := testRabbit.rabbit.LoginUserViaRabbit(req)
-				statusCodeChan <- statusCode
-				msgChan <- msg
-			}(statusCodeChan, msgChan)
-
-			// sleep so that the goroutine can send the message
-			time.Sleep(tc.sleep)
-
-			var i int
-
-			testRabbit.rabbit.RspMap.mu.RLock()
-			for correlationID, responseChannel := range testRabbit.rabbit.RspMap.data {
-				if i == index {
-					log.Printf("CorrelationID for test case '%s': %s", tc.name, correlationID)
-					tc.mockDelivery.CorrelationId = correlationID
-					responseChannel <- tc.mockDelivery
-				}
+			r := newMemoryHandler("authentication.login_user", tc.handler)
 
-				i++
-			}
-			testRabbit.rabbit.RspMap.mu.RUnlock()
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
 
-			code := <-statusCodeChan
-			msg := <-msgChan
+			code, msg := r.LoginUserViaRabbit(ctx, req)
 
 			require.Equal(t, tc.expectedStatus, code)
 			require.Equal(t, tc.expectedMsg, msg)
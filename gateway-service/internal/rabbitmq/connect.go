@@ -1,151 +1,151 @@
 package rabbitmq
 
 import (
-	"log"
-	"math"
-	"sync"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/metrics"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/routing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var _ services.RabbitInterface = (*RabbitHandler)(nil)
 
 type RabbitHandler struct {
-	Channel *amqp.Channel
-	RspMap  *responseMap
-	config  pkg.Config
-	forever chan bool
+	Bus    bus.Bus
+	config pkg.Config
 }
 
-type responseMap struct {
-	mu   sync.RWMutex
-	data map[string]chan amqp.Delivery
-}
-
-func NewRabbitService(channel *amqp.Channel, config pkg.Config) *RabbitHandler {
+func NewRabbitService(b bus.Bus, config pkg.Config) *RabbitHandler {
 	return &RabbitHandler{
-		RspMap:  newResponseMap(),
-		config:  config,
-		Channel: channel,
-	}
-}
-
-func newResponseMap() *responseMap {
-	return &responseMap{
-		data: make(map[string]chan amqp.Delivery),
+		Bus:    b,
+		config: config,
 	}
 }
 
-func ConnectToRabit(uri string) (*amqp.Connection, error) {
-	count := 0
-	maxRetries := 12
+// SetConsumer receives the replies to the requests of this instance until the bus is
+// closed. The reply queue of the instance is declared again, under the same name,
+// whenever the connection is restored.
+func (r *RabbitHandler) SetConsumer(readyChan chan struct{}) error {
+	readyChan <- struct{}{}
 
-	var err error
+	slog.Info("listening to messages in gateway service")
 
-	var connection *amqp.Connection
+	return r.Bus.Serve(context.Background())
+}
 
-	for {
-		connection, err = amqp.Dial(uri)
-		if err != nil {
-			log.Println("failed to connect to rabbitmq", err)
+// request sends req to the handler of topic and decodes the result of the reply into v.
+// The reply is waited for until the deadline of ctx, which goes along with the request
+// so that the service skips it once nobody waits for the reply anymore. It returns
+// http.StatusOK, or the status the client gets along with the status code and message
+// of the response body.
+func (r *RabbitHandler) request(ctx context.Context, topic string, req envelope.Envelope, v any) (int, int, string) {
+	ctx, span, headers := tracing.StartPublish(ctx, topic)
+	defer span.End()
 
-			if count > maxRetries {
-				return nil, err
-			}
+	logging.SetAMQPHeader(ctx, headers)
 
-			count++
-			rollOff := time.Duration(math.Pow(float64(count), 2)) * time.Second
-			time.Sleep(rollOff)
+	c, cancel := routing.WithDefaultTimeout(ctx)
+	defer cancel()
 
-			continue
-		}
+	deadline, _ := c.Deadline()
 
-		log.Println("Connected to rabbitmq")
+	req.RequestID = logging.RequestID(ctx)
+	req.Deadline = deadline
 
-		break
+	body, err := envelope.EncodeProto(req)
+	if err != nil {
+		return http.StatusInternalServerError, http.StatusInternalServerError, "internal error"
 	}
 
-	return connection, nil
-}
+	metrics.AMQPReplyPending()
+	defer metrics.AMQPReplyDone()
 
-func (r *RabbitHandler) SetConsumer(topics []string, readyChan chan struct{}) error {
-	q, err := r.Channel.QueueDeclare(
-		r.config.EXCLUSIVE_QUEUE_NAME, // name
-		false,                         // durable
-		false,                         // delete when unused
-		false,                         // exclusive
-		false,                         // no-wait
-		nil,                           // arguments
-	)
-	if err != nil {
-		return err
-	}
+	start := time.Now()
 
-	for _, topic := range topics {
-		if err := r.Channel.QueueBind(
-			q.Name,        // queue name
-			topic,         // routing key
-			r.config.EXCH, // exchange
-			false,
-			nil,
-		); err != nil {
-			return err
+	reply, err := r.Bus.Request(c, topic, bus.Message{
+		ContentType: envelope.ContentTypeProtobuf,
+		Headers:     headers,
+		Body:        body,
+		Deadline:    deadline,
+	})
+	if err != nil {
+		status, message := requestFailure(err)
+
+		switch {
+		case status == http.StatusServiceUnavailable:
+			span.SetStatus(codes.Error, message)
+		case c.Err() != nil:
+			metrics.AMQPReplyTimeout(topic)
+			span.SetStatus(codes.Error, "timeout waiting for response")
+
+			return http.StatusRequestTimeout, http.StatusInternalServerError, "timeout waiting for response. Try again"
+		default:
+			slog.ErrorContext(ctx, "request failed", "topic", topic, "error", err)
 		}
-	}
 
-	messages, err := r.Channel.Consume(
-		q.Name,                         // queue
-		r.config.GATEWAY_CONSUMER_NAME, // consumer
-		true,                           // auto ack
-		false,                          // exclusive
-		false,                          // no local
-		false,                          // no wait
-		nil,                            // args
-	)
-	if err != nil {
-		return err
+		return status, status, message
 	}
 
-	// Ensure that readyChan is closed after the consumer is successfully set up
-	readyChan <- struct{}{}
+	metrics.ObserveAMQPReply(topic, start)
 
-	r.forever = make(chan bool)
+	status, message := decodeReply(reply, v)
 
-	go func() {
-		for msg := range messages {
-			if ch, ok := r.RspMap.Get(msg.CorrelationId); ok {
-				ch <- msg
-				log.Println("Message acknowledged from callback queue", msg.DeliveryTag)
-			}
-		}
-	}()
+	return status, status, message
+}
 
-	log.Println("listening to messages in gateway service")
+// requestFailure maps an error sending a request, or waiting for its reply, to the
+// status and message the client gets.
+func requestFailure(err error) (int, string) {
+	switch {
+	case errors.Is(err, bus.ErrNoHandler):
+		// no queue is bound yet, the service has not come up
+		return http.StatusServiceUnavailable, "service unavailable. Try again"
+	case errors.Is(err, bus.ErrNoReply):
+		// the request was sent, the service may have acted on it
+		return http.StatusGatewayTimeout, "no response from service. The request may have been processed"
+	case errors.Is(err, bus.ErrUnavailable), errors.Is(err, bus.ErrClosed):
+		return http.StatusServiceUnavailable, "message broker unavailable. Try again"
+	default:
+		return http.StatusInternalServerError, "internal error"
+	}
+}
 
-	<-r.forever
+// decodeReply decodes the result of a reply into v, from protobuf or from JSON sent by
+// services not moved to protobuf yet. It returns http.StatusOK, or the status and
+// message of the error the service replied with.
+func decodeReply(msg bus.Message, v any) (int, string) {
+	reply, err := envelope.DecodeAs(msg.ContentType, msg.Body)
+	if err != nil {
+		slog.Error("failed to decode reply", "correlation_id", msg.CorrelationID, "error", err)
 
-	return nil
-}
+		return http.StatusInternalServerError, "internal error"
+	}
 
-func (rm *responseMap) Set(correlationID string, channel chan amqp.Delivery) {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-	rm.data[correlationID] = channel
-}
+	if reply.Error != nil {
+		return reply.Error.Code.HTTPStatus(), reply.Error.Message
+	}
 
-func (rm *responseMap) Get(correlationID string) (chan amqp.Delivery, bool) {
-	rm.mu.RLock()
-	defer rm.mu.RUnlock()
-	ch, exists := rm.data[correlationID]
+	if reply.ContentType == envelope.ContentTypeProtobuf {
+		err = unmarshalProtoReply(reply.Data, v)
+	} else {
+		err = reply.Unmarshal(v)
+	}
 
-	return ch, exists
-}
+	if err != nil {
+		slog.Error("failed to decode reply", "correlation_id", msg.CorrelationID, "type", reply.Type, "error", err)
+
+		return http.StatusInternalServerError, "internal error"
+	}
 
-func (rm *responseMap) Delete(correlationID string) {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-	delete(rm.data, correlationID)
+	return http.StatusOK, ""
 }
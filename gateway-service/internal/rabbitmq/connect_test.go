@@ -2,11 +2,19 @@ package rabbitmq
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/require"
 	mq "github.com/testcontainers/testcontainers-go/modules/rabbitmq"
@@ -18,6 +26,12 @@ type TestRabbitHandler struct {
 	ctx       context.Context
 }
 
+var testConfig = pkg.Config{
+	EXCLUSIVE_QUEUE_NAME:  "gateway_queue",
+	EXCH:                  "events",
+	GATEWAY_CONSUMER_NAME: "gateway_service",
+}
+
 func NewTestRabbitHandler() (*TestRabbitHandler, error) {
 	ctx := context.Background()
 
@@ -37,40 +51,30 @@ func NewTestRabbitHandler() (*TestRabbitHandler, error) {
 		return nil, err
 	}
 
-	conn, err := ConnectToRabit(connString)
+	b, err := newTestBus(connString)
 	if err != nil {
 		return nil, err
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
+	return &TestRabbitHandler{
+		ctx:       ctx,
+		container: rabbitmqContainer,
+		rabbit:    NewRabbitService(b, testConfig),
+	}, nil
+}
 
-	err = ch.ExchangeDeclare(
-		"events", // name
-		"topic",  // type
-		true,     // durable
-		false,    // auto-deleted
-		false,    // internal
-		false,    // no-wait
-		nil,      // arguments
-	)
+// newTestBus connects a gateway instance, with a reply queue of its own, to the broker.
+func newTestBus(connString string) (*bus.RabbitMQ, error) {
+	conn, err := rabbit.Dial(connString)
 	if err != nil {
 		return nil, err
 	}
 
-	r := NewRabbitService(ch, pkg.Config{
-		EXCLUSIVE_QUEUE_NAME:  "gateway_queue",
-		EXCH:                  "events",
-		GATEWAY_CONSUMER_NAME: "gateway_service",
+	return bus.NewRabbitMQ(conn, bus.RabbitMQOptions{
+		Exchange:   testConfig.EXCH,
+		Consumer:   testConfig.GATEWAY_CONSUMER_NAME,
+		ReplyQueue: testConfig.EXCLUSIVE_QUEUE_NAME,
 	})
-
-	return &TestRabbitHandler{
-		ctx:       ctx,
-		container: rabbitmqContainer,
-		rabbit:    r,
-	}, nil
 }
 
 func TestRabbitHandler_TestSetConsumer(t *testing.T) {
@@ -80,63 +84,43 @@ func TestRabbitHandler_TestSetConsumer(t *testing.T) {
 	require.NoError(t, err)
 
 	defer func() {
-		// close the channel and terminate the container
-		testRabbit.rabbit.Channel.Close()
+		// close the connection and terminate the container
+		testRabbit.rabbit.Bus.Close()
 
 		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
 			t.Fatalf("failed to terminate container: %s", err)
 		}
 	}()
 
+	connString, err := testRabbit.container.AmqpURL(testRabbit.ctx)
+	require.NoError(t, err)
+
 	errCh := make(chan error, 1)
 
 	readyChan := make(chan struct{}, 1)
 
 	go func(errCh chan error, readyChan chan struct{}) {
-		err = testRabbit.rabbit.SetConsumer(
-			[]string{
-				"gateway.initiate_payment",
-				"gateway.poll_payments",
-				"gateway.register_user",
-				"gateway.login_user",
-			}, readyChan,
-		)
-		errCh <- err
+		errCh <- testRabbit.rabbit.SetConsumer(readyChan)
 	}(errCh, readyChan)
 
 	<-readyChan
 	close(readyChan)
 
-	corrID := "test-correlation-id"
-	ch := testRabbit.rabbit.Channel
-	err = ch.Publish(
-		"events",                   // exchange
-		"gateway.initiate_payment", // routing key
-		false,                      // mandatory
-		false,                      // immediate
-		amqp.Publishing{
-			ContentType:   "text/plain",
-			Body:          []byte("test message"),
-			CorrelationId: corrID,
-		},
-	)
-	require.NoError(t, err)
+	startLoginResponder(t, connString)
 
-	msgCh := make(chan amqp.Delivery, 1)
-	testRabbit.rabbit.RspMap.Set(corrID, msgCh)
+	// the reply only arrives once the consumer of the reply queue runs
+	status, rsp := testRabbit.rabbit.LoginUserViaRabbit(testRabbit.ctx, services.LoginUserRequest{
+		Email:    "john@doe.com",
+		Password: "secret",
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "john@doe.com", rsp.Email)
 
-	select {
-	case msg := <-msgCh:
-		require.Equal(t, "test message", string(msg.Body))
-		close(testRabbit.rabbit.forever)
-	case <-time.After(5 * time.Second):
-		t.Fatal("timeout waiting for message to be consumed")
-	}
+	testRabbit.rabbit.Bus.Close()
 
 	select {
 	case err := <-errCh:
 		require.NoError(t, err)
-		log.Println(err)
 		close(errCh)
 
 		return
@@ -144,3 +128,156 @@ func TestRabbitHandler_TestSetConsumer(t *testing.T) {
 		t.Fatal("SetConsumer timed out")
 	}
 }
+
+func TestRabbitHandler_MultipleReplicas(t *testing.T) {
+	pkg.SkipCI(t)
+
+	testRabbit, err := NewTestRabbitHandler()
+	require.NoError(t, err)
+
+	defer func() {
+		// close the connection and terminate the container
+		testRabbit.rabbit.Bus.Close()
+
+		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
+			t.Fatalf("failed to terminate container: %s", err)
+		}
+	}()
+
+	connString, err := testRabbit.container.AmqpURL(testRabbit.ctx)
+	require.NoError(t, err)
+
+	// a second gateway instance on the same broker
+	b, err := newTestBus(connString)
+	require.NoError(t, err)
+
+	defer b.Close()
+
+	replicas := []*RabbitHandler{testRabbit.rabbit, NewRabbitService(b, testConfig)}
+
+	for _, replica := range replicas {
+		readyChan := make(chan struct{}, 1)
+
+		go func(replica *RabbitHandler) {
+			_ = replica.SetConsumer(readyChan)
+		}(replica)
+
+		<-readyChan
+	}
+
+	require.NotEqual(t, replicas[0].Bus.(*bus.RabbitMQ).ReplyQueue(), replicas[1].Bus.(*bus.RabbitMQ).ReplyQueue())
+
+	startLoginResponder(t, connString)
+
+	const requests = 20
+
+	var wg sync.WaitGroup
+
+	errCh := make(chan error, requests*len(replicas))
+
+	for i := 0; i < requests; i++ {
+		for index, replica := range replicas {
+			wg.Add(1)
+
+			go func(replica *RabbitHandler, email string) {
+				defer wg.Done()
+
+				status, rsp := replica.LoginUserViaRabbit(context.Background(), services.LoginUserRequest{
+					Email:    email,
+					Password: "secret",
+				})
+				if status != http.StatusOK || rsp.Email != email {
+					errCh <- fmt.Errorf("reply for %s: status %d, email %q", email, status, rsp.Email)
+				}
+			}(replica, fmt.Sprintf("user%d.replica%d@example.com", i, index))
+		}
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Error(err)
+	}
+}
+
+// startLoginResponder stands in for the authentication service, answering every login
+// with the email it was sent.
+func startLoginResponder(t *testing.T, connString string) {
+	conn, err := amqp.Dial(connString)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	ch, err := conn.Channel()
+	require.NoError(t, err)
+
+	q, err := ch.QueueDeclare("authentication_queue", false, true, false, false, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, ch.QueueBind(q.Name, "authentication.login_user", "events", false, nil))
+
+	deliveries, err := ch.Consume(q.Name, "authentication_service", true, false, false, false, nil)
+	require.NoError(t, err)
+
+	go func() {
+		for d := range deliveries {
+			request, err := envelope.DecodeAs(d.ContentType, d.Body)
+			if err != nil {
+				continue
+			}
+
+			var req pb.LoginUserRequest
+			if err := request.Unmarshal(&req); err != nil {
+				continue
+			}
+
+			reply, _ := envelope.NewProto(request.Type, &pb.LoginUserResponse{
+				Data: &pb.RegisterUserResponse{Email: req.GetEmail()},
+			})
+			body, _ := envelope.EncodeProto(reply)
+
+			_ = ch.PublishWithContext(context.Background(), "", d.ReplyTo, false, false, amqp.Publishing{
+				ContentType:   envelope.ContentTypeProtobuf,
+				CorrelationId: d.CorrelationId,
+				Body:          body,
+			})
+		}
+	}()
+}
+
+func TestRequestFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "no handler",
+			err:        fmt.Errorf("%w: payments.initiate_payment", bus.ErrNoHandler),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "not sent",
+			err:        fmt.Errorf("%w: %w", bus.ErrUnavailable, rabbit.ErrDisconnected),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "reply lost",
+			err:        fmt.Errorf("%w: %w: connection lost waiting for reply", bus.ErrUnavailable, bus.ErrNoReply),
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "other",
+			err:        fmt.Errorf("encode: failed"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status, _ := requestFailure(tc.err)
+			require.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
@@ -1,35 +1,20 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
-	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
 	"github.com/brianvoe/gofakeit"
 	"github.com/google/uuid"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRabbitHandler_InitiatePaymentViaRabbit(t *testing.T) {
-	pkg.SkipCI(t)
-
-	testRabbit, err := NewTestRabbitHandler()
-	require.NoError(t, err)
-
-	defer func() {
-		// close the channel and terminate the container
-		testRabbit.rabbit.Channel.Close()
-
-		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
-			t.Fatalf("failed to terminate container: %s", err)
-		}
-	}()
-
 	req := services.InitiatePaymentRequest{
 		Email:       gofakeit.Email(),
 		Action:      "withdrawal",
@@ -50,69 +35,47 @@ func TestRabbitHandler_InitiatePaymentViaRabbit(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		mockDelivery   amqp.Delivery
+		handler        bus.Handler
+		timeout        time.Duration
 		expectedStatus int
 		expectedMsg    services.InitiatePaymentResponse
-		sleep          time.Duration
 	}{
 		{
-			name: "success",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "success",
+			handler:        respondWith(rspBytes, 0),
+			timeout:        time.Second,
 			expectedStatus: http.StatusOK,
 			expectedMsg:    rsp,
-			sleep:          100 * time.Millisecond,
 		},
 		{
-			name: "time out",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "time out",
+			handler:        respondWith(rspBytes, 200*time.Millisecond),
+			timeout:        50 * time.Millisecond,
 			expectedStatus: http.StatusRequestTimeout,
 			expectedMsg: services.InitiatePaymentResponse{
 				Message:    "timeout waiting for response. Try again",
 				StatusCode: http.StatusInternalServerError,
 			},
-			sleep: 6 * time.Second,
+		},
+		{
+			name:           "service down",
+			timeout:        time.Second,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedMsg: services.InitiatePaymentResponse{
+				Message:    "service unavailable. Try again",
+				StatusCode: http.StatusServiceUnavailable,
+			},
 		},
 	}
 
-	for index, tc := range tests {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCodeChan := make(chan int, 1)
-			defer close(statusCodeChan)
-
-			msgChan := make(chan services.InitiatePaymentResponse, 1)
-			defer close(msgChan)
-
-			go func(statusCodeChan chan int, msgChan chan services.InitiatePaymentResponse) {
-				statusCode, msg := testRabbit.rabbit.InitiatePaymentViaRabbit(req)
-				statusCodeChan <- statusCode
-				msgChan <- msg
-			}(statusCodeChan, msgChan)
-
-			// sleep so that the goroutine can send the message
-			time.Sleep(tc.sleep)
-
-			var i int
-
-			testRabbit.rabbit.RspMap.mu.RLock()
-			for correlationID, responseChannel := range testRabbit.rabbit.RspMap.data {
-				if i == index {
-					log.Printf("CorrelationID for test case '%s': %s", tc.name, correlationID)
-					tc.mockDelivery.CorrelationId = correlationID
-					responseChannel <- tc.mockDelivery
-				}
+			r := newMemoryHandler("payments.initiate_payment", tc.handler)
 
-				i++
-			}
-			testRabbit.rabbit.RspMap.mu.RUnlock()
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
 
-			code := <-statusCodeChan
-			msg := <-msgChan
+			code, msg := r.InitiatePaymentViaRabbit(ctx, req, 1)
 
 			require.Equal(t, tc.expectedStatus, code)
 			require.Equal(t, tc.expectedMsg, msg)
@@ -121,20 +84,6 @@ func TestRabbitHandler_InitiatePaymentViaRabbit(t *testing.T) {
 }
 
 func TestRabbitHandler_PollTransactionViaRabbit(t *testing.T) {
-	pkg.SkipCI(t)
-
-	testRabbit, err := NewTestRabbitHandler()
-	require.NoError(t, err)
-
-	defer func() {
-		// close the channel and terminate the container
-		testRabbit.rabbit.Channel.Close()
-
-		if err := testRabbit.container.Terminate(testRabbit.ctx); err != nil {
-			t.Fatalf("failed to terminate container: %s", err)
-		}
-	}()
-
 	id, err := uuid.NewRandom()
 	require.NoError(t, err)
 
@@ -158,69 +107,47 @@ func TestRabbitHandler_PollTransactionViaRabbit(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		mockDelivery   amqp.Delivery
+		handler        bus.Handler
+		timeout        time.Duration
 		expectedStatus int
 		expectedMsg    services.PollingTransactionResponse
-		sleep          time.Duration
 	}{
 		{
-			name: "success",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "success",
+			handler:        respondWith(rspBytes, 0),
+			timeout:        time.Second,
 			expectedStatus: http.StatusOK,
 			expectedMsg:    rsp,
-			sleep:          100 * time.Millisecond,
 		},
 		{
-			name: "time out",
-			mockDelivery: amqp.Delivery{
-				ContentType: "text/plain",
-				Body:        rspBytes,
-			},
+			name:           "time out",
+			handler:        respondWith(rspBytes, 200*time.Millisecond),
+			timeout:        50 * time.Millisecond,
 			expectedStatus: http.StatusRequestTimeout,
 			expectedMsg: services.PollingTransactionResponse{
 				Message:    "timeout waiting for response. Try again",
 				StatusCode: http.StatusInternalServerError,
 			},
-			sleep: 6 * time.Second,
+		},
+		{
+			name:           "service down",
+			timeout:        time.Second,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedMsg: services.PollingTransactionResponse{
+				Message:    "service unavailable. Try again",
+				StatusCode: http.StatusServiceUnavailable,
+			},
 		},
 	}
 
-	for index, tc := range tests {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			statusCodeChan := make(chan int, 1)
-			defer close(statusCodeChan)
-
-			msgChan := make(chan services.PollingTransactionResponse, 1)
-			defer close(msgChan)
-
-			go func(statusCodeChan chan int, msgChan chan services.PollingTransactionResponse) {
-				statusCode, msg := testRabbit.rabbit.PollTransactionViaRabbit(req, 1)
-				statusCodeChan <- statusCode
-				msgChan <- msg
-			}(statusCodeChan, msgChan)
-
-			// sleep so that the goroutine can send the message
-			time.Sleep(tc.sleep)
-
-			var i int
-
-			testRabbit.rabbit.RspMap.mu.RLock()
-			for correlationID, responseChannel := range testRabbit.rabbit.RspMap.data {
-				if i == index {
-					log.Printf("CorrelationID for test case '%s': %s", tc.name, correlationID)
-					tc.mockDelivery.CorrelationId = correlationID
-					responseChannel <- tc.mockDelivery
-				}
+			r := newMemoryHandler("payments.poll_payments", tc.handler)
 
-				i++
-			}
-			testRabbit.rabbit.RspMap.mu.RUnlock()
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
 
-			code := <-statusCodeChan
-			msg := <-msgChan
+			code, msg := r.PollTransactionViaRabbit(ctx, req, 1)
 
 			require.Equal(t, tc.expectedStatus, code)
 			require.Equal(t, tc.expectedMsg, msg)
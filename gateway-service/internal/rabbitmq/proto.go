@@ -0,0 +1,125 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// unmarshalProtoReply decodes the protobuf result of a reply into the response v
+// points to.
+func unmarshalProtoReply(data []byte, v any) error {
+	switch rsp := v.(type) {
+	case *services.RegisterUserResponse:
+		var msg pb.RegisterUserResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		*rsp = services.RegisterUserResponse{
+			FullName:  msg.GetFullname(),
+			Email:     msg.GetEmail(),
+			CreatedAt: msg.GetCreatedAt().AsTime(),
+		}
+
+	case *services.LoginUserResponse:
+		var msg pb.LoginUserResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		*rsp = services.LoginUserResponse{
+			AccessToken:  msg.GetAccessToken(),
+			FullName:     msg.GetData().GetFullname(),
+			Email:        msg.GetData().GetEmail(),
+			ExpirationAt: msg.GetExpirationAt().AsTime(),
+			RefreshToken: msg.GetRefreshToken(),
+			CreatedAt:    msg.GetData().GetCreatedAt().AsTime(),
+		}
+
+		// left out by auth services that do not issue refresh tokens yet
+		if msg.GetRefreshExpirationAt() != nil {
+			rsp.RefreshExpirationAt = msg.GetRefreshExpirationAt().AsTime()
+		}
+
+		// users with two-factor authentication get a challenge instead of their tokens
+		if msg.GetMfaRequired() {
+			*rsp = services.LoginUserResponse{
+				FullName:        msg.GetData().GetFullname(),
+				Email:           msg.GetData().GetEmail(),
+				CreatedAt:       msg.GetData().GetCreatedAt().AsTime(),
+				MFARequired:     true,
+				MFAToken:        msg.GetMfaToken(),
+				MFAExpirationAt: msg.GetMfaExpirationAt().AsTime(),
+			}
+		}
+
+	case *services.ForgotPasswordResponse:
+		var msg pb.ForgotPasswordResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		*rsp = services.ForgotPasswordResponse{}
+
+	case *services.ResetPasswordResponse:
+		var msg pb.ResetPasswordResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		*rsp = services.ResetPasswordResponse{
+			UserID: msg.GetUserId(),
+			Email:  msg.GetEmail(),
+		}
+
+	case *services.InitiatePaymentResponse:
+		var msg pb.InitiatePaymentResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		*rsp = services.InitiatePaymentResponse{
+			TransactionID:        msg.GetTransactionId(),
+			PaymentStatus:        msg.GetPaymentStatus(),
+			Action:               msg.GetAction(),
+			ConfirmationRequired: msg.GetConfirmationRequired(),
+		}
+
+		if msg.GetConfirmationExpiresAt() != nil {
+			expiresAt := msg.GetConfirmationExpiresAt().AsTime()
+			rsp.ConfirmationExpiresAt = &expiresAt
+		}
+
+	case *services.PollingTransactionResponse:
+		var msg pb.PollingTransactionResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		transactionID, err := uuid.Parse(msg.GetTransactionId())
+		if err != nil {
+			return err
+		}
+
+		*rsp = services.PollingTransactionResponse{
+			TransactionID:      transactionID,
+			PaydTransactionRef: msg.GetPaydTransactionRef(),
+			Remarks:            msg.GetRemarks(),
+			Action:             msg.GetAction(),
+			Amount:             msg.GetAmount(),
+			PhoneNumber:        msg.GetPhoneNumber(),
+			NetworkCode:        msg.GetNetworkCode(),
+			Naration:           msg.GetNarration(),
+			PaymentStatus:      msg.GetPaymentStatus(),
+		}
+
+	default:
+		return fmt.Errorf("no protobuf reply for %T", v)
+	}
+
+	return nil
+}
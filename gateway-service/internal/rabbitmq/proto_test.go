@@ -0,0 +1,162 @@
+package rabbitmq
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDecodeReply(t *testing.T) {
+	transactionID := uuid.New()
+
+	pbReply, err := envelope.NewProto(envelope.TypePollingTransaction, &pb.PollingTransactionResponse{
+		TransactionId: transactionID.String(),
+		Action:        "withdrawal",
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	pbBody, err := envelope.EncodeProto(pbReply)
+	require.NoError(t, err)
+
+	errBody, err := envelope.EncodeProto(envelope.NewError(envelope.TypePollingTransaction, envelope.CodeNotFound, "transaction not found"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		reply      bus.Message
+		wantStatus int
+		wantMsg    string
+		want       services.PollingTransactionResponse
+	}{
+		{
+			name:       "protobuf reply",
+			reply:      bus.Message{ContentType: envelope.ContentTypeProtobuf, Body: pbBody},
+			wantStatus: http.StatusOK,
+			want: services.PollingTransactionResponse{
+				TransactionID: transactionID,
+				Action:        "withdrawal",
+				Amount:        100,
+			},
+		},
+		{
+			name:       "protobuf error reply",
+			reply:      bus.Message{ContentType: envelope.ContentTypeProtobuf, Body: errBody},
+			wantStatus: http.StatusNotFound,
+			wantMsg:    "transaction not found",
+		},
+		{
+			name: "JSON reply",
+			reply: bus.Message{
+				ContentType: envelope.ContentTypeJSON,
+				Body:        []byte(`{"type":"polling_transaction","version":2,"content_type":"application/json","data":{"transaction_id":"` + transactionID.String() + `","action":"withdrawal","amount":100}}`),
+			},
+			wantStatus: http.StatusOK,
+			want: services.PollingTransactionResponse{
+				TransactionID: transactionID,
+				Action:        "withdrawal",
+				Amount:        100,
+			},
+		},
+		{
+			name:       "malformed protobuf",
+			reply:      bus.Message{ContentType: envelope.ContentTypeProtobuf, Body: []byte("not protobuf")},
+			wantStatus: http.StatusInternalServerError,
+			wantMsg:    "internal error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got services.PollingTransactionResponse
+
+			status, msg := decodeReply(tc.reply, &got)
+			require.Equal(t, tc.wantStatus, status)
+			require.Equal(t, tc.wantMsg, msg)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestUnmarshalProtoReply(t *testing.T) {
+	reply, err := envelope.NewProto(envelope.TypeLoginUser, &pb.LoginUserResponse{
+		AccessToken:  "token",
+		ExpirationAt: timestamppb.New(TestTime),
+		Data: &pb.RegisterUserResponse{
+			Fullname:  "Jane",
+			Email:     "jane@gmail.com",
+			CreatedAt: timestamppb.New(TestTime),
+		},
+	})
+	require.NoError(t, err)
+
+	var got services.LoginUserResponse
+	require.NoError(t, unmarshalProtoReply(reply.Data, &got))
+	require.Equal(t, services.LoginUserResponse{
+		AccessToken:  "token",
+		FullName:     "Jane",
+		Email:        "jane@gmail.com",
+		ExpirationAt: TestTime,
+		CreatedAt:    TestTime,
+	}, got)
+
+	reply, err = envelope.NewProto(envelope.TypeLoginUser, &pb.LoginUserResponse{
+		AccessToken:         "token",
+		RefreshToken:        "refresh-token",
+		RefreshExpirationAt: timestamppb.New(TestTime),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, unmarshalProtoReply(reply.Data, &got))
+	require.Equal(t, "refresh-token", got.RefreshToken)
+	require.Equal(t, TestTime, got.RefreshExpirationAt)
+
+	// the login of a user with two-factor authentication carries a challenge, not tokens
+	reply, err = envelope.NewProto(envelope.TypeLoginUser, &pb.LoginUserResponse{
+		Data:            &pb.RegisterUserResponse{Email: "jane@gmail.com"},
+		MfaRequired:     true,
+		MfaToken:        "mfa-token",
+		MfaExpirationAt: timestamppb.New(TestTime),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, unmarshalProtoReply(reply.Data, &got))
+	require.True(t, got.MFARequired)
+	require.Equal(t, "mfa-token", got.MFAToken)
+	require.Equal(t, TestTime, got.MFAExpirationAt)
+	require.Empty(t, got.AccessToken)
+
+	// withdrawals above the threshold wait for the user to confirm them
+	reply, err = envelope.NewProto(envelope.TypeInitiatePayment, &pb.InitiatePaymentResponse{
+		TransactionId:         "id",
+		Action:                "withdrawal",
+		ConfirmationRequired:  true,
+		ConfirmationExpiresAt: timestamppb.New(TestTime),
+	})
+	require.NoError(t, err)
+
+	var payment services.InitiatePaymentResponse
+	require.NoError(t, unmarshalProtoReply(reply.Data, &payment))
+	require.True(t, payment.ConfirmationRequired)
+	require.NotNil(t, payment.ConfirmationExpiresAt)
+	require.Equal(t, TestTime, *payment.ConfirmationExpiresAt)
+
+	reply, err = envelope.NewProto(envelope.TypeResetPassword, &pb.ResetPasswordResponse{
+		UserId: 1,
+		Email:  "jane@gmail.com",
+	})
+	require.NoError(t, err)
+
+	var reset services.ResetPasswordResponse
+	require.NoError(t, unmarshalProtoReply(reply.Data, &reset))
+	require.Equal(t, services.ResetPasswordResponse{UserID: 1, Email: "jane@gmail.com"}, reset)
+
+	require.Error(t, unmarshalProtoReply(reply.Data, &struct{}{}))
+}
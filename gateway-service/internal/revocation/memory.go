@@ -0,0 +1,70 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the revocations within the process, for tests and for running a
+// single gateway without Redis. Other instances do not see them.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]time.Time
+	users  map[int64]entry[time.Time]
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens: make(map[uuid.UUID]time.Time),
+		users:  make(map[int64]entry[time.Time]),
+	}
+}
+
+func (s *MemoryStore) RevokeToken(_ context.Context, id uuid.UUID, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[id] = expiresAt
+
+	return nil
+}
+
+func (s *MemoryStore) RevokeUserTokens(_ context.Context, userID int64, issuedBefore, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = entry[time.Time]{value: issuedBefore, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (s *MemoryStore) Lookup(_ context.Context, id uuid.UUID, userID int64) (bool, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	revoked := false
+	if expiresAt, ok := s.tokens[id]; ok {
+		revoked = now.Before(expiresAt)
+		if !revoked {
+			delete(s.tokens, id)
+		}
+	}
+
+	var cutoff time.Time
+	if user, ok := s.users[userID]; ok {
+		if now.Before(user.expiresAt) {
+			cutoff = user.value
+		} else {
+			delete(s.users, userID)
+		}
+	}
+
+	return revoked, cutoff, nil
+}
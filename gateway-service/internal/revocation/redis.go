@@ -0,0 +1,83 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	tokenKeyPrefix = "revoked:token:"
+	userKeyPrefix  = "revoked:user:"
+)
+
+var _ Store = (*RedisStore)(nil)
+
+// RedisStore keeps the revocations in Redis, as keys that expire with the tokens they
+// revoke: the revoked token IDs, and the cutoff of each user in unix seconds.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) RevokeToken(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.client.Set(ctx, tokenKeyPrefix+id.String(), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStore) RevokeUserTokens(ctx context.Context, userID int64, issuedBefore, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	key := userKeyPrefix + strconv.FormatInt(userID, 10)
+
+	if err := s.client.Set(ctx, key, issuedBefore.Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStore) Lookup(ctx context.Context, id uuid.UUID, userID int64) (bool, time.Time, error) {
+	values, err := s.client.MGet(ctx, tokenKeyPrefix+id.String(), userKeyPrefix+strconv.FormatInt(userID, 10)).Result()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to look up revocations: %w", err)
+	}
+
+	revoked := values[0] != nil
+
+	var cutoff time.Time
+
+	if value, ok := values[1].(string); ok {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid cutoff of user %d: %w", userID, err)
+		}
+
+		cutoff = time.Unix(seconds, 0)
+	}
+
+	return revoked, cutoff, nil
+}
+
+// Ping reports whether Redis can be reached, for the readiness checks.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
@@ -0,0 +1,211 @@
+// Package revocation keeps track of the access tokens revoked before they expire: single
+// tokens on logout, and every token issued to a user before a cutoff when they log out
+// everywhere. Revocations are stored so that every gateway instance sees them, and
+// expire on their own once the tokens they revoke would have expired anyway.
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/google/uuid"
+)
+
+// Store keeps the revocations shared by the gateway instances.
+type Store interface {
+	// RevokeToken revokes the token with the given ID until it expires.
+	RevokeToken(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+
+	// RevokeUserTokens revokes the tokens of a user issued up to issuedBefore. The
+	// cutoff is kept until expiresAt, when the last token it revokes expires.
+	RevokeUserTokens(ctx context.Context, userID int64, issuedBefore, expiresAt time.Time) error
+
+	// Lookup reports whether the token with the given ID is revoked, and the cutoff of
+	// its user, zero when there is none.
+	Lookup(ctx context.Context, id uuid.UUID, userID int64) (bool, time.Time, error)
+}
+
+type entry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// Checker answers whether a token is revoked, caching the answers of the Store for a
+// while. A revocation made through another instance is seen once the cached answer
+// expires, those made through the checker are seen at once.
+type Checker struct {
+	store Store
+
+	// cacheTTL is how long the answers of the store are trusted.
+	cacheTTL time.Duration
+
+	// tokenDuration is the lifetime of the access tokens, for which user cutoffs are kept.
+	tokenDuration time.Duration
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	tokens    map[uuid.UUID]entry[bool]
+	users     map[int64]entry[time.Time]
+	nextSweep time.Time
+}
+
+func NewChecker(store Store, cacheTTL, tokenDuration time.Duration) *Checker {
+	return &Checker{
+		store:         store,
+		cacheTTL:      cacheTTL,
+		tokenDuration: tokenDuration,
+		now:           time.Now,
+		tokens:        make(map[uuid.UUID]entry[bool]),
+		users:         make(map[int64]entry[time.Time]),
+	}
+}
+
+// Revoked reports whether the token of payload has been revoked, by itself or by a
+// cutoff of its user.
+func (c *Checker) Revoked(ctx context.Context, payload *pkg.Payload) (bool, error) {
+	revoked, cutoff, ok := c.cached(payload.ID, payload.UserID)
+	if !ok {
+		var err error
+
+		revoked, cutoff, err = c.store.Lookup(ctx, payload.ID, payload.UserID)
+		if err != nil {
+			return false, err
+		}
+
+		revoked, cutoff = c.cache(payload.ID, revoked, payload.UserID, cutoff, c.now().Add(c.cacheTTL))
+	}
+
+	if revoked {
+		return true, nil
+	}
+
+	if cutoff.IsZero() {
+		return false, nil
+	}
+
+	// tokens without an issue time can not be told apart from the ones before the cutoff
+	if payload.IssuedAt == nil {
+		return true, nil
+	}
+
+	return !payload.IssuedAt.After(cutoff), nil
+}
+
+// RevokeToken revokes the token of payload until it expires.
+func (c *Checker) RevokeToken(ctx context.Context, payload *pkg.Payload) error {
+	expiresAt := c.now().Add(c.tokenDuration)
+	if payload.ExpiresAt != nil {
+		expiresAt = payload.ExpiresAt.Time
+	}
+
+	if err := c.store.RevokeToken(ctx, payload.ID, expiresAt); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[payload.ID] = entry[bool]{value: true, expiresAt: expiresAt}
+
+	return nil
+}
+
+// RevokeUserTokens revokes every token issued to the user until now.
+func (c *Checker) RevokeUserTokens(ctx context.Context, userID int64) error {
+	// tokens carry their issue time in seconds, a token issued within the current
+	// second is revoked as well
+	cutoff := c.now().Truncate(time.Second)
+	expiresAt := cutoff.Add(c.tokenDuration + time.Second)
+
+	if err := c.store.RevokeUserTokens(ctx, userID, cutoff, expiresAt); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[userID] = entry[time.Time]{value: cutoff, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (c *Checker) cached(id uuid.UUID, userID int64) (bool, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	token, ok := c.tokens[id]
+	if !ok || !now.Before(token.expiresAt) {
+		return false, time.Time{}, false
+	}
+
+	if token.value {
+		return true, time.Time{}, true
+	}
+
+	user, ok := c.users[userID]
+	if !ok || !now.Before(user.expiresAt) {
+		return false, time.Time{}, false
+	}
+
+	return token.value, user.value, true
+}
+
+// cache keeps the answer of the store until expiresAt, and returns it merged with the
+// revocations made through the checker meanwhile.
+func (c *Checker) cache(
+	id uuid.UUID,
+	revoked bool,
+	userID int64,
+	cutoff time.Time,
+	expiresAt time.Time,
+) (bool, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	// a revocation made through this instance is kept over what the store answered
+	// before it was stored
+	if token, ok := c.tokens[id]; ok && token.value && now.Before(token.expiresAt) {
+		revoked = true
+	} else {
+		c.tokens[id] = entry[bool]{value: revoked, expiresAt: expiresAt}
+	}
+
+	if user, ok := c.users[userID]; ok && now.Before(user.expiresAt) && !user.value.Before(cutoff) {
+		cutoff = user.value
+	} else {
+		c.users[userID] = entry[time.Time]{value: cutoff, expiresAt: expiresAt}
+	}
+
+	c.sweep(now)
+
+	return revoked, cutoff
+}
+
+// sweep drops the expired entries once in a while, rather than on every lookup. The
+// lock is held by the caller.
+func (c *Checker) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+
+	c.nextSweep = now.Add(c.cacheTTL)
+
+	for id, token := range c.tokens {
+		if !now.Before(token.expiresAt) {
+			delete(c.tokens, id)
+		}
+	}
+
+	for userID, user := range c.users {
+		if !now.Before(user.expiresAt) {
+			delete(c.users, userID)
+		}
+	}
+}
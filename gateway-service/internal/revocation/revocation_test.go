@@ -0,0 +1,168 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore is a MemoryStore shared by the checkers of a test, as Redis is by the
+// gateway instances, counting the lookups made and failing with err when set.
+type countingStore struct {
+	*MemoryStore
+	lookups int
+	err     error
+}
+
+func newCountingStore() *countingStore {
+	return &countingStore{MemoryStore: NewMemoryStore()}
+}
+
+func (s *countingStore) Lookup(ctx context.Context, id uuid.UUID, userID int64) (bool, time.Time, error) {
+	s.lookups++
+
+	if s.err != nil {
+		return false, time.Time{}, s.err
+	}
+
+	return s.MemoryStore.Lookup(ctx, id, userID)
+}
+
+func newPayload(userID int64, issuedAt time.Time) *pkg.Payload {
+	return &pkg.Payload{
+		ID:     uuid.New(),
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+		},
+	}
+}
+
+func TestChecker_RevokeToken(t *testing.T) {
+	store := newCountingStore()
+	now := time.Now()
+
+	checker := NewChecker(store, 5*time.Second, time.Hour)
+	checker.now = func() time.Time { return now }
+
+	other := NewChecker(store, 5*time.Second, time.Hour)
+	other.now = func() time.Time { return now }
+
+	payload := newPayload(1, now.Add(-time.Minute))
+	untouched := newPayload(1, now.Add(-time.Minute))
+
+	revoked, err := other.Revoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, checker.RevokeToken(context.Background(), payload))
+	require.Equal(t, payload.ExpiresAt.Time, store.tokens[payload.ID])
+
+	// seen at once by the instance that revoked it, without asking the store
+	revoked, err = checker.Revoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// the other instance trusts its cached answer for a while
+	revoked, err = other.Revoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+	require.Equal(t, 1, store.lookups)
+
+	now = now.Add(5 * time.Second)
+
+	revoked, err = other.Revoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = other.Revoked(context.Background(), untouched)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestChecker_RevokeUserTokens(t *testing.T) {
+	store := newCountingStore()
+	now := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+
+	checker := NewChecker(store, 5*time.Second, time.Hour)
+	checker.now = func() time.Time { return now }
+
+	before := newPayload(1, now.Add(-time.Minute))
+	sameSecond := newPayload(1, now)
+	otherUser := newPayload(2, now.Add(-time.Minute))
+
+	require.NoError(t, checker.RevokeUserTokens(context.Background(), 1))
+	require.Equal(t, now.Truncate(time.Second), store.users[1].value)
+
+	for _, tc := range []struct {
+		name    string
+		payload *pkg.Payload
+		want    bool
+	}{
+		{name: "issued before", payload: before, want: true},
+		{name: "issued the same second", payload: sameSecond, want: true},
+		{name: "another user", payload: otherUser, want: false},
+		{name: "issued after", payload: newPayload(1, now.Add(time.Second)), want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, err := checker.Revoked(context.Background(), tc.payload)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, revoked)
+		})
+	}
+
+	// a stale answer of the store does not undo the cutoff
+	store.users = map[int64]entry[time.Time]{}
+	now = now.Add(time.Minute)
+
+	revoked, err := checker.Revoked(context.Background(), newPayload(1, now.Add(-time.Hour)))
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestChecker_StoreError(t *testing.T) {
+	store := newCountingStore()
+	store.err = errors.New("connection refused")
+
+	checker := NewChecker(store, 5*time.Second, time.Hour)
+
+	_, err := checker.Revoked(context.Background(), newPayload(1, time.Now()))
+	require.Error(t, err)
+
+	// an answer that failed is not cached
+	store.err = nil
+
+	revoked, err := checker.Revoked(context.Background(), newPayload(1, time.Now()))
+	require.NoError(t, err)
+	require.False(t, revoked)
+	require.Equal(t, 2, store.lookups)
+}
+
+func TestChecker_Sweep(t *testing.T) {
+	store := newCountingStore()
+	now := time.Now()
+
+	checker := NewChecker(store, 5*time.Second, time.Hour)
+	checker.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		_, err := checker.Revoked(context.Background(), newPayload(int64(i), now))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, checker.tokens, 10)
+
+	now = now.Add(10 * time.Second)
+
+	_, err := checker.Revoked(context.Background(), newPayload(1, now))
+	require.NoError(t, err)
+	require.Len(t, checker.tokens, 1)
+	require.Len(t, checker.users, 1)
+}
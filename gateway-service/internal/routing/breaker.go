@@ -0,0 +1,87 @@
+package routing
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	closed breakerState = iota
+	open
+	halfOpen
+)
+
+// Breaker is a circuit breaker. It opens after threshold failures in a row, turning the
+// calls away until openFor has passed, then lets a single call through: the circuit
+// closes again if it succeeds and opens for another openFor if it fails.
+type Breaker struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func NewBreaker(threshold int, openFor time.Duration) *Breaker {
+	return &Breaker{
+		threshold: max(threshold, 1),
+		openFor:   openFor,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may go through, and must be followed by Success or
+// Failure when it does.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false
+		}
+
+		b.state = halfOpen
+
+		return true
+	case halfOpen:
+		// the trial call has not come back yet
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = closed
+	b.failures = 0
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.state == halfOpen || b.failures >= b.threshold {
+		b.state = open
+		b.openedAt = b.now()
+	}
+}
+
+// Open reports whether calls are being turned away.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == open && b.now().Sub(b.openedAt) < b.openFor
+}
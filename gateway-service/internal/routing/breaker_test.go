@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC)
+
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.Allow())
+	b.Failure()
+	require.False(t, b.Open())
+
+	// a success in between resets the count
+	require.True(t, b.Allow())
+	b.Success()
+	require.True(t, b.Allow())
+	b.Failure()
+	require.False(t, b.Open())
+
+	require.True(t, b.Allow())
+	b.Failure()
+	require.True(t, b.Open())
+	require.False(t, b.Allow())
+
+	// a single trial once openFor has passed, reopening on failure
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+	b.Failure()
+	require.True(t, b.Open())
+
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	b.Success()
+	require.False(t, b.Open())
+	require.True(t, b.Allow())
+}
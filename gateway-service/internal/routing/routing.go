@@ -0,0 +1,265 @@
+// Package routing picks the transport the gateway reaches a service over, per route.
+// Each route has a primary transport followed by fallbacks, which are tried in order
+// when the ones before them are unavailable or their circuit is open.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+)
+
+type Transport string
+
+const (
+	GRPC     Transport = "grpc"
+	RabbitMQ Transport = "rabbitmq"
+	HTTP     Transport = "http"
+)
+
+type Route string
+
+const (
+	RegisterUser      Route = "register_user"
+	LoginUser         Route = "login_user"
+	RefreshToken      Route = "refresh_token"
+	Logout            Route = "logout"
+	APIKeys           Route = "api_keys"
+	Admin             Route = "admin"
+	EmailVerify       Route = "email_verification"
+	PasswordReset     Route = "password_reset"
+	MFA               Route = "mfa"
+	InitiatePayment   Route = "initiate_payment"
+	ConfirmWithdrawal Route = "confirm_withdrawal"
+	PollTransaction   Route = "poll_transaction"
+)
+
+// DefaultTimeout is the budget of a route that is not given one, and bounds the calls
+// made without a deadline.
+const DefaultTimeout = 5 * time.Second
+
+// ErrUnavailable is returned when every transport of a route has its circuit open.
+var ErrUnavailable = errors.New("routing: no transport available")
+
+// supported lists the transports each route can be served over.
+var supported = map[Route][]Transport{
+	RegisterUser:      {GRPC, RabbitMQ, HTTP},
+	LoginUser:         {GRPC, RabbitMQ, HTTP},
+	RefreshToken:      {GRPC},
+	Logout:            {GRPC},
+	APIKeys:           {GRPC},
+	Admin:             {GRPC},
+	EmailVerify:       {GRPC},
+	PasswordReset:     {GRPC, RabbitMQ, HTTP},
+	MFA:               {GRPC},
+	InitiatePayment:   {GRPC, RabbitMQ},
+	ConfirmWithdrawal: {GRPC},
+	PollTransaction:   {GRPC, RabbitMQ},
+}
+
+// DefaultRoutes returns the transports used for the routes that are not configured.
+func DefaultRoutes() map[Route][]Transport {
+	return map[Route][]Transport{
+		RegisterUser:      {GRPC, RabbitMQ, HTTP},
+		LoginUser:         {HTTP, GRPC, RabbitMQ},
+		RefreshToken:      {GRPC},
+		Logout:            {GRPC},
+		APIKeys:           {GRPC},
+		Admin:             {GRPC},
+		EmailVerify:       {GRPC},
+		PasswordReset:     {GRPC, RabbitMQ, HTTP},
+		MFA:               {GRPC},
+		InitiatePayment:   {RabbitMQ, GRPC},
+		ConfirmWithdrawal: {GRPC},
+		PollTransaction:   {RabbitMQ, GRPC},
+	}
+}
+
+type Router struct {
+	routes   map[Route][]Transport
+	timeouts map[Route]time.Duration
+	breakers map[Route]map[Transport]*Breaker
+}
+
+// New returns a Router over routes, with a circuit breaker for each of their transports.
+func New(routes map[Route][]Transport, threshold int, openFor time.Duration) *Router {
+	r := &Router{
+		routes:   routes,
+		timeouts: make(map[Route]time.Duration),
+		breakers: make(map[Route]map[Transport]*Breaker, len(routes)),
+	}
+
+	for route, transports := range routes {
+		r.breakers[route] = make(map[Transport]*Breaker, len(transports))
+
+		for _, transport := range transports {
+			r.breakers[route][transport] = NewBreaker(threshold, openFor)
+		}
+	}
+
+	return r
+}
+
+// NewFromConfig returns a Router over the ROUTE_* transports of config, each a comma
+// separated list with the primary first, and the TIMEOUT_* budgets of the routes.
+func NewFromConfig(config pkg.Config) (*Router, error) {
+	routes := DefaultRoutes()
+
+	for route, value := range map[Route]string{
+		RegisterUser:    config.ROUTE_REGISTER_USER,
+		LoginUser:       config.ROUTE_LOGIN_USER,
+		PasswordReset:   config.ROUTE_PASSWORD_RESET,
+		InitiatePayment: config.ROUTE_INITIATE_PAYMENT,
+		PollTransaction: config.ROUTE_POLL_TRANSACTION,
+	} {
+		if value == "" {
+			continue
+		}
+
+		transports, err := ParseTransports(route, value)
+		if err != nil {
+			return nil, err
+		}
+
+		routes[route] = transports
+	}
+
+	r := New(routes, config.CIRCUIT_FAILURE_THRESHOLD, config.CIRCUIT_OPEN_TIMEOUT)
+
+	for route, timeout := range map[Route]time.Duration{
+		RegisterUser:      config.TIMEOUT_REGISTER_USER,
+		LoginUser:         config.TIMEOUT_LOGIN_USER,
+		RefreshToken:      config.TIMEOUT_REFRESH_TOKEN,
+		Logout:            config.TIMEOUT_LOGOUT,
+		APIKeys:           config.TIMEOUT_API_KEYS,
+		Admin:             config.TIMEOUT_ADMIN,
+		EmailVerify:       config.TIMEOUT_EMAIL_VERIFICATION,
+		PasswordReset:     config.TIMEOUT_PASSWORD_RESET,
+		MFA:               config.TIMEOUT_MFA,
+		InitiatePayment:   config.TIMEOUT_INITIATE_PAYMENT,
+		ConfirmWithdrawal: config.TIMEOUT_CONFIRM_WITHDRAWAL,
+		PollTransaction:   config.TIMEOUT_POLL_TRANSACTION,
+	} {
+		if timeout > 0 {
+			r.timeouts[route] = timeout
+		}
+	}
+
+	return r, nil
+}
+
+// ParseTransports parses a comma separated list of the transports of route.
+func ParseTransports(route Route, value string) ([]Transport, error) {
+	var transports []Transport
+
+	for _, field := range strings.Split(value, ",") {
+		transport := Transport(strings.TrimSpace(field))
+
+		if !slices.Contains(supported[route], transport) {
+			return nil, fmt.Errorf("routing: %s can not be served over %q", route, transport)
+		}
+
+		if slices.Contains(transports, transport) {
+			return nil, fmt.Errorf("routing: %s lists %q twice", route, transport)
+		}
+
+		transports = append(transports, transport)
+	}
+
+	return transports, nil
+}
+
+// Transports returns the transports of route, the primary first.
+func (r *Router) Transports(route Route) []Transport {
+	return r.routes[route]
+}
+
+// Timeout returns the time a request to route is given to be served in, across all the
+// transports tried.
+func (r *Router) Timeout(route Route) time.Duration {
+	if timeout, ok := r.timeouts[route]; ok {
+		return timeout
+	}
+
+	return DefaultTimeout
+}
+
+// WithDefaultTimeout bounds ctx by DefaultTimeout, unless it has a deadline already.
+func WithDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, DefaultTimeout)
+}
+
+// Do calls the transports of route in order and returns the result of the first one that
+// is available. A transport is skipped while its circuit is open, and the next one is
+// tried when it answers http.StatusServiceUnavailable, which transports only answer when
+// the request did not reach the service. Other failures are returned as they are,
+// including a connection lost after the request was sent: the service may have acted on
+// the request already, and sending it again could make a payment twice. The result of
+// the last transport tried is returned when none is available, and ErrUnavailable when
+// none could be tried.
+func Do[T any](
+	ctx context.Context,
+	r *Router,
+	route Route,
+	call func(Transport) (int, T),
+) (Transport, int, T, error) {
+	var (
+		served     Transport
+		statusCode int
+		rsp        T
+	)
+
+	for _, transport := range r.routes[route] {
+		breaker := r.breakers[route][transport]
+		if !breaker.Allow() {
+			continue
+		}
+
+		if served != "" {
+			slog.WarnContext(ctx, "falling back to another transport", "route", route, "from", served, "to", transport)
+		}
+
+		served = transport
+		statusCode, rsp = call(transport)
+
+		if !failed(statusCode) {
+			breaker.Success()
+
+			return served, statusCode, rsp, nil
+		}
+
+		breaker.Failure()
+
+		if statusCode != http.StatusServiceUnavailable {
+			return served, statusCode, rsp, nil
+		}
+	}
+
+	if served == "" {
+		return "", http.StatusServiceUnavailable, rsp, ErrUnavailable
+	}
+
+	return served, statusCode, rsp, nil
+}
+
+// failed reports whether statusCode means the transport, rather than the service
+// behind it, failed.
+func failed(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,216 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/gateway-service/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransports(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   Route
+		value   string
+		want    []Transport
+		wantErr bool
+	}{
+		{
+			name:  "fallbacks",
+			route: RegisterUser,
+			value: "grpc, rabbitmq,http",
+			want:  []Transport{GRPC, RabbitMQ, HTTP},
+		},
+		{
+			name:    "unsupported transport",
+			route:   InitiatePayment,
+			value:   "grpc,http",
+			wantErr: true,
+		},
+		{
+			name:    "unknown transport",
+			route:   LoginUser,
+			value:   "kafka",
+			wantErr: true,
+		},
+		{
+			name:    "refresh over grpc only",
+			route:   RefreshToken,
+			value:   "rabbitmq",
+			wantErr: true,
+		},
+		{
+			name:    "listed twice",
+			route:   LoginUser,
+			value:   "grpc,grpc",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTransports(tc.route, tc.value)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	r, err := NewFromConfig(pkg.Config{ROUTE_LOGIN_USER: "grpc,http"})
+	require.NoError(t, err)
+	require.Equal(t, []Transport{GRPC, HTTP}, r.Transports(LoginUser))
+	require.Equal(t, DefaultRoutes()[RegisterUser], r.Transports(RegisterUser))
+
+	_, err = NewFromConfig(pkg.Config{ROUTE_POLL_TRANSACTION: "http"})
+	require.Error(t, err)
+}
+
+func TestRouter_Timeout(t *testing.T) {
+	r, err := NewFromConfig(pkg.Config{TIMEOUT_INITIATE_PAYMENT: 3 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Second, r.Timeout(InitiatePayment))
+	require.Equal(t, DefaultTimeout, r.Timeout(PollTransaction))
+}
+
+func TestWithDefaultTimeout(t *testing.T) {
+	ctx, cancel := WithDefaultTimeout(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(DefaultTimeout), deadline, 100*time.Millisecond)
+
+	// an earlier deadline is kept
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+
+	ctx, cancel = WithDefaultTimeout(parent)
+	defer cancel()
+
+	require.Equal(t, parent, ctx)
+}
+
+func TestDo(t *testing.T) {
+	// answers with the status of each transport, recording the calls
+	callWith := func(statuses map[Transport]int, calls *[]Transport) func(Transport) (int, string) {
+		return func(transport Transport) (int, string) {
+			*calls = append(*calls, transport)
+
+			return statuses[transport], string(transport)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		statuses      map[Transport]int
+		wantTransport Transport
+		wantStatus    int
+		wantCalls     []Transport
+	}{
+		{
+			name:          "primary",
+			statuses:      map[Transport]int{GRPC: http.StatusOK},
+			wantTransport: GRPC,
+			wantStatus:    http.StatusOK,
+			wantCalls:     []Transport{GRPC},
+		},
+		{
+			name:          "error from the service",
+			statuses:      map[Transport]int{GRPC: http.StatusNotFound},
+			wantTransport: GRPC,
+			wantStatus:    http.StatusNotFound,
+			wantCalls:     []Transport{GRPC},
+		},
+		{
+			name:          "fallback",
+			statuses:      map[Transport]int{GRPC: http.StatusServiceUnavailable, RabbitMQ: http.StatusServiceUnavailable, HTTP: http.StatusOK},
+			wantTransport: HTTP,
+			wantStatus:    http.StatusOK,
+			wantCalls:     []Transport{GRPC, RabbitMQ, HTTP},
+		},
+		{
+			name:          "timeout",
+			statuses:      map[Transport]int{GRPC: http.StatusRequestTimeout},
+			wantTransport: GRPC,
+			wantStatus:    http.StatusRequestTimeout,
+			wantCalls:     []Transport{GRPC},
+		},
+		{
+			name:          "lost after it was sent",
+			statuses:      map[Transport]int{GRPC: http.StatusBadGateway, RabbitMQ: http.StatusOK},
+			wantTransport: GRPC,
+			wantStatus:    http.StatusBadGateway,
+			wantCalls:     []Transport{GRPC},
+		},
+		{
+			name:          "none available",
+			statuses:      map[Transport]int{GRPC: http.StatusServiceUnavailable, RabbitMQ: http.StatusServiceUnavailable, HTTP: http.StatusServiceUnavailable},
+			wantTransport: HTTP,
+			wantStatus:    http.StatusServiceUnavailable,
+			wantCalls:     []Transport{GRPC, RabbitMQ, HTTP},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := New(map[Route][]Transport{RegisterUser: {GRPC, RabbitMQ, HTTP}}, 5, time.Minute)
+
+			var calls []Transport
+
+			transport, status, rsp, err := Do(context.Background(), r, RegisterUser, callWith(tc.statuses, &calls))
+			require.NoError(t, err)
+			require.Equal(t, tc.wantTransport, transport)
+			require.Equal(t, tc.wantStatus, status)
+			require.Equal(t, string(tc.wantTransport), rsp)
+			require.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestDo_OpenCircuit(t *testing.T) {
+	r := New(map[Route][]Transport{InitiatePayment: {GRPC, RabbitMQ}}, 2, time.Minute)
+
+	statuses := map[Transport]int{GRPC: http.StatusGatewayTimeout, RabbitMQ: http.StatusOK}
+
+	var calls []Transport
+
+	call := func(transport Transport) (int, string) {
+		calls = append(calls, transport)
+
+		return statuses[transport], string(transport)
+	}
+
+	// timeouts are not retried elsewhere, but open the circuit
+	for i := 0; i < 2; i++ {
+		transport, status, _, err := Do(context.Background(), r, InitiatePayment, call)
+		require.NoError(t, err)
+		require.Equal(t, GRPC, transport)
+		require.Equal(t, http.StatusGatewayTimeout, status)
+	}
+
+	transport, status, _, err := Do(context.Background(), r, InitiatePayment, call)
+	require.NoError(t, err)
+	require.Equal(t, RabbitMQ, transport)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []Transport{GRPC, GRPC, RabbitMQ}, calls)
+
+	statuses[RabbitMQ] = http.StatusServiceUnavailable
+
+	for i := 0; i < 2; i++ {
+		_, _, _, _ = Do(context.Background(), r, InitiatePayment, call)
+	}
+
+	_, status, _, err = Do(context.Background(), r, InitiatePayment, call)
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+}
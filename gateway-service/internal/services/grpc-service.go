@@ -1,6 +1,29 @@
 package services
 
+import "context"
+
 type GrpcInterface interface {
-	RegisterUserViagRPC(RegisterUserRequest) (int, RegisterUserResponse)
-	LoginUserViagRPC(LoginUserRequest) (int, LoginUserResponse)
+	RegisterUserViagRPC(context.Context, RegisterUserRequest) (int, RegisterUserResponse)
+	LoginUserViagRPC(context.Context, LoginUserRequest) (int, LoginUserResponse)
+	RefreshTokenViagRPC(context.Context, RefreshTokenRequest) (int, RefreshTokenResponse)
+	RevokeRefreshTokensViagRPC(context.Context, LogoutRequest, int64) (int, LogoutResponse)
+	VerifyEmailViagRPC(context.Context, VerifyEmailRequest) (int, VerifyEmailResponse)
+	ResendVerificationEmailViagRPC(context.Context, ResendVerificationEmailRequest) (int, ResendVerificationEmailResponse)
+	ForgotPasswordViagRPC(context.Context, ForgotPasswordRequest) (int, ForgotPasswordResponse)
+	ResetPasswordViagRPC(context.Context, ResetPasswordRequest) (int, ResetPasswordResponse)
+	VerifyMFAViagRPC(context.Context, VerifyMFARequest) (int, LoginUserResponse)
+	EnrollMFAViagRPC(context.Context, EnrollMFARequest, int64) (int, EnrollMFAResponse)
+	ActivateMFAViagRPC(context.Context, ActivateMFARequest, int64) (int, ActivateMFAResponse)
+	DisableMFAViagRPC(context.Context, DisableMFARequest, int64) (int, DisableMFAResponse)
+	CreateAPIKeyViagRPC(context.Context, CreateAPIKeyRequest, int64) (int, CreateAPIKeyResponse)
+	ListAPIKeysViagRPC(context.Context, int64) (int, ListAPIKeysResponse)
+	RevokeAPIKeyViagRPC(context.Context, RevokeAPIKeyRequest, int64) (int, RevokeAPIKeyResponse)
+	AdminGetUserViagRPC(context.Context, AdminGetUserRequest, int64) (int, AdminUserResponse)
+	DisableUserViagRPC(context.Context, DisableUserRequest, int64) (int, AdminUserResponse)
+	UnlockUserViagRPC(context.Context, UnlockUserRequest, int64) (int, AdminUserResponse)
+	RecordAuditEventViagRPC(context.Context, RecordAuditEventRequest, int64) (int, RecordAuditEventResponse)
+	InitiatePaymentViagRPC(context.Context, InitiatePaymentRequest, int64) (int, InitiatePaymentResponse)
+	ConfirmWithdrawalViagRPC(context.Context, ConfirmWithdrawalRequest, int64) (int, InitiatePaymentResponse)
+	PollTransactionViagRPC(context.Context, PollingTransactionRequest, int64) (int, PollingTransactionResponse)
+	ListTransactionsViagRPC(context.Context, ListTransactionsRequest) (int, ListTransactionsResponse)
 }
@@ -1,6 +1,10 @@
 package services
 
+import "context"
+
 type HttpInterface interface {
-	RegisterUserViaHttp(RegisterUserRequest) (int, RegisterUserResponse)
-	LoginUserViaHttp(LoginUserRequest) (int, LoginUserResponse)
+	RegisterUserViaHttp(context.Context, RegisterUserRequest) (int, RegisterUserResponse)
+	LoginUserViaHttp(context.Context, LoginUserRequest) (int, LoginUserResponse)
+	ForgotPasswordViaHttp(context.Context, ForgotPasswordRequest) (int, ForgotPasswordResponse)
+	ResetPasswordViaHttp(context.Context, ResetPasswordRequest) (int, ResetPasswordResponse)
 }
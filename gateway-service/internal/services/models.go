@@ -27,16 +27,195 @@ type RegisterUserResponse struct {
 type LoginUserRequest struct {
 	Email    string `binding:"required" json:"email"`
 	Password string `binding:"required" json:"password"`
+	// ClientIP is the address the login comes from, set by the gateway whatever the
+	// client sent, for the authentication service to limit failed logins by.
+	ClientIP string `json:"client_ip,omitempty"`
 }
 
 type LoginUserResponse struct {
-	AccessToken  string    `json:"access_token,omitempty"`
-	FullName     string    `json:"full_name,omitempty"`
-	Email        string    `json:"email,omitempty"`
-	ExpirationAt time.Time `json:"expiration_at,omitempty"`
-	CreatedAt    time.Time `json:"created_at,omitempty"`
-	Message      string    `json:"message,omitempty"`
-	StatusCode   int       `json:"status_code,omitempty"`
+	AccessToken         string    `json:"access_token,omitempty"`
+	FullName            string    `json:"full_name,omitempty"`
+	Email               string    `json:"email,omitempty"`
+	ExpirationAt        time.Time `json:"expiration_at,omitempty"`
+	RefreshToken        string    `json:"refresh_token,omitempty"`
+	RefreshExpirationAt time.Time `json:"refresh_expiration_at,omitempty"`
+	CreatedAt           time.Time `json:"created_at,omitempty"`
+	// MFARequired is set instead of the tokens for users with two-factor authentication,
+	// who complete the login by posting MFAToken with a code to /login/mfa.
+	MFARequired     bool      `json:"mfa_required,omitempty"`
+	MFAToken        string    `json:"mfa_token,omitempty"`
+	MFAExpirationAt time.Time `json:"mfa_expiration_at,omitempty"`
+	Message         string    `json:"message,omitempty"`
+	StatusCode      int       `json:"status_code,omitempty"`
+}
+
+// MFAChallengeResponse answers the login of a user with two-factor authentication, in
+// place of their tokens.
+type MFAChallengeResponse struct {
+	MFARequired     bool      `json:"mfa_required"`
+	MFAToken        string    `json:"mfa_token"`
+	MFAExpirationAt time.Time `json:"mfa_expiration_at"`
+	Message         string    `json:"message,omitempty"`
+}
+
+// VerifyMFARequest completes a login with the token of its challenge and a TOTP code or
+// a recovery code.
+type VerifyMFARequest struct {
+	MFAToken string `binding:"required" json:"mfa_token"`
+	Code     string `binding:"required" json:"code"`
+	// ClientIP is set by the gateway, as for LoginUserRequest.
+	ClientIP string `json:"-"`
+}
+
+// EnrollMFARequest asks for a TOTP secret, with the password of the user again.
+type EnrollMFARequest struct {
+	Password string `binding:"required" json:"password"`
+}
+
+// EnrollMFAResponse carries the secret, its otpauth URI and the recovery codes, which
+// are not shown again.
+type EnrollMFAResponse struct {
+	Secret        string   `json:"secret,omitempty"`
+	OtpauthURI    string   `json:"otpauth_uri,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	Message       string   `json:"message,omitempty"`
+	StatusCode    int      `json:"status_code,omitempty"`
+}
+
+type ActivateMFARequest struct {
+	Code string `binding:"required" json:"code"`
+}
+
+type ActivateMFAResponse struct {
+	EnabledAt  time.Time `json:"enabled_at,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+}
+
+// DisableMFARequest turns two-factor authentication off with a current code, or a
+// recovery code.
+type DisableMFARequest struct {
+	Code string `binding:"required" json:"code"`
+}
+
+type DisableMFAResponse struct {
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `binding:"required" json:"refresh_token"`
+}
+
+type RefreshTokenResponse struct {
+	AccessToken         string    `json:"access_token,omitempty"`
+	ExpirationAt        time.Time `json:"expiration_at,omitempty"`
+	RefreshToken        string    `json:"refresh_token,omitempty"`
+	RefreshExpirationAt time.Time `json:"refresh_expiration_at,omitempty"`
+	Message             string    `json:"message,omitempty"`
+	StatusCode          int       `json:"status_code,omitempty"`
+}
+
+// LogoutRequest optionally carries the refresh token of the session, revoked along with
+// the access token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutResponse struct {
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// VerifyEmailRequest carries the token of the link sent to the user's email address.
+type VerifyEmailRequest struct {
+	Token string `binding:"required" form:"token"`
+}
+
+type VerifyEmailResponse struct {
+	Email      string    `json:"email,omitempty"`
+	VerifiedAt time.Time `json:"verified_at,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `binding:"required,email" json:"email"`
+}
+
+type ResendVerificationEmailResponse struct {
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `binding:"required,email" json:"email"`
+}
+
+type ForgotPasswordResponse struct {
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// ResetPasswordRequest carries the token of the link sent to the user's email address,
+// with the password to set.
+type ResetPasswordRequest struct {
+	Token    string `binding:"required" json:"token"`
+	Password string `binding:"required" json:"password"`
+}
+
+// ResetPasswordResponse names the user whose password was reset, for their access
+// tokens to be revoked.
+type ResetPasswordResponse struct {
+	UserID     int64  `json:"user_id,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// APIKey is an API key of the user, without the key itself. Prefix is the start of the
+// key, to tell the keys apart.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyRequest names the scopes of the key, and optionally the addresses or
+// networks it can be used from and when it expires.
+type CreateAPIKeyRequest struct {
+	Name       string     `binding:"required"       json:"name"`
+	Scopes     []string   `binding:"required,min=1" json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries the key itself, which is not shown again.
+type CreateAPIKeyResponse struct {
+	Key        string  `json:"key,omitempty"`
+	APIKey     *APIKey `json:"api_key,omitempty"`
+	Message    string  `json:"message,omitempty"`
+	StatusCode int     `json:"status_code,omitempty"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys    []APIKey `json:"api_keys"`
+	Message    string   `json:"message,omitempty"`
+	StatusCode int      `json:"status_code,omitempty"`
+}
+
+type RevokeAPIKeyRequest struct {
+	ID int64 `binding:"required" uri:"id"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
 }
 
 type InitiatePaymentRequest struct {
@@ -52,8 +231,22 @@ type InitiatePaymentResponse struct {
 	TransactionID string `json:"transaction_id,omitempty"`
 	PaymentStatus bool   `json:"payment_status,omitempty"`
 	Action        string `json:"action,omitempty"`
-	Message       string `json:"message,omitempty"`
-	StatusCode    int    `json:"status_code,omitempty"`
+	// ConfirmationRequired is set for withdrawals above the threshold of the payments
+	// service, which are only sent once confirmed at /payments/confirm/:id before
+	// ConfirmationExpiresAt.
+	ConfirmationRequired  bool       `json:"confirmation_required,omitempty"`
+	ConfirmationExpiresAt *time.Time `json:"confirmation_expires_at,omitempty"`
+	Message               string     `json:"message,omitempty"`
+	StatusCode            int        `json:"status_code,omitempty"`
+}
+
+// ConfirmWithdrawalRequest confirms a withdrawal with the password of the user, or a
+// code of their authenticator app when they use two-factor authentication.
+type ConfirmWithdrawalRequest struct {
+	TransactionID string `json:"-"`
+	Password      string `json:"password"`
+	Code          string `json:"code"`
+	ClientIP      string `json:"-"`
 }
 
 type PollingTransactionRequest struct {
@@ -73,3 +266,83 @@ type PollingTransactionResponse struct {
 	Message            string    `json:"message,omitempty"`
 	StatusCode         int       `json:"status_code,omitempty"`
 }
+
+// ActionTransactionsView is the audit log action of support staff viewing the
+// transactions of a user.
+const ActionTransactionsView = "transactions.view"
+
+// AdminUser is a user as support staff and admins see them.
+type AdminUser struct {
+	ID            int64      `json:"id"`
+	FullName      string     `json:"full_name"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	PaydUsername  string     `json:"payd_username"`
+	PaydAccountID string     `json:"payd_account_id"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type AdminUserURI struct {
+	ID int64 `binding:"required" uri:"id"`
+}
+
+// AdminGetUserRequest looks a user up by UserID, taken from the path, or else by Email.
+// The reason is optional, and recorded in the audit log.
+type AdminGetUserRequest struct {
+	UserID int64  `form:"-"`
+	Email  string `form:"email"`
+	Reason string `form:"reason"`
+}
+
+type AdminUserResponse struct {
+	User       *AdminUser `json:"user,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+}
+
+// DisableUserRequest disables the account of UserID, taken from the path, for a reason
+// recorded in the audit log.
+type DisableUserRequest struct {
+	UserID int64  `json:"-"`
+	Reason string `binding:"required" json:"reason"`
+}
+
+// UnlockUserRequest lifts the lockout failed logins put on the account of UserID, taken
+// from the path, for a reason recorded in the audit log.
+type UnlockUserRequest struct {
+	UserID int64  `json:"-"`
+	Reason string `binding:"required" json:"reason"`
+}
+
+// AdminTransactionsRequest pages through the transactions of a user, for a reason
+// recorded in the audit log.
+type AdminTransactionsRequest struct {
+	Reason string `binding:"required" form:"reason"`
+	Limit  int32  `binding:"min=0"    form:"limit"`
+	Offset int32  `binding:"min=0"    form:"offset"`
+}
+
+type RecordAuditEventRequest struct {
+	Action       string
+	TargetUserID int64
+	Reason       string
+}
+
+type RecordAuditEventResponse struct {
+	ID         int64  `json:"id,omitempty"`
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+type ListTransactionsRequest struct {
+	UserID int64
+	Limit  int32
+	Offset int32
+}
+
+type ListTransactionsResponse struct {
+	Transactions []PollingTransactionResponse `json:"transactions"`
+	Message      string                       `json:"message,omitempty"`
+	StatusCode   int                          `json:"status_code,omitempty"`
+}
@@ -1,16 +1,14 @@
 package services
 
-type Payload struct {
-	Name string `json:"name"`
-	Data []byte `json:"data"`
-	// Data any    `json:"data"`
-}
+import "context"
 
 type RabbitInterface interface {
-	RegisterUserViaRabbit(RegisterUserRequest) (int, RegisterUserResponse)
-	LoginUserViaRabbit(LoginUserRequest) (int, LoginUserResponse)
-	InitiatePaymentViaRabbit(InitiatePaymentRequest) (int, InitiatePaymentResponse)
-	PollTransactionViaRabbit(PollingTransactionRequest, int64) (int, PollingTransactionResponse)
+	RegisterUserViaRabbit(context.Context, RegisterUserRequest) (int, RegisterUserResponse)
+	LoginUserViaRabbit(context.Context, LoginUserRequest) (int, LoginUserResponse)
+	ForgotPasswordViaRabbit(context.Context, ForgotPasswordRequest) (int, ForgotPasswordResponse)
+	ResetPasswordViaRabbit(context.Context, ResetPasswordRequest) (int, ResetPasswordResponse)
+	InitiatePaymentViaRabbit(context.Context, InitiatePaymentRequest, int64) (int, InitiatePaymentResponse)
+	PollTransactionViaRabbit(context.Context, PollingTransactionRequest, int64) (int, PollingTransactionResponse)
 
-	SetConsumer([]string, chan struct{}) error
+	SetConsumer(chan struct{}) error
 }
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestProvider(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+func TestGinMiddleware(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	gin.SetMode(gin.TestMode)
+
+	var handlerSpan trace.SpanContext
+
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.GET("/payments/status/:id", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx.Request.Context())
+
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/payments/status/32", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /payments/status/:id", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Equal(t, spans[0].SpanContext().SpanID(), handlerSpan.SpanID())
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	var outgoing metadata.MD
+
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+
+		return nil
+	}
+
+	err := UnaryClientInterceptor()(context.Background(), "/pb.AuthenticationService/LoginUser", nil, nil, nil, invoker)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+
+	traceparent := outgoing.Get("traceparent")
+	require.Len(t, traceparent, 1)
+	require.Contains(t, traceparent[0], spans[0].SpanContext().TraceID().String())
+}
+
+func TestStartPublish(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	_, span, headers := StartPublish(context.Background(), "payments.initiate_payment")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "payments.initiate_payment publish", spans[0].Name())
+
+	extracted := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(headers))
+	require.Equal(t, spans[0].SpanContext().SpanID(), trace.SpanContextFromContext(extracted).SpanID())
+}
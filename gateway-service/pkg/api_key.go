@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key issued by the authentication service, telling them
+// apart from access tokens.
+const APIKeyPrefix = "ppk_"
+
+// The scopes an API key can be granted, each allowing a group of routes.
+const (
+	ScopePaymentsInitiate = "payments:initiate"
+	ScopePaymentsRead     = "payments:read"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("api key is invalid")
+
+	// ErrAPIKeysUnavailable is returned when the authentication service can not be asked
+	// about an API key, so that it can be neither accepted nor rejected.
+	ErrAPIKeysUnavailable = errors.New("api keys can not be verified")
+
+	// ErrAddressNotAllowed is returned for a valid API key used from an address it is
+	// not allowed from.
+	ErrAddressNotAllowed = errors.New("api key is not allowed from this address")
+)
+
+// IsAPIKey reports whether token looks like an API key rather than an access token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// HasScope reports whether the request authenticated by p is allowed scope. Access
+// tokens stand for the user themselves and are allowed everything, API keys only the
+// scopes they were granted.
+func (p *Payload) HasScope(scope string) bool {
+	if p.APIKeyID == 0 {
+		return true
+	}
+
+	return slices.Contains(p.Scopes, scope)
+}
@@ -1,17 +1,50 @@
 package pkg
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
-	SERVER_ADDRESS        string `mapstructure:"SERVER_ADDRESS"`
-	RABBITMQ_URL          string `mapstructure:"RABBITMQ_URL"`
-	GATEWAY_CONSUMER_NAME string `mapstructure:"GATEWAY_CONSUMER_NAME"`
-	EXCH                  string `mapstructure:"EXCH"`
-	EXCLUSIVE_QUEUE_NAME  string `mapstructure:"EXCLUSIVE_QUEUE_NAME"`
-	AUTH_GRPC_PORT        string `mapstructure:"AUTH_GRPC_PORT"`
-	AUTH_HTTP_PORT        string `mapstructure:"AUTH_HTTP_PORT"`
-	PRIVATE_KEY_PATH      string `mapstructure:"PRIVATE_KEY_PATH"`
-	PUBLIC_KEY_PATH       string `mapstructure:"PUBLIC_KEY_PATH"`
+	SERVER_ADDRESS             string        `mapstructure:"SERVER_ADDRESS"`
+	RABBITMQ_URL               string        `mapstructure:"RABBITMQ_URL"`
+	GATEWAY_CONSUMER_NAME      string        `mapstructure:"GATEWAY_CONSUMER_NAME"`
+	EXCH                       string        `mapstructure:"EXCH"`
+	EXCLUSIVE_QUEUE_NAME       string        `mapstructure:"EXCLUSIVE_QUEUE_NAME"`
+	AUTH_GRPC_PORT             string        `mapstructure:"AUTH_GRPC_PORT"`
+	AUTH_HTTP_PORT             string        `mapstructure:"AUTH_HTTP_PORT"`
+	PAYMENTS_GRPC_PORT         string        `mapstructure:"PAYMENTS_GRPC_PORT"`
+	JWKS_MAX_AGE               time.Duration `mapstructure:"JWKS_MAX_AGE"`
+	JWKS_MIN_REFRESH_INTERVAL  time.Duration `mapstructure:"JWKS_MIN_REFRESH_INTERVAL"`
+	TOKEN_DURATION             time.Duration `mapstructure:"TOKEN_DURATION"`
+	REDIS_ADDR                 string        `mapstructure:"REDIS_ADDR"`
+	REVOCATION_CACHE_TTL       time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	API_KEY_CACHE_TTL          time.Duration `mapstructure:"API_KEY_CACHE_TTL"`
+	TRUSTED_PROXIES            string        `mapstructure:"TRUSTED_PROXIES"`
+	TRACING_EXPORTER           string        `mapstructure:"TRACING_EXPORTER"`
+	OTLP_ENDPOINT              string        `mapstructure:"OTLP_ENDPOINT"`
+	BUS_DRIVER                 string        `mapstructure:"BUS_DRIVER"`
+	ROUTE_REGISTER_USER        string        `mapstructure:"ROUTE_REGISTER_USER"`
+	ROUTE_LOGIN_USER           string        `mapstructure:"ROUTE_LOGIN_USER"`
+	ROUTE_PASSWORD_RESET       string        `mapstructure:"ROUTE_PASSWORD_RESET"`
+	ROUTE_INITIATE_PAYMENT     string        `mapstructure:"ROUTE_INITIATE_PAYMENT"`
+	ROUTE_POLL_TRANSACTION     string        `mapstructure:"ROUTE_POLL_TRANSACTION"`
+	CIRCUIT_FAILURE_THRESHOLD  int           `mapstructure:"CIRCUIT_FAILURE_THRESHOLD"`
+	CIRCUIT_OPEN_TIMEOUT       time.Duration `mapstructure:"CIRCUIT_OPEN_TIMEOUT"`
+	TIMEOUT_REGISTER_USER      time.Duration `mapstructure:"TIMEOUT_REGISTER_USER"`
+	TIMEOUT_LOGIN_USER         time.Duration `mapstructure:"TIMEOUT_LOGIN_USER"`
+	TIMEOUT_REFRESH_TOKEN      time.Duration `mapstructure:"TIMEOUT_REFRESH_TOKEN"`
+	TIMEOUT_LOGOUT             time.Duration `mapstructure:"TIMEOUT_LOGOUT"`
+	TIMEOUT_API_KEYS           time.Duration `mapstructure:"TIMEOUT_API_KEYS"`
+	TIMEOUT_ADMIN              time.Duration `mapstructure:"TIMEOUT_ADMIN"`
+	TIMEOUT_EMAIL_VERIFICATION time.Duration `mapstructure:"TIMEOUT_EMAIL_VERIFICATION"`
+	TIMEOUT_PASSWORD_RESET     time.Duration `mapstructure:"TIMEOUT_PASSWORD_RESET"`
+	TIMEOUT_MFA                time.Duration `mapstructure:"TIMEOUT_MFA"`
+	TIMEOUT_INITIATE_PAYMENT   time.Duration `mapstructure:"TIMEOUT_INITIATE_PAYMENT"`
+	TIMEOUT_CONFIRM_WITHDRAWAL time.Duration `mapstructure:"TIMEOUT_CONFIRM_WITHDRAWAL"`
+	TIMEOUT_POLL_TRANSACTION   time.Duration `mapstructure:"TIMEOUT_POLL_TRANSACTION"`
+	HTTP_CLIENT_TIMEOUT        time.Duration `mapstructure:"HTTP_CLIENT_TIMEOUT"`
 }
 
 func LoadConfig(path string) (Config, error) {
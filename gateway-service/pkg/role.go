@@ -0,0 +1,26 @@
+package pkg
+
+import "slices"
+
+// The roles of the users, each allowed what the ones before it are.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+var roles = []string{RoleUser, RoleSupport, RoleAdmin}
+
+// HasRole reports whether the request authenticated by p is allowed what role is. Tokens
+// issued before users had roles come without one and stand for a user, API keys are only
+// allowed what a user is.
+func (p *Payload) HasRole(role string) bool {
+	have := p.Role
+	if have == "" || p.APIKeyID != 0 {
+		have = RoleUser
+	}
+
+	want := slices.Index(roles, role)
+
+	return want >= 0 && slices.Index(roles, have) >= want
+}
@@ -1,8 +1,15 @@
 package pkg
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
 	"os"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func SkipCI(t *testing.T) {
@@ -10,3 +17,53 @@ func SkipCI(t *testing.T) {
 		t.Skip("Skipping testing in CI environment")
 	}
 }
+
+// TestSigner signs tokens the way the authentication service does, for testing the
+// gateway that only verifies them.
+type TestSigner struct {
+	KeyID      string
+	PrivateKey *rsa.PrivateKey
+}
+
+func NewTestSigner() *TestSigner {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return &TestSigner{KeyID: "test-key", PrivateKey: privateKey}
+}
+
+// Keys returns the key set the tokens of the signer are verified with.
+func (s *TestSigner) Keys() StaticKeys {
+	return StaticKeys{s.KeyID: &s.PrivateKey.PublicKey}
+}
+
+func (s *TestSigner) CreateToken(username string, userID int64, duration time.Duration) (string, error) {
+	return s.CreateRoleToken(username, userID, "", duration)
+}
+
+// CreateRoleToken signs a token of a user with the given role.
+func (s *TestSigner) CreateRoleToken(username string, userID int64, role string, duration time.Duration) (string, error) {
+	uuidID, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("error generating token uuid")
+	}
+
+	claims := Payload{
+		ID:       uuidID,
+		Username: username,
+		UserID:   userID,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "authApp",
+		},
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	jwtToken.Header["kid"] = s.KeyID
+
+	return jwtToken.SignedString(s.PrivateKey)
+}
@@ -1,10 +1,10 @@
 package pkg
 
 import (
+	"context"
 	"crypto/rsa"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,80 +15,67 @@ var (
 	ErrInvalidToken  = errors.New("token is invalid")
 	ErrInvalidIssuer = errors.New("invalid issuer")
 	ErrTokenExpired  = fmt.Errorf("token is expired")
+	ErrUnknownKey    = errors.New("token signed with an unknown key")
+
+	// ErrKeysUnavailable is returned when the keys tokens are verified with can not be
+	// fetched, so that tokens can be neither accepted nor rejected.
+	ErrKeysUnavailable = errors.New("signing keys are unavailable")
 )
 
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
 	UserID   int64     `json:"user_id"`
+	Role     string    `json:"role,omitempty"`
 	jwt.RegisteredClaims
-}
 
-type JWTMaker struct {
-	PublicKey  *rsa.PublicKey
-	PrivateKey *rsa.PrivateKey
+	// APIKeyID and Scopes are set for requests authenticated by an API key rather than
+	// an access token. They are not claims of the tokens.
+	APIKeyID int64    `json:"-"`
+	Scopes   []string `json:"-"`
 }
 
-func NewJWTMaker(privateKeyPath string, publicKeyPath string) (*JWTMaker, error) {
-	privateKeyBytes, err := os.ReadFile(privateKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read private key: %s", err)
-	}
-
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %s", err)
-	}
+// KeySource returns the public key tokens are verified with, by the key ID in their
+// header. Tokens issued before keys had IDs come with an empty one.
+type KeySource interface {
+	PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
 
-	publicKeyBytes, err := os.ReadFile(publicKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read public key: %s", err)
-	}
+// StaticKeys is a KeySource over a fixed set of keys.
+type StaticKeys map[string]*rsa.PublicKey
 
-	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %s", err)
-	}
-
-	maker := &JWTMaker{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
+func (k StaticKeys) PublicKey(_ context.Context, kid string) (*rsa.PublicKey, error) {
+	key, ok := k[kid]
+	if !ok {
+		return nil, ErrUnknownKey
 	}
 
-	return maker, nil
+	return key, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, userID int64, duration time.Duration) (string, error) {
-	uuidID, err := uuid.NewRandom()
-	if err != nil {
-		return "", fmt.Errorf("error generating token uuid")
-	}
-
-	claims := Payload{
-		uuidID,
-		username,
-		userID,
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "authApp",
-		},
-	}
-
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token, err := jwtToken.SignedString(maker.PrivateKey)
+// JWTVerifier verifies the access tokens issued by the authentication service. It only
+// knows their public keys, and can not issue tokens.
+type JWTVerifier struct {
+	keys KeySource
+}
 
-	return token, err
+func NewJWTVerifier(keys KeySource) *JWTVerifier {
+	return &JWTVerifier{keys: keys}
 }
 
-func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+func (v *JWTVerifier) VerifyToken(ctx context.Context, token string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (any, error) {
 		_, ok := token.Method.(*jwt.SigningMethodRSA)
 		if !ok {
 			return nil, ErrInvalidToken
 		}
 
-		return maker.PublicKey, nil
+		kid, ok := token.Header["kid"].(string)
+		if !ok && token.Header["kid"] != nil {
+			return nil, ErrInvalidToken
+		}
+
+		return v.keys.PublicKey(ctx, kid)
 	}
 
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
@@ -1,8 +1,7 @@
 package pkg
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
+	"context"
 	"testing"
 	"time"
 
@@ -14,20 +13,20 @@ import (
 func TestTokenFunc(t *testing.T) {
 	testCases := []struct {
 		name    string
-		runTest func(maker *JWTMaker, t *testing.T)
+		runTest func(signer *TestSigner, verifier *JWTVerifier, t *testing.T)
 	}{
 		{
 			name: "valid token",
-			runTest: func(maker *JWTMaker, t *testing.T) {
+			runTest: func(signer *TestSigner, verifier *JWTVerifier, t *testing.T) {
 				username := "Emilio Cliff"
 				userID := int64(1)
 				duration := time.Minute
 
-				token, err := maker.CreateToken(username, userID, duration)
+				token, err := signer.CreateToken(username, userID, duration)
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
 
-				payload, err := maker.VerifyToken(token)
+				payload, err := verifier.VerifyToken(context.Background(), token)
 				require.NoError(t, err)
 				require.NotEmpty(t, payload)
 
@@ -38,31 +37,31 @@ func TestTokenFunc(t *testing.T) {
 		},
 		{
 			name: "expired token",
-			runTest: func(maker *JWTMaker, t *testing.T) {
+			runTest: func(signer *TestSigner, verifier *JWTVerifier, t *testing.T) {
 				username := "Emilio Cliff"
 				duration := -time.Minute
 
-				token, err := maker.CreateToken(username, 1, duration)
+				token, err := signer.CreateToken(username, 1, duration)
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
 
-				payload, err := maker.VerifyToken(token)
+				payload, err := verifier.VerifyToken(context.Background(), token)
 				require.Error(t, err)
 				require.Nil(t, payload)
 			},
 		},
 		{
 			name: "algorithm none",
-			runTest: func(maker *JWTMaker, t *testing.T) {
+			runTest: func(signer *TestSigner, verifier *JWTVerifier, t *testing.T) {
 				uuid, err := uuid.NewRandom()
 				require.NoError(t, err)
 				require.NotEmpty(t, uuid)
 
 				claims := Payload{
-					uuid,
-					"username",
-					1,
-					jwt.RegisteredClaims{
+					ID:       uuid,
+					Username: "username",
+					UserID:   1,
+					RegisteredClaims: jwt.RegisteredClaims{
 						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
 						IssuedAt:  jwt.NewNumericDate(time.Now()),
 						Issuer:    "authApp",
@@ -73,23 +72,37 @@ func TestTokenFunc(t *testing.T) {
 				token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
 				require.NoError(t, err)
 
-				payload, err := maker.VerifyToken(token)
+				payload, err := verifier.VerifyToken(context.Background(), token)
 				require.Error(t, err)
 				require.Nil(t, payload)
 			},
 		},
+		{
+			name: "unknown key",
+			runTest: func(signer *TestSigner, _ *JWTVerifier, t *testing.T) {
+				token, err := signer.CreateToken("username", 1, time.Minute)
+				require.NoError(t, err)
+
+				// the key set of the verifier is from before the key was added
+				verifier := NewJWTVerifier(StaticKeys{"old-key": &signer.PrivateKey.PublicKey})
+
+				payload, err := verifier.VerifyToken(context.Background(), token)
+				require.ErrorIs(t, err, ErrUnknownKey)
+				require.Nil(t, payload)
+			},
+		},
 		{
 			name: "wrong issuer",
-			runTest: func(maker *JWTMaker, t *testing.T) {
+			runTest: func(signer *TestSigner, verifier *JWTVerifier, t *testing.T) {
 				uuid, err := uuid.NewRandom()
 				require.NoError(t, err)
 				require.NotEmpty(t, uuid)
 
 				claims := Payload{
-					uuid,
-					"username",
-					1,
-					jwt.RegisteredClaims{
+					ID:       uuid,
+					Username: "username",
+					UserID:   1,
+					RegisteredClaims: jwt.RegisteredClaims{
 						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
 						IssuedAt:  jwt.NewNumericDate(time.Now()),
 						Issuer:    "wrong-issuer",
@@ -97,10 +110,12 @@ func TestTokenFunc(t *testing.T) {
 				}
 
 				jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-				token, err := jwtToken.SignedString(maker.PrivateKey)
+				jwtToken.Header["kid"] = signer.KeyID
+
+				token, err := jwtToken.SignedString(signer.PrivateKey)
 				require.NoError(t, err)
 
-				payload, err := maker.VerifyToken(token)
+				payload, err := verifier.VerifyToken(context.Background(), token)
 				require.Error(t, err)
 				require.EqualError(t, err, ErrInvalidIssuer.Error())
 				require.Nil(t, payload)
@@ -110,17 +125,33 @@ func TestTokenFunc(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-			require.NoError(t, err)
-
-			publicKey := &privateKey.PublicKey
+			signer := NewTestSigner()
 
-			maker := &JWTMaker{
-				PrivateKey: privateKey,
-				PublicKey:  publicKey,
-			}
-
-			tc.runTest(maker, t)
+			tc.runTest(signer, NewJWTVerifier(signer.Keys()), t)
 		})
 	}
 }
+
+func TestPayload_HasRole(t *testing.T) {
+	signer := NewTestSigner()
+	verifier := NewJWTVerifier(signer.Keys())
+
+	token, err := signer.CreateRoleToken("support", 2, RoleSupport, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, RoleSupport, payload.Role)
+
+	require.True(t, payload.HasRole(RoleUser))
+	require.True(t, payload.HasRole(RoleSupport))
+	require.False(t, payload.HasRole(RoleAdmin))
+	require.False(t, payload.HasRole("owner"))
+
+	// tokens issued before users had roles stand for a user
+	require.True(t, (&Payload{}).HasRole(RoleUser))
+	require.False(t, (&Payload{}).HasRole(RoleSupport))
+
+	// API keys are allowed what a user is, whatever the role of their owner
+	require.False(t, (&Payload{Role: RoleAdmin, APIKeyID: 1}).HasRole(RoleSupport))
+}
@@ -1,20 +1,34 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/gRPC"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/http"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/rabbitmq"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/workers"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
+	logging.Setup()
+
 	config, err := pkg.LoadConfig(".")
 	if err != nil {
 		log.Printf("error loading config: %s", err)
@@ -22,6 +36,14 @@ func main() {
 		return
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TRACING_EXPORTER, config.OTLP_ENDPOINT)
+	if err != nil {
+		log.Printf("error setting up tracing: %s", err)
+
+		return
+	}
+	defer shutdownTracing(context.Background())
+
 	store := postgres.NewStore(config)
 
 	err = store.Start()
@@ -32,6 +54,7 @@ func main() {
 	}
 
 	transactionRepo := postgres.NewTransactionService(store)
+	withdrawalConfirmationRepo := postgres.NewWithdrawalConfirmationService(store)
 
 	redisOpt := asynq.RedisClientOpt{
 		Addr: config.REDDIS_ADDR,
@@ -47,7 +70,11 @@ func main() {
 		return
 	}
 
-	clientConn, err := grpc.NewClient(config.AUTH_GRPC_URL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	clientConn, err := grpc.NewClient(
+		config.AUTH_GRPC_URL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor(), tracing.UnaryClientInterceptor()),
+	)
 	if err != nil {
 		log.Printf("error connecting to auth grpc server: %s", err)
 
@@ -59,6 +86,7 @@ func main() {
 
 	rabbit := rabbitmq.NewRabbitConn(config, client)
 
+	// connects to rabbitmq, or sets up an in-process bus when BUS_DRIVER is "memory"
 	err = rabbit.ConnectToRabbit()
 	if err != nil {
 		log.Printf("error connecting to rabbit: %s", err)
@@ -66,27 +94,98 @@ func main() {
 		return
 	}
 
+	redisClient, ok := redisOpt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		log.Printf("error creating redis client")
+
+		return
+	}
+	defer redisClient.Close()
+
+	checker := health.NewChecker()
+	checker.Add("postgres", 2*time.Second, store.Ping)
+	checker.Add("redis", time.Second, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	checker.Add("rabbitmq", time.Second, rabbit.Ready)
+	checker.Add("auth_grpc", 2*time.Second, health.GRPCCheck(clientConn))
+
 	server := http.NewHttpServer()
+	server.HealthChecker = checker
 
 	processor.TransactionRepository = transactionRepo
 
 	rabbit.TransactionRepository = transactionRepo
+	rabbit.WithdrawalConfirmationRepository = withdrawalConfirmationRepo
 	rabbit.Distributor = distributor
 
 	server.TransactionRepository = transactionRepo
 
+	grpcServer := gRPC.NewGRPCServer(config, client)
+	grpcServer.TransactionRepository = transactionRepo
+	grpcServer.WithdrawalConfirmationRepository = withdrawalConfirmationRepo
+	grpcServer.Distributor = distributor
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 4)
+
 	go func() {
-		processor.Start()
+		if err := processor.Start(); err != nil {
+			errCh <- fmt.Errorf("task processor: %w", err)
+		}
 	}()
 
 	go func() {
-		rabbit.SetConsumer([]string{"payments.initiate_payment", "payments.poll_payments"})
+		if err := rabbit.SetConsumer([]string{"payments.initiate_payment", "payments.poll_payments"}); err != nil {
+			errCh <- fmt.Errorf("rabbit consumer: %w", err)
+		}
 	}()
 
-	log.Println("Starting server on port", config.HTTP_PORT)
+	go func() {
+		slog.Info("starting payments grpc server", "address", config.GRPC_PORT)
 
-	err = server.Start(config.HTTP_PORT)
-	if err != nil {
-		log.Fatalf("Error starting server: %v", err)
+		if err := grpcServer.Start(config.GRPC_PORT); err != nil {
+			errCh <- fmt.Errorf("grpc server: %w", err)
+		}
+	}()
+
+	go func() {
+		slog.Info("starting server", "address", config.HTTP_PORT)
+
+		if err := server.Start(config.HTTP_PORT); err != nil {
+			errCh <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down payment service")
+	case err := <-errCh:
+		slog.Error("shutting down payment service", "error", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	// stop taking requests from the queue and over grpc, let the running payd calls
+	// finish, then keep receiving their callbacks until the http server is drained
+	if err := rabbit.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to stop rabbit consumer", "error", err)
+	}
+
+	grpcServer.Shutdown(shutdownCtx)
+
+	if err := processor.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to stop task processor", "error", err)
 	}
+
+	if err := server.Stop(shutdownCtx); err != nil {
+		slog.Error("failed to stop http server", "error", err)
+	}
+
+	store.Close()
+
+	slog.Info("payment service stopped")
 }
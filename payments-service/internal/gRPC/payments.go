@@ -0,0 +1,437 @@
+package gRPC
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/workers"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+func (s *GRPCServer) InitiatePayment(
+	ctx context.Context,
+	req *pb.InitiatePaymentRequest,
+) (*pb.InitiatePaymentResponse, error) {
+	if req.GetAction() != "payment" && req.GetAction() != "withdrawal" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid action: %s", req.GetAction())
+	}
+
+	transactionID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create transactionID: %v", err)
+	}
+
+	tracing.SetTransactionID(ctx, transactionID.String())
+	ctx = logging.WithTransactionID(ctx, transactionID.String())
+
+	userData, err := s.client.GetUser(ctx, &pb.GetUserRequest{Email: req.GetEmail()})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get user data from auth: %v", err)
+	}
+
+	ctx = logging.WithUserID(ctx, userData.GetUserId())
+
+	// the gateway sends the user of the session, who may only pay from their own account
+	if userData.GetUserId() != req.GetUserId() {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot initiate payments for another account")
+	}
+
+	if s.config.REQUIRE_VERIFIED_EMAIL && !userData.GetEmailVerified() {
+		return nil, status.Errorf(codes.PermissionDenied, "email address is not verified")
+	}
+
+	if s.config.NeedsConfirmation(req.GetAction(), req.GetAmount()) {
+		confirmation, err := s.WithdrawalConfirmationRepository.CreateWithdrawalConfirmation(ctx, repository.WithdrawalConfirmation{
+			TransactionID: transactionID,
+			UserID:        userData.GetUserId(),
+			Email:         req.GetEmail(),
+			Amount:        req.GetAmount(),
+			PhoneNumber:   req.GetPhoneNumber(),
+			NetworkCode:   req.GetNetworkCode(),
+			Narration:     req.GetNarration(),
+			ExpiresAt:     time.Now().Add(s.config.WITHDRAWAL_CONFIRMATION_DURATION),
+		})
+		if err != nil {
+			return nil, status.Errorf(
+				convertPkgError(pkg.ErrorCode(err)),
+				"%v",
+				fmt.Sprintf("error holding withdrawal for confirmation: %v", pkg.ErrorMessage(err)),
+			)
+		}
+
+		return &pb.InitiatePaymentResponse{
+			TransactionId:         transactionID.String(),
+			PaymentStatus:         false,
+			Action:                req.GetAction(),
+			ConfirmationRequired:  true,
+			ConfirmationExpiresAt: timestamppb.New(confirmation.ExpiresAt),
+		}, nil
+	}
+
+	payload, err := s.paydPayload(userData, services.SendPaymentWithdrawalRequestPayload{
+		TransactionID: transactionID,
+		UserID:        userData.GetUserId(),
+		Action:        req.GetAction(),
+		Amount:        req.GetAmount(),
+		PhoneNumber:   req.GetPhoneNumber(),
+		NetworkCode:   req.GetNetworkCode(),
+		Naration:      req.GetNarration(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.distribute(ctx, payload); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.InitiatePaymentResponse{
+		TransactionId: transactionID.String(),
+		PaymentStatus: false,
+		Action:        req.GetAction(),
+	}, nil
+}
+
+// ConfirmWithdrawal sends a withdrawal InitiatePayment held back for being above
+// WITHDRAWAL_CONFIRMATION_THRESHOLD, once auth verifies the password, or two-factor
+// code, of its user. A withdrawal is sent once at most, and not at all after it expires.
+func (s *GRPCServer) ConfirmWithdrawal(
+	ctx context.Context,
+	req *pb.ConfirmWithdrawalRequest,
+) (*pb.InitiatePaymentResponse, error) {
+	id, err := uuid.Parse(req.GetTransactionId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transaction id: %v", err)
+	}
+
+	if req.GetPassword() == "" && req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "password or code is required")
+	}
+
+	tracing.SetTransactionID(ctx, id.String())
+	ctx = logging.WithUserID(logging.WithTransactionID(ctx, id.String()), req.GetUserId())
+
+	confirmation, err := s.WithdrawalConfirmationRepository.GetWithdrawalConfirmation(ctx, id)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error getting withdrawal: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if confirmation.UserID != req.GetUserId() {
+		return nil, status.Errorf(codes.Unauthenticated, "cannot access this transaction")
+	}
+
+	_, err = s.client.VerifyCredentials(ctx, &pb.VerifyCredentialsRequest{
+		UserId:   req.GetUserId(),
+		Password: req.GetPassword(),
+		Code:     req.GetCode(),
+		ClientIp: req.GetClientIp(),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.ResourceExhausted:
+			return nil, status.Error(status.Code(err), status.Convert(err).Message())
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to verify credentials with auth: %v", err)
+		}
+	}
+
+	// whatever can fail before the withdrawal is sent is checked before confirming it,
+	// so that the failure does not use the confirmation up
+	userData, err := s.client.GetUser(ctx, &pb.GetUserRequest{Email: confirmation.Email})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get user data from auth: %v", err)
+	}
+
+	if s.config.REQUIRE_VERIFIED_EMAIL && !userData.GetEmailVerified() {
+		return nil, status.Errorf(codes.PermissionDenied, "email address is not verified")
+	}
+
+	payload, err := s.paydPayload(userData, services.SendPaymentWithdrawalRequestPayload{
+		TransactionID: confirmation.TransactionID,
+		UserID:        confirmation.UserID,
+		Action:        "withdrawal",
+		Amount:        confirmation.Amount,
+		PhoneNumber:   confirmation.PhoneNumber,
+		NetworkCode:   confirmation.NetworkCode,
+		Naration:      confirmation.Narration,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// confirming fails if another request confirmed the withdrawal in the meantime
+	_, err = s.WithdrawalConfirmationRepository.ConfirmWithdrawal(ctx, id, req.GetUserId())
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error confirming withdrawal: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	// the task is named after the withdrawal and kept once done for longer than the
+	// withdrawal can be confirmed, so that confirming it again does not send it twice
+	err = s.distribute(ctx, payload, asynq.TaskID(id.String()), asynq.Retention(s.config.WITHDRAWAL_CONFIRMATION_DURATION))
+	if err != nil {
+		// the withdrawal is only released when the task certainly did not reach redis,
+		// otherwise it stays confirmed and is looked into by hand if it was not sent
+		if errors.Is(err, services.ErrNotEnqueued) {
+			if err := s.WithdrawalConfirmationRepository.ReleaseWithdrawal(ctx, id, req.GetUserId()); err != nil {
+				slog.ErrorContext(ctx, "failed to release withdrawal", "error", err)
+			}
+		} else {
+			slog.ErrorContext(ctx, "withdrawal confirmed but may not have been enqueued", "error", err)
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.InitiatePaymentResponse{
+		TransactionId: confirmation.TransactionID.String(),
+		PaymentStatus: false,
+		Action:        "withdrawal",
+	}, nil
+}
+
+// paydPayload fills the payd credentials of the user in payload.
+func (s *GRPCServer) paydPayload(
+	userData *pb.GetUserResponse,
+	payload services.SendPaymentWithdrawalRequestPayload,
+) (services.SendPaymentWithdrawalRequestPayload, error) {
+	passwordApiKey, err := pkg.Decrypt(userData.GetPaydPasswordKey(), []byte(s.config.ENCRYPTION_KEY))
+	if err != nil {
+		return payload, status.Errorf(codes.Internal, "failed to decrypt payd password key: %v", err)
+	}
+
+	usernameApiKey, err := pkg.Decrypt(userData.GetPaydUsernameKey(), []byte(s.config.ENCRYPTION_KEY))
+	if err != nil {
+		return payload, status.Errorf(codes.Internal, "failed to decrypt payd username key: %v", err)
+	}
+
+	payload.PaydUsername = userData.GetPaydUsername()
+	payload.PaydAccountID = userData.GetPaydAccountId()
+	payload.PaydPasswordApiKey = passwordApiKey
+	payload.PaydUsernameApiKey = usernameApiKey
+
+	return payload, nil
+}
+
+// distribute hands payload to the workers. A task whose ID is taken was enqueued
+// already, and is not an error. Errors wrap those of the services.TaskDistributor.
+func (s *GRPCServer) distribute(
+	ctx context.Context,
+	payload services.SendPaymentWithdrawalRequestPayload,
+	opt ...asynq.Option,
+) error {
+	var err error
+
+	opts := append([]asynq.Option{
+		asynq.MaxRetry(1),
+		asynq.Queue(workers.QueueCritical),
+	}, opt...)
+
+	if payload.Action == "payment" {
+		err = s.Distributor.DistributeSendPaymentRequestTask(ctx, payload, opts...)
+	} else {
+		err = s.Distributor.DistributeSendWithdrawalRequestTask(ctx, payload, opts...)
+	}
+
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to distribute %s task: %w", payload.Action, err)
+	}
+
+	return nil
+}
+
+func (s *GRPCServer) GetTransaction(
+	ctx context.Context,
+	req *pb.PollingTransactionRequest,
+) (*pb.PollingTransactionResponse, error) {
+	id, err := uuid.Parse(req.GetTransactionId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transaction id: %v", err)
+	}
+
+	tracing.SetTransactionID(ctx, id.String())
+	ctx = logging.WithUserID(logging.WithTransactionID(ctx, id.String()), req.GetUserId())
+
+	transaction, err := s.getTransaction(ctx, id, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	return transactionToPb(transaction), nil
+}
+
+func (s *GRPCServer) ListTransactions(
+	ctx context.Context,
+	req *pb.ListTransactionsRequest,
+) (*pb.ListTransactionsResponse, error) {
+	if req.GetOffset() < 0 || req.GetLimit() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "limit and offset cannot be negative")
+	}
+
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	limit = min(limit, maxListLimit)
+
+	ctx = logging.WithUserID(ctx, req.GetUserId())
+
+	transactions, err := s.TransactionRepository.ListTransactions(ctx, req.GetUserId(), limit, req.GetOffset())
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error listing transactions: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	rsp := &pb.ListTransactionsResponse{
+		Transactions: make([]*pb.PollingTransactionResponse, 0, len(transactions)),
+	}
+
+	for i := range transactions {
+		rsp.Transactions = append(rsp.Transactions, transactionToPb(&transactions[i]))
+	}
+
+	return rsp, nil
+}
+
+// WatchTransaction sends the transaction as it is now, then again on every change until
+// it settles or the client goes away. The transaction is created by the worker after
+// InitiatePayment returns, so it is waited for until then.
+func (s *GRPCServer) WatchTransaction(
+	req *pb.PollingTransactionRequest,
+	stream pb.PaymentsService_WatchTransactionServer,
+) error {
+	id, err := uuid.Parse(req.GetTransactionId())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid transaction id: %v", err)
+	}
+
+	ctx := stream.Context()
+
+	tracing.SetTransactionID(ctx, id.String())
+	ctx = logging.WithUserID(logging.WithTransactionID(ctx, id.String()), req.GetUserId())
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var last *repository.Transaction
+
+	for {
+		transaction, err := s.getTransaction(ctx, id, req.GetUserId())
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if transaction != nil && (last == nil || changed(last, transaction)) {
+			if err := stream.Send(transactionToPb(transaction)); err != nil {
+				return err
+			}
+
+			if settled(transaction) {
+				return nil
+			}
+
+			last = transaction
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// getTransaction returns the transaction if it belongs to userID.
+func (s *GRPCServer) getTransaction(ctx context.Context, id uuid.UUID, userID int64) (*repository.Transaction, error) {
+	transaction, err := s.TransactionRepository.PollingTransaction(ctx, id)
+	if err != nil {
+		return nil, status.Errorf(
+			convertPkgError(pkg.ErrorCode(err)),
+			"%v",
+			fmt.Sprintf("error getting transaction: %v", pkg.ErrorMessage(err)),
+		)
+	}
+
+	if transaction.UserID != userID {
+		return nil, status.Errorf(codes.Unauthenticated, "cannot access this transaction")
+	}
+
+	return transaction, nil
+}
+
+// settled reports whether payd has called back for the transaction, after which it does
+// not change anymore.
+func settled(t *repository.Transaction) bool {
+	return t.Status || t.UpdatedAt.After(t.CreatedAt)
+}
+
+func changed(a, b *repository.Transaction) bool {
+	return a.Status != b.Status ||
+		a.Message != b.Message ||
+		a.PaydTransactionRef != b.PaydTransactionRef ||
+		!a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+func transactionToPb(t *repository.Transaction) *pb.PollingTransactionResponse {
+	return &pb.PollingTransactionResponse{
+		TransactionId:      t.TransactionID.String(),
+		PaydTransactionRef: t.PaydTransactionRef,
+		Remarks:            t.Message,
+		Action:             t.Action,
+		Amount:             int64(t.Amount),
+		PhoneNumber:        t.PhoneNumber,
+		NetworkCode:        t.NetworkCode,
+		Narration:          t.Narration,
+		PaymentStatus:      t.Status,
+	}
+}
+
+func convertPkgError(err string) codes.Code {
+	switch err {
+	case pkg.ALREADY_EXISTS_ERROR:
+		return codes.AlreadyExists
+	case pkg.INTERNAL_ERROR:
+		return codes.Internal
+	case pkg.INVALID_ERROR:
+		return codes.InvalidArgument
+	case pkg.NOT_FOUND_ERROR:
+		return codes.NotFound
+	case pkg.NOT_IMPLEMENTED_ERROR:
+		return codes.Unimplemented
+	case pkg.AUTHENTICATION_ERROR:
+		return codes.Unauthenticated
+	case pkg.PERMISSION_ERROR:
+		return codes.PermissionDenied
+	default:
+		return codes.Internal
+	}
+}
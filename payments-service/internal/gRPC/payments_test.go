@@ -0,0 +1,683 @@
+package gRPC
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var TestTime = time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC)
+
+func TestGRPCServer_InitiatePayment(t *testing.T) {
+	s := NewTestGRPCServer(t)
+	s.server.config.REQUIRE_VERIFIED_EMAIL = true
+
+	var distributed []string
+
+	s.Distributor.DistributeSendPaymentRequestTaskFunc = func(_ context.Context, payload services.SendPaymentWithdrawalRequestPayload, _ ...asynq.Option) error {
+		if payload.Amount == 32 {
+			return errors.New("redis is down")
+		}
+
+		distributed = append(distributed, payload.Action)
+
+		return nil
+	}
+	s.Distributor.DistributeSendWithdrawalRequestTaskFunc = s.Distributor.DistributeSendPaymentRequestTaskFunc
+
+	encryptedKey, err := pkg.Encrypt("key", []byte(s.server.config.ENCRYPTION_KEY))
+	require.NoError(t, err)
+
+	getUser := &pb.GetUserResponse{
+		UserId:          32,
+		PaydUsername:    "test",
+		PaydUsernameKey: encryptedKey,
+		PaydPasswordKey: encryptedKey,
+		PaydAccountId:   "test",
+		EmailVerified:   true,
+	}
+
+	tests := []struct {
+		name       string
+		req        *pb.InitiatePaymentRequest
+		buildStubs func()
+		wantCode   codes.Code
+	}{
+		{
+			name: "payment",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 100, UserId: 32},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: "jane@gmail.com"}).
+					Return(getUser, nil).Times(1)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "withdrawal",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "withdrawal", Amount: 100, UserId: 32},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(getUser, nil).Times(1)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "account of another user",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 100, UserId: 7},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(getUser, nil).Times(1)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:       "invalid action",
+			req:        &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "refund", Amount: 100, UserId: 32},
+			buildStubs: func() {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "auth unavailable",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 100, UserId: 32},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.Unavailable, "connection refused")).Times(1)
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name: "unverified email",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 100, UserId: 32},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Return(&pb.GetUserResponse{UserId: 32, PaydPasswordKey: encryptedKey, PaydUsernameKey: encryptedKey}, nil).Times(1)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "failed to distribute",
+			req:  &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 32, UserId: 32},
+			buildStubs: func() {
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(getUser, nil).Times(1)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs()
+
+			rsp, err := s.server.InitiatePayment(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode == codes.OK {
+				require.Equal(t, tc.req.GetAction(), rsp.GetAction())
+				require.False(t, rsp.GetPaymentStatus())
+
+				_, err := uuid.Parse(rsp.GetTransactionId())
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	require.Equal(t, []string{"payment", "withdrawal"}, distributed)
+}
+
+func TestGRPCServer_InitiatePaymentConfirmation(t *testing.T) {
+	s := NewTestGRPCServer(t)
+	s.server.config.WITHDRAWAL_CONFIRMATION_THRESHOLD = 1000
+	s.server.config.WITHDRAWAL_CONFIRMATION_DURATION = 5 * time.Minute
+
+	var distributed []int64
+
+	s.Distributor.DistributeSendPaymentRequestTaskFunc = func(_ context.Context, payload services.SendPaymentWithdrawalRequestPayload, _ ...asynq.Option) error {
+		distributed = append(distributed, payload.Amount)
+
+		return nil
+	}
+	s.Distributor.DistributeSendWithdrawalRequestTaskFunc = s.Distributor.DistributeSendPaymentRequestTaskFunc
+
+	var held []repository.WithdrawalConfirmation
+
+	s.WithdrawalConfirmationRepository.CreateWithdrawalConfirmationFunc = func(_ context.Context, c repository.WithdrawalConfirmation) (*repository.WithdrawalConfirmation, error) {
+		if c.Amount == 5000 {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create withdrawal confirmation")
+		}
+
+		held = append(held, c)
+
+		return &c, nil
+	}
+
+	encryptedKey, err := pkg.Encrypt("key", []byte(s.server.config.ENCRYPTION_KEY))
+	require.NoError(t, err)
+
+	s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(&pb.GetUserResponse{
+		UserId:          32,
+		PaydUsernameKey: encryptedKey,
+		PaydPasswordKey: encryptedKey,
+	}, nil).AnyTimes()
+
+	tests := []struct {
+		name        string
+		req         *pb.InitiatePaymentRequest
+		wantCode    codes.Code
+		wantConfirm bool
+	}{
+		{
+			name:     "withdrawal at the threshold",
+			req:      &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "withdrawal", Amount: 1000, UserId: 32},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "payment above the threshold",
+			req:      &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "payment", Amount: 2000, UserId: 32},
+			wantCode: codes.OK,
+		},
+		{
+			name:        "withdrawal above the threshold",
+			req:         &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "withdrawal", Amount: 2000, PhoneNumber: "0712345678", UserId: 32},
+			wantCode:    codes.OK,
+			wantConfirm: true,
+		},
+		{
+			name:     "failed to hold withdrawal",
+			req:      &pb.InitiatePaymentRequest{Email: "jane@gmail.com", Action: "withdrawal", Amount: 5000, UserId: 32},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp, err := s.server.InitiatePayment(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+			require.Equal(t, tc.wantConfirm, rsp.GetConfirmationRequired())
+
+			if tc.wantConfirm {
+				require.WithinDuration(t, time.Now().Add(5*time.Minute), rsp.GetConfirmationExpiresAt().AsTime(), time.Minute)
+			}
+		})
+	}
+
+	require.Equal(t, []int64{1000, 2000}, distributed)
+	require.Len(t, held, 1)
+	require.Equal(t, int64(32), held[0].UserID)
+	require.Equal(t, "jane@gmail.com", held[0].Email)
+	require.Equal(t, "0712345678", held[0].PhoneNumber)
+}
+
+func TestGRPCServer_ConfirmWithdrawal(t *testing.T) {
+	s := NewTestGRPCServer(t)
+	s.server.config.WITHDRAWAL_CONFIRMATION_DURATION = 5 * time.Minute
+
+	pending := repository.WithdrawalConfirmation{
+		TransactionID: uuid.New(),
+		UserID:        32,
+		Email:         "jane@gmail.com",
+		Amount:        2000,
+		PhoneNumber:   "0712345678",
+		NetworkCode:   "63902",
+		Narration:     "rent",
+		ExpiresAt:     time.Now().Add(5 * time.Minute),
+	}
+
+	var confirmed bool
+
+	s.WithdrawalConfirmationRepository.GetWithdrawalConfirmationFunc = func(_ context.Context, id uuid.UUID) (*repository.WithdrawalConfirmation, error) {
+		if id != pending.TransactionID || confirmed {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "withdrawal does not exist or expired")
+		}
+
+		return &pending, nil
+	}
+	s.WithdrawalConfirmationRepository.ConfirmWithdrawalFunc = func(_ context.Context, id uuid.UUID, userID int64) (*repository.WithdrawalConfirmation, error) {
+		if id != pending.TransactionID || userID != pending.UserID || confirmed {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "withdrawal does not exist or expired")
+		}
+
+		confirmed = true
+
+		return &pending, nil
+	}
+
+	var released int
+
+	s.WithdrawalConfirmationRepository.ReleaseWithdrawalFunc = func(_ context.Context, id uuid.UUID, userID int64) error {
+		require.Equal(t, pending.TransactionID, id)
+		require.Equal(t, pending.UserID, userID)
+
+		confirmed = false
+		released++
+
+		return nil
+	}
+
+	var (
+		distributed   []services.SendPaymentWithdrawalRequestPayload
+		distributeErr error
+	)
+
+	s.Distributor.DistributeSendWithdrawalRequestTaskFunc = func(_ context.Context, payload services.SendPaymentWithdrawalRequestPayload, opts ...asynq.Option) error {
+		// the task is named after the withdrawal and kept while it can be confirmed, so
+		// that it is not enqueued twice
+		var (
+			taskID    string
+			retention time.Duration
+		)
+
+		for _, opt := range opts {
+			switch opt.Type() {
+			case asynq.TaskIDOpt:
+				taskID, _ = opt.Value().(string)
+			case asynq.RetentionOpt:
+				retention, _ = opt.Value().(time.Duration)
+			}
+		}
+
+		require.Equal(t, pending.TransactionID.String(), taskID)
+		require.Equal(t, 5*time.Minute, retention)
+
+		if distributeErr != nil {
+			return distributeErr
+		}
+
+		distributed = append(distributed, payload)
+
+		return nil
+	}
+
+	encryptedKey, err := pkg.Encrypt("key", []byte(s.server.config.ENCRYPTION_KEY))
+	require.NoError(t, err)
+
+	getUser := &pb.GetUserResponse{
+		UserId:          32,
+		PaydUsername:    "test",
+		PaydUsernameKey: encryptedKey,
+		PaydPasswordKey: encryptedKey,
+		PaydAccountId:   "test",
+	}
+
+	id := pending.TransactionID.String()
+
+	tests := []struct {
+		name       string
+		req        *pb.ConfirmWithdrawalRequest
+		buildStubs func()
+		wantCode   codes.Code
+	}{
+		{
+			name:       "invalid id",
+			req:        &pb.ConfirmWithdrawalRequest{TransactionId: "invalid", UserId: 32, Password: "secret"},
+			buildStubs: func() {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "missing credentials",
+			req:        &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32},
+			buildStubs: func() {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "not found",
+			req:        &pb.ConfirmWithdrawalRequest{TransactionId: uuid.NewString(), UserId: 32, Password: "secret"},
+			buildStubs: func() {},
+			wantCode:   codes.NotFound,
+		},
+		{
+			name:       "another user's withdrawal",
+			req:        &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 33, Password: "secret"},
+			buildStubs: func() {},
+			wantCode:   codes.Unauthenticated,
+		},
+		{
+			name: "wrong password",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Password: "wrong", ClientIp: "10.0.0.1"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), &pb.VerifyCredentialsRequest{
+					UserId:   32,
+					Password: "wrong",
+					ClientIp: "10.0.0.1",
+				}).Return(nil, status.Error(codes.Unauthenticated, "invalid password")).Times(1)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "locked out",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Password: "secret"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.ResourceExhausted, "too many failed login attempts")).Times(1)
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name: "auth unavailable",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Password: "secret"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.Unavailable, "connection refused")).Times(1)
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			// the withdrawal is not confirmed, and can be confirmed again
+			name: "user lookup fails",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Code: "123456"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).
+					Return(&pb.VerifyCredentialsResponse{}, nil).Times(1)
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.Unavailable, "connection refused")).Times(1)
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			// the withdrawal is released, and can be confirmed again
+			name: "enqueue fails",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Code: "123456"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).
+					Return(&pb.VerifyCredentialsResponse{}, nil).Times(1)
+				s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Return(getUser, nil).Times(1)
+
+				distributeErr = fmt.Errorf("%w: connection refused", services.ErrNotEnqueued)
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name: "success",
+			req:  &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Code: "123456"},
+			buildStubs: func() {
+				s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).
+					Return(&pb.VerifyCredentialsResponse{}, nil).Times(1)
+				s.client.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: "jane@gmail.com"}).
+					Return(getUser, nil).Times(1)
+
+				distributeErr = nil
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "already confirmed",
+			req:        &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Code: "123456"},
+			buildStubs: func() {},
+			wantCode:   codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs()
+
+			rsp, err := s.server.ConfirmWithdrawal(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode == codes.OK {
+				require.Equal(t, id, rsp.GetTransactionId())
+				require.Equal(t, "withdrawal", rsp.GetAction())
+				require.False(t, rsp.GetConfirmationRequired())
+			}
+		})
+	}
+
+	// a task that may have reached redis leaves the withdrawal confirmed, as confirming
+	// it again could send it twice once the task is gone
+	confirmed = false
+	distributeErr = errors.New("i/o timeout")
+
+	s.client.EXPECT().VerifyCredentials(gomock.Any(), gomock.Any()).Return(&pb.VerifyCredentialsResponse{}, nil).Times(1)
+	s.client.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(getUser, nil).Times(1)
+
+	_, err = s.server.ConfirmWithdrawal(context.Background(), &pb.ConfirmWithdrawalRequest{TransactionId: id, UserId: 32, Code: "123456"})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.True(t, confirmed)
+
+	require.Equal(t, 1, released)
+	require.Len(t, distributed, 1)
+	require.Equal(t, pending.TransactionID, distributed[0].TransactionID)
+	require.Equal(t, pending.Amount, distributed[0].Amount)
+	require.Equal(t, pending.PhoneNumber, distributed[0].PhoneNumber)
+	require.Equal(t, "withdrawal", distributed[0].Action)
+	require.Equal(t, "key", distributed[0].PaydPasswordApiKey)
+}
+
+func TestGRPCServer_GetTransaction(t *testing.T) {
+	s := NewTestGRPCServer(t)
+
+	transaction := newTransaction()
+
+	s.TransactionRepository.PollingTransactionFunc = func(_ context.Context, id uuid.UUID) (*repository.Transaction, error) {
+		if id != transaction.TransactionID {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "transaction does not exist")
+		}
+
+		return &transaction, nil
+	}
+
+	tests := []struct {
+		name     string
+		req      *pb.PollingTransactionRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "success",
+			req:      &pb.PollingTransactionRequest{UserId: 1, TransactionId: transaction.TransactionID.String()},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "invalid id",
+			req:      &pb.PollingTransactionRequest{UserId: 1, TransactionId: "invalid"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "not found",
+			req:      &pb.PollingTransactionRequest{UserId: 1, TransactionId: uuid.NewString()},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "another user's transaction",
+			req:      &pb.PollingTransactionRequest{UserId: 2, TransactionId: transaction.TransactionID.String()},
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp, err := s.server.GetTransaction(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode == codes.OK {
+				require.Equal(t, transaction.TransactionID.String(), rsp.GetTransactionId())
+				require.Equal(t, int64(transaction.Amount), rsp.GetAmount())
+				require.Equal(t, transaction.Message, rsp.GetRemarks())
+			}
+		})
+	}
+}
+
+func TestGRPCServer_ListTransactions(t *testing.T) {
+	s := NewTestGRPCServer(t)
+
+	var gotLimit, gotOffset int32
+
+	s.TransactionRepository.ListTransactionsFunc = func(_ context.Context, userID int64, limit, offset int32) ([]repository.Transaction, error) {
+		if userID == 2 {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing transactions")
+		}
+
+		gotLimit, gotOffset = limit, offset
+
+		return []repository.Transaction{newTransaction(), newTransaction()}, nil
+	}
+
+	tests := []struct {
+		name       string
+		req        *pb.ListTransactionsRequest
+		wantCode   codes.Code
+		wantLimit  int32
+		wantOffset int32
+	}{
+		{
+			name:      "default limit",
+			req:       &pb.ListTransactionsRequest{UserId: 1},
+			wantCode:  codes.OK,
+			wantLimit: defaultListLimit,
+		},
+		{
+			name:       "limit is capped",
+			req:        &pb.ListTransactionsRequest{UserId: 1, Limit: 1000, Offset: 20},
+			wantCode:   codes.OK,
+			wantLimit:  maxListLimit,
+			wantOffset: 20,
+		},
+		{
+			name:     "negative offset",
+			req:      &pb.ListTransactionsRequest{UserId: 1, Offset: -1},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "repository error",
+			req:      &pb.ListTransactionsRequest{UserId: 2},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp, err := s.server.ListTransactions(context.Background(), tc.req)
+			require.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode == codes.OK {
+				require.Len(t, rsp.GetTransactions(), 2)
+				require.Equal(t, tc.wantLimit, gotLimit)
+				require.Equal(t, tc.wantOffset, gotOffset)
+			}
+		})
+	}
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.PollingTransactionResponse
+}
+
+func (w *watchStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *watchStream) Send(rsp *pb.PollingTransactionResponse) error {
+	w.sent = append(w.sent, rsp)
+
+	return nil
+}
+
+func TestGRPCServer_WatchTransaction(t *testing.T) {
+	s := NewTestGRPCServer(t)
+
+	transaction := newTransaction()
+
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+
+	// not created yet, then pending twice, then settled by the payd callback
+	s.TransactionRepository.PollingTransactionFunc = func(_ context.Context, _ uuid.UUID) (*repository.Transaction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		polls++
+
+		switch {
+		case polls == 1:
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "transaction does not exist")
+		case polls <= 3:
+			pending := transaction
+
+			return &pending, nil
+		default:
+			settled := transaction
+			settled.Status = true
+			settled.Message = "success"
+			settled.UpdatedAt = TestTime.Add(time.Minute)
+
+			return &settled, nil
+		}
+	}
+
+	t.Run("until settled", func(t *testing.T) {
+		stream := &watchStream{ctx: context.Background()}
+
+		err := s.server.WatchTransaction(&pb.PollingTransactionRequest{
+			UserId:        1,
+			TransactionId: transaction.TransactionID.String(),
+		}, stream)
+		require.NoError(t, err)
+
+		require.Len(t, stream.sent, 2)
+		require.False(t, stream.sent[0].GetPaymentStatus())
+		require.True(t, stream.sent[1].GetPaymentStatus())
+		require.Equal(t, "success", stream.sent[1].GetRemarks())
+	})
+
+	t.Run("client goes away", func(t *testing.T) {
+		s.TransactionRepository.PollingTransactionFunc = func(_ context.Context, _ uuid.UUID) (*repository.Transaction, error) {
+			pending := transaction
+
+			return &pending, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		stream := &watchStream{ctx: ctx}
+
+		err := s.server.WatchTransaction(&pb.PollingTransactionRequest{
+			UserId:        1,
+			TransactionId: transaction.TransactionID.String(),
+		}, stream)
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		require.Len(t, stream.sent, 1)
+	})
+
+	t.Run("another user's transaction", func(t *testing.T) {
+		stream := &watchStream{ctx: context.Background()}
+
+		err := s.server.WatchTransaction(&pb.PollingTransactionRequest{
+			UserId:        2,
+			TransactionId: transaction.TransactionID.String(),
+		}, stream)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Empty(t, stream.sent)
+	})
+}
+
+func newTransaction() repository.Transaction {
+	return repository.Transaction{
+		TransactionID: uuid.New(),
+		UserID:        1,
+		Action:        "withdrawal",
+		Amount:        100,
+		PhoneNumber:   "0712345678",
+		NetworkCode:   "63902",
+		Narration:     "test",
+		UpdatedAt:     TestTime,
+		CreatedAt:     TestTime,
+	}
+}
@@ -0,0 +1,124 @@
+package gRPC
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// watchInterval is how often WatchTransaction looks the transaction up again.
+const watchInterval = time.Second
+
+type GRPCServer struct {
+	pb.UnimplementedPaymentsServiceServer
+	gRPCServer *grpc.Server
+	health     *health.Server
+	config     pkg.Config
+	mu         sync.Mutex
+
+	watchInterval time.Duration
+
+	client                           pb.AuthenticationServiceClient
+	Distributor                      services.TaskDistributor
+	TransactionRepository            repository.TransactionRepository
+	WithdrawalConfirmationRepository repository.WithdrawalConfirmationRepository
+}
+
+func NewGRPCServer(config pkg.Config, client pb.AuthenticationServiceClient) *GRPCServer {
+	return &GRPCServer{
+		config:        config,
+		client:        client,
+		watchInterval: watchInterval,
+	}
+}
+
+func (s *GRPCServer) Start(port string) error {
+	s.mu.Lock()
+	s.gRPCServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(),
+			tracing.UnaryServerInterceptor(),
+			metrics.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamServerInterceptor(),
+			tracing.StreamServerInterceptor(),
+			metrics.StreamServerInterceptor(),
+		),
+	)
+
+	pb.RegisterPaymentsServiceServer(s.gRPCServer, s)
+
+	s.health = health.NewServer()
+	s.health.SetServingStatus(pb.PaymentsService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s.gRPCServer, s.health)
+
+	reflection.Register(s.gRPCServer)
+
+	server := s.gRPCServer
+	s.mu.Unlock()
+
+	listener, err := net.Listen("tcp", port)
+	if err != nil {
+		return fmt.Errorf("failed to start grpc server on port: %s", err)
+	}
+
+	return server.Serve(listener)
+}
+
+// Shutdown stops the server gracefully, letting in-flight RPCs finish, and closes the
+// connections that are still open once ctx expires. Open WatchTransaction streams are
+// among those, unless their transactions settle in time.
+func (s *GRPCServer) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	server := s.gRPCServer
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+	s.mu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+func (s *GRPCServer) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	if s.gRPCServer != nil {
+		s.gRPCServer.Stop()
+	}
+}
@@ -0,0 +1,108 @@
+package gRPC
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/mock"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
+	"go.uber.org/mock/gomock"
+)
+
+type TestGRPCServer struct {
+	server                           *GRPCServer
+	client                           *mockpb.MockAuthenticationServiceClient
+	Distributor                      mock.MockTaskDistributor
+	TransactionRepository            mock.MockTransactionRepository
+	WithdrawalConfirmationRepository mock.MockWithdrawalConfirmationRepository
+}
+
+func NewTestGRPCServer(t *testing.T) *TestGRPCServer {
+	ctrl := gomock.NewController(t)
+
+	s := &TestGRPCServer{
+		client: mockpb.NewMockAuthenticationServiceClient(ctrl),
+	}
+
+	s.server = NewGRPCServer(pkg.Config{ENCRYPTION_KEY: "12345678901234567890123456789012"}, s.client)
+	s.server.Distributor = &s.Distributor
+	s.server.TransactionRepository = &s.TransactionRepository
+	s.server.WithdrawalConfirmationRepository = &s.WithdrawalConfirmationRepository
+	s.server.watchInterval = 10 * time.Millisecond
+
+	return s
+}
+
+func TestGRPCServer_Start(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    string
+		wantErr bool
+	}{
+		{
+			name:    "Valid Port",
+			port:    "127.0.0.1:0",
+			wantErr: false,
+		},
+		{
+			name:    "Invalid Port",
+			port:    "INVALID_PORT",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTestGRPCServer(t)
+			errCh := make(chan error)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			go func(port string) {
+				errCh <- s.server.Start(port)
+			}(tc.port)
+
+			select {
+			case err := <-errCh:
+				if (err != nil) != tc.wantErr {
+					t.Errorf("GRPCServer.Start() error = %v, wantErr %v", err, tc.wantErr)
+				}
+			case <-ctx.Done():
+				if tc.wantErr {
+					t.Errorf("GRPCServer.Start() expected error for port %s, but got none", tc.port)
+				}
+			}
+
+			s.server.Stop()
+		})
+	}
+}
+
+func TestGRPCServer_Shutdown(t *testing.T) {
+	s := NewTestGRPCServer(t)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.server.Start("127.0.0.1:0")
+	}()
+
+	// wait for Start to create the server
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.server.Shutdown(ctx)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("GRPCServer.Start() error = %v after shutdown", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("GRPCServer.Start() did not return after shutdown")
+	}
+}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -27,6 +31,9 @@ func (s *HttpServer) handleCallBack(ctx *gin.Context) {
 		return
 	}
 
+	c, span := tracing.StartCallback(logging.WithTransactionID(ctx.Request.Context(), id.String()), ctx.Query("traceparent"), id.String())
+	defer span.End()
+
 	var req any
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error_message": "no body received"})
@@ -45,18 +52,26 @@ func (s *HttpServer) handleCallBack(ctx *gin.Context) {
 		status = true
 	}
 
-	_, err = s.TransactionRepository.UpdateTransaction(ctx, id, repository.TransactionUpdate{
+	transaction, err := s.TransactionRepository.UpdateTransaction(c, id, repository.TransactionUpdate{
 		Status:             status,
 		PaydTransactionRef: transactionRef,
 		Message:            remarks,
 	})
 	if err != nil {
-		// log this data
+		slog.ErrorContext(c, "failed to update transaction from callback", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error_message": err.Error()})
 
 		return
 	}
 
+	if transaction.Status {
+		metrics.CountTransaction(transaction.Action, metrics.TransactionSucceeded)
+	} else {
+		metrics.CountTransaction(transaction.Action, metrics.TransactionFailed)
+	}
+
+	slog.InfoContext(c, "transaction updated from callback", "status", transaction.Status, "action", transaction.Action)
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 }
 
@@ -53,7 +53,13 @@ func mockUpdateTransactionFunc(ctx context.Context, id uuid.UUID, req repository
 		return nil, pkg.Errorf(pkg.INVALID_ERROR, "id cannot be empty")
 	}
 
-	return nil, nil
+	return &repository.Transaction{
+		TransactionID:      id,
+		PaydTransactionRef: req.PaydTransactionRef,
+		Message:            req.Message,
+		Action:             "payment",
+		Status:             req.Status,
+	}, nil
 }
 
 func TestHttpServer_handleCallback(t *testing.T) {
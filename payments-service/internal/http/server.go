@@ -1,15 +1,24 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/health"
 	"github.com/gin-gonic/gin"
 )
 
 type HttpServer struct {
 	router *gin.Engine
+	server *http.Server
 
+	HealthChecker         *health.Checker
 	TransactionRepository repository.TransactionRepository
 }
 
@@ -22,16 +31,38 @@ func NewHttpServer() *HttpServer {
 }
 
 func (s *HttpServer) setRoutes() {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logging.GinMiddleware())
+	r.Use(tracing.GinMiddleware())
+	r.Use(metrics.GinMiddleware())
 
 	r.GET("/healthcheck", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/livez", func(ctx *gin.Context) { s.HealthChecker.Live(ctx.Writer, ctx.Request) })
+	r.GET("/readyz", func(ctx *gin.Context) { s.HealthChecker.Ready(ctx.Writer, ctx.Request) })
 	r.POST("/transaction/:id", s.handleCallBack)
 
 	s.router = r
+	s.server = &http.Server{
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
 }
 
 func (s *HttpServer) Start(addr string) error {
-	return s.router.Run(addr)
+	s.server.Addr = addr
+
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops accepting callbacks and waits for the ones being handled to finish.
+func (s *HttpServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var entry map[string]any
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	return entry
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+
+	payload := services.SendPaymentWithdrawalRequestPayload{
+		TransactionID:      uuid.New(),
+		PaydUsername:       "payd-user",
+		PaydPasswordApiKey: "password-api-key",
+		PaydUsernameApiKey: "username-api-key",
+	}
+
+	New(&buf).InfoContext(WithTransactionID(context.Background(), payload.TransactionID.String()), "sending payment", "payload", payload)
+
+	entry := decode(t, &buf)
+	require.Equal(t, payload.TransactionID.String(), entry["transaction_id"])
+	require.NotContains(t, buf.String(), "password-api-key")
+	require.NotContains(t, buf.String(), "username-api-key")
+	require.Contains(t, buf.String(), "payd-user")
+}
+
+func TestFromAMQP(t *testing.T) {
+	ctx := FromAMQP(context.Background(), amqp.Table{RequestIDHeader: "req-1"}, "correlation-1")
+	require.Equal(t, "req-1", RequestID(ctx))
+
+	ctx = FromAMQP(context.Background(), nil, "correlation-1")
+	require.Equal(t, "correlation-1", RequestID(ctx))
+}
+
+func TestAsynqMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+
+	defer slog.SetDefault(previous)
+
+	transactionID := uuid.New()
+
+	payload, err := json.Marshal(services.SendPaymentWithdrawalRequestPayload{
+		TransactionID: transactionID,
+		UserID:        32,
+		RequestID:     "req-2",
+	})
+	require.NoError(t, err)
+
+	var handlerCtx context.Context
+
+	handler := AsynqMiddleware(asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		handlerCtx = ctx
+
+		return nil
+	}))
+
+	err = handler.ProcessTask(context.Background(), asynq.NewTask("task:payment_request", payload))
+	require.NoError(t, err)
+
+	require.Equal(t, "req-2", RequestID(handlerCtx))
+
+	entry := decode(t, &buf)
+	require.Equal(t, "task processed", entry["msg"])
+	require.Equal(t, "req-2", entry["request_id"])
+	require.Equal(t, float64(32), entry["user_id"])
+	require.Equal(t, transactionID.String(), entry["transaction_id"])
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+
+	defer slog.SetDefault(previous)
+
+	info := &grpc.StreamServerInfo{FullMethod: "/pb.paymentsService/WatchTransaction", IsServerStream: true}
+
+	ss := fakeServerStream{
+		ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-1")),
+	}
+
+	var requestID string
+
+	handler := func(_ any, stream grpc.ServerStream) error {
+		requestID = RequestID(stream.Context())
+
+		return status.Error(codes.NotFound, "transaction does not exist")
+	}
+
+	err := StreamServerInterceptor()(nil, ss, info, handler)
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.Equal(t, "req-1", requestID)
+
+	entry := decode(t, &buf)
+	require.Equal(t, "req-1", entry["request_id"])
+	require.Equal(t, info.FullMethod, entry["method"])
+}
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "payments"
+
+// Final states a transaction can be counted in.
+const (
+	TransactionInitiated = "initiated"
+	TransactionSucceeded = "succeeded"
+	TransactionFailed    = "failed"
+)
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests handled by the payments service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	tasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tasks",
+		Name:      "processed_total",
+		Help:      "Total number of asynq tasks processed, by task type and outcome.",
+	}, []string{"type", "outcome"})
+
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tasks",
+		Name:      "duration_seconds",
+		Help:      "Duration of asynq task handlers.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+
+	paydRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "payd",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of calls made to the Payd API, by endpoint and response status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "status_code"})
+
+	transactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "transactions",
+		Name:      "total",
+		Help:      "Total number of transactions, by action and state.",
+	}, []string{"action", "state"})
+
+	grpcServerHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "handled_total",
+		Help:      "Total number of gRPC calls completed by the server, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcServerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "handling_seconds",
+		Help:      "Duration of gRPC calls handled by the server. Streams are timed until they end.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// Handler exposes the registered metrics in the prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// GinMiddleware records the duration and status of every request served by the router.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequestDuration.WithLabelValues(
+			ctx.Request.Method,
+			route,
+			strconv.Itoa(ctx.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// AsynqMiddleware records the outcome of every task handled by the asynq server.
+// A failed task is counted as "retry" while it still has retries left and as
+// "failed" once they are exhausted.
+func AsynqMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		start := time.Now()
+
+		err := next.ProcessTask(ctx, task)
+
+		taskDuration.WithLabelValues(task.Type()).Observe(time.Since(start).Seconds())
+		tasksProcessed.WithLabelValues(task.Type(), taskOutcome(ctx, err)).Inc()
+
+		return err
+	})
+}
+
+func taskOutcome(ctx context.Context, err error) string {
+	if err == nil {
+		return "success"
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	if retried < maxRetry {
+		return "retry"
+	}
+
+	return "failed"
+}
+
+// ObservePaydRequest records a call to the Payd endpoint. A statusCode of 0 means
+// no response was received.
+func ObservePaydRequest(endpoint string, statusCode int, start time.Time) {
+	code := "error"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+
+	paydRequestDuration.WithLabelValues(endpoint, code).Observe(time.Since(start).Seconds())
+}
+
+// CountTransaction records a transaction reaching state.
+func CountTransaction(action string, state string) {
+	transactions.WithLabelValues(action, state).Inc()
+}
+
+// UnaryServerInterceptor records the outcome and latency of every unary gRPC call.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+
+		rsp, err := handler(ctx, req)
+
+		observeGRPCCall(info.FullMethod, start, err)
+
+		return rsp, err
+	}
+}
+
+// StreamServerInterceptor records the outcome and duration of every streaming gRPC call.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		observeGRPCCall(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func observeGRPCCall(method string, start time.Time, err error) {
+	grpcServerDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	grpcServerHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func scrape(t *testing.T) string {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/metrics", Handler())
+
+	w := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return w.Body.String()
+}
+
+func TestAsynqMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		taskType string
+		err      error
+		want     string
+	}{
+		{
+			name:     "success",
+			taskType: "task:test_success",
+			err:      nil,
+			want:     `payments_tasks_processed_total{outcome="success",type="task:test_success"} 1`,
+		},
+		{
+			name:     "failure",
+			taskType: "task:test_failure",
+			err:      errors.New("payd unavailable"),
+			want:     `payments_tasks_processed_total{outcome="failed",type="task:test_failure"} 1`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := AsynqMiddleware(asynq.HandlerFunc(func(_ context.Context, _ *asynq.Task) error {
+				return tc.err
+			}))
+
+			err := handler.ProcessTask(context.Background(), asynq.NewTask(tc.taskType, nil))
+			require.Equal(t, tc.err, err)
+
+			require.Contains(t, scrape(t), tc.want)
+		})
+	}
+}
+
+func TestObservePaydRequest(t *testing.T) {
+	ObservePaydRequest("payment", http.StatusAccepted, time.Now())
+	ObservePaydRequest("withdrawal", 0, time.Now())
+
+	body := scrape(t)
+	require.Contains(t, body, `payments_payd_request_duration_seconds_count{endpoint="payment",status_code="202"} 1`)
+	require.Contains(t, body, `payments_payd_request_duration_seconds_count{endpoint="withdrawal",status_code="error"} 1`)
+}
+
+func TestCountTransaction(t *testing.T) {
+	CountTransaction("withdrawal", TransactionSucceeded)
+
+	require.Contains(t, scrape(t), `payments_transactions_total{action="withdrawal",state="succeeded"} 1`)
+}
+
+func TestServerInterceptors(t *testing.T) {
+	unaryInfo := &grpc.UnaryServerInfo{FullMethod: "/pb.paymentsService/GetTransaction"}
+
+	_, err := UnaryServerInterceptor()(context.Background(), nil, unaryInfo, func(_ context.Context, _ any) (any, error) {
+		return nil, status.Error(codes.NotFound, "transaction does not exist")
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/pb.paymentsService/WatchTransaction", IsServerStream: true}
+
+	err = StreamServerInterceptor()(nil, nil, streamInfo, func(_ any, _ grpc.ServerStream) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	metrics := scrape(t)
+	require.Contains(t, metrics, `payments_grpc_server_handled_total{code="NotFound",method="/pb.paymentsService/GetTransaction"} 1`)
+	require.Contains(t, metrics, `payments_grpc_server_handled_total{code="OK",method="/pb.paymentsService/WatchTransaction"} 1`)
+}
@@ -12,6 +12,7 @@ var _ repository.TransactionRepository = (*MockTransactionRepository)(nil)
 type MockTransactionRepository struct {
 	CreateTransactionFunc  func(context.Context, repository.Transaction) (*repository.Transaction, error)
 	PollingTransactionFunc func(context.Context, uuid.UUID) (*repository.Transaction, error)
+	ListTransactionsFunc   func(context.Context, int64, int32, int32) ([]repository.Transaction, error)
 	UpdateTransactionFunc  func(context.Context, uuid.UUID, repository.TransactionUpdate) (*repository.Transaction, error)
 }
 
@@ -23,6 +24,10 @@ func (m *MockTransactionRepository) PollingTransaction(ctx context.Context, id u
 	return m.PollingTransactionFunc(ctx, id)
 }
 
+func (m *MockTransactionRepository) ListTransactions(ctx context.Context, userID int64, limit, offset int32) ([]repository.Transaction, error) {
+	return m.ListTransactionsFunc(ctx, userID, limit, offset)
+}
+
 func (m *MockTransactionRepository) UpdateTransaction(
 	ctx context.Context,
 	id uuid.UUID,
@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.WithdrawalConfirmationRepository = (*MockWithdrawalConfirmationRepository)(nil)
+
+type MockWithdrawalConfirmationRepository struct {
+	CreateWithdrawalConfirmationFunc func(context.Context, repository.WithdrawalConfirmation) (*repository.WithdrawalConfirmation, error)
+	GetWithdrawalConfirmationFunc    func(context.Context, uuid.UUID) (*repository.WithdrawalConfirmation, error)
+	ConfirmWithdrawalFunc            func(context.Context, uuid.UUID, int64) (*repository.WithdrawalConfirmation, error)
+	ReleaseWithdrawalFunc            func(context.Context, uuid.UUID, int64) error
+}
+
+func (m *MockWithdrawalConfirmationRepository) CreateWithdrawalConfirmation(
+	ctx context.Context,
+	req repository.WithdrawalConfirmation,
+) (*repository.WithdrawalConfirmation, error) {
+	return m.CreateWithdrawalConfirmationFunc(ctx, req)
+}
+
+func (m *MockWithdrawalConfirmationRepository) GetWithdrawalConfirmation(
+	ctx context.Context,
+	id uuid.UUID,
+) (*repository.WithdrawalConfirmation, error) {
+	return m.GetWithdrawalConfirmationFunc(ctx, id)
+}
+
+func (m *MockWithdrawalConfirmationRepository) ConfirmWithdrawal(
+	ctx context.Context,
+	id uuid.UUID,
+	userID int64,
+) (*repository.WithdrawalConfirmation, error) {
+	return m.ConfirmWithdrawalFunc(ctx, id, userID)
+}
+
+func (m *MockWithdrawalConfirmationRepository) ReleaseWithdrawal(ctx context.Context, id uuid.UUID, userID int64) error {
+	return m.ReleaseWithdrawalFunc(ctx, id, userID)
+}
@@ -24,3 +24,16 @@ type Transaction struct {
 	CreatedAt          time.Time `json:"created_at"`
 	Message            string    `json:"message"`
 }
+
+type WithdrawalConfirmation struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	UserID        int64     `json:"user_id"`
+	Email         string    `json:"email"`
+	Amount        int64     `json:"amount"`
+	PhoneNumber   string    `json:"phone_number"`
+	NetworkNode   string    `json:"network_node"`
+	Narration     string    `json:"narration"`
+	Confirmed     bool      `json:"confirmed"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -11,8 +11,14 @@ import (
 )
 
 type Querier interface {
+	ConfirmWithdrawal(ctx context.Context, arg ConfirmWithdrawalParams) (WithdrawalConfirmation, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
+	CreateWithdrawalConfirmation(ctx context.Context, arg CreateWithdrawalConfirmationParams) (WithdrawalConfirmation, error)
+	DeleteExpiredWithdrawalConfirmations(ctx context.Context) error
 	GetTransaction(ctx context.Context, transactionID uuid.UUID) (Transaction, error)
+	GetWithdrawalConfirmation(ctx context.Context, transactionID uuid.UUID) (WithdrawalConfirmation, error)
+	ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error)
+	ReleaseWithdrawal(ctx context.Context, arg ReleaseWithdrawalParams) error
 	UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transaction, error)
 }
 
@@ -87,6 +87,53 @@ func (q *Queries) GetTransaction(ctx context.Context, transactionID uuid.UUID) (
 	return i, err
 }
 
+const listTransactions = `-- name: ListTransactions :many
+SELECT transaction_id, payd_transaction_ref, user_id, action, amount, phone_number, network_node, narration, status, updated_at, created_at, message FROM transactions
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListTransactionsParams struct {
+	UserID int64 `json:"user_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listTransactions, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.TransactionID,
+			&i.PaydTransactionRef,
+			&i.UserID,
+			&i.Action,
+			&i.Amount,
+			&i.PhoneNumber,
+			&i.NetworkNode,
+			&i.Narration,
+			&i.Status,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.Message,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTransaction = `-- name: UpdateTransaction :one
 UPDATE transactions
 SET status = $2,
@@ -0,0 +1,14 @@
+CREATE TABLE "withdrawal_confirmations" (
+    "transaction_id" uuid PRIMARY KEY,
+    "user_id" bigint NOT NULL,
+    "email" varchar NOT NULL,
+    "amount" bigint NOT NULL,
+    "phone_number" varchar NOT NULL,
+    "network_node" varchar NOT NULL,
+    "narration" text NOT NULL,
+    "confirmed" boolean NOT NULL DEFAULT false,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "withdrawal_confirmations" ("expires_at");
@@ -41,6 +41,21 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// ConfirmWithdrawal mocks base method.
+func (m *MockQuerier) ConfirmWithdrawal(arg0 context.Context, arg1 generated.ConfirmWithdrawalParams) (generated.WithdrawalConfirmation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmWithdrawal", arg0, arg1)
+	ret0, _ := ret[0].(generated.WithdrawalConfirmation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmWithdrawal indicates an expected call of ConfirmWithdrawal.
+func (mr *MockQuerierMockRecorder) ConfirmWithdrawal(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmWithdrawal", reflect.TypeOf((*MockQuerier)(nil).ConfirmWithdrawal), arg0, arg1)
+}
+
 // CreateTransaction mocks base method.
 func (m *MockQuerier) CreateTransaction(arg0 context.Context, arg1 generated.CreateTransactionParams) (generated.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +71,35 @@ func (mr *MockQuerierMockRecorder) CreateTransaction(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockQuerier)(nil).CreateTransaction), arg0, arg1)
 }
 
+// CreateWithdrawalConfirmation mocks base method.
+func (m *MockQuerier) CreateWithdrawalConfirmation(arg0 context.Context, arg1 generated.CreateWithdrawalConfirmationParams) (generated.WithdrawalConfirmation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithdrawalConfirmation", arg0, arg1)
+	ret0, _ := ret[0].(generated.WithdrawalConfirmation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWithdrawalConfirmation indicates an expected call of CreateWithdrawalConfirmation.
+func (mr *MockQuerierMockRecorder) CreateWithdrawalConfirmation(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithdrawalConfirmation", reflect.TypeOf((*MockQuerier)(nil).CreateWithdrawalConfirmation), arg0, arg1)
+}
+
+// DeleteExpiredWithdrawalConfirmations mocks base method.
+func (m *MockQuerier) DeleteExpiredWithdrawalConfirmations(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredWithdrawalConfirmations", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredWithdrawalConfirmations indicates an expected call of DeleteExpiredWithdrawalConfirmations.
+func (mr *MockQuerierMockRecorder) DeleteExpiredWithdrawalConfirmations(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredWithdrawalConfirmations", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredWithdrawalConfirmations), arg0)
+}
+
 // GetTransaction mocks base method.
 func (m *MockQuerier) GetTransaction(arg0 context.Context, arg1 uuid.UUID) (generated.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +115,50 @@ func (mr *MockQuerierMockRecorder) GetTransaction(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockQuerier)(nil).GetTransaction), arg0, arg1)
 }
 
+// GetWithdrawalConfirmation mocks base method.
+func (m *MockQuerier) GetWithdrawalConfirmation(arg0 context.Context, arg1 uuid.UUID) (generated.WithdrawalConfirmation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalConfirmation", arg0, arg1)
+	ret0, _ := ret[0].(generated.WithdrawalConfirmation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalConfirmation indicates an expected call of GetWithdrawalConfirmation.
+func (mr *MockQuerierMockRecorder) GetWithdrawalConfirmation(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalConfirmation", reflect.TypeOf((*MockQuerier)(nil).GetWithdrawalConfirmation), arg0, arg1)
+}
+
+// ListTransactions mocks base method.
+func (m *MockQuerier) ListTransactions(arg0 context.Context, arg1 generated.ListTransactionsParams) ([]generated.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", arg0, arg1)
+	ret0, _ := ret[0].([]generated.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockQuerierMockRecorder) ListTransactions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockQuerier)(nil).ListTransactions), arg0, arg1)
+}
+
+// ReleaseWithdrawal mocks base method.
+func (m *MockQuerier) ReleaseWithdrawal(arg0 context.Context, arg1 generated.ReleaseWithdrawalParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseWithdrawal", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseWithdrawal indicates an expected call of ReleaseWithdrawal.
+func (mr *MockQuerierMockRecorder) ReleaseWithdrawal(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseWithdrawal", reflect.TypeOf((*MockQuerier)(nil).ReleaseWithdrawal), arg0, arg1)
+}
+
 // UpdateTransaction mocks base method.
 func (m *MockQuerier) UpdateTransaction(arg0 context.Context, arg1 generated.UpdateTransactionParams) (generated.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
@@ -42,6 +43,22 @@ func (s *Store) Start() error {
 	return s.migrate()
 }
 
+// Ping reports whether the database can be reached.
+func (s *Store) Ping(ctx context.Context) error {
+	if s.conn == nil {
+		return errors.New("store is not started")
+	}
+
+	return s.conn.Ping(ctx)
+}
+
+// Close releases every connection of the pool.
+func (s *Store) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
 func (s *Store) migrate() error {
 	if s.config.MIGRATION_PATH == "" {
 		return fmt.Errorf("migration dir is empty")
@@ -10,6 +10,13 @@ RETURNING *;
 SELECT * FROM transactions
 WHERE transaction_id = $1;
 
+-- name: ListTransactions :many
+SELECT * FROM transactions
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
 -- name: UpdateTransaction :one
 UPDATE transactions
 SET status = $2,
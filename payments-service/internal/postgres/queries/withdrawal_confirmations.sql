@@ -0,0 +1,33 @@
+-- name: ConfirmWithdrawal :one
+UPDATE withdrawal_confirmations
+SET confirmed = true
+WHERE transaction_id = $1
+  AND user_id = $2
+  AND confirmed = false
+  AND expires_at > now()
+RETURNING *;
+
+-- name: CreateWithdrawalConfirmation :one
+INSERT INTO withdrawal_confirmations (
+    transaction_id, user_id, email, amount, phone_number, network_node, narration, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: DeleteExpiredWithdrawalConfirmations :exec
+DELETE FROM withdrawal_confirmations
+WHERE expires_at <= now();
+
+-- name: GetWithdrawalConfirmation :one
+SELECT * FROM withdrawal_confirmations
+WHERE transaction_id = $1
+  AND confirmed = false
+  AND expires_at > now();
+
+-- name: ReleaseWithdrawal :exec
+UPDATE withdrawal_confirmations
+SET confirmed = false
+WHERE transaction_id = $1
+  AND user_id = $2
+  AND confirmed = true;
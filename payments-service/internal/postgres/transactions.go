@@ -2,12 +2,13 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/generated"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/lib/pq"
 )
 
@@ -72,7 +73,7 @@ func (t *TransactionRepository) CreateTransaction(ctx context.Context, transacti
 func (t *TransactionRepository) PollingTransaction(ctx context.Context, id uuid.UUID) (*repository.Transaction, error) {
 	transaction, err := t.queries.GetTransaction(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "transaction does not exist")
 		}
 
@@ -95,6 +96,37 @@ func (t *TransactionRepository) PollingTransaction(ctx context.Context, id uuid.
 	}, nil
 }
 
+func (t *TransactionRepository) ListTransactions(ctx context.Context, userID int64, limit, offset int32) ([]repository.Transaction, error) {
+	transactions, err := t.queries.ListTransactions(ctx, generated.ListTransactionsParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing transactions")
+	}
+
+	result := make([]repository.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		result = append(result, repository.Transaction{
+			TransactionID:      transaction.TransactionID,
+			PaydTransactionRef: transaction.PaydTransactionRef,
+			Message:            transaction.Message,
+			UserID:             transaction.UserID,
+			Action:             transaction.Action,
+			Amount:             transaction.Amount,
+			PhoneNumber:        transaction.PhoneNumber,
+			NetworkCode:        transaction.NetworkNode,
+			Narration:          transaction.Narration,
+			Status:             transaction.Status,
+			UpdatedAt:          transaction.UpdatedAt,
+			CreatedAt:          transaction.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
 func (t *TransactionRepository) UpdateTransaction(
 	ctx context.Context,
 	id uuid.UUID,
@@ -2,19 +2,22 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/gRPC"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/generated"
 	mockdb "github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/mock"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"github.com/brianvoe/gofakeit"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/lib/pq"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
 )
 
 var TestTime = time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC)
@@ -159,6 +162,7 @@ func TestTransactionRepository_PollingTransaction(t *testing.T) {
 		id          uuid.UUID
 		mockQueries func(*mockdb.MockQuerier, uuid.UUID)
 		wantErr     bool
+		wantCode    string
 	}{
 		{
 			name: "success",
@@ -187,16 +191,18 @@ func TestTransactionRepository_PollingTransaction(t *testing.T) {
 				mockQueries.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(id)).
 					Return(generated.Transaction{}, errors.New("db error")).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.INTERNAL_ERROR,
 		},
 		{
 			name: "not found",
 			id:   uuid.New(),
 			mockQueries: func(mockQueries *mockdb.MockQuerier, id uuid.UUID) {
 				mockQueries.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(id)).
-					Return(generated.Transaction{}, sql.ErrNoRows).Times(1)
+					Return(generated.Transaction{}, pgx.ErrNoRows).Times(1)
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantCode: pkg.NOT_FOUND_ERROR,
 		},
 	}
 
@@ -208,6 +214,142 @@ func TestTransactionRepository_PollingTransaction(t *testing.T) {
 			if (err != nil) != tc.wantErr {
 				t.Errorf("PollingTransaction() error = %v, wantErr %v", err, tc.wantErr)
 			}
+
+			if tc.wantCode != "" && pkg.ErrorCode(err) != tc.wantCode {
+				t.Errorf("PollingTransaction() error code = %v, want %v", pkg.ErrorCode(err), tc.wantCode)
+			}
+		})
+	}
+}
+
+// watchStream collects what WatchTransaction sends.
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.PollingTransactionResponse
+}
+
+func (w *watchStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *watchStream) Send(rsp *pb.PollingTransactionResponse) error {
+	w.sent = append(w.sent, rsp)
+
+	return nil
+}
+
+func TestTransactionRepository_WatchTransaction(t *testing.T) {
+	tr := NewTestTransactionRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	tr.queries = mockQueries
+
+	id := uuid.New()
+
+	// WatchTransaction waits for a transaction the worker has not created yet, which
+	// only works when the missing row is reported as not found
+	gomock.InOrder(
+		mockQueries.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(id)).
+			Return(generated.Transaction{}, pgx.ErrNoRows).Times(1),
+		mockQueries.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(id)).
+			Return(generated.Transaction{
+				TransactionID: id,
+				UserID:        1,
+				Action:        "withdrawal",
+				Amount:        100,
+				Status:        true,
+				Message:       "success",
+				UpdatedAt:     TestTime.Add(time.Minute),
+				CreatedAt:     TestTime,
+			}, nil).Times(1),
+	)
+
+	server := gRPC.NewGRPCServer(pkg.Config{}, nil)
+	server.TransactionRepository = tr
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream := &watchStream{ctx: ctx}
+
+	err := server.WatchTransaction(&pb.PollingTransactionRequest{UserId: 1, TransactionId: id.String()}, stream)
+	if err != nil {
+		t.Fatalf("WatchTransaction() error = %v", err)
+	}
+
+	if len(stream.sent) != 1 || !stream.sent[0].GetPaymentStatus() {
+		t.Errorf("WatchTransaction() sent = %v, want the settled transaction", stream.sent)
+	}
+}
+
+func TestTransactionRepository_ListTransactions(t *testing.T) {
+	tr := NewTestTransactionRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	tr.queries = mockQueries
+
+	params := generated.ListTransactionsParams{UserID: 1, Limit: 10, Offset: 0}
+
+	tests := []struct {
+		name        string
+		mockQueries func(*mockdb.MockQuerier)
+		wantLen     int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			mockQueries: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().ListTransactions(gomock.Any(), gomock.Eq(params)).
+					Return([]generated.Transaction{
+						{TransactionID: uuid.New(), UserID: 1, Action: "withdrawal", Amount: 100, NetworkNode: "63902"},
+						{TransactionID: uuid.New(), UserID: 1, Action: "payment", Amount: 50, NetworkNode: "63903"},
+					}, nil).Times(1)
+			},
+			wantLen: 2,
+			wantErr: false,
+		},
+		{
+			name: "no transactions",
+			mockQueries: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().ListTransactions(gomock.Any(), gomock.Eq(params)).
+					Return([]generated.Transaction{}, nil).Times(1)
+			},
+			wantLen: 0,
+			wantErr: false,
+		},
+		{
+			name: "db error",
+			mockQueries: func(mockQueries *mockdb.MockQuerier) {
+				mockQueries.EXPECT().ListTransactions(gomock.Any(), gomock.Eq(params)).
+					Return(nil, errors.New("db error")).Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockQueries(mockQueries)
+
+			transactions, err := tr.ListTransactions(context.Background(), params.UserID, params.Limit, params.Offset)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ListTransactions() error = %v, wantErr %v", err, tc.wantErr)
+
+				return
+			}
+
+			if len(transactions) != tc.wantLen {
+				t.Errorf("ListTransactions() returned %d transactions, want %d", len(transactions), tc.wantLen)
+			}
 		})
 	}
 }
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/generated"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var _ repository.WithdrawalConfirmationRepository = (*WithdrawalConfirmationRepository)(nil)
+
+type WithdrawalConfirmationRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewWithdrawalConfirmationService(db *Store) *WithdrawalConfirmationRepository {
+	queries := generated.New(db.conn)
+
+	return &WithdrawalConfirmationRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (w *WithdrawalConfirmationRepository) CreateWithdrawalConfirmation(
+	ctx context.Context,
+	confirmation repository.WithdrawalConfirmation,
+) (*repository.WithdrawalConfirmation, error) {
+	if confirmation.TransactionID == uuid.Nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "transaction_id is required")
+	}
+
+	if confirmation.UserID == 0 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "user_id is required")
+	}
+
+	if confirmation.ExpiresAt.IsZero() {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "expires_at is required")
+	}
+
+	if err := w.queries.DeleteExpiredWithdrawalConfirmations(ctx); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired withdrawal confirmations")
+	}
+
+	created, err := w.queries.CreateWithdrawalConfirmation(ctx, generated.CreateWithdrawalConfirmationParams{
+		TransactionID: confirmation.TransactionID,
+		UserID:        confirmation.UserID,
+		Email:         confirmation.Email,
+		Amount:        confirmation.Amount,
+		PhoneNumber:   confirmation.PhoneNumber,
+		NetworkNode:   confirmation.NetworkCode,
+		Narration:     confirmation.Narration,
+		ExpiresAt:     confirmation.ExpiresAt,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "withdrawal confirmation already exists")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create withdrawal confirmation")
+	}
+
+	return withdrawalConfirmationFromRow(created), nil
+}
+
+func (w *WithdrawalConfirmationRepository) GetWithdrawalConfirmation(
+	ctx context.Context,
+	id uuid.UUID,
+) (*repository.WithdrawalConfirmation, error) {
+	confirmation, err := w.queries.GetWithdrawalConfirmation(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "withdrawal does not exist or expired")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting withdrawal confirmation")
+	}
+
+	return withdrawalConfirmationFromRow(confirmation), nil
+}
+
+func (w *WithdrawalConfirmationRepository) ConfirmWithdrawal(
+	ctx context.Context,
+	id uuid.UUID,
+	userID int64,
+) (*repository.WithdrawalConfirmation, error) {
+	confirmation, err := w.queries.ConfirmWithdrawal(ctx, generated.ConfirmWithdrawalParams{
+		TransactionID: id,
+		UserID:        userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "withdrawal does not exist or expired")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to confirm withdrawal")
+	}
+
+	return withdrawalConfirmationFromRow(confirmation), nil
+}
+
+func (w *WithdrawalConfirmationRepository) ReleaseWithdrawal(ctx context.Context, id uuid.UUID, userID int64) error {
+	err := w.queries.ReleaseWithdrawal(ctx, generated.ReleaseWithdrawalParams{
+		TransactionID: id,
+		UserID:        userID,
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to release withdrawal")
+	}
+
+	return nil
+}
+
+func withdrawalConfirmationFromRow(row generated.WithdrawalConfirmation) *repository.WithdrawalConfirmation {
+	return &repository.WithdrawalConfirmation{
+		TransactionID: row.TransactionID,
+		UserID:        row.UserID,
+		Email:         row.Email,
+		Amount:        row.Amount,
+		PhoneNumber:   row.PhoneNumber,
+		NetworkCode:   row.NetworkNode,
+		Narration:     row.Narration,
+		ExpiresAt:     row.ExpiresAt,
+		CreatedAt:     row.CreatedAt,
+	}
+}
@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/generated"
+	mockdb "github.com/EmilioCliff/payment-polling-app/payment-service/internal/postgres/mock"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/brianvoe/gofakeit"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/mock/gomock"
+)
+
+func NewTestWithdrawalConfirmationRepository() *WithdrawalConfirmationRepository {
+	store := NewStore(pkg.Config{})
+	store.conn = nil
+
+	return NewWithdrawalConfirmationService(store)
+}
+
+func TestWithdrawalConfirmationRepository_CreateWithdrawalConfirmation(t *testing.T) {
+	wr := NewTestWithdrawalConfirmationRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	wr.queries = mockQueries
+
+	tests := []struct {
+		name         string
+		confirmation repository.WithdrawalConfirmation
+		buildStubs   func(*mockdb.MockQuerier, repository.WithdrawalConfirmation)
+		wantErr      bool
+	}{
+		{
+			name:         "success",
+			confirmation: newWithdrawalConfirmation(),
+			buildStubs: func(q *mockdb.MockQuerier, confirmation repository.WithdrawalConfirmation) {
+				q.EXPECT().DeleteExpiredWithdrawalConfirmations(gomock.Any()).Times(1).Return(nil)
+				q.EXPECT().CreateWithdrawalConfirmation(gomock.Any(), gomock.Eq(generated.CreateWithdrawalConfirmationParams{
+					TransactionID: confirmation.TransactionID,
+					UserID:        confirmation.UserID,
+					Email:         confirmation.Email,
+					Amount:        confirmation.Amount,
+					PhoneNumber:   confirmation.PhoneNumber,
+					NetworkNode:   confirmation.NetworkCode,
+					Narration:     confirmation.Narration,
+					ExpiresAt:     confirmation.ExpiresAt,
+				})).Times(1).Return(generated.WithdrawalConfirmation{
+					TransactionID: confirmation.TransactionID,
+					UserID:        confirmation.UserID,
+					Email:         confirmation.Email,
+					Amount:        confirmation.Amount,
+					PhoneNumber:   confirmation.PhoneNumber,
+					NetworkNode:   confirmation.NetworkCode,
+					Narration:     confirmation.Narration,
+					ExpiresAt:     confirmation.ExpiresAt,
+					CreatedAt:     TestTime,
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:         "missing values",
+			confirmation: repository.WithdrawalConfirmation{},
+			buildStubs: func(q *mockdb.MockQuerier, _ repository.WithdrawalConfirmation) {
+				q.EXPECT().DeleteExpiredWithdrawalConfirmations(gomock.Any()).Times(0)
+				q.EXPECT().CreateWithdrawalConfirmation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name:         "failed to delete expired",
+			confirmation: newWithdrawalConfirmation(),
+			buildStubs: func(q *mockdb.MockQuerier, _ repository.WithdrawalConfirmation) {
+				q.EXPECT().DeleteExpiredWithdrawalConfirmations(gomock.Any()).Times(1).Return(errors.New("db error"))
+				q.EXPECT().CreateWithdrawalConfirmation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name:         "already exists",
+			confirmation: newWithdrawalConfirmation(),
+			buildStubs: func(q *mockdb.MockQuerier, _ repository.WithdrawalConfirmation) {
+				q.EXPECT().DeleteExpiredWithdrawalConfirmations(gomock.Any()).Times(1).Return(nil)
+				q.EXPECT().CreateWithdrawalConfirmation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(generated.WithdrawalConfirmation{}, &pgconn.PgError{Code: "23505"})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(mockQueries, tc.confirmation)
+
+			created, err := wr.CreateWithdrawalConfirmation(context.Background(), tc.confirmation)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("CreateWithdrawalConfirmation() error = %v, wantErr %v", err, tc.wantErr)
+
+				return
+			}
+
+			if !tc.wantErr && created.TransactionID != tc.confirmation.TransactionID {
+				t.Errorf("CreateWithdrawalConfirmation() transaction_id = %v, want %v", created.TransactionID, tc.confirmation.TransactionID)
+			}
+		})
+	}
+}
+
+func TestWithdrawalConfirmationRepository_GetWithdrawalConfirmation(t *testing.T) {
+	wr := NewTestWithdrawalConfirmationRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	wr.queries = mockQueries
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		mockQueries func(*mockdb.MockQuerier, uuid.UUID)
+		wantCode    string
+	}{
+		{
+			name: "success",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, id uuid.UUID) {
+				q.EXPECT().GetWithdrawalConfirmation(gomock.Any(), gomock.Eq(id)).
+					Return(generated.WithdrawalConfirmation{TransactionID: id, UserID: 1}, nil).Times(1)
+			},
+		},
+		{
+			name: "confirmed or expired",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, id uuid.UUID) {
+				q.EXPECT().GetWithdrawalConfirmation(gomock.Any(), gomock.Eq(id)).
+					Return(generated.WithdrawalConfirmation{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.NOT_FOUND_ERROR,
+		},
+		{
+			name: "db error",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, id uuid.UUID) {
+				q.EXPECT().GetWithdrawalConfirmation(gomock.Any(), gomock.Eq(id)).
+					Return(generated.WithdrawalConfirmation{}, errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockQueries(mockQueries, tc.id)
+
+			_, err := wr.GetWithdrawalConfirmation(context.Background(), tc.id)
+			if tc.wantCode == "" {
+				if err != nil {
+					t.Errorf("GetWithdrawalConfirmation() error = %v", err)
+				}
+
+				return
+			}
+
+			if pkg.ErrorCode(err) != tc.wantCode {
+				t.Errorf("GetWithdrawalConfirmation() error code = %v, want %v", pkg.ErrorCode(err), tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestWithdrawalConfirmationRepository_ConfirmWithdrawal(t *testing.T) {
+	wr := NewTestWithdrawalConfirmationRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	wr.queries = mockQueries
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		mockQueries func(*mockdb.MockQuerier, uuid.UUID)
+		wantCode    string
+	}{
+		{
+			name: "success",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, id uuid.UUID) {
+				q.EXPECT().ConfirmWithdrawal(gomock.Any(), gomock.Eq(generated.ConfirmWithdrawalParams{
+					TransactionID: id,
+					UserID:        1,
+				})).Return(generated.WithdrawalConfirmation{TransactionID: id, UserID: 1}, nil).Times(1)
+			},
+		},
+		{
+			name: "confirmed or expired",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, _ uuid.UUID) {
+				q.EXPECT().ConfirmWithdrawal(gomock.Any(), gomock.Any()).
+					Return(generated.WithdrawalConfirmation{}, pgx.ErrNoRows).Times(1)
+			},
+			wantCode: pkg.NOT_FOUND_ERROR,
+		},
+		{
+			name: "db error",
+			id:   uuid.New(),
+			mockQueries: func(q *mockdb.MockQuerier, _ uuid.UUID) {
+				q.EXPECT().ConfirmWithdrawal(gomock.Any(), gomock.Any()).
+					Return(generated.WithdrawalConfirmation{}, errors.New("db error")).Times(1)
+			},
+			wantCode: pkg.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockQueries(mockQueries, tc.id)
+
+			_, err := wr.ConfirmWithdrawal(context.Background(), tc.id, 1)
+			if tc.wantCode == "" {
+				if err != nil {
+					t.Errorf("ConfirmWithdrawal() error = %v", err)
+				}
+
+				return
+			}
+
+			if pkg.ErrorCode(err) != tc.wantCode {
+				t.Errorf("ConfirmWithdrawal() error code = %v, want %v", pkg.ErrorCode(err), tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestWithdrawalConfirmationRepository_ReleaseWithdrawal(t *testing.T) {
+	wr := NewTestWithdrawalConfirmationRepository()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+
+	wr.queries = mockQueries
+
+	id := uuid.New()
+
+	mockQueries.EXPECT().ReleaseWithdrawal(gomock.Any(), gomock.Eq(generated.ReleaseWithdrawalParams{
+		TransactionID: id,
+		UserID:        1,
+	})).Return(nil).Times(1)
+
+	if err := wr.ReleaseWithdrawal(context.Background(), id, 1); err != nil {
+		t.Errorf("ReleaseWithdrawal() error = %v", err)
+	}
+
+	mockQueries.EXPECT().ReleaseWithdrawal(gomock.Any(), gomock.Any()).Return(errors.New("db error")).Times(1)
+
+	if err := wr.ReleaseWithdrawal(context.Background(), id, 1); pkg.ErrorCode(err) != pkg.INTERNAL_ERROR {
+		t.Errorf("ReleaseWithdrawal() error code = %v, want %v", pkg.ErrorCode(err), pkg.INTERNAL_ERROR)
+	}
+}
+
+func newWithdrawalConfirmation() repository.WithdrawalConfirmation {
+	return repository.WithdrawalConfirmation{
+		TransactionID: uuid.New(),
+		UserID:        1,
+		Email:         gofakeit.Email(),
+		Amount:        50000,
+		PhoneNumber:   gofakeit.Phone(),
+		NetworkCode:   "63902",
+		Narration:     gofakeit.Sentence(10),
+		ExpiresAt:     TestTime.Add(5 * time.Minute),
+	}
+}
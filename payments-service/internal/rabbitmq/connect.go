@@ -2,30 +2,33 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
-	"log"
-	"math"
-	"time"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-type Payload struct {
-	Name string `json:"name"`
-	Data []byte `json:"data"`
-}
-
 type RabbitConn struct {
-	conn   *amqp.Connection
+	Bus    bus.Bus
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
 	config pkg.Config
 
-	client                pb.AuthenticationServiceClient
-	Distributor           services.TaskDistributor
-	TransactionRepository repository.TransactionRepository
+	client                           pb.AuthenticationServiceClient
+	Distributor                      services.TaskDistributor
+	TransactionRepository            repository.TransactionRepository
+	WithdrawalConfirmationRepository repository.WithdrawalConfirmationRepository
 }
 
 func NewRabbitConn(config pkg.Config, client pb.AuthenticationServiceClient) *RabbitConn {
@@ -35,183 +38,191 @@ func NewRabbitConn(config pkg.Config, client pb.AuthenticationServiceClient) *Ra
 	}
 }
 
+// ConnectToRabbit sets up the bus the handlers are served on: RabbitMQ, or an
+// in-process bus when BUS_DRIVER is "memory" so that the service runs without a broker.
 func (r *RabbitConn) ConnectToRabbit() error {
-	count := 0
-	maxRetries := 12
+	if r.config.BUS_DRIVER == "memory" {
+		r.Bus = bus.NewMemory()
 
-	var err error
+		return nil
+	}
 
-	var connection *amqp.Connection
+	conn, err := rabbit.Dial(r.config.RABBITMQ_URL)
+	if err != nil {
+		return err
+	}
 
-	for {
-		connection, err = amqp.Dial(r.config.RABBITMQ_URL)
-		if err != nil {
-			log.Println("failed to connect to rabbitmq", err)
+	r.Bus, err = bus.NewRabbitMQ(conn, bus.RabbitMQOptions{
+		Exchange:           r.config.EXCH,
+		DeadLetterExchange: r.config.DEAD_LETTER_EXCH,
+		Queue:              r.config.PAYMENT_QUEUE_NAME,
+		Consumer:           r.config.PAYMENT_CONSUMER_NAME,
+		Prefetch:           r.config.CONSUMER_PREFETCH,
+		Workers:            r.config.CONSUMER_WORKERS,
+	})
+	if err != nil {
+		_ = conn.Close()
 
-			if count > maxRetries {
-				return err
-			}
+		return err
+	}
 
-			count++
-			rollOff := time.Duration(math.Pow(float64(count), 2)) * time.Second
-			time.Sleep(rollOff)
+	return nil
+}
 
-			continue
-		}
+// Ready reports whether the bus is connected.
+func (r *RabbitConn) Ready(ctx context.Context) error {
+	if r.Bus == nil {
+		return errors.New("not connected")
+	}
 
-		log.Println("Connected to rabbitmq")
+	return r.Bus.Ready(ctx)
+}
 
-		break
+// Register registers the handler of the service for topics on the bus.
+func (r *RabbitConn) Register(topics []string) {
+	for _, topic := range topics {
+		r.Bus.Handle(topic, r.handleMessage)
 	}
+}
 
-	r.conn = connection
+// SetConsumer registers the handler for topics and serves the requests sent to them
+// until Shutdown is called.
+func (r *RabbitConn) SetConsumer(topics []string) error {
+	r.Register(topics)
 
-	return nil
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	r.mu.Lock()
+	r.cancel = cancel
+	r.done = done
+	r.mu.Unlock()
+
+	defer close(done)
+
+	slog.Info("listening to messages in payment service", "queue", r.config.PAYMENT_QUEUE_NAME, "workers", r.config.CONSUMER_WORKERS)
+
+	return r.Bus.Serve(ctx)
 }
 
-func (r *RabbitConn) SetConsumer(topics []string) error {
-	ch, err := r.conn.Channel()
-	if err != nil {
-		return err
-	}
-	defer ch.Close()
-
-	err = ch.ExchangeDeclare(
-		r.config.EXCH, // name
-		"topic",       // type
-		true,          // durable
-		false,         // auto-deleted
-		false,         // internal
-		false,         // no-wait
-		nil,           // arguments
-	)
-	if err != nil {
-		return err
-	}
+// Shutdown stops serving and waits for the requests being handled to be replied to
+// before closing the bus. Requests that were not acked by then are requeued by the
+// broker.
+func (r *RabbitConn) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
 
-	q, err := ch.QueueDeclare(
-		r.config.PAYMENT_QUEUE_NAME, // name
-		false,                       // durable
-		false,                       // delete when unused
-		false,                       // exclusive
-		false,                       // no-wait
-		nil,                         // arguments
-	)
-	if err != nil {
-		return err
-	}
+	var err error
 
-	for _, topic := range topics {
-		if err := ch.QueueBind(
-			q.Name,        // queue name
-			topic,         // routing key
-			r.config.EXCH, // exchange
-			false,
-			nil,
-		); err != nil {
-			return err
+	if cancel != nil {
+		cancel()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
 		}
 	}
 
-	msgs, err := ch.Consume(
-		q.Name,                         // queue
-		r.config.PAYMENT_CONSUMER_NAME, // consumer
-		false,                          // auto-ack
-		false,                          // exclusive
-		false,                          // no-local
-		false,                          // no-wait
-		nil,                            // args
-	)
-	if err != nil {
-		return err
+	if r.Bus != nil {
+		if closeErr := r.Bus.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
 	}
 
-	forever := make(chan bool)
-
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	return err
+}
 
-		for d := range msgs {
-			var payload Payload
+// handleMessage answers a request in the encoding it came in. Requests that can not be
+// decoded or are of an unknown type fail, and are dropped by the bus.
+func (r *RabbitConn) handleMessage(ctx context.Context, msg bus.Message) (bus.Message, error) {
+	msgCtx := logging.FromAMQP(ctx, msg.Headers, msg.CorrelationID)
 
-			err := json.Unmarshal(d.Body, &payload)
-			if err != nil {
-				log.Printf("failed to unmarshal message in auth rabbit: %s", err)
+	// the sender stops waiting for the reply at the deadline, the work on it stops too
+	if !msg.Deadline.IsZero() {
+		var cancel context.CancelFunc
 
-				_ = d.Nack(false, true)
+		msgCtx, cancel = context.WithDeadline(msgCtx, msg.Deadline)
+		defer cancel()
+	}
 
-				return
-			}
+	req, err := envelope.DecodeAs(msg.ContentType, msg.Body)
+	if err != nil {
+		slog.ErrorContext(msgCtx, "failed to decode message", "topic", msg.Topic, "error", err)
 
-			response := r.distributeTask(payload)
+		return bus.Message{}, err
+	}
 
-			log.Printf("Message acknowledged from payment service: %v", d.DeliveryTag)
+	msgCtx, span := tracing.StartConsume(msgCtx, msg.Topic, msg.Headers)
+	defer span.End()
 
-			_ = d.Ack(false)
+	reply, ok := r.distributeTask(msgCtx, req)
+	if !ok {
+		slog.ErrorContext(msgCtx, "unknown message", "topic", msg.Topic, "type", req.Type)
 
-			count := 0
-			maxRetries := 5
+		return bus.Message{}, fmt.Errorf("unknown message type %q", req.Type)
+	}
 
-			for {
-				err = ch.PublishWithContext(ctx,
-					r.config.EXCH, // exchange
-					d.ReplyTo,     // routing key
-					false,         // mandatory
-					false,         // immediate
-					amqp.Publishing{
-						ContentType:   "text/plain",
-						CorrelationId: d.CorrelationId,
-						Body:          response,
-					},
-				)
-				if err == nil {
-					break
-				}
+	reply.RequestID = req.RequestID
 
-				count++
+	// answered in the encoding of the request so that callers not sending protobuf
+	// yet can read the reply
+	contentType := envelope.ContentTypeJSON
+	if msg.ContentType == envelope.ContentTypeProtobuf {
+		contentType = envelope.ContentTypeProtobuf
+	}
 
-				if count > maxRetries {
-					// log to failed to send response
-					log.Printf("failed to send response: %s", err)
+	response, err := envelope.EncodeAs(contentType, reply)
+	if err != nil {
+		slog.ErrorContext(msgCtx, "failed to encode response", "type", req.Type, "error", err)
 
-					return
-				}
-			}
-		}
-	}()
+		return bus.Message{}, err
+	}
 
-	log.Println("listening to messages in payment service")
+	headers := tracing.AMQPHeaders(msgCtx)
+	logging.SetAMQPHeader(msgCtx, headers)
 
-	<-forever
+	slog.InfoContext(msgCtx, "message handled", "topic", msg.Topic, "type", req.Type)
 
-	return nil
+	return bus.Message{ContentType: contentType, Headers: headers, Body: response}, nil
 }
 
-func (r *RabbitConn) distributeTask(payload Payload) []byte {
-	switch payload.Name {
-	case "initiate_payment":
+// distributeTask hands req to the handler of its type and returns the reply, in the
+// encoding of req, or false when the type is unknown.
+func (r *RabbitConn) distributeTask(ctx context.Context, req envelope.Envelope) (envelope.Envelope, bool) {
+	switch req.Type {
+	case envelope.TypeInitiatePayment:
 		var initiatePaymentPayload initiatePaymentRequest
 
-		err := json.Unmarshal(payload.Data, &initiatePaymentPayload)
+		err := decodeRequest(req, &initiatePaymentPayload)
 		if err != nil {
-			return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "%v", err))
+			return r.errorRabbitMQResponse(req.Type, pkg.Errorf(pkg.INVALID_ERROR, "%v", err)), true
+		}
+
+		rsp, pkgErr := r.handleInitiatePayment(ctx, initiatePaymentPayload)
+		if pkgErr != nil {
+			return r.errorRabbitMQResponse(req.Type, pkgErr), true
 		}
 
-		return r.handleInitiatePayment(initiatePaymentPayload)
+		return r.resultRabbitMQResponse(req, rsp), true
 
-	case "polling_transaction":
+	case envelope.TypePollingTransaction:
 		var pollingTransactionPayload pollingTransactionRequest
 
-		err := json.Unmarshal(payload.Data, &pollingTransactionPayload)
+		err := decodeRequest(req, &pollingTransactionPayload)
 		if err != nil {
-			return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "%v", err))
+			return r.errorRabbitMQResponse(req.Type, pkg.Errorf(pkg.INVALID_ERROR, "%v", err)), true
 		}
 
-		return r.handlePollingTransaction(pollingTransactionPayload)
+		rsp, pkgErr := r.handlePollingTransaction(ctx, pollingTransactionPayload)
+		if pkgErr != nil {
+			return r.errorRabbitMQResponse(req.Type, pkgErr), true
+		}
+
+		return r.resultRabbitMQResponse(req, rsp), true
 
 	default:
-		// log unknow message
-		return nil
+		return envelope.Envelope{}, false
 	}
 }
@@ -1,8 +1,17 @@
 package rabbitmq
 
 import (
+	"context"
+	"testing"
+	"time"
+
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/mock"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/bus"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 	// "github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
 	// "go.uber.org/mock/gomock"
 )
@@ -10,8 +19,9 @@ import (
 type TestRabbitHandler struct {
 	rabbit *RabbitConn
 
-	TransactionRepository mock.MockTransactionRepository
-	TastDistributor       mock.MockTaskDistributor
+	TransactionRepository            mock.MockTransactionRepository
+	WithdrawalConfirmationRepository mock.MockWithdrawalConfirmationRepository
+	TastDistributor                  mock.MockTaskDistributor
 }
 
 func NewTestRabbitHandler() *TestRabbitHandler {
@@ -22,7 +32,135 @@ func NewTestRabbitHandler() *TestRabbitHandler {
 	}
 
 	rt.rabbit.TransactionRepository = &rt.TransactionRepository
+	rt.rabbit.WithdrawalConfirmationRepository = &rt.WithdrawalConfirmationRepository
 	rt.rabbit.Distributor = &rt.TastDistributor
 
 	return rt
 }
+
+func TestRabbitConn_handleMessage_PoisonMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{
+			name: "malformed payload",
+			body: []byte("not json"),
+		},
+		{
+			name:        "malformed protobuf payload",
+			contentType: envelope.ContentTypeProtobuf,
+			body:        []byte("not protobuf"),
+		},
+		{
+			name: "unknown message",
+			body: []byte(`{"name":"refund_payment","data":null}`),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewTestRabbitHandler()
+
+			// failed rather than answered, so that the bus dead-letters it
+			_, err := r.rabbit.handleMessage(context.Background(), bus.Message{
+				ContentType: tc.contentType,
+				Topic:       "payments.initiate_payment",
+				Body:        tc.body,
+			})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRabbitConn_MemoryBus(t *testing.T) {
+	r := NewTestRabbitHandler()
+
+	r.TransactionRepository.PollingTransactionFunc = mockPollingTransactionFunc
+
+	r.rabbit.Bus = bus.NewMemory()
+	r.rabbit.Register([]string{"payments.poll_payments"})
+
+	transactionID := uuid.New()
+
+	req, err := envelope.NewProto(envelope.TypePollingTransaction, &pb.PollingTransactionRequest{
+		UserId:        1,
+		TransactionId: transactionID.String(),
+	})
+	require.NoError(t, err)
+
+	body, err := envelope.EncodeProto(req)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := r.rabbit.Bus.Request(ctx, "payments.poll_payments", bus.Message{
+		ContentType: envelope.ContentTypeProtobuf,
+		Body:        body,
+	})
+	require.NoError(t, err)
+
+	decoded, err := envelope.DecodeAs(reply.ContentType, reply.Body)
+	require.NoError(t, err)
+	require.NoError(t, decoded.Err())
+
+	var rsp pb.PollingTransactionResponse
+	require.NoError(t, decoded.Unmarshal(&rsp))
+	require.Equal(t, transactionID.String(), rsp.GetTransactionId())
+}
+
+func TestRabbitConn_distributeTask(t *testing.T) {
+	r := NewTestRabbitHandler()
+
+	r.TransactionRepository.PollingTransactionFunc = mockPollingTransactionFunc
+
+	transactionID := uuid.New()
+
+	t.Run("protobuf request", func(t *testing.T) {
+		req, err := envelope.NewProto(envelope.TypePollingTransaction, &pb.PollingTransactionRequest{
+			UserId:        1,
+			TransactionId: transactionID.String(),
+		})
+		require.NoError(t, err)
+
+		reply, ok := r.rabbit.distributeTask(context.Background(), req)
+		require.True(t, ok)
+		require.NoError(t, reply.Err())
+		require.Equal(t, envelope.ContentTypeProtobuf, reply.ContentType)
+
+		var rsp pb.PollingTransactionResponse
+		require.NoError(t, reply.Unmarshal(&rsp))
+		require.Equal(t, transactionID.String(), rsp.GetTransactionId())
+		require.Equal(t, int64(100), rsp.GetAmount())
+	})
+
+	t.Run("JSON request", func(t *testing.T) {
+		req, err := envelope.New(envelope.TypePollingTransaction, pollingTransactionRequest{
+			UserID:        1,
+			TransactionId: transactionID.String(),
+		})
+		require.NoError(t, err)
+
+		reply, ok := r.rabbit.distributeTask(context.Background(), req)
+		require.True(t, ok)
+		require.Equal(t, envelope.ContentTypeJSON, reply.ContentType)
+
+		var rsp pollingTransactionResponse
+		require.NoError(t, reply.Unmarshal(&rsp))
+		require.Equal(t, int32(100), rsp.Amount)
+	})
+
+	t.Run("error reply", func(t *testing.T) {
+		req, err := envelope.NewProto(envelope.TypePollingTransaction, &pb.PollingTransactionRequest{
+			UserId:        2,
+			TransactionId: transactionID.String(),
+		})
+		require.NoError(t, err)
+
+		reply, ok := r.rabbit.distributeTask(context.Background(), req)
+		require.True(t, ok)
+		require.Equal(t, &envelope.Error{Code: envelope.CodeUnauthenticated, Message: "cannot access this transaction"}, reply.Error)
+	})
+}
@@ -2,10 +2,12 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/workers"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
@@ -20,26 +22,68 @@ type initiatePaymentRequest struct {
 	PhoneNumber string `json:"phone_number"`
 	NetworkCode string `json:"network_code"`
 	Naration    string `json:"naration"`
+	UserID      int64  `json:"user_id"`
 }
 
 type initiatePaymentResponse struct {
-	TransactionID string `json:"transaction_id"`
-	PaymentStatus bool   `json:"payment_status"`
-	Action        string `json:"action"`
+	TransactionID         string     `json:"transaction_id"`
+	PaymentStatus         bool       `json:"payment_status"`
+	Action                string     `json:"action"`
+	ConfirmationRequired  bool       `json:"confirmation_required,omitempty"`
+	ConfirmationExpiresAt *time.Time `json:"confirmation_expires_at,omitempty"`
 }
 
-func (r *RabbitConn) handleInitiatePayment(req initiatePaymentRequest) []byte {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+func (r *RabbitConn) handleInitiatePayment(ctx context.Context, req initiatePaymentRequest) (*initiatePaymentResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
 
 	transactionID, err := uuid.NewRandom()
 	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create transactionID: %v", err))
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create transactionID: %v", err)
 	}
 
+	tracing.SetTransactionID(ctx, transactionID.String())
+	ctx = logging.WithTransactionID(ctx, transactionID.String())
+
 	userData, err := r.client.GetUser(ctx, &pb.GetUserRequest{Email: req.Email})
 	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get user data from auth: %v", err))
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get user data from auth: %v", err)
+	}
+
+	ctx = logging.WithUserID(ctx, userData.GetUserId())
+
+	// the gateway sends the user of the session, who may only pay from their own account
+	if userData.GetUserId() != req.UserID {
+		return nil, pkg.Errorf(pkg.PERMISSION_ERROR, "cannot initiate payments for another account")
+	}
+
+	if r.config.REQUIRE_VERIFIED_EMAIL && !userData.GetEmailVerified() {
+		return nil, pkg.Errorf(pkg.PERMISSION_ERROR, "email address is not verified")
+	}
+
+	// held back withdrawals are confirmed over gRPC, see GRPCServer.ConfirmWithdrawal
+	if r.config.NeedsConfirmation(req.Action, req.Amount) {
+		confirmation, err := r.WithdrawalConfirmationRepository.CreateWithdrawalConfirmation(ctx, repository.WithdrawalConfirmation{
+			TransactionID: transactionID,
+			UserID:        userData.GetUserId(),
+			Email:         req.Email,
+			Amount:        req.Amount,
+			PhoneNumber:   req.PhoneNumber,
+			NetworkCode:   req.NetworkCode,
+			Narration:     req.Naration,
+			ExpiresAt:     time.Now().Add(r.config.WITHDRAWAL_CONFIRMATION_DURATION),
+		})
+		if err != nil {
+			return nil, pkg.Errorf(pkg.ErrorCode(err), "error holding withdrawal for confirmation: %v", pkg.ErrorMessage(err))
+		}
+
+		return &initiatePaymentResponse{
+			TransactionID:         transactionID.String(),
+			PaymentStatus:         false,
+			Action:                req.Action,
+			ConfirmationRequired:  true,
+			ConfirmationExpiresAt: &confirmation.ExpiresAt,
+		}, nil
 	}
 
 	opts := []asynq.Option{
@@ -49,12 +93,12 @@ func (r *RabbitConn) handleInitiatePayment(req initiatePaymentRequest) []byte {
 
 	passwordApiKey, err := pkg.Decrypt(userData.GetPaydPasswordKey(), []byte(r.config.ENCRYPTION_KEY))
 	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to decrypt payd password key: %v", err))
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to decrypt payd password key: %v", err)
 	}
 
 	usernameApiKey, err := pkg.Decrypt(userData.GetPaydUsernameKey(), []byte(r.config.ENCRYPTION_KEY))
 	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to decrypt payd username key: %v", err))
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to decrypt payd username key: %v", err)
 	}
 
 	payload := services.SendPaymentWithdrawalRequestPayload{
@@ -75,31 +119,24 @@ func (r *RabbitConn) handleInitiatePayment(req initiatePaymentRequest) []byte {
 	case "payment":
 		err = r.Distributor.DistributeSendPaymentRequestTask(ctx, payload, opts...)
 		if err != nil {
-			return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to distribute payment task: %v", err))
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to distribute payment task: %v", err)
 		}
 
 	case "withdrawal":
 		err = r.Distributor.DistributeSendWithdrawalRequestTask(ctx, payload, opts...)
 		if err != nil {
-			return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to distribute withdrawal task: %v", err))
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to distribute withdrawal task: %v", err)
 		}
 
 	default:
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid action: %s", req.Action))
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid action: %s", req.Action)
 	}
 
-	rsp := initiatePaymentResponse{
+	return &initiatePaymentResponse{
 		TransactionID: transactionID.String(),
 		PaymentStatus: false,
 		Action:        req.Action,
-	}
-
-	rspBytes, err := json.Marshal(rsp)
-	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal response from initiate-payment %v", err))
-	}
-
-	return rspBytes
+	}, nil
 }
 
 type pollingTransactionRequest struct {
@@ -119,27 +156,30 @@ type pollingTransactionResponse struct {
 	PaymentStatus      bool   `json:"payment_status"`
 }
 
-func (r *RabbitConn) handlePollingTransaction(req pollingTransactionRequest) []byte {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+func (r *RabbitConn) handlePollingTransaction(ctx context.Context, req pollingTransactionRequest) (*pollingTransactionResponse, *pkg.Error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
 
 	id, err := uuid.Parse(req.TransactionId)
 	if err != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid transaction id: %v", err))
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid transaction id: %v", err)
 	}
 
+	tracing.SetTransactionID(ctx, id.String())
+	ctx = logging.WithUserID(logging.WithTransactionID(ctx, id.String()), req.UserID)
+
 	transaction, err := r.TransactionRepository.PollingTransaction(ctx, id)
 	if err != nil {
 		pkgError, _ := err.(*pkg.Error)
 
-		return r.errorRabbitMQResponse(pkg.Errorf(pkgError.Code, pkgError.Message))
+		return nil, pkg.Errorf(pkgError.Code, pkgError.Message)
 	}
 
 	if transaction.UserID != req.UserID {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "cannot access this transaction"))
+		return nil, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "cannot access this transaction")
 	}
 
-	rsp := pollingTransactionResponse{
+	return &pollingTransactionResponse{
 		TransactionID:      transaction.TransactionID.String(),
 		PaydTransactionRef: transaction.PaydTransactionRef,
 		Remarks:            transaction.Message,
@@ -149,12 +189,5 @@ func (r *RabbitConn) handlePollingTransaction(req pollingTransactionRequest) []b
 		NetworkCode:        transaction.NetworkCode,
 		Naration:           transaction.Narration,
 		PaymentStatus:      transaction.Status,
-	}
-
-	rspBytes, marshalErr := json.Marshal(rsp)
-	if marshalErr != nil {
-		return r.errorRabbitMQResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal response from initiate-payment %v", marshalErr))
-	}
-
-	return rspBytes
+	}, nil
 }
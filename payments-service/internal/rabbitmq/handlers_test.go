@@ -2,15 +2,15 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"log"
-	"net/http"
 	"testing"
+	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/mockpb"
 	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	"github.com/brianvoe/gofakeit"
@@ -45,6 +45,20 @@ func mockDistributeSendWithdrawalRequestTaskFunc(
 
 func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 	r := NewTestRabbitHandler()
+	r.rabbit.config.REQUIRE_VERIFIED_EMAIL = true
+	r.rabbit.config.WITHDRAWAL_CONFIRMATION_THRESHOLD = 1000
+	r.rabbit.config.WITHDRAWAL_CONFIRMATION_DURATION = 5 * time.Minute
+
+	r.WithdrawalConfirmationRepository.CreateWithdrawalConfirmationFunc = func(
+		_ context.Context,
+		c repository.WithdrawalConfirmation,
+	) (*repository.WithdrawalConfirmation, error) {
+		if c.Amount == 9999 {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create withdrawal confirmation")
+		}
+
+		return &c, nil
+	}
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -65,6 +79,7 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 			PaydUsernameKey: emailEncrypted,
 			PaydPasswordKey: emailEncrypted,
 			PaydAccountId:   "test",
+			EmailVerified:   in.GetEmail() != "unverified",
 		}, nil
 	}
 
@@ -84,6 +99,7 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 				PhoneNumber: "test",
 				NetworkCode: "test",
 				Naration:    "test",
+				UserID:      32,
 			},
 			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
 				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
@@ -105,6 +121,7 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 				PhoneNumber: "test",
 				NetworkCode: "test",
 				Naration:    "test",
+				UserID:      32,
 			},
 			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
 				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
@@ -126,13 +143,14 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 				PhoneNumber: "test",
 				NetworkCode: "test",
 				Naration:    "test",
+				UserID:      32,
 			},
 			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
 				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
 					DoAndReturn(pbGetUserStub).Times(1)
 			},
-			wantRsp: errorResponse{
-				Status:  http.StatusBadRequest,
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeInvalid,
 				Message: "invalid action: invalid",
 			},
 			wantErr: true,
@@ -146,17 +164,104 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 				PhoneNumber: "test",
 				NetworkCode: "test",
 				Naration:    "test",
+				UserID:      32,
 			},
 			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, _ any) {
 				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
 					Return(nil, errors.New("user not found")).Times(1)
 			},
-			wantRsp: errorResponse{
-				Status:  http.StatusInternalServerError,
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeInternal,
 				Message: "failed to get user data from auth: user not found",
 			},
 			wantErr: true,
 		},
+		{
+			name: "account of another user",
+			req: initiatePaymentRequest{
+				Email:       "test",
+				Action:      "payment",
+				Amount:      100,
+				PhoneNumber: "test",
+				NetworkCode: "test",
+				Naration:    "test",
+				UserID:      7,
+			},
+			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
+				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
+					DoAndReturn(pbGetUserStub).Times(1)
+			},
+			wantRsp: envelope.Error{
+				Code:    envelope.CodePermissionDenied,
+				Message: "cannot initiate payments for another account",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unverified email",
+			req: initiatePaymentRequest{
+				Email:       "unverified",
+				Action:      "payment",
+				Amount:      100,
+				PhoneNumber: "test",
+				NetworkCode: "test",
+				Naration:    "test",
+				UserID:      32,
+			},
+			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
+				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
+					DoAndReturn(pbGetUserStub).Times(1)
+			},
+			wantRsp: envelope.Error{
+				Code:    envelope.CodePermissionDenied,
+				Message: "email address is not verified",
+			},
+			wantErr: true,
+		},
+		{
+			name: "withdrawal held for confirmation",
+			req: initiatePaymentRequest{
+				Email:       "test",
+				Action:      "withdrawal",
+				Amount:      5000,
+				PhoneNumber: "test",
+				NetworkCode: "test",
+				Naration:    "test",
+				UserID:      32,
+			},
+			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
+				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
+					DoAndReturn(pbGetUserStub).Times(1)
+			},
+			wantRsp: initiatePaymentResponse{
+				TransactionID:        "test",
+				PaymentStatus:        false,
+				Action:               "withdrawal",
+				ConfirmationRequired: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed to hold withdrawal",
+			req: initiatePaymentRequest{
+				Email:       "test",
+				Action:      "withdrawal",
+				Amount:      9999,
+				PhoneNumber: "test",
+				NetworkCode: "test",
+				Naration:    "test",
+				UserID:      32,
+			},
+			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
+				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
+					DoAndReturn(pbGetUserStub).Times(1)
+			},
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeInternal,
+				Message: "error holding withdrawal for confirmation: failed to create withdrawal confirmation",
+			},
+			wantErr: true,
+		},
 		{
 			name: "task distribution error",
 			req: initiatePaymentRequest{
@@ -166,13 +271,14 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 				PhoneNumber: "test",
 				NetworkCode: "test",
 				Naration:    "test",
+				UserID:      32,
 			},
 			buildPbStubs: func(mockedClient *mockpb.MockAuthenticationServiceClient, email string, pbGetUserStub any) {
 				mockedClient.EXPECT().GetUser(gomock.Any(), &pb.GetUserRequest{Email: email}).
 					DoAndReturn(pbGetUserStub).Times(1)
 			},
-			wantRsp: errorResponse{
-				Status:  http.StatusInternalServerError,
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeInternal,
 				Message: "failed to distribute payment task: invalid payload",
 			},
 			wantErr: true,
@@ -183,28 +289,24 @@ func TestRabbitConn_handleInitiatePayment(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.buildPbStubs(mockedClient, tc.req.Email, pbGetUserStub)
 
-			rspBytes := r.rabbit.handleInitiatePayment(tc.req)
+			rsp, pkgErr := r.rabbit.handleInitiatePayment(context.Background(), tc.req)
 
 			if tc.wantErr {
-				var rsp errorResponse
-
-				err := json.Unmarshal(rspBytes, &rsp)
-				require.NoError(t, err)
+				require.NotNil(t, pkgErr)
 
-				rabbitError, ok := tc.wantRsp.(errorResponse)
+				rabbitError, ok := tc.wantRsp.(envelope.Error)
 				require.True(t, ok)
 
-				require.Equal(t, rabbitError.Message, rsp.Message)
-				require.Equal(t, rabbitError.Status, rsp.Status)
+				require.Equal(t, rabbitError.Message, pkgErr.Message)
+				require.Equal(t, rabbitError.Code, convertPkgError(pkgErr.Code))
 			} else {
-				var rsp initiatePaymentResponse
-
-				err := json.Unmarshal(rspBytes, &rsp)
-				require.NoError(t, err)
+				require.Nil(t, pkgErr)
 
 				rabbitRsp, _ := tc.wantRsp.(initiatePaymentResponse)
 
 				require.Equal(t, rabbitRsp.Action, rsp.Action)
+				require.Equal(t, rabbitRsp.ConfirmationRequired, rsp.ConfirmationRequired)
+				require.Equal(t, rabbitRsp.ConfirmationRequired, rsp.ConfirmationExpiresAt != nil)
 			}
 		})
 	}
@@ -262,8 +364,8 @@ func TestRabbitConn_handlePollingTransaction(t *testing.T) {
 				TransactionId: "invalid uuid",
 				UserID:        1,
 			},
-			wantRsp: errorResponse{
-				Status:  http.StatusBadRequest,
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeInvalid,
 				Message: "invalid transaction id: invalid UUID length: 12",
 			},
 			wantErr: true,
@@ -274,8 +376,8 @@ func TestRabbitConn_handlePollingTransaction(t *testing.T) {
 				TransactionId: gofakeit.UUID(),
 				UserID:        32,
 			},
-			wantRsp: errorResponse{
-				Status:  http.StatusUnauthorized,
+			wantRsp: envelope.Error{
+				Code:    envelope.CodeUnauthenticated,
 				Message: "cannot access this transaction",
 			},
 			wantErr: true,
@@ -284,23 +386,18 @@ func TestRabbitConn_handlePollingTransaction(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			rspBytes := r.rabbit.handlePollingTransaction(tc.req)
+			rsp, pkgErr := r.rabbit.handlePollingTransaction(context.Background(), tc.req)
 
 			if tc.wantErr {
-				var rsp errorResponse
-
-				err := json.Unmarshal(rspBytes, &rsp)
-				require.NoError(t, err)
+				require.NotNil(t, pkgErr)
 
-				rabbitRsp, ok := tc.wantRsp.(errorResponse)
+				rabbitRsp, ok := tc.wantRsp.(envelope.Error)
 				require.True(t, ok)
 
-				require.Equal(t, rabbitRsp.Message, rsp.Message)
-				require.Equal(t, rabbitRsp.Status, rsp.Status)
+				require.Equal(t, rabbitRsp.Message, pkgErr.Message)
+				require.Equal(t, rabbitRsp.Code, convertPkgError(pkgErr.Code))
 			} else {
-				var rsp pollingTransactionResponse
-				err := json.Unmarshal(rspBytes, &rsp)
-				require.NoError(t, err)
+				require.Nil(t, pkgErr)
 
 				rabbitRsp, ok := tc.wantRsp.(pollingTransactionResponse)
 				require.True(t, ok)
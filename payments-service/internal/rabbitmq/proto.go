@@ -0,0 +1,79 @@
+package rabbitmq
+
+import (
+	"github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoRequest is a request that can be read from its protobuf form.
+type protoRequest interface {
+	unmarshalProto(data []byte) error
+}
+
+// protoResponse is a response that can be sent in its protobuf form.
+type protoResponse interface {
+	toProto() proto.Message
+}
+
+func (req *initiatePaymentRequest) unmarshalProto(data []byte) error {
+	var msg pb.InitiatePaymentRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = initiatePaymentRequest{
+		Email:       msg.GetEmail(),
+		Action:      msg.GetAction(),
+		Amount:      msg.GetAmount(),
+		PhoneNumber: msg.GetPhoneNumber(),
+		NetworkCode: msg.GetNetworkCode(),
+		Naration:    msg.GetNarration(),
+		UserID:      msg.GetUserId(),
+	}
+
+	return nil
+}
+
+func (rsp *initiatePaymentResponse) toProto() proto.Message {
+	msg := &pb.InitiatePaymentResponse{
+		TransactionId:        rsp.TransactionID,
+		PaymentStatus:        rsp.PaymentStatus,
+		Action:               rsp.Action,
+		ConfirmationRequired: rsp.ConfirmationRequired,
+	}
+
+	if rsp.ConfirmationExpiresAt != nil {
+		msg.ConfirmationExpiresAt = timestamppb.New(*rsp.ConfirmationExpiresAt)
+	}
+
+	return msg
+}
+
+func (req *pollingTransactionRequest) unmarshalProto(data []byte) error {
+	var msg pb.PollingTransactionRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*req = pollingTransactionRequest{
+		UserID:        msg.GetUserId(),
+		TransactionId: msg.GetTransactionId(),
+	}
+
+	return nil
+}
+
+func (rsp *pollingTransactionResponse) toProto() proto.Message {
+	return &pb.PollingTransactionResponse{
+		TransactionId:      rsp.TransactionID,
+		PaydTransactionRef: rsp.PaydTransactionRef,
+		Remarks:            rsp.Remarks,
+		Action:             rsp.Action,
+		Amount:             int64(rsp.Amount),
+		PhoneNumber:        rsp.PhoneNumber,
+		NetworkCode:        rsp.NetworkCode,
+		Narration:          rsp.Naration,
+		PaymentStatus:      rsp.PaymentStatus,
+	}
+}
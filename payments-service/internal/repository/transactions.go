@@ -64,5 +64,6 @@ func (t *Transaction) Validate() error {
 type TransactionRepository interface {
 	CreateTransaction(context.Context, Transaction) (*Transaction, error)
 	PollingTransaction(context.Context, uuid.UUID) (*Transaction, error)
+	ListTransactions(ctx context.Context, userID int64, limit, offset int32) ([]Transaction, error)
 	UpdateTransaction(context.Context, uuid.UUID, TransactionUpdate) (*Transaction, error)
 }
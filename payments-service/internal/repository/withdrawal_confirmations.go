@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WithdrawalConfirmation is a withdrawal held back until the user confirms it with
+// their password, or a code when they use two-factor authentication. It is sent under
+// its TransactionID once confirmed, and never if it expires first.
+type WithdrawalConfirmation struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	UserID        int64     `json:"user_id"`
+	Email         string    `json:"email"`
+	Amount        int64     `json:"amount"`
+	PhoneNumber   string    `json:"phone_number"`
+	NetworkCode   string    `json:"network_code"`
+	Narration     string    `json:"narration"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type WithdrawalConfirmationRepository interface {
+	// CreateWithdrawalConfirmation holds a withdrawal back until it is confirmed. The
+	// confirmations that expired are deleted on the way.
+	CreateWithdrawalConfirmation(context.Context, WithdrawalConfirmation) (*WithdrawalConfirmation, error)
+	// GetWithdrawalConfirmation returns the withdrawal waiting for confirmation under
+	// id. It fails with NOT_FOUND_ERROR once the withdrawal was confirmed or expired.
+	GetWithdrawalConfirmation(ctx context.Context, id uuid.UUID) (*WithdrawalConfirmation, error)
+	// ConfirmWithdrawal marks the withdrawal of userID under id confirmed, which only
+	// succeeds once: it fails with NOT_FOUND_ERROR when the withdrawal was confirmed or
+	// expired in the meantime, so that it is never sent twice.
+	ConfirmWithdrawal(ctx context.Context, id uuid.UUID, userID int64) (*WithdrawalConfirmation, error)
+	// ReleaseWithdrawal lets the withdrawal of userID under id, confirmed but not sent,
+	// be confirmed again until it expires.
+	ReleaseWithdrawal(ctx context.Context, id uuid.UUID, userID int64) error
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
@@ -19,14 +20,22 @@ type SendPaymentWithdrawalRequestPayload struct {
 	PaydAccountID      string    `json:"payd_account_id"`
 	PaydPasswordApiKey string    `json:"payd_password_api_key"`
 	PaydUsernameApiKey string    `json:"payd_username_api_key"`
+
+	RequestID    string            `json:"request_id,omitempty"`
+	TraceCarrier map[string]string `json:"trace_carrier,omitempty"`
 }
 
 type TaskProcessor interface {
 	Start() error
+	Shutdown(ctx context.Context) error
 	ProcessPaymentRequestTask(ctx context.Context, task *asynq.Task) error
 	ProcessWithdrawalRequestTask(ctx context.Context, task *asynq.Task) error
 }
 
+// ErrNotEnqueued is returned along with the errors of a TaskDistributor when the task
+// certainly did not reach redis. After any other error the task may have been enqueued.
+var ErrNotEnqueued = errors.New("task not enqueued")
+
 type TaskDistributor interface {
 	DistributeSendPaymentRequestTask(ctx context.Context, payload SendPaymentWithdrawalRequestPayload, opt ...asynq.Option) error
 	DistributeSendWithdrawalRequestTask(ctx context.Context, payload SendPaymentWithdrawalRequestPayload, opt ...asynq.Option) error
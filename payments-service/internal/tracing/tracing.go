@@ -0,0 +1,399 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const serviceName = "payments-service"
+
+// Exporters supported by Setup.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// TransactionIDKey is the span attribute holding the ID of a transaction.
+const TransactionIDKey = attribute.Key("transaction.id")
+
+const instrumentationName = "github.com/EmilioCliff/payment-polling-app/payment-service"
+
+// tracer is looked up on every use so spans follow the provider installed by Setup.
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+// Spans are sent over OTLP/gRPC to endpoint or printed to stdout depending on exporter,
+// any other value keeps propagating incoming context without recording spans.
+// The returned function flushes the pending spans and must be called before exiting.
+func Setup(ctx context.Context, exporter string, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		err          error
+	)
+
+	switch exporter {
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// GinMiddleware starts a server span for every request, continuing the trace of
+// the caller when it sent a traceparent header. Handlers get the span through
+// ctx.Request.Context().
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		c, span := tracer().Start(parent, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(c)
+
+		ctx.Next()
+
+		statusCode := ctx.Writer.Status()
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+}
+
+// StartClientRequest starts a client span for an outgoing HTTP request and writes its
+// context into the request headers. The span is finished by EndClientRequest.
+func StartClientRequest(ctx context.Context, req *http.Request) trace.Span {
+	ctx, span := tracer().Start(ctx, req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Host),
+		),
+	)
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return span
+}
+
+// EndClientRequest records the outcome of a request started with StartClientRequest
+// and ends its span.
+func EndClientRequest(span trace.Span, res *http.Response, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+
+		return
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+
+	if res.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+}
+
+// UnaryClientInterceptor starts a client span for every unary call and sends its
+// context to the server in the call metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx, span := tracer().Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(method)),
+		)
+		defer span.End()
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+		err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+
+		st := status.Convert(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+		if err != nil {
+			span.SetStatus(codes.Error, st.Message())
+		}
+
+		return err
+	}
+}
+
+// UnaryServerInterceptor continues the trace of the client, if any, in a server span
+// for every unary call.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		rsp, err := handler(ctx, req)
+
+		endServerSpan(span, err)
+
+		return rsp, err
+	}
+}
+
+// StreamServerInterceptor does for streaming calls what UnaryServerInterceptor does
+// for unary ones, the span lasting as long as the stream.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+
+		endServerSpan(span, err)
+
+		return err
+	}
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	return tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(method)),
+	)
+}
+
+func endServerSpan(span trace.Span, err error) {
+	st := status.Convert(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+	if err != nil {
+		span.SetStatus(codes.Error, st.Message())
+	}
+}
+
+// serverStream is a server stream handing its handler a context of its own.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StartConsume starts a consumer span for a message delivered with routingKey,
+// continuing the trace whose context the publisher put in headers.
+func StartConsume(ctx context.Context, routingKey string, headers amqp.Table) (context.Context, trace.Span) {
+	if headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(headers))
+	}
+
+	return tracer().Start(ctx, routingKey+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingRabbitmqDestinationRoutingKey(routingKey),
+		),
+	)
+}
+
+// AMQPHeaders returns message headers carrying the trace context of ctx.
+func AMQPHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+
+	return headers
+}
+
+// TaskCarrier returns the trace context of ctx in a form that travels inside an asynq
+// task payload.
+func TaskCarrier(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	return carrier
+}
+
+// AsynqMiddleware starts a consumer span for every task handled by the asynq server,
+// continuing the trace stored in the "trace_carrier" field of the task payload.
+func AsynqMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		var payload struct {
+			TraceCarrier map[string]string `json:"trace_carrier"`
+		}
+
+		if err := json.Unmarshal(task.Payload(), &payload); err == nil {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(payload.TraceCarrier))
+		}
+
+		ctx, span := tracer().Start(ctx, task.Type()+" process", trace.WithSpanKind(trace.SpanKindConsumer))
+		defer span.End()
+
+		err := next.ProcessTask(ctx, task)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		return err
+	})
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or an empty string
+// when there is none.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
+// StartCallback starts the span of a Payd callback. Payd calls back long after the task
+// that sent the request has finished, so instead of a parent the span gets a link to
+// the span identified by traceparent, which the task put on the callback URL.
+func StartCallback(ctx context.Context, traceparent string, transactionID string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(TransactionIDKey.String(transactionID)),
+	}
+
+	origin := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceparent})
+	if spanContext := trace.SpanContextFromContext(origin); spanContext.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: spanContext}))
+	}
+
+	return tracer().Start(ctx, "payd callback", opts...)
+}
+
+// SetTransactionID tags the span in ctx with the transaction it works on, which is
+// how the spans of a transaction are found once the callback arrives.
+func SetTransactionID(ctx context.Context, transactionID string) {
+	trace.SpanFromContext(ctx).SetAttributes(TransactionIDKey.String(transactionID))
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+
+	return value
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestProvider(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+func TestStartConsume(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	ctx, publisher := otel.Tracer("test").Start(context.Background(), "publish")
+	headers := AMQPHeaders(ctx)
+	publisher.End()
+
+	_, span := StartConsume(context.Background(), "payments.initiate_payment", headers)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "payments.initiate_payment process", spans[1].Name())
+	require.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	require.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func TestAsynqMiddleware(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	ctx, consumer := otel.Tracer("test").Start(context.Background(), "consume")
+
+	payload, err := json.Marshal(map[string]any{
+		"transaction_id": "f0e6b1c4-3d52-4a53-9ad1-7d2b3f3ad0a1",
+		"trace_carrier":  TaskCarrier(ctx),
+	})
+	require.NoError(t, err)
+
+	consumer.End()
+
+	var taskSpan trace.SpanContext
+
+	handler := AsynqMiddleware(asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		taskSpan = trace.SpanContextFromContext(ctx)
+
+		return nil
+	}))
+
+	err = handler.ProcessTask(context.Background(), asynq.NewTask("task:payment_request", payload))
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "task:payment_request process", spans[1].Name())
+	require.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	require.Equal(t, spans[1].SpanContext().SpanID(), taskSpan.SpanID())
+}
+
+func TestStartCallback(t *testing.T) {
+	recorder := newTestProvider(t)
+
+	ctx, task := otel.Tracer("test").Start(context.Background(), "task")
+	traceparent := TraceParent(ctx)
+	task.End()
+
+	require.NotEmpty(t, traceparent)
+
+	_, span := StartCallback(context.Background(), traceparent, "f0e6b1c4-3d52-4a53-9ad1-7d2b3f3ad0a1")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	callback := spans[1]
+	require.NotEqual(t, spans[0].SpanContext().TraceID(), callback.SpanContext().TraceID())
+	require.Len(t, callback.Links(), 1)
+	require.Equal(t, spans[0].SpanContext().SpanID(), callback.Links()[0].SpanContext.SpanID())
+	require.Contains(t, callback.Attributes(), TransactionIDKey.String("f0e6b1c4-3d52-4a53-9ad1-7d2b3f3ad0a1"))
+
+	_, span = StartCallback(context.Background(), "", "f0e6b1c4-3d52-4a53-9ad1-7d2b3f3ad0a1")
+	span.End()
+
+	require.Empty(t, recorder.Ended()[2].Links())
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestServerInterceptors(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	))
+
+	t.Run("unary", func(t *testing.T) {
+		recorder := newTestProvider(t)
+
+		info := &grpc.UnaryServerInfo{FullMethod: "/pb.paymentsService/GetTransaction"}
+
+		handler := func(_ context.Context, _ any) (any, error) {
+			return nil, status.Error(codes.NotFound, "transaction does not exist")
+		}
+
+		_, err := UnaryServerInterceptor()(ctx, nil, info, handler)
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		require.Equal(t, "transaction does not exist", spans[0].Status().Description)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		recorder := newTestProvider(t)
+
+		info := &grpc.StreamServerInfo{FullMethod: "/pb.paymentsService/WatchTransaction", IsServerStream: true}
+
+		var traceID string
+
+		handler := func(_ any, stream grpc.ServerStream) error {
+			traceID = trace.SpanContextFromContext(stream.Context()).TraceID().String()
+
+			return nil
+		}
+
+		err := StreamServerInterceptor()(nil, fakeServerStream{ctx: ctx}, info, handler)
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+		require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	})
+}
@@ -2,9 +2,16 @@ package workers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
@@ -39,5 +46,34 @@ func (p *RedisTaskProcessor) createTransaction(
 		return err
 	}
 
+	if status == "failed" {
+		metrics.CountTransaction(req.Action, metrics.TransactionFailed)
+	} else {
+		metrics.CountTransaction(req.Action, metrics.TransactionInitiated)
+	}
+
 	return nil
 }
+
+// callbackURL is where Payd reports the result of transactionID. It carries the
+// traceparent of the task so the callback can be linked back to its trace.
+func (p *RedisTaskProcessor) callbackURL(ctx context.Context, transactionID uuid.UUID) string {
+	callbackURL := fmt.Sprintf("%s/transaction/%v", p.config.PAYD_CALLBACK_URL, transactionID.String())
+
+	if traceparent := tracing.TraceParent(ctx); traceparent != "" {
+		callbackURL += "?traceparent=" + url.QueryEscape(traceparent)
+	}
+
+	return callbackURL
+}
+
+// enqueueError tells the errors of enqueueing a task apart. Only a failure to connect to
+// redis means the task was not sent, after others it may have been enqueued.
+func enqueueError(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return fmt.Errorf("%w: failed to enqueue task: %w", services.ErrNotEnqueued, err)
+	}
+
+	return fmt.Errorf("failed to enqueue task: %w", err)
+}
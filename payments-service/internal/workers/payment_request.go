@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
 	"github.com/hibiken/asynq"
 )
@@ -21,19 +25,22 @@ func (distributor *RedisTaskDistributor) DistributeSendPaymentRequestTask(
 	payload services.SendPaymentWithdrawalRequestPayload,
 	opt ...asynq.Option,
 ) error {
+	payload.RequestID = logging.RequestID(ctx)
+	payload.TraceCarrier = tracing.TaskCarrier(ctx)
+
 	jsonPaymentRequestPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("Failed to marshal payload: %w", err)
+		return fmt.Errorf("%w: failed to marshal payload: %w", services.ErrNotEnqueued, err)
 	}
 
 	task := asynq.NewTask(SendPaymentRequestTask, jsonPaymentRequestPayload, opt...)
 
 	info, err := distributor.client.EnqueueContext(ctx, task)
 	if err != nil {
-		return fmt.Errorf("failed to enqueue task: %w", err)
+		return enqueueError(err)
 	}
 
-	log.Printf("Enqueued task: %s\n", info.ID)
+	slog.InfoContext(ctx, "enqueued task", "task_id", info.ID, "type", task.Type())
 
 	return nil
 }
@@ -44,6 +51,8 @@ func (processor *RedisTaskProcessor) ProcessPaymentRequestTask(ctx context.Conte
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
+	tracing.SetTransactionID(ctx, taskPayload.TransactionID.String())
+
 	payload := map[string]interface{}{
 		"username":     taskPayload.PaydUsername,
 		"network_code": taskPayload.NetworkCode,
@@ -51,7 +60,7 @@ func (processor *RedisTaskProcessor) ProcessPaymentRequestTask(ctx context.Conte
 		"phone_number": taskPayload.PhoneNumber,
 		"narration":    taskPayload.Naration,
 		"currency":     "KES",
-		"callback_url": fmt.Sprintf("%s/transaction/%v", processor.config.PAYD_CALLBACK_URL, taskPayload.TransactionID.String()),
+		"callback_url": processor.callbackURL(ctx, taskPayload.TransactionID),
 	}
 
 	jsonPayload, err := json.Marshal(payload)
@@ -71,12 +80,20 @@ func (processor *RedisTaskProcessor) ProcessPaymentRequestTask(ctx context.Conte
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(taskPayload.PaydUsernameApiKey, taskPayload.PaydPasswordApiKey)
 
+	span := tracing.StartClientRequest(ctx, req)
+	start := time.Now()
+
 	res, err := client.Do(req)
+	tracing.EndClientRequest(span, res, err)
 	if err != nil {
+		metrics.ObservePaydRequest("payment", 0, start)
+
 		return fmt.Errorf("Failed to send request: %w", err)
 	}
 	defer res.Body.Close()
 
+	metrics.ObservePaydRequest("payment", res.StatusCode, start)
+
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("Failed to read response body: %w", err)
@@ -3,11 +3,14 @@ package workers
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/repository"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
 	"github.com/hibiken/asynq"
 )
@@ -36,6 +39,9 @@ func NewRedisTaskProcessor(redisOpt *asynq.RedisClientOpt, config pkg.Config) *R
 	server := asynq.NewServer(redisOpt, asynq.Config{
 		RetryDelayFunc: asynq.RetryDelayFunc(CustomRetryDelayFunc),
 		ErrorHandler:   asynq.ErrorHandlerFunc(ReportError),
+		// tasks still running after the timeout are abandoned and retried once their
+		// lease expires
+		ShutdownTimeout: config.SHUTDOWN_TIMEOUT,
 		Queues: map[string]int{
 			QueueCritical: 10,
 		},
@@ -51,6 +57,9 @@ func NewRedisTaskProcessor(redisOpt *asynq.RedisClientOpt, config pkg.Config) *R
 
 func (processor *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
+	mux.Use(logging.AsynqMiddleware)
+	mux.Use(tracing.AsynqMiddleware)
+	mux.Use(metrics.AsynqMiddleware)
 
 	mux.HandleFunc(SendPaymentRequestTask, processor.ProcessPaymentRequestTask)
 	mux.HandleFunc(SendWithdrawalRequestTask, processor.ProcessWithdrawalRequestTask)
@@ -58,6 +67,24 @@ func (processor *RedisTaskProcessor) Start() error {
 	return processor.server.Start(mux)
 }
 
+// Shutdown stops pulling tasks from redis and waits for the running ones to finish,
+// returning early when ctx expires.
+func (processor *RedisTaskProcessor) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		processor.server.Shutdown()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func CustomRetryDelayFunc(_ int, _ error, _ *asynq.Task) time.Duration {
 	return 500 * time.Millisecond
 }
@@ -70,7 +97,6 @@ func ReportError(ctx context.Context, task *asynq.Task, err error) {
 		err = fmt.Errorf("retry exhausted for task %s: %w", task.Type(), err)
 	}
 
-	// log it or something
 	// errorReportingService.Notify(err)
-	log.Println(err)
+	slog.ErrorContext(ctx, "task error", "type", task.Type(), "error", err)
 }
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/logging"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/metrics"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
+	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/tracing"
 	"github.com/EmilioCliff/payment-polling-app/payment-service/pkg"
 	"github.com/hibiken/asynq"
 )
@@ -21,19 +25,22 @@ func (distributor *RedisTaskDistributor) DistributeSendWithdrawalRequestTask(
 	payload services.SendPaymentWithdrawalRequestPayload,
 	opt ...asynq.Option,
 ) error {
+	payload.RequestID = logging.RequestID(ctx)
+	payload.TraceCarrier = tracing.TaskCarrier(ctx)
+
 	jsonWithdrawalRequestPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("Failed to marshal payload: %w", err)
+		return fmt.Errorf("%w: failed to marshal payload: %w", services.ErrNotEnqueued, err)
 	}
 
 	task := asynq.NewTask(SendWithdrawalRequestTask, jsonWithdrawalRequestPayload, opt...)
 
 	info, err := distributor.client.EnqueueContext(ctx, task)
 	if err != nil {
-		return fmt.Errorf("failed to enqueue task: %w", err)
+		return enqueueError(err)
 	}
 
-	log.Printf("Enqueued task: %s\n", info.ID)
+	slog.InfoContext(ctx, "enqueued task", "task_id", info.ID, "type", task.Type())
 
 	return nil
 }
@@ -44,13 +51,15 @@ func (processor *RedisTaskProcessor) ProcessWithdrawalRequestTask(ctx context.Co
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
+	tracing.SetTransactionID(ctx, taskPayload.TransactionID.String())
+
 	payload := map[string]interface{}{
 		"account_id":   taskPayload.PaydAccountID,
 		"phone_number": taskPayload.PhoneNumber,
 		"amount":       taskPayload.Amount,
 		"narration":    taskPayload.Naration,
 		"channel":      taskPayload.NetworkCode,
-		"callback_url": fmt.Sprintf("%s/transaction/%v", processor.config.PAYD_CALLBACK_URL, taskPayload.TransactionID.String()),
+		"callback_url": processor.callbackURL(ctx, taskPayload.TransactionID),
 	}
 
 	jsonPayload, err := json.Marshal(payload)
@@ -70,12 +79,20 @@ func (processor *RedisTaskProcessor) ProcessWithdrawalRequestTask(ctx context.Co
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(taskPayload.PaydUsernameApiKey, taskPayload.PaydPasswordApiKey)
 
+	span := tracing.StartClientRequest(ctx, req)
+	start := time.Now()
+
 	res, err := client.Do(req)
+	tracing.EndClientRequest(span, res, err)
 	if err != nil {
+		metrics.ObservePaydRequest("withdrawal", 0, start)
+
 		return fmt.Errorf("Failed to send request: %w", err)
 	}
 	defer res.Body.Close()
 
+	metrics.ObservePaydRequest("withdrawal", res.StatusCode, start)
+
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("Failed to read response body: %w", err)
@@ -3,9 +3,11 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/EmilioCliff/payment-polling-app/payment-service/internal/services"
 	"github.com/brianvoe/gofakeit"
@@ -105,3 +107,21 @@ func TestRedisTaskProcessor_ProcessWithdrawalRequestTask(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisTaskDistributor_DistributeSendWithdrawalRequestTask(t *testing.T) {
+	// an address nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	distributor := NewRedisTaskDistributor(&asynq.RedisClientOpt{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the task did not reach redis, so it can be sent again
+	err = distributor.DistributeSendWithdrawalRequestTask(ctx, services.SendPaymentWithdrawalRequestPayload{TransactionID: uuid.New()})
+	require.ErrorIs(t, err, services.ErrNotEnqueued)
+}
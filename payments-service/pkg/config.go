@@ -1,22 +1,47 @@
 package pkg
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
-	HTTP_PORT             string `mapstructure:"HTTP_PORT"`
-	AUTH_GRPC_URL         string `mapstructure:"AUTH_GRPC_URL"`
-	REDDIS_ADDR           string `mapstructure:"REDDIS_ADDR"`
-	PAYMENT_QUEUE_NAME    string `mapstructure:"PAYMENT_QUEUE_NAME"`
-	PAYMENT_CONSUMER_NAME string `mapstructure:"PAYMENT_CONSUMER_NAME"`
-	RABBITMQ_URL          string `mapstructure:"RABBITMQ_URL"`
-	PAYD_CALLBACK_URL     string `mapstructure:"PAYD_CALLBACK_URL"`
-	EXCH                  string `mapstructure:"EXCH"`
-	POSTGRES_USER         string `mapstructure:"POSTGRES_USER"`
-	POSTGRES_PASSWORD     string `mapstructure:"POSTGRES_PASSWORD"`
-	POSTGRES_DB           string `mapstructure:"POSTGRES_DB"`
-	DB_URL                string `mapstructure:"DB_URL"`
-	ENCRYPTION_KEY        string `mapstructure:"ENCRYPTION_KEY"`
-	MIGRATION_PATH        string `mapstructure:"MIGRATION_PATH"`
+	HTTP_PORT              string        `mapstructure:"HTTP_PORT"`
+	GRPC_PORT              string        `mapstructure:"GRPC_PORT"`
+	AUTH_GRPC_URL          string        `mapstructure:"AUTH_GRPC_URL"`
+	REDDIS_ADDR            string        `mapstructure:"REDDIS_ADDR"`
+	PAYMENT_QUEUE_NAME     string        `mapstructure:"PAYMENT_QUEUE_NAME"`
+	PAYMENT_CONSUMER_NAME  string        `mapstructure:"PAYMENT_CONSUMER_NAME"`
+	RABBITMQ_URL           string        `mapstructure:"RABBITMQ_URL"`
+	PAYD_CALLBACK_URL      string        `mapstructure:"PAYD_CALLBACK_URL"`
+	EXCH                   string        `mapstructure:"EXCH"`
+	POSTGRES_USER          string        `mapstructure:"POSTGRES_USER"`
+	POSTGRES_PASSWORD      string        `mapstructure:"POSTGRES_PASSWORD"`
+	POSTGRES_DB            string        `mapstructure:"POSTGRES_DB"`
+	DB_URL                 string        `mapstructure:"DB_URL"`
+	ENCRYPTION_KEY         string        `mapstructure:"ENCRYPTION_KEY"`
+	MIGRATION_PATH         string        `mapstructure:"MIGRATION_PATH"`
+	TRACING_EXPORTER       string        `mapstructure:"TRACING_EXPORTER"`
+	OTLP_ENDPOINT          string        `mapstructure:"OTLP_ENDPOINT"`
+	SHUTDOWN_TIMEOUT       time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	DEAD_LETTER_EXCH       string        `mapstructure:"DEAD_LETTER_EXCH"`
+	CONSUMER_WORKERS       int           `mapstructure:"CONSUMER_WORKERS"`
+	CONSUMER_PREFETCH      int           `mapstructure:"CONSUMER_PREFETCH"`
+	BUS_DRIVER             string        `mapstructure:"BUS_DRIVER"`
+	REQUIRE_VERIFIED_EMAIL bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+
+	WITHDRAWAL_CONFIRMATION_THRESHOLD int64         `mapstructure:"WITHDRAWAL_CONFIRMATION_THRESHOLD"`
+	WITHDRAWAL_CONFIRMATION_DURATION  time.Duration `mapstructure:"WITHDRAWAL_CONFIRMATION_DURATION"`
+}
+
+// NeedsConfirmation reports whether a withdrawal of amount is held back until the user
+// confirms it, which is when it is above WITHDRAWAL_CONFIRMATION_THRESHOLD. A threshold
+// of 0 sends every withdrawal straight away.
+func (c Config) NeedsConfirmation(action string, amount int64) bool {
+	return action == "withdrawal" &&
+		c.WITHDRAWAL_CONFIRMATION_THRESHOLD > 0 &&
+		amount > c.WITHDRAWAL_CONFIRMATION_THRESHOLD
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -12,6 +12,7 @@ const (
 	NOT_FOUND_ERROR       = "not_found"
 	NOT_IMPLEMENTED_ERROR = "not_implemented"
 	AUTHENTICATION_ERROR  = "authentication"
+	PERMISSION_ERROR      = "permission"
 )
 
 type Error struct {
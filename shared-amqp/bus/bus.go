@@ -0,0 +1,106 @@
+// Package bus is the messaging the services rely on, without tying them to a broker.
+// Requests are answered by the one handler registered for their topic and events reach
+// every subscriber of theirs. RabbitMQ carries them between services, and Memory within
+// a process for tests and for running a service without a broker.
+package bus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoHandler is returned when no handler is registered for the topic of a request.
+	ErrNoHandler = errors.New("bus: no handler for topic")
+
+	// ErrUnavailable is returned when a message could not be sent, or its reply was lost,
+	// because the transport went away.
+	ErrUnavailable = errors.New("bus: unavailable")
+
+	// ErrNoReply is returned along with ErrUnavailable when a request was sent but the
+	// broker did not confirm it, or its reply was lost. The handler may have acted on the
+	// request, so it is not safe to send it again.
+	ErrNoReply = errors.New("bus: no reply")
+
+	// ErrClosed is returned once Close has been called.
+	ErrClosed = errors.New("bus: closed")
+)
+
+// Message is a request, reply or event.
+type Message struct {
+	ContentType string
+
+	// Headers carry the request ID and the trace context along with the message.
+	Headers map[string]any
+
+	Body []byte
+
+	// Deadline is when the sender of a request stops waiting for the reply. Requests
+	// delivered after it are dropped unanswered.
+	Deadline time.Time
+
+	// Topic and CorrelationID are set on delivery. CorrelationID is unique to a request
+	// and shared by its reply.
+	Topic         string
+	CorrelationID string
+}
+
+// Handler answers a request. It reports failures the sender should know about in the
+// reply, and returns an error only for requests that can never be handled, such as
+// malformed ones. Those are dropped without a reply: RabbitMQ dead-letters them while
+// Memory hands the error to the sender.
+type Handler func(ctx context.Context, req Message) (Message, error)
+
+// Subscriber handles an event. Events it returns an error for are dropped, to the
+// dead-letter queue on RabbitMQ.
+type Subscriber func(ctx context.Context, event Message) error
+
+// Bus carries requests, replies and events between services.
+type Bus interface {
+	// Request sends req to the handler of topic and waits for the reply until ctx is
+	// done. The deadline of req defaults to the one of ctx.
+	Request(ctx context.Context, topic string, req Message) (Message, error)
+
+	// Publish sends event to the subscribers of topic, if any.
+	Publish(ctx context.Context, topic string, event Message) error
+
+	// Handle registers h to answer the requests sent to topic. Handlers and subscribers
+	// are registered before Serve is called, one per topic.
+	Handle(topic string, h Handler)
+
+	// Subscribe registers s to receive the events published to topic.
+	Subscribe(topic string, s Subscriber)
+
+	// Serve delivers requests and events to the registered handlers and subscribers
+	// until ctx is cancelled or the bus is closed. The deliveries being handled are
+	// finished first.
+	Serve(ctx context.Context) error
+
+	// Ready reports whether messages can be sent.
+	Ready(ctx context.Context) error
+
+	Close() error
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func cloneHeaders(headers map[string]any) map[string]any {
+	if headers == nil {
+		return nil
+	}
+
+	clone := make(map[string]any, len(headers))
+	for key, value := range headers {
+		clone[key] = value
+	}
+
+	return clone
+}
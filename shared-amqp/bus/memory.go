@@ -0,0 +1,165 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Memory is a Bus within a single process. Messages are copied on the way so that
+// handlers can not share memory with their senders, as they could not over a broker.
+type Memory struct {
+	mu          sync.RWMutex
+	handlers    map[string]Handler
+	subscribers map[string][]Subscriber
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Bus = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		handlers:    make(map[string]Handler),
+		subscribers: make(map[string][]Subscriber),
+		done:        make(chan struct{}),
+	}
+}
+
+// Request hands a copy of req to the handler of topic and returns its reply. An error
+// returned by the handler is returned as is.
+func (m *Memory) Request(ctx context.Context, topic string, req Message) (Message, error) {
+	if m.isClosed() {
+		return Message{}, ErrClosed
+	}
+
+	m.mu.RLock()
+	h, ok := m.handlers[topic]
+	m.mu.RUnlock()
+
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrNoHandler, topic)
+	}
+
+	req = deliver(req, topic, newCorrelationID())
+	if req.Deadline.IsZero() {
+		req.Deadline, _ = ctx.Deadline()
+	}
+
+	type result struct {
+		reply Message
+		err   error
+	}
+
+	replied := make(chan result, 1)
+
+	go func() {
+		// like a handler behind a broker, it does not stop when the sender gives up
+		reply, err := h(context.Background(), req)
+		replied <- result{reply, err}
+	}()
+
+	select {
+	case r := <-replied:
+		if r.err != nil {
+			return Message{}, r.err
+		}
+
+		return deliver(r.reply, topic, req.CorrelationID), nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// Publish hands a copy of event to each subscriber of topic before returning. Errors of
+// the subscribers are logged, the event is dropped for them.
+func (m *Memory) Publish(ctx context.Context, topic string, event Message) error {
+	if m.isClosed() {
+		return ErrClosed
+	}
+
+	m.mu.RLock()
+	subscribers := m.subscribers[topic]
+	m.mu.RUnlock()
+
+	for _, s := range subscribers {
+		if err := s(context.WithoutCancel(ctx), deliver(event, topic, "")); err != nil {
+			slog.ErrorContext(ctx, "failed to handle event", "topic", topic, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Handle registers h to answer the requests sent to topic. It panics if topic already
+// has a handler.
+func (m *Memory) Handle(topic string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.handlers[topic]; ok {
+		panic("bus: handler already registered for " + topic)
+	}
+
+	m.handlers[topic] = h
+}
+
+// Subscribe registers s to receive the events published to topic.
+func (m *Memory) Subscribe(topic string, s Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscribers[topic] = append(m.subscribers[topic], s)
+}
+
+// Serve blocks until ctx is cancelled or the bus is closed. Messages are delivered as
+// they are sent, whether Serve runs or not.
+func (m *Memory) Serve(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+	case <-m.done:
+	}
+
+	return nil
+}
+
+// Ready reports whether the bus is still open.
+func (m *Memory) Ready(_ context.Context) error {
+	if m.isClosed() {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+
+	return nil
+}
+
+func (m *Memory) isClosed() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliver returns a copy of msg as delivered on topic.
+func deliver(msg Message, topic, correlationID string) Message {
+	return Message{
+		ContentType:   msg.ContentType,
+		Headers:       cloneHeaders(msg.Headers),
+		Body:          bytes.Clone(msg.Body),
+		Deadline:      msg.Deadline,
+		Topic:         topic,
+		CorrelationID: correlationID,
+	}
+}
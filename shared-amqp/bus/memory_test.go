@@ -0,0 +1,127 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Request(t *testing.T) {
+	b := NewMemory()
+
+	b.Handle("authentication.login_user", func(_ context.Context, req Message) (Message, error) {
+		require.Equal(t, "authentication.login_user", req.Topic)
+		require.NotEmpty(t, req.CorrelationID)
+		require.False(t, req.Deadline.IsZero())
+
+		// the sender does not see changes to what it sent
+		req.Headers["X-Request-ID"] = "changed"
+
+		return Message{ContentType: req.ContentType, Body: append([]byte("reply to "), req.Body...)}, nil
+	})
+
+	headers := map[string]any{"X-Request-ID": "req-1"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := b.Request(ctx, "authentication.login_user", Message{
+		ContentType: "text/plain",
+		Headers:     headers,
+		Body:        []byte("login"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "reply to login", string(reply.Body))
+	require.Equal(t, "text/plain", reply.ContentType)
+	require.Equal(t, "authentication.login_user", reply.Topic)
+	require.Equal(t, "req-1", headers["X-Request-ID"])
+}
+
+func TestMemory_RequestFailures(t *testing.T) {
+	b := NewMemory()
+
+	errMalformed := errors.New("malformed request")
+
+	b.Handle("payments.initiate_payment", func(context.Context, Message) (Message, error) {
+		return Message{}, errMalformed
+	})
+
+	b.Handle("payments.poll_payments", func(context.Context, Message) (Message, error) {
+		time.Sleep(100 * time.Millisecond)
+
+		return Message{}, nil
+	})
+
+	_, err := b.Request(context.Background(), "payments.refund_payment", Message{})
+	require.ErrorIs(t, err, ErrNoHandler)
+
+	_, err = b.Request(context.Background(), "payments.initiate_payment", Message{})
+	require.ErrorIs(t, err, errMalformed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = b.Request(ctx, "payments.poll_payments", Message{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, b.Close())
+	require.ErrorIs(t, b.Ready(context.Background()), ErrClosed)
+
+	_, err = b.Request(context.Background(), "payments.poll_payments", Message{})
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestMemory_Publish(t *testing.T) {
+	b := NewMemory()
+
+	var got []string
+
+	for _, name := range []string{"payments", "notifications"} {
+		name := name
+
+		b.Subscribe("users.registered", func(_ context.Context, event Message) error {
+			got = append(got, name+": "+string(event.Body))
+
+			return nil
+		})
+	}
+
+	b.Subscribe("users.registered", func(context.Context, Message) error {
+		return errors.New("failed")
+	})
+
+	require.NoError(t, b.Publish(context.Background(), "users.registered", Message{Body: []byte("jane")}))
+	require.Equal(t, []string{"payments: jane", "notifications: jane"}, got)
+
+	// nobody subscribed
+	require.NoError(t, b.Publish(context.Background(), "users.deleted", Message{}))
+}
+
+func TestMemory_Handle_Twice(t *testing.T) {
+	b := NewMemory()
+
+	h := func(context.Context, Message) (Message, error) { return Message{}, nil }
+
+	b.Handle("authentication.login_user", h)
+	require.Panics(t, func() { b.Handle("authentication.login_user", h) })
+}
+
+func TestMemory_Serve(t *testing.T) {
+	b := NewMemory()
+
+	served := make(chan error, 1)
+
+	go func() { served <- b.Serve(context.Background()) }()
+
+	require.NoError(t, b.Close())
+
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Serve did not return after Close")
+	}
+}
@@ -0,0 +1,514 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQOptions configures a RabbitMQ bus.
+type RabbitMQOptions struct {
+	// Exchange is the topic exchange requests and events are published to, with their
+	// topic as the routing key.
+	Exchange string
+
+	// DeadLetterExchange receives the requests and events of Queue that could not be
+	// handled or expired, which are kept in Queue+".dead" for inspection.
+	DeadLetterExchange string
+
+	// Queue receives the requests and events of the topics registered with Handle and
+	// Subscribe. It is shared by the instances of a service, which take turns.
+	Queue    string
+	Consumer string
+
+	// Prefetch caps how many deliveries of Queue are handled or waiting to be, and
+	// Workers how many are handled at the same time.
+	Prefetch int
+	Workers  int
+
+	// ReplyQueue, when set, names the queue this instance receives the replies to its
+	// requests on, suffixed with an ID of the instance. Without it Request fails.
+	ReplyQueue string
+}
+
+// RabbitMQ is a Bus over a RabbitMQ connection. Requests and events are persistent and
+// published with confirms. A request expires with its deadline, and its reply goes
+// through the default exchange straight to the reply queue of the sender.
+type RabbitMQ struct {
+	conn       *rabbit.Conn
+	opts       RabbitMQOptions
+	replyQueue string
+
+	// publish is conn.Publish, replaced in tests.
+	publish func(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+
+	mu          sync.RWMutex
+	handlers    map[string]Handler
+	subscribers map[string]Subscriber
+
+	// pending holds the requests waiting for their reply, by correlation ID.
+	pendingMu sync.Mutex
+	pending   map[string]chan amqp.Delivery
+}
+
+var _ Bus = (*RabbitMQ)(nil)
+
+// NewRabbitMQ returns a bus over conn, which it closes along with itself. The exchange
+// and the reply queue are declared right away, on conn and every connection made after
+// it.
+func NewRabbitMQ(conn *rabbit.Conn, opts RabbitMQOptions) (*RabbitMQ, error) {
+	b := &RabbitMQ{
+		conn:        conn,
+		opts:        opts,
+		publish:     conn.Publish,
+		handlers:    make(map[string]Handler),
+		subscribers: make(map[string]Subscriber),
+		pending:     make(map[string]chan amqp.Delivery),
+	}
+
+	if opts.ReplyQueue != "" {
+		b.replyQueue = opts.ReplyQueue + "." + newCorrelationID()
+	}
+
+	err := conn.Declare(func(ch *amqp.Channel) error {
+		if err := declareExchange(ch, opts.Exchange); err != nil {
+			return err
+		}
+
+		if b.replyQueue == "" {
+			return nil
+		}
+
+		// exclusive to the connection, declared again under the same name on the next one
+		_, err := ch.QueueDeclare(
+			b.replyQueue, // name
+			false,        // durable
+			true,         // delete when unused
+			true,         // exclusive
+			false,        // no-wait
+			nil,          // arguments
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ReplyQueue returns the name of the queue the replies to this instance arrive on.
+func (b *RabbitMQ) ReplyQueue() string {
+	return b.replyQueue
+}
+
+// Request publishes req to topic and waits for the reply on the reply queue. It fails
+// with ErrNoHandler when no queue is bound to topic, and with ErrUnavailable when the
+// broker could not be reached before ctx expired, did not confirm the request, or the
+// connection went away while waiting. The last two are also ErrNoReply, as the request
+// was sent by then.
+func (b *RabbitMQ) Request(ctx context.Context, topic string, req Message) (Message, error) {
+	if b.replyQueue == "" {
+		return Message{}, errors.New("bus: requests need a reply queue")
+	}
+
+	correlationID := newCorrelationID()
+
+	replied := make(chan amqp.Delivery, 1)
+
+	b.pendingMu.Lock()
+	b.pending[correlationID] = replied
+	b.pendingMu.Unlock()
+
+	defer func() {
+		b.pendingMu.Lock()
+		delete(b.pending, correlationID)
+		b.pendingMu.Unlock()
+	}()
+
+	deadline := req.Deadline
+	if deadline.IsZero() {
+		deadline, _ = ctx.Deadline()
+	}
+
+	err := b.publish(ctx, b.opts.Exchange, topic, rabbit.WithDeadline(amqp.Publishing{
+		ContentType:   req.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: correlationID,
+		Headers:       amqp.Table(req.Headers),
+		ReplyTo:       b.replyQueue,
+		Body:          req.Body,
+	}, deadline))
+	if err != nil {
+		return Message{}, publishError(topic, err)
+	}
+
+	// replies sent over a connection that went away are not coming anymore
+	lost := b.conn.Lost()
+
+	select {
+	case d := <-replied:
+		return delivered(d), nil
+	case <-lost:
+		return Message{}, fmt.Errorf("%w: %w: connection lost waiting for reply", ErrUnavailable, ErrNoReply)
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// Publish sends event to the queues bound to topic. An event no queue is bound to is
+// dropped without an error.
+func (b *RabbitMQ) Publish(ctx context.Context, topic string, event Message) error {
+	err := b.publish(ctx, b.opts.Exchange, topic, amqp.Publishing{
+		ContentType:  event.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Headers:      amqp.Table(event.Headers),
+		Body:         event.Body,
+	})
+	if errors.Is(err, rabbit.ErrUnroutable) {
+		return nil
+	}
+
+	if err != nil {
+		return publishError(topic, err)
+	}
+
+	return nil
+}
+
+// Handle registers h to answer the requests sent to topic, which Serve binds to the
+// queue of the service. It panics if topic is already registered.
+func (b *RabbitMQ) Handle(topic string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mustBeFree(topic)
+	b.handlers[topic] = h
+}
+
+// Subscribe registers s to receive the events published to topic, which Serve binds to
+// the queue of the service. It panics if topic is already registered.
+func (b *RabbitMQ) Subscribe(topic string, s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mustBeFree(topic)
+	b.subscribers[topic] = s
+}
+
+func (b *RabbitMQ) mustBeFree(topic string) {
+	_, handled := b.handlers[topic]
+	_, subscribed := b.subscribers[topic]
+
+	if handled || subscribed {
+		panic("bus: topic already registered: " + topic)
+	}
+}
+
+// Serve declares the queue of the service, bound to the registered topics, and consumes
+// it along with the reply queue until ctx is cancelled or the connection is closed.
+// Deliveries being handled are replied to and acked first; those not acked by then are
+// requeued by the broker.
+func (b *RabbitMQ) Serve(ctx context.Context) error {
+	b.mu.RLock()
+	topics := make([]string, 0, len(b.handlers)+len(b.subscribers))
+
+	for topic := range b.handlers {
+		topics = append(topics, topic)
+	}
+
+	for topic := range b.subscribers {
+		topics = append(topics, topic)
+	}
+	b.mu.RUnlock()
+
+	if len(topics) > 0 {
+		if err := b.conn.Declare(b.serviceTopology(topics)); err != nil {
+			return err
+		}
+	}
+
+	errCh := make(chan error, 2)
+
+	var wg sync.WaitGroup
+
+	if b.replyQueue != "" {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errCh <- b.conn.Consume(ctx, rabbit.ConsumeOptions{
+				Queue:    b.replyQueue,
+				Consumer: b.opts.Consumer,
+				AutoAck:  true,
+			}, b.handleReply)
+		}()
+	}
+
+	if len(topics) > 0 {
+		// not cancelled with ctx so that the deliveries being handled are finished
+		handleCtx := context.WithoutCancel(ctx)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errCh <- b.conn.Consume(ctx, rabbit.ConsumeOptions{
+				Queue:    b.opts.Queue,
+				Consumer: b.opts.Consumer,
+				Prefetch: b.opts.Prefetch,
+				Workers:  b.opts.Workers,
+			}, func(d amqp.Delivery) {
+				b.handleDelivery(handleCtx, d)
+			})
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ready reports whether the broker is connected.
+func (b *RabbitMQ) Ready(ctx context.Context) error {
+	return b.conn.Ready(ctx)
+}
+
+// Close closes the connection, which stops Serve.
+func (b *RabbitMQ) Close() error {
+	return b.conn.Close()
+}
+
+func (b *RabbitMQ) serviceTopology(topics []string) rabbit.Topology {
+	return func(ch *amqp.Channel) error {
+		var args amqp.Table
+
+		if b.opts.DeadLetterExchange != "" {
+			if err := declareExchange(ch, b.opts.DeadLetterExchange); err != nil {
+				return err
+			}
+
+			args = amqp.Table{"x-dead-letter-exchange": b.opts.DeadLetterExchange}
+		}
+
+		q, err := ch.QueueDeclare(
+			b.opts.Queue, // name
+			true,         // durable
+			false,        // delete when unused
+			false,        // exclusive
+			false,        // no-wait
+			args,         // arguments
+		)
+		if err != nil {
+			return err
+		}
+
+		var dlq amqp.Queue
+
+		if b.opts.DeadLetterExchange != "" {
+			// keeps the messages that could not be handled for inspection
+			dlq, err = ch.QueueDeclare(
+				q.Name+".dead", // name
+				true,           // durable
+				false,          // delete when unused
+				false,          // exclusive
+				false,          // no-wait
+				nil,            // arguments
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, topic := range topics {
+			if err := ch.QueueBind(q.Name, topic, b.opts.Exchange, false, nil); err != nil {
+				return err
+			}
+
+			if b.opts.DeadLetterExchange == "" {
+				continue
+			}
+
+			if err := ch.QueueBind(dlq.Name, topic, b.opts.DeadLetterExchange, false, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// handleReply hands a reply to the request waiting for it. Replies nobody waits for
+// anymore are dropped.
+func (b *RabbitMQ) handleReply(d amqp.Delivery) {
+	b.pendingMu.Lock()
+	replied, ok := b.pending[d.CorrelationId]
+	b.pendingMu.Unlock()
+
+	if !ok {
+		slog.Warn("dropping reply nobody waits for", "correlation_id", d.CorrelationId)
+
+		return
+	}
+
+	select {
+	case replied <- d:
+	default:
+		// a duplicate of a reply already handed over
+	}
+}
+
+// handleDelivery hands a request or event to the handler or subscriber of its topic and
+// acks it once handled, after the reply is out for requests so that a crash in between
+// redelivers it. Deliveries that can never be handled are dead-lettered.
+func (b *RabbitMQ) handleDelivery(ctx context.Context, d amqp.Delivery) {
+	log := slog.With("routing_key", d.RoutingKey, "correlation_id", d.CorrelationId)
+
+	if rabbit.Expired(d) {
+		log.WarnContext(ctx, "skipping expired message")
+
+		// the sender gave up on it, dead-lettered like the ones the broker expires
+		_ = d.Nack(false, false)
+
+		return
+	}
+
+	b.mu.RLock()
+	h, handled := b.handlers[d.RoutingKey]
+	s, subscribed := b.subscribers[d.RoutingKey]
+	b.mu.RUnlock()
+
+	msg := delivered(d)
+
+	switch {
+	case subscribed:
+		if err := s(ctx, msg); err != nil {
+			log.ErrorContext(ctx, "failed to handle event", "error", err)
+
+			_ = d.Nack(false, false)
+
+			return
+		}
+
+		_ = d.Ack(false)
+
+	case handled:
+		reply, err := h(ctx, msg)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to handle request", "error", err)
+
+			// dead-lettered, redelivering it would fail the same way
+			_ = d.Nack(false, false)
+
+			return
+		}
+
+		b.reply(ctx, d, reply)
+
+	default:
+		log.ErrorContext(ctx, "no handler for message")
+
+		_ = d.Nack(false, false)
+	}
+}
+
+func (b *RabbitMQ) reply(ctx context.Context, d amqp.Delivery, reply Message) {
+	if d.ReplyTo == "" {
+		// nobody waits for the reply
+		_ = d.Ack(false)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// waits for the broker to come back if the connection dropped meanwhile
+	err := b.publish(ctx,
+		"",        // exchange
+		d.ReplyTo, // routing key
+		amqp.Publishing{
+			ContentType:   reply.ContentType,
+			CorrelationId: d.CorrelationId,
+			Headers:       amqp.Table(reply.Headers),
+			Body:          reply.Body,
+		},
+	)
+	if errors.Is(err, rabbit.ErrUnroutable) {
+		// the reply queue went away with the sender, nobody is waiting for the reply
+		slog.WarnContext(ctx, "dropping reply to missing reply queue", "reply_to", d.ReplyTo)
+
+		_ = d.Ack(false)
+
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send reply", "reply_to", d.ReplyTo, "error", err)
+
+		// handled again once the broker is back
+		_ = d.Nack(false, true)
+
+		return
+	}
+
+	_ = d.Ack(false)
+}
+
+func declareExchange(ch *amqp.Channel, name string) error {
+	return ch.ExchangeDeclare(
+		name,    // name
+		"topic", // type
+		true,    // durable
+		false,   // auto-deleted
+		false,   // internal
+		false,   // no-wait
+		nil,     // arguments
+	)
+}
+
+// publishError tells the errors of publishing to topic apart for the caller.
+func publishError(topic string, err error) error {
+	switch {
+	case errors.Is(err, rabbit.ErrUnroutable):
+		// no queue is bound yet, the service has not come up
+		return fmt.Errorf("%w: %s: %w", ErrNoHandler, topic, err)
+	case errors.Is(err, rabbit.ErrNacked):
+		// the message was published, it may be on the queue even though the broker did
+		// not confirm it
+		return fmt.Errorf("%w: %w: %w", ErrUnavailable, ErrNoReply, err)
+	case errors.Is(err, rabbit.ErrDisconnected), errors.Is(err, rabbit.ErrClosed):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	default:
+		return err
+	}
+}
+
+func delivered(d amqp.Delivery) Message {
+	deadline, _ := rabbit.Deadline(d)
+
+	return Message{
+		ContentType:   d.ContentType,
+		Headers:       d.Headers,
+		Body:          d.Body,
+		Deadline:      deadline,
+		Topic:         d.RoutingKey,
+		CorrelationID: d.CorrelationId,
+	}
+}
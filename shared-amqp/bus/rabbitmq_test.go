@@ -0,0 +1,205 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/rabbit"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAcknowledger struct {
+	acked    bool
+	nacked   bool
+	requeued bool
+}
+
+func (a *fakeAcknowledger) Ack(_ uint64, _ bool) error {
+	a.acked = true
+
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.nacked = true
+	a.requeued = requeue
+
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(_ uint64, requeue bool) error {
+	return a.Nack(0, false, requeue)
+}
+
+// newTestRabbitMQ returns a bus without a connection, publishing with publish.
+func newTestRabbitMQ(publish func(ctx context.Context, exchange, key string, msg amqp.Publishing) error) *RabbitMQ {
+	return &RabbitMQ{
+		publish:     publish,
+		handlers:    make(map[string]Handler),
+		subscribers: make(map[string]Subscriber),
+		pending:     make(map[string]chan amqp.Delivery),
+	}
+}
+
+func TestRabbitMQ_handleDelivery(t *testing.T) {
+	errMalformed := errors.New("malformed request")
+
+	tests := []struct {
+		name        string
+		delivery    amqp.Delivery
+		publishErr  error
+		wantReply   bool
+		wantAck     bool
+		wantRequeue bool
+	}{
+		{
+			name:      "request",
+			delivery:  amqp.Delivery{RoutingKey: "payments.initiate_payment", ReplyTo: "gateway_queue.1", Body: []byte("ok")},
+			wantReply: true,
+			wantAck:   true,
+		},
+		{
+			name:     "request nobody waits for",
+			delivery: amqp.Delivery{RoutingKey: "payments.initiate_payment", Body: []byte("ok")},
+			wantAck:  true,
+		},
+		{
+			name:     "malformed request",
+			delivery: amqp.Delivery{RoutingKey: "payments.initiate_payment", ReplyTo: "gateway_queue.1", Body: []byte("not json")},
+		},
+		{
+			name: "expired request",
+			delivery: amqp.Delivery{
+				RoutingKey: "payments.initiate_payment",
+				ReplyTo:    "gateway_queue.1",
+				Headers:    amqp.Table{rabbit.DeadlineHeader: time.Now().Add(-time.Second).UnixMilli()},
+				Body:       []byte("ok"),
+			},
+		},
+		{
+			name:     "unknown topic",
+			delivery: amqp.Delivery{RoutingKey: "payments.refund_payment", ReplyTo: "gateway_queue.1"},
+		},
+		{
+			name:       "reply queue gone",
+			delivery:   amqp.Delivery{RoutingKey: "payments.initiate_payment", ReplyTo: "gateway_queue.1", Body: []byte("ok")},
+			publishErr: rabbit.ErrUnroutable,
+			wantReply:  true,
+			wantAck:    true,
+		},
+		{
+			name:        "broker down while replying",
+			delivery:    amqp.Delivery{RoutingKey: "payments.initiate_payment", ReplyTo: "gateway_queue.1", Body: []byte("ok")},
+			publishErr:  rabbit.ErrDisconnected,
+			wantReply:   true,
+			wantRequeue: true,
+		},
+		{
+			name:     "event",
+			delivery: amqp.Delivery{RoutingKey: "users.registered", Body: []byte("ok")},
+			wantAck:  true,
+		},
+		{
+			name:     "failed event",
+			delivery: amqp.Delivery{RoutingKey: "users.registered", Body: []byte("fail")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var replies []amqp.Publishing
+
+			b := newTestRabbitMQ(func(_ context.Context, exchange, key string, msg amqp.Publishing) error {
+				require.Empty(t, exchange)
+				require.Equal(t, tc.delivery.ReplyTo, key)
+
+				replies = append(replies, msg)
+
+				return tc.publishErr
+			})
+
+			b.Handle("payments.initiate_payment", func(_ context.Context, req Message) (Message, error) {
+				if string(req.Body) != "ok" {
+					return Message{}, errMalformed
+				}
+
+				return Message{ContentType: "text/plain", Body: []byte("done")}, nil
+			})
+
+			b.Subscribe("users.registered", func(_ context.Context, event Message) error {
+				if string(event.Body) != "ok" {
+					return errors.New("failed")
+				}
+
+				return nil
+			})
+
+			acknowledger := &fakeAcknowledger{}
+
+			tc.delivery.Acknowledger = acknowledger
+			tc.delivery.CorrelationId = "corr-1"
+
+			b.handleDelivery(context.Background(), tc.delivery)
+
+			if tc.wantReply {
+				require.Len(t, replies, 1)
+				require.Equal(t, "corr-1", replies[0].CorrelationId)
+				require.Equal(t, "done", string(replies[0].Body))
+			} else {
+				require.Empty(t, replies)
+			}
+
+			require.Equal(t, tc.wantAck, acknowledger.acked)
+			require.Equal(t, !tc.wantAck, acknowledger.nacked)
+			require.Equal(t, tc.wantRequeue, acknowledger.requeued)
+		})
+	}
+}
+
+func TestRabbitMQ_handleReply(t *testing.T) {
+	b := newTestRabbitMQ(nil)
+
+	replied := make(chan amqp.Delivery, 1)
+	b.pending["corr-1"] = replied
+
+	b.handleReply(amqp.Delivery{CorrelationId: "corr-1", Body: []byte("reply")})
+
+	// duplicates and replies nobody waits for are dropped without blocking
+	b.handleReply(amqp.Delivery{CorrelationId: "corr-1", Body: []byte("duplicate")})
+	b.handleReply(amqp.Delivery{CorrelationId: "corr-2"})
+
+	require.Equal(t, "reply", string((<-replied).Body))
+}
+
+func TestRabbitMQ_PublishEvent(t *testing.T) {
+	b := newTestRabbitMQ(func(context.Context, string, string, amqp.Publishing) error {
+		return rabbit.ErrUnroutable
+	})
+
+	// no subscriber is bound
+	require.NoError(t, b.Publish(context.Background(), "users.registered", Message{}))
+}
+
+func TestPublishError(t *testing.T) {
+	err := publishError("payments.initiate_payment", fmt.Errorf("%w: events", rabbit.ErrUnroutable))
+	require.ErrorIs(t, err, ErrNoHandler)
+
+	err = publishError("payments.initiate_payment", fmt.Errorf("%w: %w", rabbit.ErrDisconnected, context.DeadlineExceeded))
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// nothing was sent, so the request can be sent again
+	require.NotErrorIs(t, err, ErrNoReply)
+
+	// the request was published, it can not be sent again
+	err = publishError("payments.initiate_payment", fmt.Errorf("%w: %w", rabbit.ErrNacked, amqp.ErrClosed))
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.ErrorIs(t, err, ErrNoReply)
+	require.ErrorIs(t, publishError("payments.initiate_payment", rabbit.ErrNacked), ErrNoReply)
+
+	other := errors.New("other")
+	require.Equal(t, other, publishError("payments.initiate_payment", other))
+}
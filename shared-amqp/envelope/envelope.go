@@ -0,0 +1,395 @@
+// Package envelope defines the messages the services exchange over RabbitMQ. Every
+// request and reply is an Envelope naming its message type and schema version, with
+// either the result or an error carrying a machine-readable code.
+//
+// Envelopes travel as protobuf, with the application/x-protobuf content type, or as
+// JSON. Version 1 is the JSON format used before envelopes: requests were {"name",
+// "data"} objects and replies were the bare response, told apart from an error by a
+// non-empty "message". Decode still reads JSON so that services can be upgraded one at
+// a time.
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope/envelopepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the schema version written by Encode.
+const Version = 2
+
+// Content types of envelopes and of the data they hold.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Message types.
+const (
+	TypeRegisterUser       = "register_user"
+	TypeLoginUser          = "login_user"
+	TypeInitiatePayment    = "initiate_payment"
+	TypePollingTransaction = "polling_transaction"
+	TypeForgotPassword     = "forgot_password"
+	TypeResetPassword      = "reset_password"
+)
+
+var (
+	// ErrUnsupportedVersion is returned when decoding an envelope written by a newer
+	// schema than this one.
+	ErrUnsupportedVersion = errors.New("envelope: unsupported version")
+
+	// ErrUnsupportedContentType is returned when the data of an envelope is in an
+	// encoding that can not be read.
+	ErrUnsupportedContentType = errors.New("envelope: unsupported content type")
+)
+
+// Code classifies an error so that the receiver can act on it without parsing the
+// message.
+type Code string
+
+const (
+	CodeInvalid          Code = "invalid"
+	CodeNotFound         Code = "not_found"
+	CodeAlreadyExists    Code = "already_exists"
+	CodeUnauthenticated  Code = "unauthenticated"
+	CodePermissionDenied Code = "permission_denied"
+	CodeNotImplemented   Code = "not_implemented"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal"
+)
+
+// HTTPStatus returns the HTTP status matching c.
+func (c Code) HTTPStatus() int {
+	switch c {
+	case CodeInvalid:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeAlreadyExists:
+		return http.StatusConflict
+	case CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case CodePermissionDenied:
+		return http.StatusForbidden
+	case CodeNotImplemented:
+		return http.StatusNotImplemented
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CodeFromHTTPStatus returns the code matching an HTTP status, as version 1 replies
+// carried one instead of a code.
+func CodeFromHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalid
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeAlreadyExists
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	default:
+		return CodeInternal
+	}
+}
+
+// Error is the failure a reply carries instead of a result.
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Envelope is a request or reply exchanged over RabbitMQ. Data holds the request or the
+// result, encoded as ContentType; a failed reply holds Error instead.
+type Envelope struct {
+	Type        string
+	Version     int
+	ContentType string
+	RequestID   string
+	Deadline    time.Time
+	Data        []byte
+	Error       *Error
+}
+
+// New returns an envelope of msgType holding data encoded as JSON.
+func New(msgType string, data any) (Envelope, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("envelope: encoding %s: %w", msgType, err)
+	}
+
+	return Envelope{
+		Type:        msgType,
+		Version:     Version,
+		ContentType: ContentTypeJSON,
+		Data:        encoded,
+	}, nil
+}
+
+// NewProto returns an envelope of msgType holding msg encoded as protobuf.
+func NewProto(msgType string, msg proto.Message) (Envelope, error) {
+	encoded, err := proto.Marshal(msg)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("envelope: encoding %s: %w", msgType, err)
+	}
+
+	return Envelope{
+		Type:        msgType,
+		Version:     Version,
+		ContentType: ContentTypeProtobuf,
+		Data:        encoded,
+	}, nil
+}
+
+// NewError returns a reply of msgType that failed with code.
+func NewError(msgType string, code Code, message string) Envelope {
+	return Envelope{
+		Type:    msgType,
+		Version: Version,
+		Error:   &Error{Code: code, Message: message},
+	}
+}
+
+// Err returns the error the envelope carries, or nil.
+func (e Envelope) Err() error {
+	if e.Error == nil {
+		return nil
+	}
+
+	return e.Error
+}
+
+// Unmarshal decodes the data of the envelope into v, which must be a proto.Message
+// when the data is protobuf.
+func (e Envelope) Unmarshal(v any) error {
+	switch e.ContentType {
+	case ContentTypeJSON, "":
+		return json.Unmarshal(e.Data, v)
+	case ContentTypeProtobuf:
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return fmt.Errorf("envelope: %s data can not be decoded into %T", e.Type, v)
+		}
+
+		return proto.Unmarshal(e.Data, msg)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, e.ContentType)
+	}
+}
+
+// wire is the JSON form of an envelope, which version 1 messages also decode into.
+type wire struct {
+	Type        string          `json:"type,omitempty"`
+	Version     int             `json:"version,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Deadline    int64           `json:"deadline,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Error       *Error          `json:"error,omitempty"`
+
+	// version 1
+	Name       string `json:"name,omitempty"`
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Status     int    `json:"status,omitempty"`
+}
+
+// Encode returns the JSON form of e at the current version. JSON data is embedded as
+// is, data in other encodings as a base64 string.
+func Encode(e Envelope) ([]byte, error) {
+	w := wire{
+		Type:        e.Type,
+		Version:     Version,
+		ContentType: e.ContentType,
+		RequestID:   e.RequestID,
+		Error:       e.Error,
+	}
+
+	if !e.Deadline.IsZero() {
+		w.Deadline = e.Deadline.UnixMilli()
+	}
+
+	if len(e.Data) > 0 {
+		if e.ContentType == ContentTypeJSON {
+			if !json.Valid(e.Data) {
+				return nil, fmt.Errorf("envelope: %s data is not valid JSON", e.Type)
+			}
+
+			w.Data = e.Data
+		} else {
+			data, err := json.Marshal(e.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			w.Data = data
+		}
+	}
+
+	return json.Marshal(w)
+}
+
+// EncodeProto returns the protobuf form of e at the current version.
+func EncodeProto(e Envelope) ([]byte, error) {
+	msg := &envelopepb.Envelope{
+		Type:        e.Type,
+		Version:     Version,
+		ContentType: e.ContentType,
+		RequestId:   e.RequestID,
+		Data:        e.Data,
+	}
+
+	if !e.Deadline.IsZero() {
+		msg.Deadline = e.Deadline.UnixMilli()
+	}
+
+	if e.Error != nil {
+		msg.Error = &envelopepb.Error{Code: string(e.Error.Code), Message: e.Error.Message}
+	}
+
+	return proto.Marshal(msg)
+}
+
+// DecodeProto reads an envelope encoded by EncodeProto.
+func DecodeProto(body []byte) (Envelope, error) {
+	var msg envelopepb.Envelope
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return Envelope{}, fmt.Errorf("envelope: %w", err)
+	}
+
+	if msg.GetVersion() > Version {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, msg.GetVersion())
+	}
+
+	e := Envelope{
+		Type:        msg.GetType(),
+		Version:     int(msg.GetVersion()),
+		ContentType: msg.GetContentType(),
+		RequestID:   msg.GetRequestId(),
+		Data:        msg.GetData(),
+	}
+
+	if msg.GetDeadline() != 0 {
+		e.Deadline = time.UnixMilli(msg.GetDeadline())
+	}
+
+	if msg.GetError() != nil {
+		e.Error = &Error{Code: Code(msg.GetError().GetCode()), Message: msg.GetError().GetMessage()}
+	}
+
+	return e, nil
+}
+
+// EncodeAs encodes e as protobuf when contentType asks for it and as JSON otherwise.
+func EncodeAs(contentType string, e Envelope) ([]byte, error) {
+	if contentType == ContentTypeProtobuf {
+		return EncodeProto(e)
+	}
+
+	return Encode(e)
+}
+
+// DecodeAs reads an envelope sent with contentType, protobuf or, from senders that have
+// not moved to it yet, JSON.
+func DecodeAs(contentType string, body []byte) (Envelope, error) {
+	if contentType == ContentTypeProtobuf {
+		return DecodeProto(body)
+	}
+
+	return Decode(body)
+}
+
+// Decode reads an envelope encoded by Encode, or a version 1 request or reply.
+func Decode(body []byte) (Envelope, error) {
+	var w wire
+	if err := json.Unmarshal(body, &w); err != nil {
+		return Envelope{}, fmt.Errorf("envelope: %w", err)
+	}
+
+	if w.Version == 0 {
+		return decodeV1(body, w)
+	}
+
+	if w.Version > Version {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, w.Version)
+	}
+
+	e := Envelope{
+		Type:        w.Type,
+		Version:     w.Version,
+		ContentType: w.ContentType,
+		RequestID:   w.RequestID,
+		Error:       w.Error,
+	}
+
+	if w.Deadline != 0 {
+		e.Deadline = time.UnixMilli(w.Deadline)
+	}
+
+	if len(w.Data) > 0 {
+		if w.ContentType == ContentTypeJSON {
+			e.Data = w.Data
+		} else if err := json.Unmarshal(w.Data, &e.Data); err != nil {
+			return Envelope{}, fmt.Errorf("envelope: %w", err)
+		}
+	}
+
+	return e, nil
+}
+
+// decodeV1 reads a message written before envelopes. A request names its type and
+// holds its data either as an object or, from senders that marshalled it to bytes
+// first, as a base64 string of one. A reply is the response itself.
+func decodeV1(body []byte, w wire) (Envelope, error) {
+	e := Envelope{Version: 1, ContentType: ContentTypeJSON}
+
+	if w.Name != "" {
+		e.Type = w.Name
+
+		if bytes.HasPrefix(bytes.TrimSpace(w.Data), []byte(`"`)) {
+			if err := json.Unmarshal(w.Data, &e.Data); err != nil {
+				return Envelope{}, fmt.Errorf("envelope: %w", err)
+			}
+		} else {
+			e.Data = w.Data
+		}
+
+		return e, nil
+	}
+
+	if w.Message != "" {
+		status := w.StatusCode
+		if status == 0 {
+			status = w.Status
+		}
+
+		e.Error = &Error{Code: CodeFromHTTPStatus(status), Message: w.Message}
+
+		return e, nil
+	}
+
+	e.Data = body
+
+	return e, nil
+}
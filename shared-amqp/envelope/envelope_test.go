@@ -0,0 +1,253 @@
+package envelope
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/payment-polling-service/shared-amqp/envelope/envelopepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type loginUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	deadline := time.UnixMilli(time.Now().Add(5 * time.Second).UnixMilli())
+
+	request, err := New(TypeLoginUser, loginUserRequest{Email: "john@doe.com", Password: "secret"})
+	require.NoError(t, err)
+
+	request.RequestID = "req-1"
+	request.Deadline = deadline
+
+	tests := []struct {
+		name     string
+		envelope Envelope
+		check    func(t *testing.T, body []byte, decoded Envelope)
+	}{
+		{
+			name:     "request",
+			envelope: request,
+			check: func(t *testing.T, body []byte, decoded Envelope) {
+				// JSON data is embedded rather than base64 encoded
+				require.Contains(t, string(body), `"data":{"email":"john@doe.com","password":"secret"}`)
+
+				require.Equal(t, request, decoded)
+
+				var req loginUserRequest
+				require.NoError(t, decoded.Unmarshal(&req))
+				require.Equal(t, "john@doe.com", req.Email)
+				require.NoError(t, decoded.Err())
+			},
+		},
+		{
+			name:     "error reply",
+			envelope: NewError(TypeLoginUser, CodeNotFound, "user not found"),
+			check: func(t *testing.T, body []byte, decoded Envelope) {
+				require.JSONEq(t, `{
+					"type": "login_user",
+					"version": 2,
+					"error": {"code": "not_found", "message": "user not found"}
+				}`, string(body))
+
+				require.Equal(t, &Error{Code: CodeNotFound, Message: "user not found"}, decoded.Error)
+				require.EqualError(t, decoded.Err(), "not_found: user not found")
+				require.Empty(t, decoded.Data)
+			},
+		},
+		{
+			name: "binary data",
+			envelope: Envelope{
+				Type:        TypeInitiatePayment,
+				Version:     Version,
+				ContentType: "application/octet-stream",
+				Data:        []byte{0x0a, 0x00, 0xff},
+			},
+			check: func(t *testing.T, body []byte, decoded Envelope) {
+				require.Contains(t, string(body), `"data":"CgD/"`)
+				require.Equal(t, []byte{0x0a, 0x00, 0xff}, decoded.Data)
+				require.ErrorIs(t, decoded.Unmarshal(&loginUserRequest{}), ErrUnsupportedContentType)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := Encode(tc.envelope)
+			require.NoError(t, err)
+
+			decoded, err := Decode(body)
+			require.NoError(t, err)
+			require.Equal(t, Version, decoded.Version)
+
+			tc.check(t, body, decoded)
+		})
+	}
+}
+
+func TestEncode_InvalidJSON(t *testing.T) {
+	_, err := Encode(Envelope{Type: TypeLoginUser, ContentType: ContentTypeJSON, Data: []byte("{")})
+	require.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	loginData, err := json.Marshal(loginUserRequest{Email: "john@doe.com", Password: "secret"})
+	require.NoError(t, err)
+
+	// how the gateway wrote requests, with the data marshalled to bytes first
+	v1GatewayRequest, err := json.Marshal(struct {
+		Name string `json:"name"`
+		Data []byte `json:"data"`
+	}{Name: TypeLoginUser, Data: loginData})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    Envelope
+		wantErr error
+	}{
+		{
+			name: "v1 request with base64 data",
+			body: string(v1GatewayRequest),
+			want: Envelope{Type: TypeLoginUser, Version: 1, ContentType: ContentTypeJSON, Data: loginData},
+		},
+		{
+			name: "v1 request with object data",
+			body: `{"name":"login_user","data":{"email":"john@doe.com","password":"secret"}}`,
+			want: Envelope{Type: TypeLoginUser, Version: 1, ContentType: ContentTypeJSON, Data: loginData},
+		},
+		{
+			name: "v1 reply",
+			body: `{"full_name":"John Doe","email":"john@doe.com"}`,
+			want: Envelope{Version: 1, ContentType: ContentTypeJSON, Data: []byte(`{"full_name":"John Doe","email":"john@doe.com"}`)},
+		},
+		{
+			name: "v1 auth error reply",
+			body: `{"status_code":404,"message":"user not found"}`,
+			want: Envelope{Version: 1, ContentType: ContentTypeJSON, Error: &Error{Code: CodeNotFound, Message: "user not found"}},
+		},
+		{
+			name: "v1 payments error reply",
+			body: `{"status":401,"message":"cannot access this transaction"}`,
+			want: Envelope{Version: 1, ContentType: ContentTypeJSON, Error: &Error{Code: CodeUnauthenticated, Message: "cannot access this transaction"}},
+		},
+		{
+			name: "v2 request",
+			body: `{"type":"login_user","version":2,"content_type":"application/json","request_id":"req-1","deadline":1726660800000,"data":{"email":"john@doe.com","password":"secret"}}`,
+			want: Envelope{
+				Type:        TypeLoginUser,
+				Version:     2,
+				ContentType: ContentTypeJSON,
+				RequestID:   "req-1",
+				Deadline:    time.Date(2024, time.September, 18, 12, 0, 0, 0, time.UTC).Local(),
+				Data:        loginData,
+			},
+		},
+		{
+			name:    "newer version",
+			body:    `{"type":"login_user","version":3,"data":{}}`,
+			wantErr: ErrUnsupportedVersion,
+		},
+		{
+			name:    "malformed",
+			body:    `not json`,
+			wantErr: &json.SyntaxError{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode([]byte(tc.body))
+			if tc.wantErr != nil {
+				require.Error(t, err)
+
+				if syntaxErr, ok := tc.wantErr.(*json.SyntaxError); ok {
+					require.ErrorAs(t, err, &syntaxErr)
+				} else {
+					require.ErrorIs(t, err, tc.wantErr)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestEncodeDecodeProto(t *testing.T) {
+	deadline := time.UnixMilli(time.Now().Add(5 * time.Second).UnixMilli())
+
+	// any message will do as the data, the envelope does not look into it
+	data := &envelopepb.Error{Code: "payload", Message: "john@doe.com"}
+
+	request, err := NewProto(TypeLoginUser, data)
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeProtobuf, request.ContentType)
+
+	request.RequestID = "req-1"
+	request.Deadline = deadline
+
+	body, err := EncodeAs(ContentTypeProtobuf, request)
+	require.NoError(t, err)
+
+	decoded, err := DecodeAs(ContentTypeProtobuf, body)
+	require.NoError(t, err)
+	require.Equal(t, request, decoded)
+
+	var got envelopepb.Error
+	require.NoError(t, decoded.Unmarshal(&got))
+	require.True(t, proto.Equal(data, &got))
+
+	// protobuf data only decodes into protobuf messages
+	require.Error(t, decoded.Unmarshal(&loginUserRequest{}))
+
+	body, err = EncodeProto(NewError(TypeLoginUser, CodeNotFound, "user not found"))
+	require.NoError(t, err)
+
+	decoded, err = DecodeProto(body)
+	require.NoError(t, err)
+	require.EqualError(t, decoded.Err(), "not_found: user not found")
+	require.Empty(t, decoded.Data)
+
+	body, err = proto.Marshal(&envelopepb.Envelope{Type: TypeLoginUser, Version: Version + 1})
+	require.NoError(t, err)
+
+	_, err = DecodeProto(body)
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = DecodeProto([]byte("not protobuf"))
+	require.Error(t, err)
+}
+
+func TestDecodeAs_JSON(t *testing.T) {
+	// senders that have not moved to protobuf yet
+	for _, contentType := range []string{ContentTypeJSON, ""} {
+		request, err := New(TypeLoginUser, loginUserRequest{Email: "john@doe.com"})
+		require.NoError(t, err)
+
+		body, err := EncodeAs(contentType, request)
+		require.NoError(t, err)
+		require.True(t, json.Valid(body))
+
+		decoded, err := DecodeAs(contentType, body)
+		require.NoError(t, err)
+		require.Equal(t, request, decoded)
+	}
+}
+
+func TestCode_HTTPStatus(t *testing.T) {
+	for _, code := range []Code{CodeInvalid, CodeNotFound, CodeAlreadyExists, CodeUnauthenticated, CodePermissionDenied, CodeNotImplemented, CodeRateLimited, CodeInternal} {
+		require.Equal(t, code, CodeFromHTTPStatus(code.HTTPStatus()))
+	}
+
+	require.Equal(t, http.StatusInternalServerError, Code("unknown").HTTPStatus())
+	require.Equal(t, CodeInternal, CodeFromHTTPStatus(http.StatusTeapot))
+}
@@ -0,0 +1,15 @@
+module github.com/EmilioCliff/payment-polling-service/shared-amqp
+
+go 1.21.6
+
+require (
+	github.com/rabbitmq/amqp091-go v1.10.0
+	github.com/stretchr/testify v1.9.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,594 @@
+// Package rabbit keeps a RabbitMQ connection alive for the services. When the broker
+// goes away it dials again, declares the topology of the service on the new connection
+// and subscribes the consumers again.
+package rabbit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrDisconnected is returned when the broker could not be reached before the
+	// context of the call expired.
+	ErrDisconnected = errors.New("rabbitmq: disconnected")
+
+	// ErrClosed is returned once Close has been called.
+	ErrClosed = errors.New("rabbitmq: connection closed")
+
+	// ErrUnroutable is returned when the broker had no queue to route a message to and
+	// returned it.
+	ErrUnroutable = errors.New("rabbitmq: message unroutable")
+
+	// ErrNacked is returned when the broker did not confirm a message, either because it
+	// nacked it or because the channel or the context of the call went away first.
+	ErrNacked = errors.New("rabbitmq: message not confirmed")
+)
+
+const (
+	dialRetries       = 12
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Topology declares the exchanges, queues and bindings a service relies on. It is run
+// on every new connection before publishers and consumers are let through.
+type Topology func(ch *amqp.Channel) error
+
+// Conn is a RabbitMQ connection that restores itself after the broker restarts or the
+// network drops. It is safe for concurrent use.
+type Conn struct {
+	url string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	pub      *publisher
+	up       chan struct{}
+	lost     chan struct{}
+	topology []Topology
+
+	// returned holds the Publish calls waiting to learn whether their message, keyed by
+	// message ID, was returned.
+	returnedMu sync.Mutex
+	returned   map[string]chan amqp.Return
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker at url, retrying with a growing delay while it can not be
+// reached, and keeps the connection alive until Close is called.
+func Dial(url string) (*Conn, error) {
+	c := newConn(url)
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		var conn *amqp.Connection
+
+		conn, err = amqp.Dial(url)
+		if err == nil {
+			if err = c.establish(conn); err == nil {
+				slog.Info("connected to rabbitmq")
+
+				return c, nil
+			}
+
+			_ = conn.Close()
+		}
+
+		slog.Error("failed to connect to rabbitmq", "error", err, "attempt", attempt)
+
+		if attempt > dialRetries {
+			return nil, err
+		}
+
+		time.Sleep(backoff(attempt))
+	}
+}
+
+// publisher is the channel Publish sends on. It is in confirm mode and hands the
+// messages the broker returns to the Publish call that sent them.
+type publisher struct {
+	ch *amqp.Channel
+
+	// flush is answered once every return received before it has been handed over.
+	flush   chan chan struct{}
+	stopped chan struct{}
+}
+
+func newConn(url string) *Conn {
+	return &Conn{
+		url:      url,
+		up:       make(chan struct{}),
+		lost:     make(chan struct{}),
+		done:     make(chan struct{}),
+		returned: make(map[string]chan amqp.Return),
+	}
+}
+
+// Declare runs topology on the current connection and on every one made after it.
+func (c *Conn) Declare(topology Topology) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.topology = append(c.topology, topology)
+
+	if c.conn == nil {
+		// declared as soon as the broker is back
+		return nil
+	}
+
+	return declare(c.conn, topology)
+}
+
+// Publish sends msg on the shared publishing channel and waits for the broker to
+// confirm it. While the broker is unreachable it waits for the connection to come back
+// until ctx expires. Messages are published as mandatory, so one that no queue is bound
+// to fails with ErrUnroutable instead of being dropped. Returns are matched to the call
+// by message ID, which is generated when msg has none.
+func (c *Conn) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	if msg.MessageId == "" {
+		msg.MessageId = newMessageID()
+	}
+
+	returned := c.awaitReturn(msg.MessageId)
+	defer c.forgetReturn(msg.MessageId)
+
+	for {
+		pub, err := c.channel(ctx)
+		if err != nil {
+			return err
+		}
+
+		confirm, err := pub.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, msg)
+		if errors.Is(err, amqp.ErrClosed) {
+			c.markDown(pub)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNacked, err)
+		}
+
+		// the broker sends a return before the confirmation of the same message
+		pub.flushReturns()
+
+		select {
+		case ret := <-returned:
+			return fmt.Errorf("%w: %s %q: %s", ErrUnroutable, ret.Exchange, ret.RoutingKey, ret.ReplyText)
+		default:
+		}
+
+		if !acked {
+			return ErrNacked
+		}
+
+		return nil
+	}
+}
+
+// ConsumeOptions configures a consumer.
+type ConsumeOptions struct {
+	Queue    string
+	Consumer string
+	AutoAck  bool
+
+	// Prefetch caps how many unacked deliveries the broker sends the consumer. Zero
+	// leaves it unlimited.
+	Prefetch int
+
+	// Workers is how many deliveries are handled at the same time. It defaults to one.
+	Workers int
+}
+
+// Consume subscribes to opts.Queue and hands the deliveries to handle, from opts.Workers
+// goroutines, until ctx is cancelled or the connection is closed. When the connection
+// is lost it subscribes again on the next one. Cancelling ctx lets the deliveries being
+// handled finish; those still unacked are requeued by the broker.
+func (c *Conn) Consume(ctx context.Context, opts ConsumeOptions, handle func(amqp.Delivery)) error {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for {
+		ch, err := c.openChannel(ctx)
+		if err != nil {
+			if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		deliveries, err := subscribe(ch, opts)
+		if err != nil {
+			_ = ch.Close()
+
+			if errors.Is(err, amqp.ErrClosed) {
+				continue
+			}
+
+			return err
+		}
+
+		stop := context.AfterFunc(ctx, func() {
+			_ = ch.Cancel(opts.Consumer, false)
+		})
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for d := range deliveries {
+					handle(d)
+				}
+			}()
+		}
+
+		wg.Wait()
+		stop()
+
+		_ = ch.Close()
+
+		if ctx.Err() != nil || c.isClosed() {
+			return nil
+		}
+
+		slog.Warn("rabbitmq consumer interrupted, subscribing again", "queue", opts.Queue)
+	}
+}
+
+// Lost returns a channel that is closed when the current connection goes away, so that
+// a caller waiting for a reply sent over it can give up instead of timing out.
+func (c *Conn) Lost() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lost
+}
+
+// Ready reports whether the broker is connected.
+func (c *Conn) Ready(_ context.Context) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pub == nil {
+		return ErrDisconnected
+	}
+
+	return nil
+}
+
+// Close closes the connection and stops reconnecting. Consumers return once their
+// deliveries stop.
+func (c *Conn) Close() error {
+	var err error
+
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		conn := c.conn
+
+		if c.pub != nil {
+			c.conn, c.pub = nil, nil
+			close(c.lost)
+		}
+		c.mu.Unlock()
+
+		if conn != nil {
+			err = conn.Close()
+		}
+	})
+
+	return err
+}
+
+func (c *Conn) establish(conn *amqp.Connection) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	if err := declare(conn, c.topology...); err != nil {
+		return err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		return err
+	}
+
+	pub := &publisher{
+		ch:      ch,
+		flush:   make(chan chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	// unbuffered so that a return has been taken by routeReturns before the channel
+	// moves on to the confirmation that follows it
+	go c.routeReturns(pub, ch.NotifyReturn(make(chan amqp.Return)))
+
+	c.conn, c.pub = conn, pub
+	c.lost = make(chan struct{})
+	close(c.up)
+
+	go c.watch(conn, pub)
+
+	return nil
+}
+
+// routeReturns hands every message returned on the publishing channel to the Publish
+// call waiting for it until the channel is closed.
+func (c *Conn) routeReturns(pub *publisher, returns <-chan amqp.Return) {
+	defer close(pub.stopped)
+
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				return
+			}
+
+			c.returnedMu.Lock()
+			waiting, found := c.returned[ret.MessageId]
+			c.returnedMu.Unlock()
+
+			if !found {
+				slog.Warn("rabbitmq returned a message nobody waits for", "message_id", ret.MessageId, "routing_key", ret.RoutingKey)
+
+				continue
+			}
+
+			select {
+			case waiting <- ret:
+			default:
+			}
+		case flushed := <-pub.flush:
+			close(flushed)
+		}
+	}
+}
+
+// flushReturns waits for the returns received so far to be handed over.
+func (p *publisher) flushReturns() {
+	flushed := make(chan struct{})
+
+	select {
+	case p.flush <- flushed:
+		<-flushed
+	case <-p.stopped:
+	}
+}
+
+func (c *Conn) awaitReturn(messageID string) <-chan amqp.Return {
+	returned := make(chan amqp.Return, 1)
+
+	c.returnedMu.Lock()
+	c.returned[messageID] = returned
+	c.returnedMu.Unlock()
+
+	return returned
+}
+
+func (c *Conn) forgetReturn(messageID string) {
+	c.returnedMu.Lock()
+	delete(c.returned, messageID)
+	c.returnedMu.Unlock()
+}
+
+// watch waits for the connection or its publishing channel to close and starts over on
+// a new connection unless Close was called.
+func (c *Conn) watch(conn *amqp.Connection, pub *publisher) {
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := pub.ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	select {
+	case err := <-connClosed:
+		if err != nil {
+			slog.Error("rabbitmq connection lost", "error", err)
+		}
+	case err := <-chClosed:
+		if err != nil {
+			slog.Error("rabbitmq channel closed", "error", err)
+		}
+
+		_ = conn.Close()
+	case <-c.done:
+		return
+	}
+
+	c.markDown(pub)
+
+	if c.isClosed() {
+		return
+	}
+
+	c.reconnect()
+}
+
+func (c *Conn) reconnect() {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(backoff(attempt)):
+		}
+
+		conn, err := amqp.Dial(c.url)
+		if err == nil {
+			if err = c.establish(conn); err == nil {
+				slog.Info("reconnected to rabbitmq", "attempt", attempt)
+
+				return
+			}
+
+			_ = conn.Close()
+		}
+
+		slog.Error("failed to reconnect to rabbitmq", "error", err, "attempt", attempt)
+	}
+}
+
+// markDown forgets pub and the connection it belongs to, unless a newer connection
+// replaced them already.
+func (c *Conn) markDown(pub *publisher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pub != pub {
+		return
+	}
+
+	c.conn, c.pub = nil, nil
+	c.up = make(chan struct{})
+	close(c.lost)
+}
+
+// channel returns the publishing channel, waiting for the connection while it is down.
+func (c *Conn) channel(ctx context.Context) (*publisher, error) {
+	for {
+		if c.isClosed() {
+			return nil, ErrClosed
+		}
+
+		c.mu.Lock()
+		pub, up := c.pub, c.up
+		c.mu.Unlock()
+
+		if pub != nil {
+			return pub, nil
+		}
+
+		select {
+		case <-up:
+		case <-c.done:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrDisconnected, ctx.Err())
+		}
+	}
+}
+
+// openChannel opens a channel for a consumer, waiting for the connection while it is
+// down.
+func (c *Conn) openChannel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		pub, err := c.channel(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		conn := c.conn
+		c.mu.Unlock()
+
+		if conn == nil {
+			continue
+		}
+
+		ch, err := conn.Channel()
+		if err == nil {
+			return ch, nil
+		}
+
+		if !errors.Is(err, amqp.ErrClosed) {
+			return nil, err
+		}
+
+		c.markDown(pub)
+	}
+}
+
+func subscribe(ch *amqp.Channel, opts ConsumeOptions) (<-chan amqp.Delivery, error) {
+	if opts.Prefetch > 0 {
+		if err := ch.Qos(opts.Prefetch, 0, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return ch.Consume(
+		opts.Queue,    // queue
+		opts.Consumer, // consumer
+		opts.AutoAck,  // auto ack
+		false,         // exclusive
+		false,         // no local
+		false,         // no wait
+		nil,           // args
+	)
+}
+
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func declare(conn *amqp.Connection, topology ...Topology) error {
+	if len(topology) == 0 {
+		return nil
+	}
+
+	// declarations get a channel of their own since a failing one closes it
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	for _, declare := range topology {
+		if err := declare(ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newMessageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func backoff(attempt int) time.Duration {
+	delay := time.Duration(attempt*attempt) * time.Second
+	if delay > maxReconnectDelay {
+		return maxReconnectDelay
+	}
+
+	return delay
+}
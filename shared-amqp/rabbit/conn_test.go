@@ -0,0 +1,109 @@
+package rabbit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn_PublishWhileDisconnected(t *testing.T) {
+	c := newConn("amqp://localhost")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := c.Publish(ctx, "events", "payments.initiate_payment", amqp.Publishing{})
+	require.ErrorIs(t, err, ErrDisconnected)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, c.Ready(context.Background()), ErrDisconnected)
+}
+
+func TestConn_Close(t *testing.T) {
+	c := newConn("amqp://localhost")
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- c.Publish(context.Background(), "events", "payments.initiate_payment", amqp.Publishing{})
+	}()
+
+	consumed := make(chan error, 1)
+
+	go func() {
+		consumed <- c.Consume(context.Background(), ConsumeOptions{
+			Queue:    "payment_queue",
+			Consumer: "payment_service",
+			Prefetch: 20,
+			Workers:  10,
+		}, func(amqp.Delivery) {})
+	}()
+
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Publish did not return after Close")
+	}
+
+	select {
+	case err := <-consumed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Consume did not return after Close")
+	}
+
+	require.ErrorIs(t, c.Ready(context.Background()), ErrClosed)
+}
+
+func TestConn_routeReturns(t *testing.T) {
+	c := newConn("amqp://localhost")
+
+	pub := &publisher{
+		flush:   make(chan chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	returns := make(chan amqp.Return)
+
+	go c.routeReturns(pub, returns)
+
+	returned := c.awaitReturn("msg-1")
+
+	returns <- amqp.Return{MessageId: "msg-1", ReplyText: "NO_ROUTE"}
+	returns <- amqp.Return{MessageId: "msg-2", ReplyText: "NO_ROUTE"}
+
+	pub.flushReturns()
+
+	select {
+	case ret := <-returned:
+		require.Equal(t, "NO_ROUTE", ret.ReplyText)
+	default:
+		t.Fatal("return was not handed over before flushReturns returned")
+	}
+
+	c.forgetReturn("msg-1")
+	require.Empty(t, c.returned)
+
+	close(returns)
+
+	select {
+	case <-pub.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("routeReturns did not stop after the channel closed")
+	}
+
+	// does not block once the channel is gone
+	pub.flushReturns()
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, backoff(1))
+	require.Equal(t, 9*time.Second, backoff(3))
+	require.Equal(t, maxReconnectDelay, backoff(10))
+}
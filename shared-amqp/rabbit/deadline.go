@@ -0,0 +1,65 @@
+package rabbit
+
+import (
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadlineHeader carries, in Unix milliseconds, the time after which the sender of a
+// request no longer waits for its reply.
+const DeadlineHeader = "X-Deadline"
+
+// WithDeadline returns msg set to expire at deadline. The broker drops it, through the
+// dead-letter exchange of the queue, once it has waited in the queue that long, and
+// Expired tells consumers that took it before then but got to it too late. A zero
+// deadline leaves msg unchanged.
+func WithDeadline(msg amqp.Publishing, deadline time.Time) amqp.Publishing {
+	if deadline.IsZero() {
+		return msg
+	}
+
+	ttl := time.Until(deadline).Milliseconds()
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	headers := make(amqp.Table, len(msg.Headers)+1)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+
+	headers[DeadlineHeader] = deadline.UnixMilli()
+
+	msg.Headers = headers
+	msg.Expiration = strconv.FormatInt(ttl, 10)
+
+	return msg
+}
+
+// Deadline returns the deadline the sender set on d with WithDeadline.
+func Deadline(d amqp.Delivery) (time.Time, bool) {
+	var ms int64
+
+	switch value := d.Headers[DeadlineHeader].(type) {
+	case int64:
+		ms = value
+	case int32:
+		ms = int64(value)
+	case int:
+		ms = int64(value)
+	default:
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(ms), true
+}
+
+// Expired reports whether the deadline of d has passed, meaning its sender stopped
+// waiting for the reply.
+func Expired(d amqp.Delivery) bool {
+	deadline, ok := Deadline(d)
+
+	return ok && time.Now().After(deadline)
+}
@@ -0,0 +1,67 @@
+package rabbit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDeadline(t *testing.T) {
+	headers := amqp.Table{"X-Request-ID": "req-1"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	msg := WithDeadline(amqp.Publishing{Headers: headers}, deadline)
+
+	ttl, err := strconv.ParseInt(msg.Expiration, 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, 5000, ttl, 100)
+	require.Equal(t, "req-1", msg.Headers["X-Request-ID"])
+	require.Equal(t, deadline.UnixMilli(), msg.Headers[DeadlineHeader])
+	require.NotContains(t, headers, DeadlineHeader)
+
+	// already past, the broker takes an expiration of zero as never
+	msg = WithDeadline(amqp.Publishing{}, time.Now().Add(-time.Second))
+	require.Equal(t, "1", msg.Expiration)
+
+	msg = WithDeadline(amqp.Publishing{}, time.Time{})
+	require.Empty(t, msg.Expiration)
+	require.Nil(t, msg.Headers)
+}
+
+func TestExpired(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		expired bool
+	}{
+		{
+			name:    "no deadline",
+			headers: nil,
+			expired: false,
+		},
+		{
+			name:    "deadline ahead",
+			headers: amqp.Table{DeadlineHeader: time.Now().Add(time.Minute).UnixMilli()},
+			expired: false,
+		},
+		{
+			name:    "deadline passed",
+			headers: amqp.Table{DeadlineHeader: time.Now().Add(-time.Second).UnixMilli()},
+			expired: true,
+		},
+		{
+			name:    "malformed deadline",
+			headers: amqp.Table{DeadlineHeader: "yesterday"},
+			expired: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expired, Expired(amqp.Delivery{Headers: tc.headers}))
+		})
+	}
+}
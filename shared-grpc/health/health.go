@@ -0,0 +1,161 @@
+// Package health holds the liveness and readiness checks the services serve on
+// /livez and /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultTimeout bounds a check added without its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Check reports whether a dependency can be used, returning the reason when it can't.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check as reported by /readyz.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type namedCheck struct {
+	name    string
+	timeout time.Duration
+	check   Check
+}
+
+// Checker runs the readiness checks of the service's dependencies.
+type Checker struct {
+	checks []namedCheck
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add registers a dependency check. A timeout of zero uses DefaultTimeout.
+func (c *Checker) Add(name string, timeout time.Duration, check Check) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	c.checks = append(c.checks, namedCheck{name: name, timeout: timeout, check: check})
+}
+
+// Run executes every check concurrently, each under its own timeout, and reports
+// whether all of them passed.
+func (c *Checker) Run(ctx context.Context) (bool, map[string]Result) {
+	results := make(map[string]Result)
+	ready := true
+
+	if c == nil {
+		return ready, results
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, nc := range c.checks {
+		wg.Add(1)
+
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			result := runCheck(ctx, nc)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[nc.name] = result
+			if result.Status != "up" {
+				ready = false
+			}
+		}(nc)
+	}
+
+	wg.Wait()
+
+	return ready, results
+}
+
+func runCheck(ctx context.Context, nc namedCheck) Result {
+	c, cancel := context.WithTimeout(ctx, nc.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- nc.check(c)
+	}()
+
+	var err error
+
+	select {
+	case err = <-errCh:
+	case <-c.Done():
+		err = c.Err()
+	}
+
+	result := Result{Status: "up", Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Live reports that the process is running and able to serve requests. It does
+// not look at dependencies so that an outage elsewhere does not get it restarted.
+func (c *Checker) Live(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "alive"})
+}
+
+// Ready reports whether every dependency check passes, with the detail of each.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	ready, results := c.Run(r.Context())
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "not ready", "checks": results})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ready", "checks": results})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// GRPCCheck asks the server behind conn for its grpc.health.v1 serving status.
+func GRPCCheck(conn grpc.ClientConnInterface) Check {
+	client := healthpb.NewHealthClient(conn)
+
+	return func(ctx context.Context) error {
+		rsp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+
+		if rsp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("server is %s", rsp.GetStatus())
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name      string
+		checks    map[string]Check
+		wantReady bool
+		check     func(t *testing.T, results map[string]Result)
+	}{
+		{
+			name: "all up",
+			checks: map[string]Check{
+				"rabbitmq": func(context.Context) error { return nil },
+			},
+			wantReady: true,
+			check: func(t *testing.T, results map[string]Result) {
+				require.Equal(t, "up", results["rabbitmq"].Status)
+				require.Empty(t, results["rabbitmq"].Error)
+			},
+		},
+		{
+			name: "failing check",
+			checks: map[string]Check{
+				"rabbitmq":  func(context.Context) error { return nil },
+				"auth_grpc": func(context.Context) error { return errors.New("connection refused") },
+			},
+			wantReady: false,
+			check: func(t *testing.T, results map[string]Result) {
+				require.Equal(t, "up", results["rabbitmq"].Status)
+				require.Equal(t, "down", results["auth_grpc"].Status)
+				require.Equal(t, "connection refused", results["auth_grpc"].Error)
+			},
+		},
+		{
+			name: "hanging check times out",
+			checks: map[string]Check{
+				"auth_grpc": func(context.Context) error {
+					time.Sleep(time.Second)
+
+					return nil
+				},
+			},
+			wantReady: false,
+			check: func(t *testing.T, results map[string]Result) {
+				require.Equal(t, "down", results["auth_grpc"].Status)
+				require.Equal(t, context.DeadlineExceeded.Error(), results["auth_grpc"].Error)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker()
+			for name, check := range tc.checks {
+				checker.Add(name, 50*time.Millisecond, check)
+			}
+
+			start := time.Now()
+			ready, results := checker.Run(context.Background())
+
+			require.Less(t, time.Since(start), 500*time.Millisecond)
+			require.Equal(t, tc.wantReady, ready)
+			require.Len(t, results, len(tc.checks))
+			tc.check(t, results)
+		})
+	}
+}
+
+func TestChecker_Ready(t *testing.T) {
+	checker := NewChecker()
+	checker.Add("rabbitmq", 0, func(context.Context) error { return errors.New("channel is closed") })
+
+	w := httptest.NewRecorder()
+	checker.Live(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	checker.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]Result `json:"checks"`
+	}
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "not ready", body.Status)
+	require.Equal(t, "channel is closed", body.Checks["rabbitmq"].Error)
+}
+
+func TestGRPCCheck(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	healthServer := grpchealth.NewServer()
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	check := GRPCCheck(conn)
+
+	require.NoError(t, check(context.Background()))
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	require.EqualError(t, check(context.Background()), "server is NOT_SERVING")
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/EmilioCliff/payment-polling-service/shared-grpc/pb (interfaces: AuthenticationServiceClient)
+// Source: github.com/EmilioCliff/payment-polling-service/shared-grpc/pb (interfaces: AuthenticationServiceClient,PaymentsServiceClient,PaymentsService_WatchTransactionClient)
 //
 // Generated by this command:
 //
-//	mockgen -package mockpb -destination ./mock/mock.go github.com/EmilioCliff/payment-polling-service/shared-grpc/pb AuthenticationServiceClient
+//	mockgen -package mockpb -destination ./mock/mock.go github.com/EmilioCliff/payment-polling-service/shared-grpc/pb AuthenticationServiceClient,PaymentsServiceClient,PaymentsService_WatchTransactionClient
 //
 
 // Package mockpb is a generated GoMock package.
@@ -16,6 +16,7 @@ import (
 	pb "github.com/EmilioCliff/payment-polling-service/shared-grpc/pb"
 	gomock "go.uber.org/mock/gomock"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
 )
 
 // MockAuthenticationServiceClient is a mock of AuthenticationServiceClient interface.
@@ -41,6 +42,106 @@ func (m *MockAuthenticationServiceClient) EXPECT() *MockAuthenticationServiceCli
 	return m.recorder
 }
 
+// AdminGetUser mocks base method.
+func (m *MockAuthenticationServiceClient) AdminGetUser(arg0 context.Context, arg1 *pb.AdminGetUserRequest, arg2 ...grpc.CallOption) (*pb.AdminGetUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AdminGetUser", varargs...)
+	ret0, _ := ret[0].(*pb.AdminGetUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdminGetUser indicates an expected call of AdminGetUser.
+func (mr *MockAuthenticationServiceClientMockRecorder) AdminGetUser(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdminGetUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).AdminGetUser), varargs...)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAuthenticationServiceClient) CreateAPIKey(arg0 context.Context, arg1 *pb.CreateAPIKeyRequest, arg2 ...grpc.CallOption) (*pb.CreateAPIKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateAPIKey", varargs...)
+	ret0, _ := ret[0].(*pb.CreateAPIKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAuthenticationServiceClientMockRecorder) CreateAPIKey(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).CreateAPIKey), varargs...)
+}
+
+// DisableUser mocks base method.
+func (m *MockAuthenticationServiceClient) DisableUser(arg0 context.Context, arg1 *pb.DisableUserRequest, arg2 ...grpc.CallOption) (*pb.DisableUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableUser", varargs...)
+	ret0, _ := ret[0].(*pb.DisableUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockAuthenticationServiceClientMockRecorder) DisableUser(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).DisableUser), varargs...)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockAuthenticationServiceClient) ForgotPassword(arg0 context.Context, arg1 *pb.ForgotPasswordRequest, arg2 ...grpc.CallOption) (*pb.ForgotPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ForgotPassword", varargs...)
+	ret0, _ := ret[0].(*pb.ForgotPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockAuthenticationServiceClientMockRecorder) ForgotPassword(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).ForgotPassword), varargs...)
+}
+
+// GetJWKS mocks base method.
+func (m *MockAuthenticationServiceClient) GetJWKS(arg0 context.Context, arg1 *pb.GetJWKSRequest, arg2 ...grpc.CallOption) (*pb.GetJWKSResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetJWKS", varargs...)
+	ret0, _ := ret[0].(*pb.GetJWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockAuthenticationServiceClientMockRecorder) GetJWKS(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).GetJWKS), varargs...)
+}
+
 // GetUser mocks base method.
 func (m *MockAuthenticationServiceClient) GetUser(arg0 context.Context, arg1 *pb.GetUserRequest, arg2 ...grpc.CallOption) (*pb.GetUserResponse, error) {
 	m.ctrl.T.Helper()
@@ -61,6 +162,26 @@ func (mr *MockAuthenticationServiceClientMockRecorder) GetUser(arg0, arg1 any, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).GetUser), varargs...)
 }
 
+// ListAPIKeys mocks base method.
+func (m *MockAuthenticationServiceClient) ListAPIKeys(arg0 context.Context, arg1 *pb.ListAPIKeysRequest, arg2 ...grpc.CallOption) (*pb.ListAPIKeysResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAPIKeys", varargs...)
+	ret0, _ := ret[0].(*pb.ListAPIKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAuthenticationServiceClientMockRecorder) ListAPIKeys(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).ListAPIKeys), varargs...)
+}
+
 // LoginUser mocks base method.
 func (m *MockAuthenticationServiceClient) LoginUser(arg0 context.Context, arg1 *pb.LoginUserRequest, arg2 ...grpc.CallOption) (*pb.LoginUserResponse, error) {
 	m.ctrl.T.Helper()
@@ -81,6 +202,126 @@ func (mr *MockAuthenticationServiceClientMockRecorder) LoginUser(arg0, arg1 any,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).LoginUser), varargs...)
 }
 
+// RecordAuditEvent mocks base method.
+func (m *MockAuthenticationServiceClient) RecordAuditEvent(arg0 context.Context, arg1 *pb.RecordAuditEventRequest, arg2 ...grpc.CallOption) (*pb.RecordAuditEventResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RecordAuditEvent", varargs...)
+	ret0, _ := ret[0].(*pb.RecordAuditEventResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordAuditEvent indicates an expected call of RecordAuditEvent.
+func (mr *MockAuthenticationServiceClientMockRecorder) RecordAuditEvent(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAuditEvent", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).RecordAuditEvent), varargs...)
+}
+
+// RefreshToken mocks base method.
+func (m *MockAuthenticationServiceClient) RefreshToken(arg0 context.Context, arg1 *pb.RefreshTokenRequest, arg2 ...grpc.CallOption) (*pb.RefreshTokenResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RefreshToken", varargs...)
+	ret0, _ := ret[0].(*pb.RefreshTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockAuthenticationServiceClientMockRecorder) RefreshToken(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).RefreshToken), varargs...)
+}
+
+// ResendVerificationEmail mocks base method.
+func (m *MockAuthenticationServiceClient) ResendVerificationEmail(arg0 context.Context, arg1 *pb.ResendVerificationEmailRequest, arg2 ...grpc.CallOption) (*pb.ResendVerificationEmailResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResendVerificationEmail", varargs...)
+	ret0, _ := ret[0].(*pb.ResendVerificationEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResendVerificationEmail indicates an expected call of ResendVerificationEmail.
+func (mr *MockAuthenticationServiceClientMockRecorder) ResendVerificationEmail(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerificationEmail", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).ResendVerificationEmail), varargs...)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthenticationServiceClient) ResetPassword(arg0 context.Context, arg1 *pb.ResetPasswordRequest, arg2 ...grpc.CallOption) (*pb.ResetPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResetPassword", varargs...)
+	ret0, _ := ret[0].(*pb.ResetPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthenticationServiceClientMockRecorder) ResetPassword(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).ResetPassword), varargs...)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAuthenticationServiceClient) RevokeAPIKey(arg0 context.Context, arg1 *pb.RevokeAPIKeyRequest, arg2 ...grpc.CallOption) (*pb.RevokeAPIKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeAPIKey", varargs...)
+	ret0, _ := ret[0].(*pb.RevokeAPIKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAuthenticationServiceClientMockRecorder) RevokeAPIKey(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).RevokeAPIKey), varargs...)
+}
+
+// RevokeRefreshTokens mocks base method.
+func (m *MockAuthenticationServiceClient) RevokeRefreshTokens(arg0 context.Context, arg1 *pb.RevokeRefreshTokensRequest, arg2 ...grpc.CallOption) (*pb.RevokeRefreshTokensResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeRefreshTokens", varargs...)
+	ret0, _ := ret[0].(*pb.RevokeRefreshTokensResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRefreshTokens indicates an expected call of RevokeRefreshTokens.
+func (mr *MockAuthenticationServiceClientMockRecorder) RevokeRefreshTokens(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokens", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).RevokeRefreshTokens), varargs...)
+}
+
 // RegisterUser mocks base method.
 func (m *MockAuthenticationServiceClient) RegisterUser(arg0 context.Context, arg1 *pb.RegisterUserRequest, arg2 ...grpc.CallOption) (*pb.RegisterUserResponse, error) {
 	m.ctrl.T.Helper()
@@ -100,3 +341,409 @@ func (mr *MockAuthenticationServiceClientMockRecorder) RegisterUser(arg0, arg1 a
 	varargs := append([]any{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).RegisterUser), varargs...)
 }
+
+// VerifyEmail mocks base method.
+func (m *MockAuthenticationServiceClient) VerifyEmail(arg0 context.Context, arg1 *pb.VerifyEmailRequest, arg2 ...grpc.CallOption) (*pb.VerifyEmailResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyEmail", varargs...)
+	ret0, _ := ret[0].(*pb.VerifyEmailResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthenticationServiceClientMockRecorder) VerifyEmail(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).VerifyEmail), varargs...)
+}
+
+// UnlockUser mocks base method.
+func (m *MockAuthenticationServiceClient) UnlockUser(arg0 context.Context, arg1 *pb.UnlockUserRequest, arg2 ...grpc.CallOption) (*pb.UnlockUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UnlockUser", varargs...)
+	ret0, _ := ret[0].(*pb.UnlockUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockAuthenticationServiceClientMockRecorder) UnlockUser(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).UnlockUser), varargs...)
+}
+
+// EnrollMFA mocks base method.
+func (m *MockAuthenticationServiceClient) EnrollMFA(arg0 context.Context, arg1 *pb.EnrollMFARequest, arg2 ...grpc.CallOption) (*pb.EnrollMFAResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EnrollMFA", varargs...)
+	ret0, _ := ret[0].(*pb.EnrollMFAResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFA indicates an expected call of EnrollMFA.
+func (mr *MockAuthenticationServiceClientMockRecorder) EnrollMFA(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFA", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).EnrollMFA), varargs...)
+}
+
+// ActivateMFA mocks base method.
+func (m *MockAuthenticationServiceClient) ActivateMFA(arg0 context.Context, arg1 *pb.ActivateMFARequest, arg2 ...grpc.CallOption) (*pb.ActivateMFAResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ActivateMFA", varargs...)
+	ret0, _ := ret[0].(*pb.ActivateMFAResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateMFA indicates an expected call of ActivateMFA.
+func (mr *MockAuthenticationServiceClientMockRecorder) ActivateMFA(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateMFA", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).ActivateMFA), varargs...)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthenticationServiceClient) VerifyMFA(arg0 context.Context, arg1 *pb.VerifyMFARequest, arg2 ...grpc.CallOption) (*pb.LoginUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyMFA", varargs...)
+	ret0, _ := ret[0].(*pb.LoginUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthenticationServiceClientMockRecorder) VerifyMFA(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).VerifyMFA), varargs...)
+}
+
+// DisableMFA mocks base method.
+func (m *MockAuthenticationServiceClient) DisableMFA(arg0 context.Context, arg1 *pb.DisableMFARequest, arg2 ...grpc.CallOption) (*pb.DisableMFAResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableMFA", varargs...)
+	ret0, _ := ret[0].(*pb.DisableMFAResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockAuthenticationServiceClientMockRecorder) DisableMFA(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).DisableMFA), varargs...)
+}
+
+// VerifyCredentials mocks base method.
+func (m *MockAuthenticationServiceClient) VerifyCredentials(arg0 context.Context, arg1 *pb.VerifyCredentialsRequest, arg2 ...grpc.CallOption) (*pb.VerifyCredentialsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyCredentials", varargs...)
+	ret0, _ := ret[0].(*pb.VerifyCredentialsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyCredentials indicates an expected call of VerifyCredentials.
+func (mr *MockAuthenticationServiceClientMockRecorder) VerifyCredentials(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCredentials", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).VerifyCredentials), varargs...)
+}
+
+// VerifyAPIKey mocks base method.
+func (m *MockAuthenticationServiceClient) VerifyAPIKey(arg0 context.Context, arg1 *pb.VerifyAPIKeyRequest, arg2 ...grpc.CallOption) (*pb.VerifyAPIKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyAPIKey", varargs...)
+	ret0, _ := ret[0].(*pb.VerifyAPIKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAPIKey indicates an expected call of VerifyAPIKey.
+func (mr *MockAuthenticationServiceClientMockRecorder) VerifyAPIKey(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAPIKey", reflect.TypeOf((*MockAuthenticationServiceClient)(nil).VerifyAPIKey), varargs...)
+}
+
+// MockPaymentsServiceClient is a mock of PaymentsServiceClient interface.
+type MockPaymentsServiceClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentsServiceClientMockRecorder
+}
+
+// MockPaymentsServiceClientMockRecorder is the mock recorder for MockPaymentsServiceClient.
+type MockPaymentsServiceClientMockRecorder struct {
+	mock *MockPaymentsServiceClient
+}
+
+// NewMockPaymentsServiceClient creates a new mock instance.
+func NewMockPaymentsServiceClient(ctrl *gomock.Controller) *MockPaymentsServiceClient {
+	mock := &MockPaymentsServiceClient{ctrl: ctrl}
+	mock.recorder = &MockPaymentsServiceClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentsServiceClient) EXPECT() *MockPaymentsServiceClientMockRecorder {
+	return m.recorder
+}
+
+// ConfirmWithdrawal mocks base method.
+func (m *MockPaymentsServiceClient) ConfirmWithdrawal(arg0 context.Context, arg1 *pb.ConfirmWithdrawalRequest, arg2 ...grpc.CallOption) (*pb.InitiatePaymentResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmWithdrawal", varargs...)
+	ret0, _ := ret[0].(*pb.InitiatePaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmWithdrawal indicates an expected call of ConfirmWithdrawal.
+func (mr *MockPaymentsServiceClientMockRecorder) ConfirmWithdrawal(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmWithdrawal", reflect.TypeOf((*MockPaymentsServiceClient)(nil).ConfirmWithdrawal), varargs...)
+}
+
+// GetTransaction mocks base method.
+func (m *MockPaymentsServiceClient) GetTransaction(arg0 context.Context, arg1 *pb.PollingTransactionRequest, arg2 ...grpc.CallOption) (*pb.PollingTransactionResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetTransaction", varargs...)
+	ret0, _ := ret[0].(*pb.PollingTransactionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransaction indicates an expected call of GetTransaction.
+func (mr *MockPaymentsServiceClientMockRecorder) GetTransaction(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockPaymentsServiceClient)(nil).GetTransaction), varargs...)
+}
+
+// InitiatePayment mocks base method.
+func (m *MockPaymentsServiceClient) InitiatePayment(arg0 context.Context, arg1 *pb.InitiatePaymentRequest, arg2 ...grpc.CallOption) (*pb.InitiatePaymentResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InitiatePayment", varargs...)
+	ret0, _ := ret[0].(*pb.InitiatePaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitiatePayment indicates an expected call of InitiatePayment.
+func (mr *MockPaymentsServiceClientMockRecorder) InitiatePayment(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitiatePayment", reflect.TypeOf((*MockPaymentsServiceClient)(nil).InitiatePayment), varargs...)
+}
+
+// ListTransactions mocks base method.
+func (m *MockPaymentsServiceClient) ListTransactions(arg0 context.Context, arg1 *pb.ListTransactionsRequest, arg2 ...grpc.CallOption) (*pb.ListTransactionsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListTransactions", varargs...)
+	ret0, _ := ret[0].(*pb.ListTransactionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockPaymentsServiceClientMockRecorder) ListTransactions(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockPaymentsServiceClient)(nil).ListTransactions), varargs...)
+}
+
+// WatchTransaction mocks base method.
+func (m *MockPaymentsServiceClient) WatchTransaction(arg0 context.Context, arg1 *pb.PollingTransactionRequest, arg2 ...grpc.CallOption) (pb.PaymentsService_WatchTransactionClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WatchTransaction", varargs...)
+	ret0, _ := ret[0].(pb.PaymentsService_WatchTransactionClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchTransaction indicates an expected call of WatchTransaction.
+func (mr *MockPaymentsServiceClientMockRecorder) WatchTransaction(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchTransaction", reflect.TypeOf((*MockPaymentsServiceClient)(nil).WatchTransaction), varargs...)
+}
+
+// MockPaymentsService_WatchTransactionClient is a mock of PaymentsService_WatchTransactionClient interface.
+type MockPaymentsService_WatchTransactionClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentsService_WatchTransactionClientMockRecorder
+}
+
+// MockPaymentsService_WatchTransactionClientMockRecorder is the mock recorder for MockPaymentsService_WatchTransactionClient.
+type MockPaymentsService_WatchTransactionClientMockRecorder struct {
+	mock *MockPaymentsService_WatchTransactionClient
+}
+
+// NewMockPaymentsService_WatchTransactionClient creates a new mock instance.
+func NewMockPaymentsService_WatchTransactionClient(ctrl *gomock.Controller) *MockPaymentsService_WatchTransactionClient {
+	mock := &MockPaymentsService_WatchTransactionClient{ctrl: ctrl}
+	mock.recorder = &MockPaymentsService_WatchTransactionClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentsService_WatchTransactionClient) EXPECT() *MockPaymentsService_WatchTransactionClientMockRecorder {
+	return m.recorder
+}
+
+// CloseSend mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).CloseSend))
+}
+
+// Context mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).Context))
+}
+
+// Header mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).Header))
+}
+
+// Recv mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) Recv() (*pb.PollingTransactionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*pb.PollingTransactionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).Recv))
+}
+
+// RecvMsg mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) RecvMsg(arg0 any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecvMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) RecvMsg(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).RecvMsg), arg0)
+}
+
+// SendMsg mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) SendMsg(arg0 any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) SendMsg(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).SendMsg), arg0)
+}
+
+// Trailer mocks base method.
+func (m *MockPaymentsService_WatchTransactionClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer.
+func (mr *MockPaymentsService_WatchTransactionClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockPaymentsService_WatchTransactionClient)(nil).Trailer))
+}
@@ -0,0 +1,289 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.12.4
+// source: payments_service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	PaymentsService_InitiatePayment_FullMethodName   = "/pb.paymentsService/InitiatePayment"
+	PaymentsService_GetTransaction_FullMethodName    = "/pb.paymentsService/GetTransaction"
+	PaymentsService_ListTransactions_FullMethodName  = "/pb.paymentsService/ListTransactions"
+	PaymentsService_WatchTransaction_FullMethodName  = "/pb.paymentsService/WatchTransaction"
+	PaymentsService_ConfirmWithdrawal_FullMethodName = "/pb.paymentsService/ConfirmWithdrawal"
+)
+
+// PaymentsServiceClient is the client API for PaymentsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PaymentsServiceClient interface {
+	InitiatePayment(ctx context.Context, in *InitiatePaymentRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error)
+	GetTransaction(ctx context.Context, in *PollingTransactionRequest, opts ...grpc.CallOption) (*PollingTransactionResponse, error)
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// streams the transaction as it is now, then again on every change until it settles
+	WatchTransaction(ctx context.Context, in *PollingTransactionRequest, opts ...grpc.CallOption) (PaymentsService_WatchTransactionClient, error)
+	// distributes a withdrawal InitiatePayment left pending, once the user proved who they are
+	ConfirmWithdrawal(ctx context.Context, in *ConfirmWithdrawalRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error)
+}
+
+type paymentsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaymentsServiceClient(cc grpc.ClientConnInterface) PaymentsServiceClient {
+	return &paymentsServiceClient{cc}
+}
+
+func (c *paymentsServiceClient) InitiatePayment(ctx context.Context, in *InitiatePaymentRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error) {
+	out := new(InitiatePaymentResponse)
+	err := c.cc.Invoke(ctx, PaymentsService_InitiatePayment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentsServiceClient) GetTransaction(ctx context.Context, in *PollingTransactionRequest, opts ...grpc.CallOption) (*PollingTransactionResponse, error) {
+	out := new(PollingTransactionResponse)
+	err := c.cc.Invoke(ctx, PaymentsService_GetTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentsServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, PaymentsService_ListTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentsServiceClient) WatchTransaction(ctx context.Context, in *PollingTransactionRequest, opts ...grpc.CallOption) (PaymentsService_WatchTransactionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentsService_ServiceDesc.Streams[0], PaymentsService_WatchTransaction_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentsServiceWatchTransactionClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentsService_WatchTransactionClient interface {
+	Recv() (*PollingTransactionResponse, error)
+	grpc.ClientStream
+}
+
+type paymentsServiceWatchTransactionClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentsServiceWatchTransactionClient) Recv() (*PollingTransactionResponse, error) {
+	m := new(PollingTransactionResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paymentsServiceClient) ConfirmWithdrawal(ctx context.Context, in *ConfirmWithdrawalRequest, opts ...grpc.CallOption) (*InitiatePaymentResponse, error) {
+	out := new(InitiatePaymentResponse)
+	err := c.cc.Invoke(ctx, PaymentsService_ConfirmWithdrawal_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaymentsServiceServer is the server API for PaymentsService service.
+// All implementations must embed UnimplementedPaymentsServiceServer
+// for forward compatibility
+type PaymentsServiceServer interface {
+	InitiatePayment(context.Context, *InitiatePaymentRequest) (*InitiatePaymentResponse, error)
+	GetTransaction(context.Context, *PollingTransactionRequest) (*PollingTransactionResponse, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// streams the transaction as it is now, then again on every change until it settles
+	WatchTransaction(*PollingTransactionRequest, PaymentsService_WatchTransactionServer) error
+	// distributes a withdrawal InitiatePayment left pending, once the user proved who they are
+	ConfirmWithdrawal(context.Context, *ConfirmWithdrawalRequest) (*InitiatePaymentResponse, error)
+	mustEmbedUnimplementedPaymentsServiceServer()
+}
+
+// UnimplementedPaymentsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPaymentsServiceServer struct {
+}
+
+func (UnimplementedPaymentsServiceServer) InitiatePayment(context.Context, *InitiatePaymentRequest) (*InitiatePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitiatePayment not implemented")
+}
+func (UnimplementedPaymentsServiceServer) GetTransaction(context.Context, *PollingTransactionRequest) (*PollingTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedPaymentsServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedPaymentsServiceServer) WatchTransaction(*PollingTransactionRequest, PaymentsService_WatchTransactionServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTransaction not implemented")
+}
+func (UnimplementedPaymentsServiceServer) ConfirmWithdrawal(context.Context, *ConfirmWithdrawalRequest) (*InitiatePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmWithdrawal not implemented")
+}
+func (UnimplementedPaymentsServiceServer) mustEmbedUnimplementedPaymentsServiceServer() {}
+
+// UnsafePaymentsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaymentsServiceServer will
+// result in compilation errors.
+type UnsafePaymentsServiceServer interface {
+	mustEmbedUnimplementedPaymentsServiceServer()
+}
+
+func RegisterPaymentsServiceServer(s grpc.ServiceRegistrar, srv PaymentsServiceServer) {
+	s.RegisterService(&PaymentsService_ServiceDesc, srv)
+}
+
+func _PaymentsService_InitiatePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitiatePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServiceServer).InitiatePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentsService_InitiatePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServiceServer).InitiatePayment(ctx, req.(*InitiatePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentsService_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PollingTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServiceServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentsService_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServiceServer).GetTransaction(ctx, req.(*PollingTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentsService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentsService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentsService_WatchTransaction_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PollingTransactionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentsServiceServer).WatchTransaction(m, &paymentsServiceWatchTransactionServer{stream})
+}
+
+type PaymentsService_WatchTransactionServer interface {
+	Send(*PollingTransactionResponse) error
+	grpc.ServerStream
+}
+
+type paymentsServiceWatchTransactionServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentsServiceWatchTransactionServer) Send(m *PollingTransactionResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaymentsService_ConfirmWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServiceServer).ConfirmWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentsService_ConfirmWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServiceServer).ConfirmWithdrawal(ctx, req.(*ConfirmWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaymentsService_ServiceDesc is the grpc.ServiceDesc for PaymentsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaymentsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.paymentsService",
+	HandlerType: (*PaymentsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InitiatePayment",
+			Handler:    _PaymentsService_InitiatePayment_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _PaymentsService_GetTransaction_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _PaymentsService_ListTransactions_Handler,
+		},
+		{
+			MethodName: "ConfirmWithdrawal",
+			Handler:    _PaymentsService_ConfirmWithdrawal_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTransaction",
+			Handler:       _PaymentsService_WatchTransaction_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "payments_service.proto",
+}
@@ -0,0 +1,212 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v3.12.4
+// source: rpc_revoke_refresh_tokens.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RevokeRefreshTokensRequest revokes the family of refresh_token, or every refresh token
+// of the user when it is empty.
+type RevokeRefreshTokensRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId       int64  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RefreshToken string `protobuf:"bytes,2,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+}
+
+func (x *RevokeRefreshTokensRequest) Reset() {
+	*x = RevokeRefreshTokensRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_revoke_refresh_tokens_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeRefreshTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeRefreshTokensRequest) ProtoMessage() {}
+
+func (x *RevokeRefreshTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_revoke_refresh_tokens_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeRefreshTokensRequest.ProtoReflect.Descriptor instead.
+func (*RevokeRefreshTokensRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_revoke_refresh_tokens_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RevokeRefreshTokensRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RevokeRefreshTokensRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type RevokeRefreshTokensResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RevokeRefreshTokensResponse) Reset() {
+	*x = RevokeRefreshTokensResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_revoke_refresh_tokens_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeRefreshTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeRefreshTokensResponse) ProtoMessage() {}
+
+func (x *RevokeRefreshTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_revoke_refresh_tokens_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeRefreshTokensResponse.ProtoReflect.Descriptor instead.
+func (*RevokeRefreshTokensResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_revoke_refresh_tokens_proto_rawDescGZIP(), []int{1}
+}
+
+var File_rpc_revoke_refresh_tokens_proto protoreflect.FileDescriptor
+
+var file_rpc_revoke_refresh_tokens_proto_rawDesc = []byte{
+	0x0a, 0x1f, 0x72, 0x70, 0x63, 0x5f, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x5f, 0x72, 0x65, 0x66,
+	0x72, 0x65, 0x73, 0x68, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x02, 0x70, 0x62, 0x22, 0x5a, 0x0a, 0x1a, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52,
+	0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d,
+	0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x22, 0x1d, 0x0a, 0x1b, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x66, 0x72, 0x65,
+	0x73, 0x68, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x42, 0x3f, 0x5a, 0x3d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x45,
+	0x6d, 0x69, 0x6c, 0x69, 0x6f, 0x43, 0x6c, 0x69, 0x66, 0x66, 0x2f, 0x70, 0x61, 0x79, 0x6d, 0x65,
+	0x6e, 0x74, 0x2d, 0x70, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2f, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x2d, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_revoke_refresh_tokens_proto_rawDescOnce sync.Once
+	file_rpc_revoke_refresh_tokens_proto_rawDescData = file_rpc_revoke_refresh_tokens_proto_rawDesc
+)
+
+func file_rpc_revoke_refresh_tokens_proto_rawDescGZIP() []byte {
+	file_rpc_revoke_refresh_tokens_proto_rawDescOnce.Do(func() {
+		file_rpc_revoke_refresh_tokens_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_revoke_refresh_tokens_proto_rawDescData)
+	})
+	return file_rpc_revoke_refresh_tokens_proto_rawDescData
+}
+
+var file_rpc_revoke_refresh_tokens_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_revoke_refresh_tokens_proto_goTypes = []interface{}{
+	(*RevokeRefreshTokensRequest)(nil),  // 0: pb.RevokeRefreshTokensRequest
+	(*RevokeRefreshTokensResponse)(nil), // 1: pb.RevokeRefreshTokensResponse
+}
+var file_rpc_revoke_refresh_tokens_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_rpc_revoke_refresh_tokens_proto_init() }
+func file_rpc_revoke_refresh_tokens_proto_init() {
+	if File_rpc_revoke_refresh_tokens_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_revoke_refresh_tokens_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeRefreshTokensRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_revoke_refresh_tokens_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeRefreshTokensResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_revoke_refresh_tokens_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_revoke_refresh_tokens_proto_goTypes,
+		DependencyIndexes: file_rpc_revoke_refresh_tokens_proto_depIdxs,
+		MessageInfos:      file_rpc_revoke_refresh_tokens_proto_msgTypes,
+	}.Build()
+	File_rpc_revoke_refresh_tokens_proto = out.File
+	file_rpc_revoke_refresh_tokens_proto_rawDesc = nil
+	file_rpc_revoke_refresh_tokens_proto_goTypes = nil
+	file_rpc_revoke_refresh_tokens_proto_depIdxs = nil
+}